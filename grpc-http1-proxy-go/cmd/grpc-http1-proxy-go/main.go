@@ -19,6 +19,14 @@ import (
 	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/httpserver"
 )
 
+// Build information, injected at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildTime=..."
+var (
+	version   = "dev"
+	commit    = "none"
+	buildTime = "unknown"
+)
+
 // main is the application entry point. It performs the following steps:
 // 1. Load configuration from environment variables
 // 2. Parse command-line flags to override environment variables
@@ -51,8 +59,19 @@ func main() {
 	// Step 4: Initialize structured logging
 	// Using slog (structured logging) which is part of the standard library in Go 1.21+
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger.Info("starting grpc-http1-proxy-go",
+		slog.String("version", version),
+		slog.String("commit", commit),
+		slog.String("buildTime", buildTime),
+	)
+	// Log the effective configuration once; Config.LogValue redacts secrets
+	logger.Info("effective configuration", slog.Any("config", cfg))
+
+	// Step 5: Create Prometheus metrics registry
+	// This will collect metrics from the HTTP server and gRPC client
+	registry := prometheus.NewRegistry()
 
-	// Step 5: Create gRPC client connection to the backend service
+	// Step 6: Create gRPC client connection to the backend service
 	// This establishes a connection pool and configures retry logic
 	ctx := context.Background()
 	grpcClient, err := grpcclient.New(ctx, grpcclient.Config{
@@ -60,6 +79,27 @@ func main() {
 		DialTimeout: cfg.GRPCDialTimeout,
 		Deadline:    cfg.GRPCDeadline,
 		MaxRetries:  cfg.MaxGRPCRetries,
+
+		BackendFile:    cfg.GRPCBackendFile,
+		BackendService: cfg.GRPCBackendService,
+
+		RetryBackoff: cfg.GRPCRetryBackoff,
+		Jitter:       cfg.GRPCJitter,
+
+		NonRetryableMethods: cfg.GRPCNonRetryableMethods,
+
+		WarmupOnStart: cfg.GRPCWarmup,
+
+		MaxConnectionIdle: cfg.GRPCMaxConnIdle,
+		MaxConnectionAge:  cfg.GRPCMaxConnAge,
+
+		MaxConcurrentCalls: cfg.GRPCMaxConcurrentCalls,
+		MaxConcurrencyWait: cfg.GRPCConcurrencyWait,
+		Registry:           registry,
+		MetricsNamespace:   cfg.MetricsNamespace,
+		MetricsSubsystem:   cfg.MetricsSubsystem,
+
+		MaxRecvMsgBytes: cfg.GRPCMaxRecvMsgBytes,
 	}, logger)
 	if err != nil {
 		logger.Error("failed to create gRPC client", slog.String("err", err.Error()))
@@ -68,16 +108,68 @@ func main() {
 	// Ensure the connection is closed when the program exits
 	defer grpcClient.Close()
 
-	// Step 6: Create Prometheus metrics registry
-	// This will collect metrics from the HTTP server and gRPC client
-	registry := prometheus.NewRegistry()
+	// Poll the backend's gRPC health service in the background so grpc_backend_up
+	// reflects reachability even without traffic; stopped before the client closes
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	healthDone := make(chan struct{})
+	if cfg.GRPCHealthInterval > 0 {
+		monitor, err := grpcclient.NewHealthMonitor(grpcClient, cfg.GRPCHealthInterval, registry, cfg.MetricsNamespace, cfg.MetricsSubsystem, logger)
+		if err != nil {
+			logger.Error("failed to create backend health monitor", slog.String("err", err.Error()))
+			os.Exit(1)
+		}
+		go func() {
+			defer close(healthDone)
+			monitor.Run(healthCtx)
+		}()
+	} else {
+		close(healthDone)
+	}
+	defer func() {
+		stopHealth()
+		<-healthDone
+	}()
 
 	// Step 7: Create HTTP server that will proxy requests to gRPC backend
 	server, err := httpserver.New(httpserver.Config{
 		ListenAddr:        cfg.HTTPListenAddr,
 		MetricsPath:       cfg.MetricsPath,
+		MetricsNamespace:  cfg.MetricsNamespace,
+		MetricsSubsystem:  cfg.MetricsSubsystem,
 		HealthPath:        cfg.HealthPath,
+		ReadyPath:         cfg.ReadyPath,
+		PreShutdownDelay:  cfg.PreShutdownDelay,
 		ReadHeaderTimeout: 5 * time.Second, // Prevent slowloris attacks
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		MaxRequestTimeout: cfg.MaxTimeout,
+		EnableIndex:       cfg.EnableIndex,
+		BackendAddr:       grpcClient.Address,
+		RedactBackend:     cfg.RedactBackend,
+		PrettyJSON:        cfg.PrettyJSON,
+		UseEnumNumbers:    cfg.UseEnumNumbers,
+		Int64AsNumber:     cfg.Int64AsNumber,
+		EnableH2C:         cfg.EnableH2C,
+		StripPathPrefix:   cfg.StripPathPrefix,
+		MaxResponseBytes:  cfg.MaxResponseBytes,
+		APIKeys:           cfg.APIKeys,
+		SingleFlight:      cfg.SingleFlight,
+		EnableETag:        cfg.EnableETag,
+		EnableGET:         cfg.EnableGET,
+		ForwardFieldMask:  cfg.FieldMask,
+		ExposeSchemas:     cfg.ExposeSchemas,
+		CacheTTL:          cfg.CacheTTL,
+		CacheMaxEntries:   cfg.CacheMaxEntries,
+		ServeStaleOnError: cfg.CacheServeStale,
+		MaxConcurrent:     cfg.MaxConcurrent,
+		MaxQueue:          cfg.MaxQueue,
+		MaxQueueWait:      cfg.QueueWait,
+		TLSCertFile:       cfg.TLSCertFile,
+		TLSKeyFile:        cfg.TLSKeyFile,
+		Build:             httpserver.BuildInfo{Version: version, Commit: commit, BuildTime: buildTime},
+
+		NegotiateCompression: cfg.Compression,
 	}, grpcClient, logger, registry)
 	if err != nil {
 		logger.Error("failed to create HTTP server", slog.String("err", err.Error()))
@@ -94,6 +186,22 @@ func main() {
 		}
 	}()
 
+	// Re-read the backend file on SIGHUP so a changed address is picked up without
+	// a restart; a file that cannot be used is logged and the current backend kept
+	if cfg.GRPCBackendFile != "" {
+		hupCh := make(chan os.Signal, 1)
+		signal.Notify(hupCh, syscall.SIGHUP)
+		go func() {
+			for range hupCh {
+				if err := grpcClient.ReloadBackends(); err != nil {
+					logger.Error("failed to reload backend file", slog.String("file", cfg.GRPCBackendFile), slog.String("err", err.Error()))
+					continue
+				}
+				logger.Info("backend file reloaded", slog.String("file", cfg.GRPCBackendFile), slog.String("addr", grpcClient.Address()))
+			}
+		}()
+	}
+
 	// Step 9: Set up signal handling for graceful shutdown
 	// Create a channel to receive OS signals (SIGINT from Ctrl+C, SIGTERM from kill)
 	sigCh := make(chan os.Signal, 1)
@@ -105,8 +213,9 @@ func main() {
 	logger.Info("received shutdown signal", slog.String("signal", sig.String()))
 
 	// Step 11: Gracefully shut down the HTTP server
-	// Create a context with timeout to limit how long we wait for in-flight requests
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	// Create a context with timeout to limit how long we wait for in-flight requests;
+	// the pre-shutdown delay (readiness already failing) comes on top of it
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), cfg.PreShutdownDelay+cfg.ShutdownTimeout)
 	defer cancel()
 	if err := server.Shutdown(ctxShutdown); err != nil {
 		logger.Error("graceful shutdown failed", slog.String("err", err.Error()))
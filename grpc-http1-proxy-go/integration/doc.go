@@ -0,0 +1,6 @@
+// Package integration holds end-to-end tests that run a gRPC Greeter backend, the
+// gRPC client and the HTTP server in one process and send real HTTP requests
+// through the proxy. The tests only build with the integration tag:
+//
+//	go test -tags integration ./integration/
+package integration
@@ -0,0 +1,146 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/grpcclient"
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/httpserver"
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// greeterServer answers like the Go server of the routing setup
+type greeterServer struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (s *greeterServer) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	return &pb.HelloReply{Message: fmt.Sprintf("Hello %s! Greetings from Go Server v1", req.GetName())}, nil
+}
+
+// startBackend serves greeterServer on an ephemeral port and returns its address
+func startBackend(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterGreeterServer(srv, &greeterServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// startProxy wires grpcclient and httpserver against backend the way main does,
+// serves on a Unix socket through Server.Start and returns a client for it. The
+// proxy is shut down and the client closed when the test ends.
+func startProxy(t *testing.T, backend string) *http.Client {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	client, err := grpcclient.New(context.Background(), grpcclient.Config{
+		Address:     backend,
+		DialTimeout: 2 * time.Second,
+		Deadline:    2 * time.Second,
+		MaxRetries:  2,
+		Registry:    registry,
+	}, nil)
+	if err != nil {
+		t.Fatalf("grpcclient.New() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	socket := filepath.Join(t.TempDir(), "proxy.sock")
+	server, err := httpserver.New(httpserver.Config{ListenAddr: "unix:" + socket}, client, nil, registry)
+	if err != nil {
+		t.Fatalf("httpserver.New() error = %v", err)
+	}
+	started := make(chan error, 1)
+	go func() { started <- server.Start() }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			t.Errorf("Shutdown() error = %v", err)
+		}
+		if err := <-started; err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	})
+
+	httpClient := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}},
+	}
+	// The socket appears once Start is listening
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := httpClient.Get("http://proxy/healthz")
+		if err == nil {
+			resp.Body.Close()
+			return httpClient
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("proxy did not come up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// post sends body to path on the proxy and decodes the JSON response
+func post(t *testing.T, client *http.Client, path, body string) (int, map[string]any) {
+	t.Helper()
+	resp, err := client.Post("http://proxy"+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	var decoded map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatalf("POST %s: decoding the response: %v", path, err)
+	}
+	return resp.StatusCode, decoded
+}
+
+func TestSayHelloRoundTripsThroughTheProxy(t *testing.T) {
+	client := startProxy(t, startBackend(t))
+
+	for _, path := range []string{"/helloworld/SayHello", "/helloworld.Greeter/SayHello"} {
+		code, reply := post(t, client, path, `{"name":"Alice"}`)
+		if code != http.StatusOK {
+			t.Fatalf("POST %s = %d %v, want 200", path, code, reply)
+		}
+		if want := "Hello Alice! Greetings from Go Server v1"; reply["message"] != want {
+			t.Errorf("POST %s message = %v, want %q", path, reply["message"], want)
+		}
+	}
+}
+
+func TestBackendErrorsReachTheHTTPClient(t *testing.T) {
+	client := startProxy(t, startBackend(t))
+
+	code, reply := post(t, client, "/helloworld/SayHello", `{"name":""}`)
+	if code != http.StatusBadGateway {
+		t.Fatalf("expected 502 for a backend InvalidArgument, got %d %v", code, reply)
+	}
+}
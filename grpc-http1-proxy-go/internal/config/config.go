@@ -6,36 +6,147 @@ package config
 import (
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Environment variable names used for configuration.
 const (
-	envHTTPListen     = "HTTP_LISTEN_ADDR"      // HTTP server bind address
-	envMetricsPath    = "METRICS_PATH"          // Path for Prometheus metrics endpoint
-	envGRPCBackend    = "GRPC_BACKEND_ADDR"     // Target gRPC backend address
-	envGRPCDeadlineMS = "GRPC_DEADLINE_MS"      // Per-request timeout in milliseconds
-	envGRPCDialMS     = "GRPC_DIAL_TIMEOUT_MS"  // Connection establishment timeout in milliseconds
-	envShutdownMS     = "SHUTDOWN_TIMEOUT_MS"   // Graceful shutdown timeout in milliseconds
-	envMaxRetries     = "GRPC_MAX_RETRIES"      // Maximum retry attempts for transient errors
+	envHTTPListen     = "HTTP_LISTEN_ADDR"          // HTTP server bind address
+	envMetricsPath    = "METRICS_PATH"              // Path for Prometheus metrics endpoint
+	envMetricsNS      = "METRICS_NAMESPACE"         // Prefix of the metric names
+	envMetricsSubsys  = "METRICS_SUBSYSTEM"         // Optional prefix between the namespace and the metric names
+	envGRPCBackend    = "GRPC_BACKEND_ADDR"         // Target gRPC backend address
+	envBackendFile    = "GRPC_BACKEND_FILE"         // JSON file mapping service names to backend addresses
+	envBackendService = "GRPC_BACKEND_SERVICE"      // Service whose address is read from the backend file
+	envGRPCDeadlineMS = "GRPC_DEADLINE_MS"          // Per-request timeout in milliseconds
+	envGRPCDialMS     = "GRPC_DIAL_TIMEOUT_MS"      // Connection establishment timeout in milliseconds
+	envShutdownMS     = "SHUTDOWN_TIMEOUT_MS"       // Graceful shutdown timeout in milliseconds
+	envShutdownDelay  = "SHUTDOWN_DELAY_MS"         // Time readiness fails before shutdown begins
+	envMaxRetries     = "GRPC_MAX_RETRIES"          // Maximum retry attempts for transient errors
+	envRetryBackoffMS = "GRPC_RETRY_BACKOFF_MS"     // Wait before the first retry, doubled for every further attempt
+	envJitter         = "GRPC_BACKOFF_JITTER"       // Fraction by which retry and reconnect waits are randomized
+	envNonRetryable   = "GRPC_NO_RETRY_METHODS"     // Comma-separated full method names that are never retried
+	envWarmup         = "GRPC_WARMUP_ON_START"      // Connect to the backend before serving
+	envMaxConnIdleMS  = "GRPC_MAX_CONN_IDLE_MS"     // Idle time before the gRPC channel drops its transports
+	envMaxConnAgeMS   = "GRPC_MAX_CONN_AGE_MS"      // Age after which the gRPC connection is recycled
+	envMaxConcurrent  = "GRPC_MAX_CONCURRENT_CALLS" // Cap on in-flight gRPC calls
+	envConcurrencyMS  = "GRPC_CONCURRENCY_WAIT_MS"  // Time a call waits for a free slot once the cap is reached
+	envHealthMS       = "GRPC_HEALTH_INTERVAL_MS"   // Interval between backend health checks (0 disables)
+	envMaxRecvBytes   = "GRPC_MAX_RECV_MSG_BYTES"   // Largest gRPC reply accepted from the backend
+	envMaxTimeoutMS   = "HTTP_MAX_TIMEOUT_MS"       // Upper bound for client-supplied X-Timeout-Ms deadlines
+	envEnableIndex    = "HTTP_ENABLE_INDEX"         // Serve the JSON route index at GET /
+	envRedactBackend  = "HTTP_REDACT_BACKEND"       // Hide the backend address from the index
+	envPrettyJSON     = "HTTP_PRETTY_JSON"          // Indent JSON response bodies by default
+	envEnumNumbers    = "HTTP_ENUM_NUMBERS"         // Render enum fields as numbers instead of names
+	envInt64AsNumber  = "HTTP_INT64_AS_NUMBER"      // Render 64-bit integer fields as numbers instead of strings
+	envEnableH2C      = "HTTP_ENABLE_H2C"           // Accept HTTP/2 over cleartext (h2c)
+	envStripPrefix    = "HTTP_STRIP_PATH_PREFIX"    // Path prefix added by an ingress, removed before routing
+	envMaxRespBytes   = "HTTP_MAX_RESPONSE_BYTES"   // Largest JSON response body sent to clients
+	envMaxHeaderBytes = "HTTP_MAX_HEADER_BYTES"     // Largest request header block accepted
+	envWriteTimeoutMS = "HTTP_WRITE_TIMEOUT_MS"     // Time allowed to read the body and write the response (0 disables)
+	envIdleTimeoutMS  = "HTTP_IDLE_TIMEOUT_MS"      // Time an idle keep-alive connection stays open (0 disables)
+	envAPIKeys        = "HTTP_API_KEYS"             // Comma-separated keys accepted in the X-API-Key header
+	envSingleFlight   = "HTTP_SINGLE_FLIGHT"        // Collapse concurrent identical requests into one backend call
+	envCacheTTLMS     = "HTTP_CACHE_TTL_MS"         // How long successful replies are cached by request body (0 disables)
+	envCacheEntries   = "HTTP_CACHE_MAX_ENTRIES"    // Most replies kept in the response cache
+	envCacheStale     = "HTTP_CACHE_SERVE_STALE"    // Answer failed backend calls with expired cached replies
+	envHTTPConcurrent = "HTTP_MAX_CONCURRENT"       // Most proxy requests processed at once (0 disables)
+	envHTTPQueue      = "HTTP_MAX_QUEUE"            // Most requests waiting for a slot once the cap is reached
+	envHTTPQueueWait  = "HTTP_QUEUE_WAIT_MS"        // Longest wait for a slot before 503 (0 waits until the client gives up)
+	envEnableETag     = "HTTP_ENABLE_ETAG"          // Set ETag on 200 responses and honor If-None-Match
+	envEnableGET      = "HTTP_ENABLE_GET"           // Accept GET with request fields as query parameters
+	envFieldMask      = "HTTP_FORWARD_FIELD_MASK"   // Forward the fields present in requests as x-field-mask metadata
+	envCompression    = "HTTP_NEGOTIATE_GZIP"       // Gzip replies and backend calls for clients sending Accept-Encoding: gzip
+	envExposeSchemas  = "HTTP_EXPOSE_SCHEMAS"       // Serve request JSON Schemas at GET <route>/schema
+	envTLSCertFile    = "HTTP_TLS_CERT_FILE"        // PEM certificate for serving HTTPS
+	envTLSKeyFile     = "HTTP_TLS_KEY_FILE"         // PEM private key for the certificate
 )
 
 // Config holds all configuration parameters for the proxy service.
 // Fields can be set via environment variables or command-line flags.
 type Config struct {
 	// HTTP server configuration
-	HTTPListenAddr string // Address and port to bind the HTTP server (e.g., ":8080")
-	MetricsPath    string // URL path for Prometheus metrics endpoint (default: "/metrics")
-	HealthPath     string // URL path for health check endpoint (default: "/healthz")
+	HTTPListenAddr string        // Address and port to bind the HTTP server (e.g., ":8080"), or "unix:<path>" for a Unix domain socket
+	MetricsPath    string        // URL path for Prometheus metrics endpoint (default: "/metrics")
+	HealthPath     string        // URL path for health check endpoint (default: "/healthz")
+	ReadyPath      string        // URL path for the readiness endpoint (default: "/readyz")
+	MaxTimeout     time.Duration // Upper bound for client-supplied X-Timeout-Ms deadlines
+	EnableIndex    bool          // Serve a JSON index of the registered routes at GET / (default: true)
+	RedactBackend  bool          // Hide the backend address from the index (default: false)
+	PrettyJSON     bool          // Indent JSON response bodies by default (default: false)
+	UseEnumNumbers bool          // Render enum fields as numbers instead of value names (default: false)
+	Int64AsNumber  bool          // Render 64-bit integer fields as JSON numbers instead of strings (default: false)
+	EnableH2C      bool          // Accept HTTP/2 over cleartext (h2c) besides HTTP/1.1 (default: false)
+	SingleFlight   bool          // Collapse concurrent identical requests into one backend call (default: false)
+	EnableETag     bool          // Set a weak ETag on 200 responses and answer a matching If-None-Match with 304 (default: false)
+	EnableGET      bool          // Accept GET on the proxy routes with request fields as query parameters (default: false)
+	FieldMask      bool          // Forward the top-level fields present in requests as x-field-mask gRPC metadata (default: false)
+	Compression    bool          // Gzip replies and backend calls of clients sending Accept-Encoding: gzip (default: false)
+	ExposeSchemas  bool          // Serve the JSON Schema of each method's request at GET <route>/schema (default: false)
+
+	StripPathPrefix  string // Path prefix added by an ingress, e.g. "/api/v1", removed before routing (default: "")
+	MaxResponseBytes int    // Largest JSON response body; bigger replies fail with 502 (0 disables)
+	MetricsNamespace string // Prefix of the metric names, so they do not collide with other services (default: "grpc_http1_proxy")
+	MetricsSubsystem string // Optional prefix between the namespace and the metric names (default: "")
+
+	// Limits of the HTTP server so large headers are accepted and slow or idle
+	// clients cannot hold connections forever
+	MaxHeaderBytes int           // Largest request header block accepted (default: 1 MiB)
+	WriteTimeout   time.Duration // Time from the end of the request headers to the end of the response (0 disables, default: 0)
+	IdleTimeout    time.Duration // Time an idle keep-alive connection stays open (0 disables, default: 2m)
+
+	// HTTPS; both files must be set together and are reloaded when they change
+	TLSCertFile string // PEM certificate (default: "", plain HTTP)
+	TLSKeyFile  string // PEM private key for TLSCertFile (default: "")
+
+	// Response cache keyed by request body
+	CacheTTL        time.Duration // How long successful replies are served from the cache (0 disables, default: 0)
+	CacheMaxEntries int           // Most replies kept; the least recently used is evicted (default: 1024)
+	CacheServeStale bool          // Answer a failed backend call with the expired cached reply, if any (default: false)
+
+	// Concurrency limit of the proxy routes; requests over it queue, and get 503
+	// once the queue is full or their wait is over
+	MaxConcurrent int           // Most proxy requests processed at once (0 disables, default: 0)
+	MaxQueue      int           // Most requests waiting for a slot (0 rejects at once, default: 0)
+	QueueWait     time.Duration // Longest wait for a slot (0 waits until the client gives up, default: 0)
+
+	// API keys accepted in the X-API-Key header (empty disables auth). Only read from
+	// the environment so that keys do not show up in the process list.
+	APIKeys []string
 
 	// gRPC client configuration
-	GRPCBackendAddr string        // Target gRPC backend address (e.g., "localhost:50051")
-	GRPCDeadline    time.Duration // Maximum time to wait for a gRPC call to complete
-	GRPCDialTimeout time.Duration // Maximum time to establish a gRPC connection
-	ShutdownTimeout time.Duration // Maximum time to wait for graceful shutdown
-	MaxGRPCRetries  uint          // Maximum number of retry attempts for transient gRPC errors
+	GRPCBackendAddr  string        // Target gRPC backend address (e.g., "localhost:50051")
+	GRPCDeadline     time.Duration // Maximum time to wait for a gRPC call to complete
+	GRPCDialTimeout  time.Duration // Maximum time to establish a gRPC connection
+	ShutdownTimeout  time.Duration // Maximum time to wait for graceful shutdown
+	PreShutdownDelay time.Duration // Time readiness reports 503 before shutdown begins (0 disables)
+	MaxGRPCRetries   uint          // Maximum number of retry attempts for transient gRPC errors
+	GRPCRetryBackoff time.Duration // Wait before the first retry, doubled for every further attempt
+	GRPCJitter       float64       // Randomizes retry and reconnect waits by up to this fraction, e.g. 0.2 for ±20% (0 disables)
+	GRPCWarmup       bool          // Connect to the backend at startup and wait for it to be ready (default: false)
+	GRPCMaxConnIdle  time.Duration // Idle time before the gRPC channel drops its transports (0 disables)
+	GRPCMaxConnAge   time.Duration // Age after which the gRPC connection is recycled (0 disables)
+
+	GRPCMaxConcurrentCalls int           // Cap on in-flight gRPC calls (0 disables)
+	GRPCConcurrencyWait    time.Duration // Time a call waits for a free slot before ResourceExhausted (0 fails immediately)
+	GRPCHealthInterval     time.Duration // Interval between backend health checks feeding grpc_backend_up (0 disables)
+
+	// Full gRPC method names, e.g. "/helloworld.Greeter/SayHello", that are not
+	// idempotent and therefore never retried, whatever status they fail with
+	GRPCNonRetryableMethods []string
+
+	GRPCMaxRecvMsgBytes int // Largest gRPC reply accepted from the backend (0 keeps gRPC's 4 MiB default)
+
+	// Service discovery file for local multi-backend setups, e.g.
+	// { "greeter": "localhost:50051" }. When set, the backend address is the entry of
+	// GRPCBackendService instead of GRPCBackendAddr, and is re-read on SIGHUP.
+	GRPCBackendFile    string // Path of the JSON file (default: "", use GRPCBackendAddr)
+	GRPCBackendService string // Service whose address is used (default: "greeter")
 }
 
 // Defaults returns a Config with all fields set to their default values.
@@ -46,12 +157,28 @@ func Defaults() Config {
 		HTTPListenAddr: ":8080",
 		MetricsPath:    "/metrics",
 		HealthPath:     "/healthz",
+		ReadyPath:      "/readyz",
+		MaxTimeout:     30 * time.Second,
+		EnableIndex:    true,
+		MaxHeaderBytes: 1 << 20,
+		IdleTimeout:    2 * time.Minute,
+
+		MetricsNamespace: "grpc_http1_proxy",
 
 		GRPCBackendAddr: "localhost:50051",
 		GRPCDeadline:    5 * time.Second,
 		GRPCDialTimeout: 5 * time.Second,
 		ShutdownTimeout: 10 * time.Second,
 		MaxGRPCRetries:  2,
+
+		GRPCRetryBackoff: 50 * time.Millisecond,
+		GRPCJitter:       0.2,
+
+		GRPCHealthInterval: 10 * time.Second,
+
+		GRPCBackendService: "greeter",
+
+		CacheMaxEntries: 1024,
 	}
 }
 
@@ -74,9 +201,72 @@ func FromEnv() Config {
 	if v := os.Getenv(envMetricsPath); v != "" {
 		cfg.MetricsPath = v
 	}
+	if v := os.Getenv(envMetricsNS); v != "" {
+		cfg.MetricsNamespace = v
+	}
+	if v := os.Getenv(envMetricsSubsys); v != "" {
+		cfg.MetricsSubsystem = v
+	}
 	if v := os.Getenv(envGRPCBackend); v != "" {
 		cfg.GRPCBackendAddr = v
 	}
+	if v := os.Getenv(envBackendFile); v != "" {
+		cfg.GRPCBackendFile = v
+	}
+	if v := os.Getenv(envBackendService); v != "" {
+		cfg.GRPCBackendService = v
+	}
+	if v := os.Getenv(envStripPrefix); v != "" {
+		cfg.StripPathPrefix = v
+	}
+	if v := os.Getenv(envTLSCertFile); v != "" {
+		cfg.TLSCertFile = v
+	}
+	if v := os.Getenv(envTLSKeyFile); v != "" {
+		cfg.TLSKeyFile = v
+	}
+	if v := parseList(envAPIKeys); len(v) > 0 {
+		cfg.APIKeys = v
+	}
+	if v, ok := parseBool(envEnableIndex); ok {
+		cfg.EnableIndex = v
+	}
+	if v, ok := parseBool(envRedactBackend); ok {
+		cfg.RedactBackend = v
+	}
+	if v, ok := parseBool(envPrettyJSON); ok {
+		cfg.PrettyJSON = v
+	}
+	if v, ok := parseBool(envEnumNumbers); ok {
+		cfg.UseEnumNumbers = v
+	}
+	if v, ok := parseBool(envInt64AsNumber); ok {
+		cfg.Int64AsNumber = v
+	}
+	if v, ok := parseBool(envEnableH2C); ok {
+		cfg.EnableH2C = v
+	}
+	if v, ok := parseBool(envSingleFlight); ok {
+		cfg.SingleFlight = v
+	}
+	if v, ok := parseBool(envEnableETag); ok {
+		cfg.EnableETag = v
+	}
+	if v, ok := parseBool(envEnableGET); ok {
+		cfg.EnableGET = v
+	}
+	if v, ok := parseBool(envFieldMask); ok {
+		cfg.FieldMask = v
+	}
+	if v, ok := parseBool(envCompression); ok {
+		cfg.Compression = v
+	}
+	if v, ok := parseBool(envExposeSchemas); ok {
+		cfg.ExposeSchemas = v
+	}
+	if v, ok := parseBool(envWarmup); ok {
+		cfg.GRPCWarmup = v
+	}
 
 	// Load duration-based settings (converted from milliseconds)
 	if v := parseDurationFromMillis(envGRPCDeadlineMS); v > 0 {
@@ -85,14 +275,88 @@ func FromEnv() Config {
 	if v := parseDurationFromMillis(envGRPCDialMS); v > 0 {
 		cfg.GRPCDialTimeout = v
 	}
+	if v := parseDurationFromMillis(envMaxTimeoutMS); v > 0 {
+		cfg.MaxTimeout = v
+	}
 	if v := parseDurationFromMillis(envShutdownMS); v > 0 {
 		cfg.ShutdownTimeout = v
 	}
+	if v := parseDurationFromMillis(envShutdownDelay); v > 0 {
+		cfg.PreShutdownDelay = v
+	}
+	if v := parseDurationFromMillis(envMaxConnIdleMS); v > 0 {
+		cfg.GRPCMaxConnIdle = v
+	}
+	if v := parseDurationFromMillis(envMaxConnAgeMS); v > 0 {
+		cfg.GRPCMaxConnAge = v
+	}
+	if v := parseDurationFromMillis(envConcurrencyMS); v > 0 {
+		cfg.GRPCConcurrencyWait = v
+	}
+	// 0 is meaningful here (disables the health checks), so parse it like a count
+	if v := parseUint(envHealthMS); v >= 0 {
+		cfg.GRPCHealthInterval = time.Duration(v) * time.Millisecond
+	}
 
 	// Load retry configuration
 	if v := parseUint(envMaxRetries); v >= 0 {
 		cfg.MaxGRPCRetries = uint(v)
 	}
+	if v := parseDurationFromMillis(envRetryBackoffMS); v > 0 {
+		cfg.GRPCRetryBackoff = v
+	}
+	if v, ok := parseFloat(envJitter); ok {
+		cfg.GRPCJitter = v
+	}
+	if v := parseList(envNonRetryable); len(v) > 0 {
+		cfg.GRPCNonRetryableMethods = v
+	}
+
+	// Load concurrency limit
+	if v := parseUint(envMaxConcurrent); v >= 0 {
+		cfg.GRPCMaxConcurrentCalls = int(v)
+	}
+
+	// Load message size limits
+	if v := parseUint(envMaxRecvBytes); v >= 0 {
+		cfg.GRPCMaxRecvMsgBytes = int(v)
+	}
+	if v := parseUint(envMaxRespBytes); v >= 0 {
+		cfg.MaxResponseBytes = int(v)
+	}
+	if v := parseUint(envMaxHeaderBytes); v > 0 {
+		cfg.MaxHeaderBytes = int(v)
+	}
+
+	// Load connection timeouts; 0 disables them
+	if v := parseUint(envWriteTimeoutMS); v >= 0 {
+		cfg.WriteTimeout = time.Duration(v) * time.Millisecond
+	}
+	if v := parseUint(envIdleTimeoutMS); v >= 0 {
+		cfg.IdleTimeout = time.Duration(v) * time.Millisecond
+	}
+
+	// Load response cache settings; a TTL of 0 disables the cache
+	if v := parseUint(envCacheTTLMS); v >= 0 {
+		cfg.CacheTTL = time.Duration(v) * time.Millisecond
+	}
+	if v := parseUint(envCacheEntries); v >= 0 {
+		cfg.CacheMaxEntries = int(v)
+	}
+	if v, ok := parseBool(envCacheStale); ok {
+		cfg.CacheServeStale = v
+	}
+
+	// Load the concurrency limit of the proxy routes; 0 disables it
+	if v := parseUint(envHTTPConcurrent); v >= 0 {
+		cfg.MaxConcurrent = int(v)
+	}
+	if v := parseUint(envHTTPQueue); v >= 0 {
+		cfg.MaxQueue = int(v)
+	}
+	if v := parseUint(envHTTPQueueWait); v >= 0 {
+		cfg.QueueWait = time.Duration(v) * time.Millisecond
+	}
 
 	return cfg
 }
@@ -122,6 +386,41 @@ func parseUint(key string) int64 {
 	return -1
 }
 
+// parseFloat reads an environment variable and parses it with strconv.ParseFloat.
+// The boolean result is false if the variable is not set, empty, or invalid.
+func parseFloat(key string) (float64, bool) {
+	if raw := os.Getenv(key); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// parseList reads a comma-separated environment variable. Entries are trimmed and
+// empty ones dropped; nil is returned if the variable is not set or has no entries.
+func parseList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseBool reads an environment variable and parses it with strconv.ParseBool.
+// The second return value is false if the variable is not set, empty, or invalid,
+// so callers keep their default in that case.
+func parseBool(key string) (bool, bool) {
+	if raw := os.Getenv(key); raw != "" {
+		if v, err := strconv.ParseBool(raw); err == nil {
+			return v, true
+		}
+	}
+	return false, false
+}
+
 // BindFlags registers command-line flags for all Config fields in the provided FlagSet.
 // The default value for each flag is taken from the current Config state, allowing
 // environment variables to be overridden by command-line arguments.
@@ -138,15 +437,68 @@ func (cfg *Config) BindFlags(fs *flag.FlagSet) {
 	if fs == nil {
 		panic("nil FlagSet")
 	}
-	fs.StringVar(&cfg.HTTPListenAddr, "http-listen", cfg.HTTPListenAddr, "address to bind the HTTP server to")
+	fs.StringVar(&cfg.HTTPListenAddr, "http-listen", cfg.HTTPListenAddr, "address to bind the HTTP server to, or unix:<path> for a Unix domain socket")
 	fs.StringVar(&cfg.MetricsPath, "metrics-path", cfg.MetricsPath, "path that exposes Prometheus metrics")
+	fs.StringVar(&cfg.MetricsNamespace, "metrics-namespace", cfg.MetricsNamespace, "prefix of the metric names, e.g. to keep them apart from other services scraped by the same Prometheus")
+	fs.StringVar(&cfg.MetricsSubsystem, "metrics-subsystem", cfg.MetricsSubsystem, "optional prefix between the metrics namespace and the metric names")
+	fs.DurationVar(&cfg.MaxTimeout, "http-max-timeout", cfg.MaxTimeout, "upper bound for deadlines requested via the X-Timeout-Ms header")
+	fs.BoolVar(&cfg.EnableIndex, "enable-index", cfg.EnableIndex, "serve a JSON index of the registered routes at GET /")
+	fs.BoolVar(&cfg.RedactBackend, "redact-backend", cfg.RedactBackend, "hide the gRPC backend address from the index")
+	fs.BoolVar(&cfg.PrettyJSON, "pretty-json", cfg.PrettyJSON, "indent JSON response bodies (clients can override with ?pretty=0 or ?pretty=1)")
+	fs.BoolVar(&cfg.UseEnumNumbers, "enum-numbers", cfg.UseEnumNumbers, "render enum fields in JSON responses as numbers instead of value names")
+	fs.BoolVar(&cfg.Int64AsNumber, "int64-as-number", cfg.Int64AsNumber, "render 64-bit integer fields in JSON responses as numbers instead of strings (values above 2^53 lose precision in JavaScript)")
+	fs.StringVar(&cfg.StripPathPrefix, "strip-path-prefix", cfg.StripPathPrefix, "path prefix added by an ingress (e.g. /api/v1) that is removed before routing")
+	fs.IntVar(&cfg.MaxResponseBytes, "http-max-response-bytes", cfg.MaxResponseBytes, "largest JSON response body sent to clients; bigger backend replies fail with 502 (0 disables)")
+	fs.IntVar(&cfg.MaxHeaderBytes, "http-max-header-bytes", cfg.MaxHeaderBytes, "largest request header block accepted; bigger requests get 431")
+	fs.DurationVar(&cfg.WriteTimeout, "http-write-timeout", cfg.WriteTimeout, "time from the end of the request headers to the end of the response, so slow clients cannot hold connections (0 disables)")
+	fs.DurationVar(&cfg.IdleTimeout, "http-idle-timeout", cfg.IdleTimeout, "time an idle keep-alive connection stays open (0 disables)")
+	fs.StringVar(&cfg.TLSCertFile, "tls-cert-file", cfg.TLSCertFile, "PEM certificate to serve HTTPS with; rotated files are picked up without a restart")
+	fs.StringVar(&cfg.TLSKeyFile, "tls-key-file", cfg.TLSKeyFile, "PEM private key for --tls-cert-file")
+	fs.BoolVar(&cfg.EnableH2C, "enable-h2c", cfg.EnableH2C, "also serve HTTP/2 over cleartext (h2c) on the HTTP listen address")
+	fs.BoolVar(&cfg.SingleFlight, "single-flight", cfg.SingleFlight, "collapse concurrent requests with identical bodies into one gRPC call")
+	fs.BoolVar(&cfg.EnableETag, "enable-etag", cfg.EnableETag, "set a weak ETag on 200 responses and answer a matching If-None-Match with 304 Not Modified")
+	fs.BoolVar(&cfg.EnableGET, "enable-get", cfg.EnableGET, "also accept GET requests whose request fields are given as query parameters")
+	fs.BoolVar(&cfg.FieldMask, "forward-field-mask", cfg.FieldMask, "send the top-level fields present in each request as x-field-mask gRPC metadata, so backends can tell omitted fields from zero values")
+	fs.BoolVar(&cfg.Compression, "negotiate-gzip", cfg.Compression, "gzip the replies of clients sending Accept-Encoding: gzip and request gzip from the backend on their calls")
+	fs.BoolVar(&cfg.ExposeSchemas, "expose-schemas", cfg.ExposeSchemas, "serve the JSON Schema of each method's request message at GET <route>/schema, e.g. /helloworld/SayHello/schema")
+	fs.DurationVar(&cfg.CacheTTL, "cache-ttl", cfg.CacheTTL, "how long successful replies are served from the in-memory cache keyed by request body (0 disables)")
+	fs.IntVar(&cfg.CacheMaxEntries, "cache-max-entries", cfg.CacheMaxEntries, "most replies kept in the response cache; the least recently used is evicted")
+	fs.IntVar(&cfg.MaxConcurrent, "max-concurrent", cfg.MaxConcurrent, "most proxy requests processed at once; further requests queue or are answered with 503 (0 disables)")
+	fs.IntVar(&cfg.MaxQueue, "max-queue", cfg.MaxQueue, "most requests waiting for a slot once --max-concurrent is reached; the rest are answered with 503")
+	fs.DurationVar(&cfg.QueueWait, "queue-wait", cfg.QueueWait, "longest time a queued request waits for a slot before 503 (0 waits until the client gives up)")
+	fs.BoolVar(&cfg.CacheServeStale, "cache-serve-stale", cfg.CacheServeStale, "answer a failed backend call with the cached reply for the same body, even an expired one, with X-Cache: STALE (needs --cache-ttl)")
 	fs.StringVar(&cfg.GRPCBackendAddr, "grpc-backend", cfg.GRPCBackendAddr, "address of the target gRPC backend")
+	fs.StringVar(&cfg.GRPCBackendFile, "backend-file", cfg.GRPCBackendFile, "JSON file mapping service names to gRPC addresses, e.g. {\"greeter\": \"localhost:50051\"}; replaces --grpc-backend and is re-read on SIGHUP")
+	fs.StringVar(&cfg.GRPCBackendService, "backend-service", cfg.GRPCBackendService, "service whose address is taken from --backend-file")
 	fs.DurationVar(&cfg.GRPCDeadline, "grpc-deadline", cfg.GRPCDeadline, "per-request timeout when calling the gRPC backend")
 	fs.DurationVar(&cfg.GRPCDialTimeout, "grpc-dial-timeout", cfg.GRPCDialTimeout, "timeout for establishing the gRPC connection")
 	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "maximum time to wait for graceful HTTP shutdown")
+	fs.DurationVar(&cfg.PreShutdownDelay, "pre-shutdown-delay", cfg.PreShutdownDelay, "time the readiness endpoint reports 503 before shutdown begins, so load balancers stop routing first (0 disables)")
+	fs.BoolVar(&cfg.GRPCWarmup, "grpc-warmup", cfg.GRPCWarmup, "connect to the gRPC backend at startup and wait up to the dial timeout for it to be ready")
 	fs.UintVar(&cfg.MaxGRPCRetries, "grpc-max-retries", cfg.MaxGRPCRetries, "maximum number of retry attempts for transient gRPC errors")
+	fs.DurationVar(&cfg.GRPCRetryBackoff, "grpc-retry-backoff", cfg.GRPCRetryBackoff, "wait before the first gRPC retry, doubled for every further attempt")
+	fs.Float64Var(&cfg.GRPCJitter, "grpc-backoff-jitter", cfg.GRPCJitter, "fraction by which gRPC retry and reconnect waits are randomized, e.g. 0.2 for ±20% (0 disables)")
+	fs.Func("grpc-non-retryable-methods", "comma-separated full gRPC method names (e.g. /helloworld.Greeter/SayHello) that are never retried", func(v string) error {
+		cfg.GRPCNonRetryableMethods = nil
+		for _, method := range strings.Split(v, ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				cfg.GRPCNonRetryableMethods = append(cfg.GRPCNonRetryableMethods, method)
+			}
+		}
+		return nil
+	})
+	fs.DurationVar(&cfg.GRPCMaxConnIdle, "grpc-max-conn-idle", cfg.GRPCMaxConnIdle, "idle time before the gRPC channel drops its transports (0 disables)")
+	fs.DurationVar(&cfg.GRPCMaxConnAge, "grpc-max-conn-age", cfg.GRPCMaxConnAge, "age after which the gRPC connection is recycled to pick up new backends (0 disables)")
+	fs.IntVar(&cfg.GRPCMaxConcurrentCalls, "grpc-max-concurrent-calls", cfg.GRPCMaxConcurrentCalls, "maximum number of in-flight gRPC calls (0 disables)")
+	fs.DurationVar(&cfg.GRPCConcurrencyWait, "grpc-concurrency-wait", cfg.GRPCConcurrencyWait, "time a call waits for a free slot once the concurrency cap is reached (0 rejects immediately)")
+	fs.IntVar(&cfg.GRPCMaxRecvMsgBytes, "grpc-max-recv-msg-bytes", cfg.GRPCMaxRecvMsgBytes, "largest gRPC reply accepted from the backend (0 keeps the 4 MiB gRPC default)")
+	fs.DurationVar(&cfg.GRPCHealthInterval, "grpc-health-interval", cfg.GRPCHealthInterval, "interval between gRPC health checks of the backend feeding grpc_backend_up (0 disables)")
 }
 
+// metricNamePart matches a namespace or subsystem that keeps Prometheus metric
+// names valid once joined with underscores
+var metricNamePart = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 // Validate checks that all required configuration fields have valid values.
 // Returns an error describing the first validation failure encountered.
 // This should be called before using the Config to start the service.
@@ -154,9 +506,15 @@ func (cfg Config) Validate() error {
 	if cfg.HTTPListenAddr == "" {
 		return fmt.Errorf("http listen address must not be empty")
 	}
-	if cfg.GRPCBackendAddr == "" {
+	if cfg.MaxTimeout <= 0 {
+		return fmt.Errorf("http max timeout must be positive")
+	}
+	if cfg.GRPCBackendAddr == "" && cfg.GRPCBackendFile == "" {
 		return fmt.Errorf("grpc backend address must not be empty")
 	}
+	if cfg.GRPCBackendFile != "" && cfg.GRPCBackendService == "" {
+		return fmt.Errorf("grpc backend service must not be empty when a backend file is set")
+	}
 	if cfg.GRPCDeadline <= 0 {
 		return fmt.Errorf("grpc deadline must be positive")
 	}
@@ -166,5 +524,139 @@ func (cfg Config) Validate() error {
 	if cfg.ShutdownTimeout <= 0 {
 		return fmt.Errorf("shutdown timeout must be positive")
 	}
+	if cfg.PreShutdownDelay < 0 {
+		return fmt.Errorf("pre-shutdown delay must not be negative")
+	}
+	if cfg.StripPathPrefix != "" && !strings.HasPrefix(cfg.StripPathPrefix, "/") {
+		return fmt.Errorf("strip path prefix must start with /")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return fmt.Errorf("tls cert file and tls key file must be set together")
+	}
+	if cfg.GRPCMaxConnIdle < 0 {
+		return fmt.Errorf("grpc max connection idle must not be negative")
+	}
+	if cfg.GRPCMaxConnAge < 0 {
+		return fmt.Errorf("grpc max connection age must not be negative")
+	}
+	if cfg.GRPCMaxConcurrentCalls < 0 {
+		return fmt.Errorf("grpc max concurrent calls must not be negative")
+	}
+	if cfg.GRPCConcurrencyWait < 0 {
+		return fmt.Errorf("grpc concurrency wait must not be negative")
+	}
+	if cfg.GRPCMaxRecvMsgBytes < 0 {
+		return fmt.Errorf("grpc max recv message bytes must not be negative")
+	}
+	if cfg.MaxResponseBytes < 0 {
+		return fmt.Errorf("http max response bytes must not be negative")
+	}
+	if !metricNamePart.MatchString(cfg.MetricsNamespace) {
+		return fmt.Errorf("metrics namespace %q must consist of letters, digits and underscores", cfg.MetricsNamespace)
+	}
+	if cfg.MetricsSubsystem != "" && !metricNamePart.MatchString(cfg.MetricsSubsystem) {
+		return fmt.Errorf("metrics subsystem %q must consist of letters, digits and underscores", cfg.MetricsSubsystem)
+	}
+	if cfg.MaxHeaderBytes <= 0 {
+		return fmt.Errorf("http max header bytes must be positive")
+	}
+	if cfg.WriteTimeout < 0 || cfg.IdleTimeout < 0 {
+		return fmt.Errorf("http write and idle timeouts must not be negative")
+	}
+	if cfg.WriteTimeout > 0 && cfg.WriteTimeout <= cfg.MaxTimeout {
+		// The server would cut off responses to requests still within their deadline
+		return fmt.Errorf("http write timeout must be longer than the http max timeout (%s)", cfg.MaxTimeout)
+	}
+	if cfg.GRPCHealthInterval < 0 {
+		return fmt.Errorf("grpc health interval must not be negative")
+	}
+	if cfg.GRPCRetryBackoff <= 0 {
+		return fmt.Errorf("grpc retry backoff must be positive")
+	}
+	if cfg.GRPCJitter < 0 || cfg.GRPCJitter > 1 {
+		return fmt.Errorf("grpc backoff jitter must be between 0 and 1")
+	}
+	if cfg.CacheTTL < 0 {
+		return fmt.Errorf("cache ttl must not be negative")
+	}
+	if cfg.CacheMaxEntries <= 0 {
+		return fmt.Errorf("cache max entries must be positive")
+	}
+	if cfg.CacheServeStale && cfg.CacheTTL == 0 {
+		return fmt.Errorf("serving stale cache entries needs a cache ttl")
+	}
+	if cfg.MaxConcurrent < 0 || cfg.MaxQueue < 0 || cfg.QueueWait < 0 {
+		return fmt.Errorf("http max concurrent, max queue and queue wait must not be negative")
+	}
+	if cfg.MaxQueue > 0 && cfg.MaxConcurrent == 0 {
+		return fmt.Errorf("http max queue needs http max concurrent")
+	}
 	return nil
 }
+
+// redacted replaces the value of a secret setting in LogValue output
+const redacted = "[redacted]"
+
+// LogValue implements slog.LogValuer so the effective configuration can be
+// logged as one group. Secrets are never written: API keys are reduced to
+// their count and the TLS key path is replaced by "[redacted]" when set.
+// Settings added to Config must be added here too.
+func (cfg Config) LogValue() slog.Value {
+	tlsKeyFile := ""
+	if cfg.TLSKeyFile != "" {
+		tlsKeyFile = redacted
+	}
+	return slog.GroupValue(
+		slog.String("httpListenAddr", cfg.HTTPListenAddr),
+		slog.String("metricsPath", cfg.MetricsPath),
+		slog.String("metricsNamespace", cfg.MetricsNamespace),
+		slog.String("metricsSubsystem", cfg.MetricsSubsystem),
+		slog.String("healthPath", cfg.HealthPath),
+		slog.String("readyPath", cfg.ReadyPath),
+		slog.Duration("maxTimeout", cfg.MaxTimeout),
+		slog.Bool("enableIndex", cfg.EnableIndex),
+		slog.Bool("redactBackend", cfg.RedactBackend),
+		slog.Bool("prettyJSON", cfg.PrettyJSON),
+		slog.Bool("useEnumNumbers", cfg.UseEnumNumbers),
+		slog.Bool("int64AsNumber", cfg.Int64AsNumber),
+		slog.Bool("enableH2C", cfg.EnableH2C),
+		slog.Bool("singleFlight", cfg.SingleFlight),
+		slog.Bool("enableETag", cfg.EnableETag),
+		slog.Bool("enableGET", cfg.EnableGET),
+		slog.Bool("fieldMask", cfg.FieldMask),
+		slog.Bool("compression", cfg.Compression),
+		slog.Bool("exposeSchemas", cfg.ExposeSchemas),
+		slog.String("stripPathPrefix", cfg.StripPathPrefix),
+		slog.Int("maxResponseBytes", cfg.MaxResponseBytes),
+		slog.Int("maxHeaderBytes", cfg.MaxHeaderBytes),
+		slog.Duration("writeTimeout", cfg.WriteTimeout),
+		slog.Duration("idleTimeout", cfg.IdleTimeout),
+		slog.String("tlsCertFile", cfg.TLSCertFile),
+		slog.String("tlsKeyFile", tlsKeyFile),
+		slog.Duration("cacheTTL", cfg.CacheTTL),
+		slog.Int("cacheMaxEntries", cfg.CacheMaxEntries),
+		slog.Bool("cacheServeStale", cfg.CacheServeStale),
+		slog.Int("maxConcurrent", cfg.MaxConcurrent),
+		slog.Int("maxQueue", cfg.MaxQueue),
+		slog.Duration("queueWait", cfg.QueueWait),
+		slog.Int("apiKeys", len(cfg.APIKeys)),
+		slog.String("grpcBackendAddr", cfg.GRPCBackendAddr),
+		slog.String("grpcBackendFile", cfg.GRPCBackendFile),
+		slog.String("grpcBackendService", cfg.GRPCBackendService),
+		slog.Duration("grpcDeadline", cfg.GRPCDeadline),
+		slog.Duration("grpcDialTimeout", cfg.GRPCDialTimeout),
+		slog.Duration("shutdownTimeout", cfg.ShutdownTimeout),
+		slog.Duration("preShutdownDelay", cfg.PreShutdownDelay),
+		slog.Uint64("maxGRPCRetries", uint64(cfg.MaxGRPCRetries)),
+		slog.Duration("grpcRetryBackoff", cfg.GRPCRetryBackoff),
+		slog.Float64("grpcJitter", cfg.GRPCJitter),
+		slog.Any("grpcNonRetryableMethods", cfg.GRPCNonRetryableMethods),
+		slog.Bool("grpcWarmup", cfg.GRPCWarmup),
+		slog.Duration("grpcMaxConnIdle", cfg.GRPCMaxConnIdle),
+		slog.Duration("grpcMaxConnAge", cfg.GRPCMaxConnAge),
+		slog.Int("grpcMaxConcurrentCalls", cfg.GRPCMaxConcurrentCalls),
+		slog.Duration("grpcConcurrencyWait", cfg.GRPCConcurrencyWait),
+		slog.Duration("grpcHealthInterval", cfg.GRPCHealthInterval),
+		slog.Int("grpcMaxRecvMsgBytes", cfg.GRPCMaxRecvMsgBytes),
+	)
+}
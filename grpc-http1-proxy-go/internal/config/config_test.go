@@ -0,0 +1,46 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogValueRedactsSecrets(t *testing.T) {
+	cfg := Defaults()
+	cfg.GRPCBackendAddr = "backend.internal:50051"
+	cfg.TLSCertFile = "/etc/proxy/tls.crt"
+	cfg.TLSKeyFile = "/etc/proxy/secret/tls.key"
+	cfg.APIKeys = []string{"key-one-s3cret", "key-two-s3cret"}
+
+	var out bytes.Buffer
+	slog.New(slog.NewTextHandler(&out, nil)).Info("effective configuration", slog.Any("config", cfg))
+	logged := out.String()
+
+	for _, secret := range []string{"/etc/proxy/secret/tls.key", "key-one-s3cret", "key-two-s3cret"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("log output leaks %q:\n%s", secret, logged)
+		}
+	}
+	for _, want := range []string{
+		"config.tlsKeyFile=[redacted]",
+		"config.apiKeys=2",
+		"config.tlsCertFile=/etc/proxy/tls.crt",
+		"config.grpcBackendAddr=backend.internal:50051",
+		"config.httpListenAddr=:8080",
+		"config.grpcDeadline=5s",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log output lacks %q:\n%s", want, logged)
+		}
+	}
+}
+
+func TestLogValueLeavesUnsetKeyFileEmpty(t *testing.T) {
+	var out bytes.Buffer
+	slog.New(slog.NewTextHandler(&out, nil)).Info("effective configuration", slog.Any("config", Defaults()))
+	if !strings.Contains(out.String(), `config.tlsKeyFile=""`) {
+		t.Errorf("expected an empty tlsKeyFile:\n%s", out.String())
+	}
+}
@@ -0,0 +1,41 @@
+package grpcclient
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// attemptsKey is the context key of the attempt counter of a logged call
+type attemptsKey struct{}
+
+// countAttempts must be chained after the retry interceptor, so it runs once per
+// attempt: it increments the counter put into the call context by logCall.
+func countAttempts(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if attempts, ok := ctx.Value(attemptsKey{}).(*int); ok {
+		*attempts++
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// logCall prepares ctx for a call of method that is logged at debug level with its
+// duration, status code and number of attempts once the returned function is called
+// with the call's error. When the logger does not log debug messages, ctx is
+// returned unchanged and the function does nothing.
+func (c *Client) logCall(ctx context.Context, method string) (context.Context, func(error)) {
+	if !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return ctx, func(error) {}
+	}
+	start := time.Now()
+	attempts := new(int)
+	return context.WithValue(ctx, attemptsKey{}, attempts), func(err error) {
+		c.logger.LogAttrs(ctx, slog.LevelDebug, "gRPC call finished",
+			slog.String("method", method),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("code", status.Code(err).String()),
+			slog.Int("attempts", *attempts))
+	}
+}
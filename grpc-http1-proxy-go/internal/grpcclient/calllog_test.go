@@ -0,0 +1,81 @@
+package grpcclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// callLogs decodes the "gRPC call finished" records written to logs
+func callLogs(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	dec := json.NewDecoder(logs)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("decode log record: %v", err)
+		}
+		if record["msg"] == "gRPC call finished" {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func TestCallsAreLoggedAtDebugLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		impl     pb.GreeterServer
+		code     string
+		attempts float64
+	}{
+		{"success", &greeterServer{}, "OK", 1},
+		{"retried failure", &unavailableServer{}, "Unavailable", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			client, err := New(context.Background(), Config{Address: startServer(t, tt.impl), MaxRetries: 2}, logger)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"})
+			records := callLogs(t, &logs)
+			if len(records) != 1 {
+				t.Fatalf("got %d call log records, want 1:\n%s", len(records), logs.String())
+			}
+			record := records[0]
+			if record["level"] != "DEBUG" || record["method"] != pb.Greeter_SayHello_FullMethodName || record["code"] != tt.code || record["attempts"] != tt.attempts {
+				t.Errorf("record = %v, want code %s after %v attempts", record, tt.code, tt.attempts)
+			}
+			if _, ok := record["duration"].(float64); !ok {
+				t.Errorf("record has no duration: %v", record)
+			}
+		})
+	}
+}
+
+func TestCallsAreNotLoggedAboveDebugLevel(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	client, err := New(context.Background(), Config{Address: startServer(t, &greeterServer{})}, logger)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"}); err != nil {
+		t.Fatalf("SayHello() error = %v", err)
+	}
+	if records := callLogs(t, &logs); len(records) != 0 {
+		t.Errorf("got call log records at info level: %v", records)
+	}
+}
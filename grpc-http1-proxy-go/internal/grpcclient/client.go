@@ -8,12 +8,15 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"sync"
 	"time"
 
 	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
@@ -25,16 +28,98 @@ type Config struct {
 	DialTimeout time.Duration // Maximum time to wait when establishing the connection
 	Deadline    time.Duration // Maximum time to wait for each RPC call to complete
 	MaxRetries  uint          // Maximum number of retry attempts for transient errors
+
+	// BackendFile, when set, is a service discovery file (see BackendFile) that the
+	// address of BackendService is read from instead of Address. ReloadBackends
+	// re-reads it and moves new calls to the service's new address.
+	BackendFile    string
+	BackendService string
+
+	// RetryBackoff is the wait before the first retry; it doubles with every
+	// further attempt. Zero uses 50ms.
+	RetryBackoff time.Duration
+	// Jitter randomizes retry waits and reconnect delays by up to this fraction in
+	// either direction (0.2 means ±20%), so proxies recovering from a backend outage
+	// do not all retry and reconnect in lockstep. Zero disables jitter.
+	Jitter float64
+	// NonRetryableMethods lists methods that are sent exactly once even when they
+	// fail with a retryable status, because they are not idempotent. Names are full
+	// method names such as "/helloworld.Greeter/SayHello"; the leading slash is optional.
+	NonRetryableMethods []string
+
+	// WarmupOnStart makes New connect right away and wait, at most DialTimeout, for
+	// the connection to become ready, so the first call does not pay for connection
+	// setup. A backend that is not ready in time is logged and New still succeeds.
+	WarmupOnStart bool
+
+	// MaxConnectionIdle lets the channel go idle (closing its transports) after
+	// this long without RPCs; the next call reconnects and re-resolves the target.
+	// Zero disables the idle timeout.
+	MaxConnectionIdle time.Duration
+	// MaxConnectionAge replaces the connection with a freshly dialed one after
+	// this long so that long-running proxies pick up new backend instances.
+	// Zero disables recycling.
+	MaxConnectionAge time.Duration
+
+	// MaxConcurrentCalls caps the number of in-flight calls to protect the backend.
+	// Zero disables the limit.
+	MaxConcurrentCalls int
+	// MaxConcurrencyWait is how long a call waits for a free slot once the limit is
+	// reached before failing with codes.ResourceExhausted. Zero fails immediately.
+	MaxConcurrencyWait time.Duration
+	// Registry receives the concurrency metrics (in-flight calls and rejections).
+	// If nil, metrics are disabled.
+	Registry *prometheus.Registry
+	// MetricsNamespace and MetricsSubsystem prefix the names of those metrics, as
+	// for the HTTP server's; an empty namespace uses "grpc_http1_proxy".
+	MetricsNamespace string
+	MetricsSubsystem string
+
+	// MaxRecvMsgBytes caps the size of a reply accepted from the backend; larger
+	// replies fail with codes.ResourceExhausted before they are decoded. Zero keeps
+	// gRPC's default of 4 MiB.
+	MaxRecvMsgBytes int
+
+	// AuthTokenProvider, when set, is asked for a token before every call, which is
+	// sent as "authorization: Bearer <token>" metadata for backends that check OAuth
+	// tokens per call. It is independent of the transport credentials; wrap a
+	// TokenCache to reuse tokens until they are about to expire. An error fails the
+	// call with codes.Unavailable, unless it carries a gRPC status of its own.
+	AuthTokenProvider func(ctx context.Context) (string, error)
+
+	// DialOptions are appended after the built-in dial options (credentials, retry
+	// interceptors, connect params, idle timeout) and apply to every connection,
+	// including recycled ones. Options that set a single value, such as
+	// grpc.WithTransportCredentials, override the built-in setting because the last
+	// one wins; grpc.WithChainUnaryInterceptor adds interceptors that run inside
+	// the retry interceptor, i.e. once per attempt.
+	DialOptions []grpc.DialOption
 }
 
 // Client wraps a gRPC connection and provides methods to call the Greeter service.
 // It automatically handles retries, timeouts, and connection lifecycle management.
 // The client should be closed when no longer needed to free up resources.
 type Client struct {
-	cfg     Config              // Client configuration
-	conn    *grpc.ClientConn    // Underlying gRPC connection
-	greeter pb.GreeterClient    // Generated gRPC client stub
-	logger  *slog.Logger        // Logger for error and debug messages
+	cfg      Config            // Client configuration
+	dialOpts []grpc.DialOption // Options used for the initial dial and every recycle
+	logger   *slog.Logger      // Logger for error and debug messages
+	limiter  *limiter          // Caps concurrent calls (nil means unlimited)
+	backends *BackendFile      // Service discovery file (nil when Address is used)
+
+	mu      sync.RWMutex // Guards address, current and closed
+	address string       // Backend address current was dialed to
+	current *managedConn // Connection new calls are sent on
+	closed  bool         // Set by Close; stops recycling
+
+	done      chan struct{} // Closed by Close to stop the recycle loop
+	closeOnce sync.Once     // Makes Close idempotent
+}
+
+// managedConn wraps a gRPC connection and tracks in-flight calls so a recycled
+// connection is only closed once every call using it has finished.
+type managedConn struct {
+	conn     *grpc.ClientConn // Underlying gRPC connection
+	inflight sync.WaitGroup   // Calls currently using conn
 }
 
 // New creates a new gRPC client with the provided configuration.
@@ -43,7 +128,7 @@ type Client struct {
 //
 // Parameters:
 //   - ctx: Context for the connection establishment. If nil, context.Background() is used.
-//   - cfg: Client configuration. Address is required; other fields have defaults.
+//   - cfg: Client configuration. Address or BackendFile is required; other fields have defaults.
 //   - logger: Logger instance. If nil, a no-op logger is used.
 //
 // Returns:
@@ -51,13 +136,26 @@ type Client struct {
 //   - error: Non-nil if connection establishment fails.
 //
 // The client will automatically retry on transient errors (Unavailable, ResourceExhausted,
-// DeadlineExceeded) up to MaxRetries times. Each retry uses exponential backoff.
+// DeadlineExceeded) up to MaxRetries times, except for NonRetryableMethods. Each retry uses exponential backoff
+// from RetryBackoff with Jitter applied.
 func New(ctx context.Context, cfg Config, logger *slog.Logger) (*Client, error) {
 	// Use background context if none provided
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
+	// Resolve the address from the service discovery file if one is given
+	var backends *BackendFile
+	if cfg.BackendFile != "" {
+		var err error
+		if backends, err = LoadBackendFile(cfg.BackendFile); err != nil {
+			return nil, err
+		}
+		if cfg.Address, err = backends.Resolve(cfg.BackendService); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate required configuration
 	if cfg.Address == "" {
 		return nil, errors.New("grpcclient: address must be provided")
@@ -70,6 +168,12 @@ func New(ctx context.Context, cfg Config, logger *slog.Logger) (*Client, error)
 	if cfg.Deadline <= 0 {
 		cfg.Deadline = 5 * time.Second
 	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 50 * time.Millisecond
+	}
+	if cfg.Jitter < 0 || cfg.Jitter > 1 {
+		return nil, errors.New("grpcclient: jitter must be between 0 and 1")
+	}
 	if logger == nil {
 		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
 	}
@@ -83,49 +187,235 @@ func New(ctx context.Context, cfg Config, logger *slog.Logger) (*Client, error)
 		grpc_retry.WithCodes(codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded),
 		// Each retry attempt has its own deadline
 		grpc_retry.WithPerRetryTimeout(cfg.Deadline),
+		// Wait RetryBackoff, then twice as long for every further attempt, ±Jitter
+		grpc_retry.WithBackoff(retryBackoff(cfg)),
 	}
 	if cfg.MaxRetries > 0 {
 		retryOpts = append(retryOpts, grpc_retry.WithMax(cfg.MaxRetries))
 	}
 
-	// Create a context with timeout for the dial operation
-	dctx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
-	defer cancel()
+	noRetry := newDisableRetries(cfg.NonRetryableMethods)
 
-	// Establish gRPC connection with retry middleware and connection parameters
-	conn, err := grpc.DialContext(
-		dctx,
-		cfg.Address,
+	// Connection options shared by the initial dial and every recycled connection
+	dialOpts := []grpc.DialOption{
 		// Use insecure credentials (no TLS) - suitable for local development
 		// In production, use grpc.WithTransportCredentials() with proper TLS config
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		// Add retry interceptors for both unary and streaming calls
-		grpc.WithChainUnaryInterceptor(grpc_retry.UnaryClientInterceptor(retryOpts...)),
-		grpc.WithChainStreamInterceptor(grpc_retry.StreamClientInterceptor(retryOpts...)),
+		// Add retry interceptors for both unary and streaming calls, preceded by the
+		// ones that turn retries off for NonRetryableMethods; unary calls then count
+		// their attempts for the debug log
+		grpc.WithChainUnaryInterceptor(noRetry.unary, grpc_retry.UnaryClientInterceptor(retryOpts...), countAttempts),
+		grpc.WithChainStreamInterceptor(noRetry.stream, grpc_retry.StreamClientInterceptor(retryOpts...)),
 		// Configure connection backoff: start with 200ms, multiply by 1.6, max 2s
-		grpc.WithConnectParams(grpc.ConnectParams{
-			MinConnectTimeout: cfg.DialTimeout,
-			Backoff: backoff.Config{
-				BaseDelay:  200 * time.Millisecond,
-				Multiplier: 1.6,
-				MaxDelay:   2 * time.Second,
-			},
-		}),
-	)
+		grpc.WithConnectParams(connectParams(cfg)),
+	}
+	if cfg.MaxConnectionIdle > 0 {
+		// Client-side counterpart of the server's MaxConnectionIdle keepalive policy
+		dialOpts = append(dialOpts, grpc.WithIdleTimeout(cfg.MaxConnectionIdle))
+	}
+	if cfg.MaxRecvMsgBytes > 0 {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(cfg.MaxRecvMsgBytes)))
+	}
+	if cfg.AuthTokenProvider != nil {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearerToken(cfg.AuthTokenProvider)))
+	}
+	// Caller-supplied options go last so they can extend or override the defaults
+	dialOpts = append(dialOpts, cfg.DialOptions...)
+
+	c := &Client{
+		cfg:      cfg,
+		dialOpts: dialOpts,
+		logger:   logger,
+		limiter:  newLimiter(cfg.MaxConcurrentCalls, cfg.MaxConcurrencyWait, cfg.Registry, cfg.MetricsNamespace, cfg.MetricsSubsystem),
+		backends: backends,
+		address:  cfg.Address,
+		done:     make(chan struct{}),
+	}
+
+	// Establish the initial gRPC connection
+	mc, err := c.dial(ctx, cfg.Address)
 	if err != nil {
 		return nil, err
 	}
+	c.current = mc
+	if cfg.WarmupOnStart {
+		c.warmup(ctx, mc.conn)
+	}
+
+	// Periodically replace the connection once it reaches MaxConnectionAge
+	if cfg.MaxConnectionAge > 0 {
+		go c.recycleLoop()
+	}
 
-	return &Client{
-		cfg:     cfg,
-		conn:    conn,
-		greeter: pb.NewGreeterClient(conn),
-		logger:  logger,
-	}, nil
+	return c, nil
+}
+
+// retryBackoff returns the wait between retry attempts: exponential from
+// cfg.RetryBackoff, randomized by cfg.Jitter
+func retryBackoff(cfg Config) grpc_retry.BackoffFunc {
+	return grpc_retry.BackoffExponentialWithJitter(cfg.RetryBackoff, cfg.Jitter)
+}
+
+// connectParams returns the reconnect backoff of the channel, randomized by cfg.Jitter
+func connectParams(cfg Config) grpc.ConnectParams {
+	return grpc.ConnectParams{
+		MinConnectTimeout: cfg.DialTimeout,
+		Backoff: backoff.Config{
+			BaseDelay:  200 * time.Millisecond,
+			Multiplier: 1.6,
+			Jitter:     cfg.Jitter,
+			MaxDelay:   2 * time.Second,
+		},
+	}
+}
+
+// dial establishes a new gRPC connection to address using the client's dial
+// options, bounded by the configured DialTimeout.
+func (c *Client) dial(ctx context.Context, address string) (*managedConn, error) {
+	// Create a context with timeout for the dial operation
+	dctx, cancel := context.WithTimeout(ctx, c.cfg.DialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(dctx, address, c.dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &managedConn{conn: conn}, nil
+}
+
+// warmup asks conn to connect and waits up to DialTimeout for connectivity.Ready,
+// logging how long that took. It reports whether the connection became ready.
+func (c *Client) warmup(ctx context.Context, conn *grpc.ClientConn) bool {
+	start := time.Now()
+	wctx, cancel := context.WithTimeout(ctx, c.cfg.DialTimeout)
+	defer cancel()
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			c.logger.Info("gRPC connection warmed up",
+				slog.String("target", conn.Target()), slog.Duration("took", time.Since(start)))
+			return true
+		}
+		// Idle, Connecting and TransientFailure all move on by themselves after Connect
+		if !conn.WaitForStateChange(wctx, state) {
+			c.logger.Warn("gRPC connection not ready after warm-up; calls will connect on demand",
+				slog.String("target", conn.Target()), slog.String("state", state.String()),
+				slog.Duration("waited", time.Since(start)))
+			return false
+		}
+	}
+}
+
+// recycleLoop replaces the connection every MaxConnectionAge until the client is closed.
+func (c *Client) recycleLoop() {
+	timer := time.NewTimer(c.cfg.MaxConnectionAge)
+	defer timer.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-timer.C:
+			c.recycle()
+			timer.Reset(c.cfg.MaxConnectionAge)
+		}
+	}
+}
+
+// recycle dials a replacement connection and swaps it in for new calls.
+// The old connection is closed in the background once its in-flight calls complete,
+// so requests that started before the swap are never cut off. If the dial fails,
+// the current connection is kept and the next recycle attempt retries.
+func (c *Client) recycle() {
+	address := c.Address()
+	next, err := c.dial(context.Background(), address)
+	if err != nil {
+		c.logger.Warn("gRPC connection recycle failed; keeping current connection", slog.String("err", err.Error()))
+		return
+	}
+	// A reload that moved to another address in the meantime wins
+	if c.swap(next, address, address) {
+		c.logger.Debug("gRPC connection recycled", slog.String("target", address))
+	}
+}
+
+// swap makes next, dialed to address, the connection for new calls if the client
+// is still open and its address is still expected. The replaced connection is
+// closed once its in-flight calls complete; next is closed if it is not used.
+func (c *Client) swap(next *managedConn, address, expected string) bool {
+	c.mu.Lock()
+	if c.closed || c.address != expected {
+		c.mu.Unlock()
+		_ = next.conn.Close()
+		return false
+	}
+	old := c.current
+	c.current = next
+	c.address = address
+	c.mu.Unlock()
+
+	go func() {
+		old.inflight.Wait()
+		_ = old.conn.Close()
+	}()
+	return true
+}
+
+// Address returns the backend address new calls are sent to.
+func (c *Client) Address() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.address
+}
+
+// ReloadBackends re-reads the BackendFile and, if the address of BackendService
+// changed, connects to the new address and sends new calls there; calls already
+// in flight finish on the old connection. If the file cannot be read or no longer
+// lists the service, the error is returned and the current connection is kept.
+func (c *Client) ReloadBackends() error {
+	if c.backends == nil {
+		return errors.New("grpcclient: no backend file configured")
+	}
+	if err := c.backends.Reload(); err != nil {
+		return err
+	}
+	address, err := c.backends.Resolve(c.cfg.BackendService)
+	if err != nil {
+		return err
+	}
+
+	previous := c.Address()
+	if address == previous {
+		return nil
+	}
+	next, err := c.dial(context.Background(), address)
+	if err != nil {
+		return err
+	}
+	if !c.swap(next, address, previous) {
+		return errors.New("grpcclient: client closed or reloaded concurrently")
+	}
+	c.logger.Info("gRPC backend address changed", slog.String("from", previous), slog.String("to", address))
+	return nil
+}
+
+// acquire returns the current connection and registers a call on it.
+// The caller must call inflight.Done() on the returned connection when the call finishes.
+func (c *Client) acquire() (*managedConn, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return nil, errors.New("grpcclient: client is closed")
+	}
+	c.current.inflight.Add(1)
+	return c.current, nil
 }
 
 // SayHello calls the SayHello RPC method on the Greeter service.
 // It applies the configured deadline to the request and handles context cancellation.
+// If ctx already carries a deadline (e.g. one requested by the HTTP client), that
+// deadline is used instead of the configured one and is propagated to the backend.
 //
 // Parameters:
 //   - ctx: Request context. If nil, context.Background() is used. The context can be
@@ -139,7 +429,32 @@ func New(ctx context.Context, cfg Config, logger *slog.Logger) (*Client, error)
 // The method will automatically retry on transient errors according to the client's
 // retry configuration. If the context is cancelled or the deadline is exceeded,
 // the call will fail immediately.
+//
+// When MaxConcurrentCalls is set and that many calls are in flight, SayHello waits
+// up to MaxConcurrencyWait for one to finish and otherwise fails with
+// codes.ResourceExhausted without contacting the backend.
+//
+// With the logger at debug level every call is logged when it is done, with its
+// method, duration, status code and the number of attempts sent to the backend.
 func (c *Client) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	return c.Invoke(ctx, pb.Greeter_SayHello_FullMethodName, req)
+}
+
+// Invoke calls the unary method named by its full gRPC method name (e.g.
+// "/helloworld.Greeter/SayHello") with a HelloRequest and decodes a HelloReply, so
+// services that share the Greeter's message shape can be proxied without a
+// generated stub. Deadlines, retries and the concurrency limit apply as for SayHello,
+// and a compressor set on ctx with WithCompressor is used for the call.
+//
+// Parameters:
+//   - ctx: Request context. If nil, context.Background() is used.
+//   - method: Full gRPC method name, "/<package>.<Service>/<Method>".
+//   - req: The request message. Must not be nil.
+//
+// Returns:
+//   - *pb.HelloReply: The reply of the method.
+//   - error: Non-nil if the RPC call fails.
+func (c *Client) Invoke(ctx context.Context, method string, req *pb.HelloRequest) (reply *pb.HelloReply, err error) {
 	// Use background context if none provided
 	if ctx == nil {
 		ctx = context.Background()
@@ -150,27 +465,73 @@ func (c *Client) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloR
 		return nil, errors.New("grpcclient: request must not be nil")
 	}
 
-	// Apply deadline if configured
+	// Log the method, duration, status and attempts at debug level when the call is done
+	ctx, logDone := c.logCall(ctx, method)
+	defer func() { logDone(err) }()
+
+	// Apply deadline if configured and the caller did not set one
+	// A caller deadline also disables the per-retry timeout, which would
+	// otherwise cap every attempt at the configured deadline
 	callCtx := ctx
-	if c.cfg.Deadline > 0 {
+	var callOpts []grpc.CallOption
+	if _, ok := ctx.Deadline(); ok {
+		callOpts = append(callOpts, grpc_retry.WithPerRetryTimeout(0))
+	} else if c.cfg.Deadline > 0 {
 		var cancel context.CancelFunc
 		callCtx, cancel = context.WithTimeout(ctx, c.cfg.Deadline)
 		defer cancel() // Ensure the cancel function is called to free resources
 	}
 
+	// Compress the call when the caller asked for it with WithCompressor
+	if name, ok := CompressorFromContext(ctx); ok {
+		callOpts = append(callOpts, grpc.UseCompressor(name))
+	}
+
+	// Take a concurrency slot; the wait is bounded by the call deadline too
+	release, err := c.limiter.acquire(callCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Pin the call to the current connection so a concurrent recycle waits for it
+	mc, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer mc.inflight.Done()
+
 	// Make the gRPC call (retries are handled by the interceptor)
-	return c.greeter.SayHello(callCtx, req)
+	reply = new(pb.HelloReply)
+	if err := mc.conn.Invoke(callCtx, method, req, reply, callOpts...); err != nil {
+		return nil, err
+	}
+	return reply, nil
 }
 
 // Close closes the underlying gRPC connection and releases associated resources.
 // This should be called when the client is no longer needed to prevent resource leaks.
-// It is safe to call Close multiple times or on a nil client.
+// It also stops connection recycling. It is safe to call Close multiple times or on a nil client.
 //
 // Returns:
 //   - error: Non-nil if closing the connection fails (rare).
 func (c *Client) Close() error {
-	if c == nil || c.conn == nil {
+	if c == nil {
 		return nil
 	}
-	return c.conn.Close()
+
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.mu.Lock()
+		c.closed = true
+		mc := c.current
+		c.mu.Unlock()
+
+		if mc != nil {
+			err = mc.conn.Close()
+		}
+	})
+	return err
 }
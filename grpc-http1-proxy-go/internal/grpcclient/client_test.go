@@ -0,0 +1,349 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// greeterServer replies "Hello, <name>" and optionally blocks until release is closed.
+type greeterServer struct {
+	pb.UnimplementedGreeterServer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *greeterServer) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	if s.release != nil {
+		s.started <- struct{}{}
+		<-s.release
+	}
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+func startServer(t *testing.T, impl pb.GreeterServer) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterGreeterServer(srv, impl)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func (c *Client) currentConn() *grpc.ClientConn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current.conn
+}
+
+func TestClientServesCallsAcrossRecycle(t *testing.T) {
+	addr := startServer(t, &greeterServer{})
+	client, err := New(context.Background(), Config{Address: addr, MaxConnectionAge: 20 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	first := client.currentConn()
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		resp, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"})
+		if err != nil {
+			t.Fatalf("SayHello() error = %v", err)
+		}
+		if resp.GetMessage() != "Hello, alice" {
+			t.Fatalf("unexpected reply %q", resp.GetMessage())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if client.currentConn() == first {
+		t.Fatalf("expected connection to be recycled after MaxConnectionAge")
+	}
+}
+
+func TestRecycleLetsInFlightCallsFinish(t *testing.T) {
+	impl := &greeterServer{started: make(chan struct{}, 1), release: make(chan struct{})}
+	addr := startServer(t, impl)
+	client, err := New(context.Background(), Config{Address: addr}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	var callErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, callErr = client.SayHello(context.Background(), &pb.HelloRequest{Name: "bob"})
+	}()
+
+	<-impl.started
+	old := client.currentConn()
+	client.recycle()
+	if client.currentConn() == old {
+		t.Fatalf("expected recycle to swap the connection")
+	}
+	close(impl.release)
+	wg.Wait()
+
+	if callErr != nil {
+		t.Fatalf("in-flight call failed across recycle: %v", callErr)
+	}
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	addr := startServer(t, &greeterServer{})
+	client, err := New(context.Background(), Config{Address: addr, MaxConnectionAge: time.Hour}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+	if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "x"}); err == nil {
+		t.Fatalf("expected SayHello on a closed client to fail")
+	}
+}
+
+// deadlineServer reports how far away the incoming call's deadline is.
+type deadlineServer struct {
+	pb.UnimplementedGreeterServer
+	remaining chan time.Duration
+}
+
+func (s *deadlineServer) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		s.remaining <- 0
+	} else {
+		s.remaining <- time.Until(deadline)
+	}
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+func TestSayHelloPropagatesCallerDeadline(t *testing.T) {
+	impl := &deadlineServer{remaining: make(chan time.Duration, 1)}
+	addr := startServer(t, impl)
+	client, err := New(context.Background(), Config{Address: addr, Deadline: 200 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// A caller deadline longer than the configured one must reach the backend unchanged
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := client.SayHello(ctx, &pb.HelloRequest{Name: "alice"}); err != nil {
+		t.Fatalf("SayHello() error = %v", err)
+	}
+	if remaining := <-impl.remaining; remaining < 2*time.Second {
+		t.Fatalf("expected the backend to see the ~3s caller deadline, got %v", remaining)
+	}
+}
+
+func TestSayHelloAppliesConfiguredDeadlineByDefault(t *testing.T) {
+	impl := &deadlineServer{remaining: make(chan time.Duration, 1)}
+	addr := startServer(t, impl)
+	client, err := New(context.Background(), Config{Address: addr, Deadline: 2 * time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"}); err != nil {
+		t.Fatalf("SayHello() error = %v", err)
+	}
+	if remaining := <-impl.remaining; remaining <= 0 || remaining > 2*time.Second {
+		t.Fatalf("expected the configured 2s deadline, got %v", remaining)
+	}
+}
+
+func TestDialOptionsAddUnaryInterceptor(t *testing.T) {
+	addr := startServer(t, &greeterServer{})
+
+	var calls []string
+	var mu sync.Mutex
+	interceptor := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		mu.Lock()
+		calls = append(calls, method)
+		mu.Unlock()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	client, err := New(context.Background(), Config{
+		Address:     addr,
+		DialOptions: []grpc.DialOption{grpc.WithChainUnaryInterceptor(interceptor)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"}); err != nil {
+		t.Fatalf("SayHello() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 1 || calls[0] != pb.Greeter_SayHello_FullMethodName {
+		t.Fatalf("expected the custom interceptor to see one SayHello call, got %v", calls)
+	}
+}
+
+// largeReplyServer replies with a message of size bytes
+type largeReplyServer struct {
+	pb.UnimplementedGreeterServer
+	size int
+}
+
+func (s *largeReplyServer) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: strings.Repeat("x", s.size)}, nil
+}
+
+func TestMaxRecvMsgBytesRejectsOversizedReply(t *testing.T) {
+	impl := &largeReplyServer{size: 64 << 10}
+	addr := startServer(t, impl)
+
+	limited, err := New(context.Background(), Config{Address: addr, MaxRecvMsgBytes: 16 << 10}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer limited.Close()
+	_, err = limited.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted for a 64 KiB reply with a 16 KiB limit, got %v", err)
+	}
+
+	// The same reply fits under gRPC's default limit
+	unlimited, err := New(context.Background(), Config{Address: addr}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer unlimited.Close()
+	reply, err := unlimited.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"})
+	if err != nil || len(reply.GetMessage()) != impl.size {
+		t.Fatalf("expected the full reply without a limit, got %d bytes, err %v", len(reply.GetMessage()), err)
+	}
+}
+
+func TestWarmupReachesReadyBeforeFirstCall(t *testing.T) {
+	addr := startServer(t, &greeterServer{})
+	client, err := New(context.Background(), Config{Address: addr, WarmupOnStart: true}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if state := client.currentConn().GetState(); state != connectivity.Ready {
+		t.Fatalf("expected the connection to be Ready after New, got %s", state)
+	}
+}
+
+func TestWarmupGivesUpAfterDialTimeout(t *testing.T) {
+	// Nothing listens on this port once the listener is closed
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	start := time.Now()
+	client, err := New(context.Background(), Config{Address: addr, WarmupOnStart: true, DialTimeout: 100 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("warm-up took %s, expected it to stop at the dial timeout", elapsed)
+	}
+	if state := client.currentConn().GetState(); state == connectivity.Ready {
+		t.Fatal("expected the connection not to be Ready without a backend")
+	}
+}
+
+func TestRetryBackoffIsExponentialWithJitter(t *testing.T) {
+	backoff := retryBackoff(Config{RetryBackoff: 100 * time.Millisecond, Jitter: 0.2})
+	for attempt, base := range map[uint]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond} {
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 50; i++ {
+			wait := backoff(context.Background(), attempt)
+			if wait < base*8/10 || wait > base*12/10 {
+				t.Fatalf("attempt %d: wait %v outside %v ±20%%", attempt, wait, base)
+			}
+			seen[wait] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("attempt %d: expected jittered waits, always got %v", attempt, base)
+		}
+	}
+
+	// Without jitter the waits are exact
+	if wait := retryBackoff(Config{RetryBackoff: 100 * time.Millisecond})(context.Background(), 2); wait != 200*time.Millisecond {
+		t.Errorf("expected 200ms without jitter, got %v", wait)
+	}
+}
+
+func TestConnectParamsApplyJitter(t *testing.T) {
+	params := connectParams(Config{DialTimeout: time.Second, Jitter: 0.3})
+	if params.Backoff.Jitter != 0.3 || params.MinConnectTimeout != time.Second {
+		t.Fatalf("unexpected connect params %+v", params)
+	}
+}
+
+func TestNewRejectsJitterOutOfRange(t *testing.T) {
+	for _, jitter := range []float64{-0.1, 1.5} {
+		if _, err := New(context.Background(), Config{Address: "127.0.0.1:1", Jitter: jitter}, nil); err == nil {
+			t.Errorf("expected an error for jitter %v", jitter)
+		}
+	}
+}
+
+func TestInvokeCallsMethodOfAnotherService(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// A service with the Greeter's shape under another name
+	desc := pb.Greeter_ServiceDesc
+	desc.ServiceName = "demo.Welcomer"
+	srv := grpc.NewServer()
+	srv.RegisterService(&desc, &greeterServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	client, err := New(context.Background(), Config{Address: lis.Addr().String()}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	resp, err := client.Invoke(context.Background(), "/demo.Welcomer/SayHello", &pb.HelloRequest{Name: "alice"})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if resp.GetMessage() != "Hello, alice" {
+		t.Errorf("message = %q", resp.GetMessage())
+	}
+	if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("SayHello() error = %v, want Unimplemented without a Greeter service", err)
+	}
+}
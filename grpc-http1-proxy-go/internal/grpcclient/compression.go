@@ -0,0 +1,26 @@
+package grpcclient
+
+import (
+	"context"
+
+	// Registers the gzip compressor, so gzip.Name can be requested per call
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// compressorKey is the context key of the compressor requested for a call
+type compressorKey struct{}
+
+// WithCompressor returns a copy of ctx that makes Invoke and SayHello send the
+// call with grpc.UseCompressor(name), e.g. gzip.Name from
+// google.golang.org/grpc/encoding/gzip. The request is compressed with it and the
+// backend is asked to compress its reply the same way. The compressor must be
+// registered; gzip always is.
+func WithCompressor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, compressorKey{}, name)
+}
+
+// CompressorFromContext returns the compressor requested with WithCompressor.
+func CompressorFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(compressorKey{}).(string)
+	return name, ok && name != ""
+}
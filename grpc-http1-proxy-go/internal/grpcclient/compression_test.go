@@ -0,0 +1,46 @@
+package grpcclient
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func TestWithCompressorSelectsPerCallCompressor(t *testing.T) {
+	var compressors []string
+	interceptor := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		compressor := ""
+		for _, opt := range opts {
+			if c, ok := opt.(grpc.CompressorCallOption); ok {
+				compressor = c.CompressorType
+			}
+		}
+		compressors = append(compressors, compressor)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	client, err := New(context.Background(), Config{
+		Address:     startServer(t, &greeterServer{}),
+		DialOptions: []grpc.DialOption{grpc.WithChainUnaryInterceptor(interceptor)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	for _, ctx := range []context.Context{context.Background(), WithCompressor(context.Background(), gzip.Name)} {
+		reply, err := client.SayHello(ctx, &pb.HelloRequest{Name: "alice"})
+		if err != nil {
+			t.Fatalf("SayHello() error = %v", err)
+		}
+		if reply.GetMessage() != "Hello, alice" {
+			t.Errorf("reply = %q, want %q", reply.GetMessage(), "Hello, alice")
+		}
+	}
+	if len(compressors) != 2 || compressors[0] != "" || compressors[1] != gzip.Name {
+		t.Errorf("compressors = %q, want none for the plain call and gzip for the WithCompressor call", compressors)
+	}
+}
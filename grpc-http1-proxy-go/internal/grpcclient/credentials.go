@@ -0,0 +1,74 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// bearerToken is the per-RPC credentials of Config.AuthTokenProvider: every call
+// carries "authorization: Bearer <token>" metadata with the token it returns.
+type bearerToken func(ctx context.Context) (string, error)
+
+var _ credentials.PerRPCCredentials = bearerToken(nil)
+
+// GetRequestMetadata asks the provider for the token of one call. A provider error
+// fails the call with codes.Unavailable (or the error's own status), which the retry
+// interceptor treats like any other unavailable backend.
+func (b bearerToken) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := b(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, errors.New("grpcclient: auth token provider returned an empty token")
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity returns false: the backend connection is plaintext, and
+// the token must still be sent over it. Use TLS dial options where tokens cross
+// untrusted networks.
+func (b bearerToken) RequireTransportSecurity() bool {
+	return false
+}
+
+// TokenCache caches a token fetched from an identity provider and fetches a new one
+// shortly before it expires, so Config.AuthTokenProvider can be cache.Token without
+// a round trip per call. It is safe for concurrent use; concurrent calls needing a
+// new token share one fetch.
+type TokenCache struct {
+	fetch  func(ctx context.Context) (token string, expires time.Time, err error)
+	margin time.Duration    // Refresh this long before the token expires
+	now    func() time.Time // Clock, replaceable in tests
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewTokenCache returns a cache of the tokens returned by fetch, which also reports
+// when each expires. A token is refreshed once less than margin of its lifetime is
+// left, so calls in flight do not present an expired token.
+func NewTokenCache(fetch func(ctx context.Context) (token string, expires time.Time, err error), margin time.Duration) *TokenCache {
+	return &TokenCache{fetch: fetch, margin: margin, now: time.Now}
+}
+
+// Token returns the cached token, fetching a new one first if there is none or it
+// is about to expire. A failed fetch is returned and retried on the next call.
+func (c *TokenCache) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && c.now().Add(c.margin).Before(c.expires) {
+		return c.token, nil
+	}
+	token, expires, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expires = token, expires
+	return token, nil
+}
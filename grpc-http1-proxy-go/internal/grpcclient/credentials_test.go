@@ -0,0 +1,121 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// authServer remembers the authorization metadata of every call
+type authServer struct {
+	pb.UnimplementedGreeterServer
+	mu     sync.Mutex
+	tokens []string
+}
+
+func (s *authServer) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.mu.Lock()
+	s.tokens = append(s.tokens, md.Get("authorization")...)
+	s.mu.Unlock()
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+func TestAuthTokenProviderAttachesBearerToken(t *testing.T) {
+	impl := &authServer{}
+	next := 0
+	client, err := New(context.Background(), Config{
+		Address: startServer(t, impl),
+		AuthTokenProvider: func(ctx context.Context) (string, error) {
+			next++
+			return fmt.Sprintf("token-%d", next), nil
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"}); err != nil {
+			t.Fatalf("SayHello() error = %v", err)
+		}
+	}
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	if len(impl.tokens) != 2 || impl.tokens[0] != "Bearer token-1" || impl.tokens[1] != "Bearer token-2" {
+		t.Fatalf("authorization metadata = %q, want a fresh bearer token per call", impl.tokens)
+	}
+}
+
+func TestAuthTokenProviderErrorFailsCall(t *testing.T) {
+	impl := &authServer{}
+	client, err := New(context.Background(), Config{
+		Address: startServer(t, impl),
+		AuthTokenProvider: func(ctx context.Context) (string, error) {
+			return "", status.Error(codes.Unauthenticated, "identity provider down")
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("SayHello() error = %v, want Unauthenticated", err)
+	}
+	if len(impl.tokens) != 0 {
+		t.Fatalf("expected the call not to reach the backend, got tokens %q", impl.tokens)
+	}
+}
+
+func TestTokenCacheRefreshesBeforeExpiry(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	fetches := 0
+	var fetchErr error
+	cache := NewTokenCache(func(ctx context.Context) (string, time.Time, error) {
+		if fetchErr != nil {
+			return "", time.Time{}, fetchErr
+		}
+		fetches++
+		return fmt.Sprintf("token-%d", fetches), now.Add(time.Minute), nil
+	}, 10*time.Second)
+	cache.now = func() time.Time { return now }
+
+	steps := []struct {
+		advance time.Duration
+		want    string
+	}{
+		{0, "token-1"},
+		{49 * time.Second, "token-1"}, // 11s left, more than the margin
+		{time.Second, "token-2"},      // 10s left: refreshed
+		{time.Second, "token-2"},
+	}
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		token, err := cache.Token(context.Background())
+		if err != nil {
+			t.Fatalf("step %d: Token() error = %v", i, err)
+		}
+		if token != step.want {
+			t.Fatalf("step %d: Token() = %q, want %q", i, token, step.want)
+		}
+	}
+
+	// A failed refresh is reported, not papered over with the expiring token
+	now = now.Add(time.Hour)
+	fetchErr = errors.New("identity provider down")
+	if _, err := cache.Token(context.Background()); !errors.Is(err, fetchErr) {
+		t.Fatalf("Token() error = %v, want the fetch error", err)
+	}
+}
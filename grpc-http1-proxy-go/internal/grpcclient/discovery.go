@@ -0,0 +1,76 @@
+package grpcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BackendFile is a service discovery file for local multi-backend setups: a JSON
+// object mapping service names to gRPC addresses, such as
+//
+//	{ "greeter": "localhost:50051" }
+//
+// It is read once by LoadBackendFile and again on every Reload, e.g. on SIGHUP.
+type BackendFile struct {
+	path string
+
+	mu    sync.RWMutex      // Guards addrs
+	addrs map[string]string // Service name → address, as last read
+}
+
+// LoadBackendFile reads the service discovery file at path.
+//
+// Returns:
+//   - *BackendFile: The parsed file, ready to Resolve service names.
+//   - error: Non-nil if the file cannot be read, is not a JSON object of strings,
+//     or maps a service to an empty address.
+func LoadBackendFile(path string) (*BackendFile, error) {
+	b := &BackendFile{path: path}
+	if err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Reload re-reads the file. If it cannot be read or parsed, the error is returned
+// and the previously loaded addresses are kept.
+func (b *BackendFile) Reload() error {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return fmt.Errorf("grpcclient: read backend file: %w", err)
+	}
+	var addrs map[string]string
+	if err := json.Unmarshal(data, &addrs); err != nil {
+		return fmt.Errorf("grpcclient: parse backend file %s: %w", b.path, err)
+	}
+	for service, addr := range addrs {
+		if strings.TrimSpace(addr) == "" {
+			return fmt.Errorf("grpcclient: backend file %s: service %q has an empty address", b.path, service)
+		}
+	}
+
+	b.mu.Lock()
+	b.addrs = addrs
+	b.mu.Unlock()
+	return nil
+}
+
+// Resolve returns the address of service as last read from the file. The error
+// for an unknown service lists the services the file does define.
+func (b *BackendFile) Resolve(service string) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if addr, ok := b.addrs[service]; ok {
+		return addr, nil
+	}
+	known := make([]string, 0, len(b.addrs))
+	for name := range b.addrs {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return "", fmt.Errorf("grpcclient: service %q not found in backend file %s (services: %s)", service, b.path, strings.Join(known, ", "))
+}
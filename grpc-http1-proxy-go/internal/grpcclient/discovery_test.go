@@ -0,0 +1,149 @@
+package grpcclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func writeBackendFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestBackendFileResolvesServiceAddress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.json")
+	writeBackendFile(t, path, `{ "greeter": "localhost:50051", "orders": "localhost:50052" }`)
+
+	backends, err := LoadBackendFile(path)
+	if err != nil {
+		t.Fatalf("LoadBackendFile() error = %v", err)
+	}
+	addr, err := backends.Resolve("orders")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if addr != "localhost:50052" {
+		t.Errorf("Resolve(orders) = %q, want localhost:50052", addr)
+	}
+}
+
+func TestBackendFileMissingService(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.json")
+	writeBackendFile(t, path, `{ "orders": "localhost:50052", "greeter": "localhost:50051" }`)
+
+	backends, err := LoadBackendFile(path)
+	if err != nil {
+		t.Fatalf("LoadBackendFile() error = %v", err)
+	}
+	_, err = backends.Resolve("billing")
+	if err == nil {
+		t.Fatal("Resolve() succeeded for a service that is not in the file")
+	}
+	if !strings.Contains(err.Error(), `service "billing" not found`) || !strings.Contains(err.Error(), "(services: greeter, orders)") {
+		t.Errorf("Resolve() error = %v, want it to name the service and list the known ones", err)
+	}
+}
+
+func TestLoadBackendFileRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"not an object":  `["localhost:50051"]`,
+		"empty address":  `{ "greeter": " " }`,
+		"non-string":     `{ "greeter": 50051 }`,
+		"truncated JSON": `{ "greeter": "localhost:50051"`,
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".json")
+		writeBackendFile(t, path, content)
+		if _, err := LoadBackendFile(path); err == nil {
+			t.Errorf("%s: LoadBackendFile() succeeded, want an error", name)
+		}
+	}
+	if _, err := LoadBackendFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadBackendFile() succeeded for a missing file")
+	}
+}
+
+func TestBackendFileReloadKeepsAddressesOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.json")
+	writeBackendFile(t, path, `{ "greeter": "localhost:50051" }`)
+	backends, err := LoadBackendFile(path)
+	if err != nil {
+		t.Fatalf("LoadBackendFile() error = %v", err)
+	}
+
+	writeBackendFile(t, path, `{ "greeter": `)
+	if err := backends.Reload(); err == nil {
+		t.Fatal("Reload() succeeded for a truncated file")
+	}
+	if addr, err := backends.Resolve("greeter"); err != nil || addr != "localhost:50051" {
+		t.Errorf("Resolve() = %q, %v after a failed reload, want the previous address", addr, err)
+	}
+}
+
+func TestClientReloadBackendsMovesToNewAddress(t *testing.T) {
+	first := startServer(t, &greeterServer{})
+	second := startServer(t, &greeterServer{})
+	path := filepath.Join(t.TempDir(), "backends.json")
+	writeBackendFile(t, path, `{ "greeter": "`+first+`" }`)
+
+	client, err := New(context.Background(), Config{BackendFile: path, BackendService: "greeter"}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+	if got := client.Address(); got != first {
+		t.Fatalf("Address() = %q, want %q from the backend file", got, first)
+	}
+
+	writeBackendFile(t, path, `{ "greeter": "`+second+`" }`)
+	if err := client.ReloadBackends(); err != nil {
+		t.Fatalf("ReloadBackends() error = %v", err)
+	}
+	if got := client.Address(); got != second {
+		t.Fatalf("Address() = %q after reload, want %q", got, second)
+	}
+	if got := client.currentConn().Target(); got != second {
+		t.Errorf("connection target = %q after reload, want %q", got, second)
+	}
+	if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "reload"}); err != nil {
+		t.Errorf("SayHello() after reload error = %v", err)
+	}
+
+	// A reload that drops the service keeps the current backend
+	writeBackendFile(t, path, `{ "orders": "`+first+`" }`)
+	if err := client.ReloadBackends(); err == nil || !strings.Contains(err.Error(), `service "greeter" not found`) {
+		t.Errorf("ReloadBackends() error = %v, want the missing service reported", err)
+	}
+	if got := client.Address(); got != second {
+		t.Errorf("Address() = %q after a failed reload, want %q", got, second)
+	}
+}
+
+func TestNewRejectsUnknownBackendService(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.json")
+	writeBackendFile(t, path, `{ "greeter": "localhost:50051" }`)
+
+	_, err := New(context.Background(), Config{BackendFile: path, BackendService: "orders"}, nil)
+	if err == nil || !strings.Contains(err.Error(), `service "orders" not found`) {
+		t.Fatalf("New() error = %v, want the missing service reported", err)
+	}
+}
+
+func TestReloadBackendsWithoutBackendFile(t *testing.T) {
+	client, err := New(context.Background(), Config{Address: startServer(t, &greeterServer{})}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.ReloadBackends(); err == nil {
+		t.Error("ReloadBackends() succeeded without a backend file")
+	}
+}
@@ -0,0 +1,137 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthChecker is the subset of healthpb.HealthClient used by HealthMonitor.
+// *Client implements it on top of its current connection.
+type HealthChecker interface {
+	Check(ctx context.Context, in *healthpb.HealthCheckRequest, opts ...grpc.CallOption) (*healthpb.HealthCheckResponse, error)
+}
+
+// Check calls grpc.health.v1.Health/Check on the backend over the current connection.
+// Retries are disabled so that a single failed probe is reported as such; the call
+// does not count towards MaxConcurrentCalls.
+//
+// Parameters:
+//   - ctx: Context bounding the check. It should carry a deadline.
+//   - in: The request; an empty Service asks about the server as a whole.
+//   - opts: Additional call options, applied after the built-in ones.
+//
+// Returns:
+//   - *healthpb.HealthCheckResponse: The backend's serving status.
+//   - error: Non-nil if the backend could not be reached or does not implement the
+//     health service (codes.Unimplemented).
+func (c *Client) Check(ctx context.Context, in *healthpb.HealthCheckRequest, opts ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	mc, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer mc.inflight.Done()
+
+	opts = append([]grpc.CallOption{grpc_retry.Disable()}, opts...)
+	return healthpb.NewHealthClient(mc.conn).Check(ctx, in, opts...)
+}
+
+// HealthMonitor periodically probes the backend's health service and publishes the
+// result as the grpc_backend_up gauge, prefixed with the metrics namespace: 1 while
+// the backend reports SERVING, 0 when it reports anything else or cannot be reached.
+// This makes backend reachability observable even when no requests are flowing.
+type HealthMonitor struct {
+	checker  HealthChecker    // Health client used for the probes
+	interval time.Duration    // Time between probes; also bounds each probe
+	up       prometheus.Gauge // 1 if the last probe succeeded (nil if metrics are disabled)
+	logger   *slog.Logger     // Logs status transitions
+}
+
+// NewHealthMonitor creates a monitor probing checker every interval.
+//
+// Parameters:
+//   - checker: Health client to probe, typically the proxy's *Client.
+//   - interval: Time between probes. Must be positive.
+//   - registry: Prometheus registry for the grpc_backend_up gauge. If nil, metrics are disabled.
+//   - namespace, subsystem: Prefixes of the gauge name; an empty namespace uses defaultMetricsNamespace.
+//   - logger: Logger for status transitions. If nil, a no-op logger is used.
+//
+// Returns:
+//   - *HealthMonitor: A monitor ready to Run; the gauge starts at 0 until the first probe.
+//   - error: Non-nil if checker is nil or interval is not positive.
+func NewHealthMonitor(checker HealthChecker, interval time.Duration, registry *prometheus.Registry, namespace, subsystem string, logger *slog.Logger) (*HealthMonitor, error) {
+	if checker == nil {
+		return nil, errors.New("grpcclient: health checker must not be nil")
+	}
+	if interval <= 0 {
+		return nil, errors.New("grpcclient: health check interval must be positive")
+	}
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	m := &HealthMonitor{checker: checker, interval: interval, logger: logger}
+	if registry != nil {
+		if namespace == "" {
+			namespace = defaultMetricsNamespace
+		}
+		m.up = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "grpc_backend_up",
+			Help:      "1 if the last gRPC health check of the backend reported SERVING, 0 otherwise",
+		})
+		registry.MustRegister(m.up)
+	}
+	return m, nil
+}
+
+// Run probes the backend immediately and then every interval until ctx is done.
+// It blocks, so it is usually started in its own goroutine; cancel ctx to stop it.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	healthy := m.probe(ctx)
+	m.logger.Info("backend health", slog.Bool("up", healthy))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if now := m.probe(ctx); now != healthy {
+				healthy = now
+				m.logger.Info("backend health changed", slog.Bool("up", healthy))
+			}
+		}
+	}
+}
+
+// probe runs a single health check bounded by the interval and updates the gauge.
+//
+// Returns:
+//   - bool: true if the backend reported SERVING.
+func (m *HealthMonitor) probe(ctx context.Context) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, m.interval)
+	defer cancel()
+
+	resp, err := m.checker.Check(probeCtx, &healthpb.HealthCheckRequest{})
+	healthy := err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+	if err != nil && ctx.Err() == nil {
+		m.logger.Debug("backend health check failed", slog.String("err", err.Error()))
+	}
+	if m.up != nil {
+		if healthy {
+			m.up.Set(1)
+		} else {
+			m.up.Set(0)
+		}
+	}
+	return healthy
+}
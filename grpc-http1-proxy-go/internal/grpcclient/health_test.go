@@ -0,0 +1,184 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// stubHealth answers every probe with its current status or error and reports
+// each probe on probed
+type stubHealth struct {
+	mu     sync.Mutex
+	status healthpb.HealthCheckResponse_ServingStatus
+	err    error
+	probed chan struct{}
+}
+
+func (s *stubHealth) set(status healthpb.HealthCheckResponse_ServingStatus, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.err = status, err
+}
+
+func (s *stubHealth) Check(ctx context.Context, in *healthpb.HealthCheckRequest, opts ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	s.mu.Lock()
+	status, err := s.status, s.err
+	s.mu.Unlock()
+	defer func() { s.probed <- struct{}{} }()
+	if err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: status}, nil
+}
+
+// waitForProbes blocks until n more probes have completed
+func waitForProbes(t *testing.T, stub *stubHealth, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-stub.probed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a health probe")
+		}
+	}
+}
+
+func TestHealthMonitorTogglesBackendUpGauge(t *testing.T) {
+	stub := &stubHealth{status: healthpb.HealthCheckResponse_SERVING, probed: make(chan struct{})}
+	registry := prometheus.NewRegistry()
+	monitor, err := NewHealthMonitor(stub, 5*time.Millisecond, registry, "", "", nil)
+	if err != nil {
+		t.Fatalf("NewHealthMonitor() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		monitor.Run(ctx)
+	}()
+
+	// probed fires before the gauge is updated, and a probe finishing right after
+	// set() may have read the previous status. Probes run one after another, so
+	// once the third probe after set() has fired the second one's result is visible.
+	waitForProbes(t, stub, 2)
+	if got := testutil.ToFloat64(monitor.up); got != 1 {
+		t.Fatalf("grpc_backend_up = %v while SERVING, want 1", got)
+	}
+
+	stub.set(healthpb.HealthCheckResponse_NOT_SERVING, nil)
+	waitForProbes(t, stub, 3)
+	if got := testutil.ToFloat64(monitor.up); got != 0 {
+		t.Fatalf("grpc_backend_up = %v while NOT_SERVING, want 0", got)
+	}
+
+	stub.set(healthpb.HealthCheckResponse_SERVING, nil)
+	waitForProbes(t, stub, 3)
+	if got := testutil.ToFloat64(monitor.up); got != 1 {
+		t.Fatalf("grpc_backend_up = %v after recovery, want 1", got)
+	}
+
+	stub.set(healthpb.HealthCheckResponse_UNKNOWN, errors.New("connection refused"))
+	waitForProbes(t, stub, 3)
+	if got := testutil.ToFloat64(monitor.up); got != 0 {
+		t.Fatalf("grpc_backend_up = %v while unreachable, want 0", got)
+	}
+
+	// Run returns once its context is canceled; drain a probe that may be in flight
+	cancel()
+	for {
+		select {
+		case <-done:
+			return
+		case <-stub.probed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Run did not return after cancel")
+		}
+	}
+}
+
+// metricNames returns the names of the metric families in registry
+func metricNames(t *testing.T, registry *prometheus.Registry) map[string]bool {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	names := make(map[string]bool)
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	return names
+}
+
+func TestHealthMonitorGaugeUsesMetricsNamespace(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if _, err := NewHealthMonitor(&stubHealth{}, time.Second, registry, "", "", nil); err != nil {
+		t.Fatalf("NewHealthMonitor() error = %v", err)
+	}
+	if names := metricNames(t, registry); !names["grpc_http1_proxy_grpc_backend_up"] {
+		t.Errorf("metrics = %v, want grpc_http1_proxy_grpc_backend_up", names)
+	}
+
+	registry = prometheus.NewRegistry()
+	if _, err := NewHealthMonitor(&stubHealth{}, time.Second, registry, "edge", "greeter", nil); err != nil {
+		t.Fatalf("NewHealthMonitor() error = %v", err)
+	}
+	if names := metricNames(t, registry); !names["edge_greeter_grpc_backend_up"] {
+		t.Errorf("metrics = %v, want edge_greeter_grpc_backend_up", names)
+	}
+}
+
+func TestNewHealthMonitorValidatesArguments(t *testing.T) {
+	if _, err := NewHealthMonitor(nil, time.Second, nil, "", "", nil); err == nil {
+		t.Error("expected an error for a nil checker")
+	}
+	if _, err := NewHealthMonitor(&stubHealth{}, 0, nil, "", "", nil); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
+
+func TestClientCheckQueriesBackendHealthService(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthSrv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	client, err := New(context.Background(), Config{Address: lis.Addr().String()}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		return resp.GetStatus()
+	}
+	if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Check() = %v, want SERVING", got)
+	}
+	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	if got := check(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("Check() = %v, want NOT_SERVING", got)
+	}
+}
@@ -0,0 +1,117 @@
+package grpcclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultMetricsNamespace prefixes the metric names unless a namespace is configured.
+const defaultMetricsNamespace = "grpc_http1_proxy"
+
+// errTooManyCalls is returned when no call slot frees up within MaxConcurrencyWait.
+var errTooManyCalls = status.Error(codes.ResourceExhausted, "grpcclient: too many concurrent calls")
+
+// limiter caps the number of in-flight gRPC calls with a buffered channel used as
+// a counting semaphore. A nil limiter places no limit.
+type limiter struct {
+	slots    chan struct{}      // One buffered element per in-flight call
+	wait     time.Duration      // How long to wait for a free slot (0 rejects immediately)
+	inflight prometheus.Gauge   // Current number of in-flight calls (nil if metrics are disabled)
+	rejected prometheus.Counter // Calls rejected because the limit was reached (nil if metrics are disabled)
+}
+
+// newLimiter creates a limiter allowing max concurrent calls.
+//
+// Parameters:
+//   - max: Maximum number of concurrent calls. Zero or negative disables the limit.
+//   - wait: How long a call may wait for a free slot before it is rejected.
+//   - registry: Prometheus registry for the concurrency metrics. If nil, metrics are disabled.
+//   - namespace, subsystem: Metric name prefixes; an empty namespace uses defaultMetricsNamespace.
+//
+// Returns:
+//   - *limiter: The limiter, or nil when max is not positive.
+func newLimiter(max int, wait time.Duration, registry *prometheus.Registry, namespace, subsystem string) *limiter {
+	if max <= 0 {
+		return nil
+	}
+	l := &limiter{slots: make(chan struct{}, max), wait: wait}
+	if registry != nil {
+		if namespace == "" {
+			namespace = defaultMetricsNamespace
+		}
+		l.inflight = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "grpc_inflight_calls",
+			Help:      "Number of gRPC calls currently in flight to the backend",
+		})
+		l.rejected = prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "grpc_rejected_calls_total",
+			Help:      "gRPC calls rejected because the concurrency limit was reached",
+		})
+		registry.MustRegister(l.inflight, l.rejected)
+	}
+	return l
+}
+
+// acquire takes a call slot, waiting up to l.wait (or until ctx is done) when all
+// slots are in use.
+//
+// Returns:
+//   - func(): Releases the slot. Must be called exactly once when the call finishes.
+//   - error: errTooManyCalls if no slot freed up in time, or ctx.Err() if the
+//     context ended first.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	default:
+		if err := l.waitForSlot(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.inflight != nil {
+		l.inflight.Inc()
+	}
+	return func() {
+		if l.inflight != nil {
+			l.inflight.Dec()
+		}
+		<-l.slots
+	}, nil
+}
+
+// waitForSlot blocks until a slot is taken, the wait elapses or ctx is done
+func (l *limiter) waitForSlot(ctx context.Context) error {
+	if l.wait <= 0 {
+		l.reject()
+		return errTooManyCalls
+	}
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		l.reject()
+		return errTooManyCalls
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) reject() {
+	if l.rejected != nil {
+		l.rejected.Inc()
+	}
+}
@@ -0,0 +1,200 @@
+package grpcclient
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// countingServer records the highest number of calls it handled at once.
+type countingServer struct {
+	pb.UnimplementedGreeterServer
+	active  atomic.Int32
+	maxSeen atomic.Int32
+}
+
+func (s *countingServer) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	n := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		seen := s.maxSeen.Load()
+		if n <= seen || s.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+func TestSayHelloRejectsCallsOverLimit(t *testing.T) {
+	impl := &greeterServer{started: make(chan struct{}, 1), release: make(chan struct{})}
+	addr := startServer(t, impl)
+	registry := prometheus.NewRegistry()
+	client, err := New(context.Background(), Config{Address: addr, MaxConcurrentCalls: 1, Registry: registry}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "first"})
+		done <- err
+	}()
+	<-impl.started
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "second"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("SayHello() over the limit: got %v, want ResourceExhausted", err)
+	}
+	if got := testutil.ToFloat64(client.limiter.inflight); got != 1 {
+		t.Fatalf("inflight gauge = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(client.limiter.rejected); got != 1 {
+		t.Fatalf("rejected counter = %v, want 1", got)
+	}
+
+	close(impl.release)
+	if err := <-done; err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	if got := testutil.ToFloat64(client.limiter.inflight); got != 0 {
+		t.Fatalf("inflight gauge after release = %v, want 0", got)
+	}
+
+	// The slot is free again once the first call returns
+	if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "third"}); err != nil {
+		t.Fatalf("SayHello() after release error = %v", err)
+	}
+}
+
+func TestLimiterMetricsUseMetricsNamespace(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	newLimiter(1, 0, registry, "edge", "greeter")
+	names := metricNames(t, registry)
+	for _, want := range []string{"edge_greeter_grpc_inflight_calls", "edge_greeter_grpc_rejected_calls_total"} {
+		if !names[want] {
+			t.Errorf("metrics = %v, want %s", names, want)
+		}
+	}
+
+	registry = prometheus.NewRegistry()
+	newLimiter(1, 0, registry, "", "")
+	if names := metricNames(t, registry); !names["grpc_http1_proxy_grpc_inflight_calls"] {
+		t.Errorf("metrics = %v, want the default grpc_http1_proxy namespace", names)
+	}
+}
+
+func TestSayHelloWaitsForFreeSlot(t *testing.T) {
+	impl := &greeterServer{started: make(chan struct{}, 2), release: make(chan struct{})}
+	addr := startServer(t, impl)
+	client, err := New(context.Background(), Config{
+		Address:            addr,
+		MaxConcurrentCalls: 1,
+		MaxConcurrencyWait: 5 * time.Second,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, errs[0] = client.SayHello(context.Background(), &pb.HelloRequest{Name: "first"})
+	}()
+	<-impl.started
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, errs[1] = client.SayHello(context.Background(), &pb.HelloRequest{Name: "second"})
+	}()
+
+	// The second call must be held back by the limiter, not reach the backend
+	select {
+	case <-impl.started:
+		t.Fatalf("second call reached the backend while the limit was reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(impl.release)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+}
+
+func TestSayHelloWaitRespectsTimeout(t *testing.T) {
+	impl := &greeterServer{started: make(chan struct{}, 1), release: make(chan struct{})}
+	addr := startServer(t, impl)
+	client, err := New(context.Background(), Config{
+		Address:            addr,
+		MaxConcurrentCalls: 1,
+		MaxConcurrencyWait: 20 * time.Millisecond,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+	defer close(impl.release)
+
+	go client.SayHello(context.Background(), &pb.HelloRequest{Name: "first"})
+	<-impl.started
+
+	start := time.Now()
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "second"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("SayHello() after wait: got %v, want ResourceExhausted", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("SayHello() rejected after %v, expected it to wait first", elapsed)
+	}
+}
+
+func TestSayHelloNeverExceedsLimit(t *testing.T) {
+	impl := &countingServer{}
+	addr := startServer(t, impl)
+	client, err := New(context.Background(), Config{
+		Address:            addr,
+		MaxConcurrentCalls: 2,
+		MaxConcurrencyWait: 5 * time.Second,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	var failures atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "x"}); err != nil {
+				failures.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := failures.Load(); n != 0 {
+		t.Fatalf("%d calls failed", n)
+	}
+	if got := impl.maxSeen.Load(); got > 2 {
+		t.Fatalf("backend saw %d concurrent calls, limit is 2", got)
+	}
+}
@@ -0,0 +1,44 @@
+package grpcclient
+
+import (
+	"context"
+	"strings"
+
+	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"google.golang.org/grpc"
+)
+
+// disableRetries holds the full method names (e.g. "/helloworld.Greeter/SayHello")
+// of the methods that must never be retried. A nil set disables nothing.
+type disableRetries map[string]bool
+
+// newDisableRetries builds the set from names given with or without the leading
+// slash of a full method name.
+func newDisableRetries(methods []string) disableRetries {
+	if len(methods) == 0 {
+		return nil
+	}
+	set := make(disableRetries, len(methods))
+	for _, method := range methods {
+		set["/"+strings.TrimPrefix(method, "/")] = true
+	}
+	return set
+}
+
+// unary must be chained before the retry interceptor: for a listed method it
+// adds grpc_retry.WithMax(0) to the call options, so the retry interceptor sends
+// the call exactly once whatever status it fails with.
+func (d disableRetries) unary(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if d[method] {
+		opts = append(opts, grpc_retry.WithMax(0))
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// stream is the streaming counterpart of unary.
+func (d disableRetries) stream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if d[method] {
+		opts = append(opts, grpc_retry.WithMax(0))
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
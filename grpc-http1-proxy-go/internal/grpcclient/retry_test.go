@@ -0,0 +1,55 @@
+package grpcclient
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// unavailableServer fails every call with codes.Unavailable and counts them
+type unavailableServer struct {
+	pb.UnimplementedGreeterServer
+	calls atomic.Int32
+}
+
+func (s *unavailableServer) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	s.calls.Add(1)
+	return nil, status.Error(codes.Unavailable, "backend down")
+}
+
+func TestNonRetryableMethodIsCalledOnce(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []string
+		want    int32
+	}{
+		{"retried by default", nil, 2},
+		{"full method name", []string{pb.Greeter_SayHello_FullMethodName}, 1},
+		{"without leading slash", []string{"helloworld.Greeter/SayHello"}, 1},
+		{"other method", []string{"/helloworld.Greeter/SayGoodbye"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl := &unavailableServer{}
+			addr := startServer(t, impl)
+			client, err := New(context.Background(), Config{Address: addr, MaxRetries: 2, NonRetryableMethods: tt.methods}, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "alice"})
+			if status.Code(err) != codes.Unavailable {
+				t.Fatalf("expected Unavailable, got %v", err)
+			}
+			if got := impl.calls.Load(); got != tt.want {
+				t.Fatalf("backend called %d times, want %d", got, tt.want)
+			}
+		})
+	}
+}
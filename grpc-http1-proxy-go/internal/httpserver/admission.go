@@ -0,0 +1,123 @@
+package httpserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// codeUnavailable is the error code of the 503 body sent when the proxy is at its
+// concurrency limit and cannot queue the request.
+const codeUnavailable = "UNAVAILABLE"
+
+// admission caps the number of proxy requests processed at once. Requests over the
+// cap wait in a bounded queue for a free slot; once the queue is full, or a queued
+// request waited too long, they are answered with 503. Like the gRPC client's
+// limiter, slots and queue places are buffered channels used as counting semaphores.
+type admission struct {
+	slots  chan struct{}    // One buffered element per request being processed
+	queue  chan struct{}    // One buffered element per request waiting for a slot
+	wait   time.Duration    // How long a queued request waits for a slot (0 waits until the client gives up)
+	queued prometheus.Gauge // Current number of queued requests (nil if metrics are disabled)
+}
+
+// newAdmission creates the admission control for Config.MaxConcurrent.
+//
+// Parameters:
+//   - maxConcurrent: Requests processed at once. Zero or negative disables the limit.
+//   - maxQueue: Requests that may wait for a slot; zero rejects every request over the cap.
+//   - wait: How long a queued request waits before it is rejected.
+//   - registry: Prometheus registry for the queue depth gauge. If nil, metrics are disabled.
+//   - namespace, subsystem: Metric name prefixes, as for the request duration histogram.
+//
+// Returns:
+//   - *admission: The admission control, or nil when maxConcurrent is not positive.
+func newAdmission(maxConcurrent, maxQueue int, wait time.Duration, registry *prometheus.Registry, namespace, subsystem string) *admission {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	a := &admission{
+		slots: make(chan struct{}, maxConcurrent),
+		queue: make(chan struct{}, maxQueue),
+		wait:  wait,
+	}
+	if registry != nil {
+		if namespace == "" {
+			namespace = defaultMetricsNamespace
+		}
+		a.queued = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "http_queued_requests",
+			Help:      "Number of requests waiting for a free slot under the concurrency limit",
+		})
+		registry.MustRegister(a.queued)
+	}
+	return a
+}
+
+// middleware returns the Gin middleware that holds a slot while the rest of the
+// handler chain runs, queueing or rejecting the request when none is free.
+func (a *admission) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		select {
+		case a.slots <- struct{}{}:
+		default:
+			if !a.await(c) {
+				return
+			}
+		}
+		defer func() { <-a.slots }()
+		c.Next()
+	}
+}
+
+// await queues the request until it takes a slot. It reports false after aborting
+// the request when the queue is full, the wait elapsed or the client went away.
+func (a *admission) await(c *gin.Context) bool {
+	select {
+	case a.queue <- struct{}{}:
+	default:
+		abortUnavailable(c, "too many concurrent requests")
+		return false
+	}
+	a.addQueued(1)
+	defer func() {
+		<-a.queue
+		a.addQueued(-1)
+	}()
+
+	// A nil channel never fires, so without a wait only a slot or the client ends it
+	var timeout <-chan time.Time
+	if a.wait > 0 {
+		timer := time.NewTimer(a.wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case a.slots <- struct{}{}:
+		return true
+	case <-timeout:
+		abortUnavailable(c, "timed out waiting for a free request slot")
+		return false
+	case <-c.Request.Context().Done():
+		c.AbortWithStatus(statusClientClosedRequest)
+		return false
+	}
+}
+
+func (a *admission) addQueued(delta float64) {
+	if a.queued != nil {
+		a.queued.Add(delta)
+	}
+}
+
+// abortUnavailable stops the handler chain with a JSON 503.
+func abortUnavailable(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, errorEnvelope{Error: errorDetail{
+		Code:    codeUnavailable,
+		Message: message,
+	}})
+}
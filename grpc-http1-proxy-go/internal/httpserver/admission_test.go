@@ -0,0 +1,194 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// busyGreeter blocks every call until release is closed and records the highest
+// number of calls it was handling at once.
+type busyGreeter struct {
+	active  atomic.Int32
+	maxSeen atomic.Int32
+	release chan struct{}
+}
+
+func (g *busyGreeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	n := g.active.Add(1)
+	defer g.active.Add(-1)
+	for {
+		seen := g.maxSeen.Load()
+		if n <= seen || g.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	<-g.release
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+// queuedRequests returns the value of the queue depth gauge
+func queuedRequests(t *testing.T, registry *prometheus.Registry) float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "grpc_http1_proxy_http_queued_requests" {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatal("queue depth gauge not registered")
+	return 0
+}
+
+// waitFor polls cond until it holds, failing the test after a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// postConcurrently starts n POSTs to the SayHello route and returns their recorders
+// with a function that waits for all of them
+func postConcurrently(srv *Server, n int) ([]*httptest.ResponseRecorder, func()) {
+	recs := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range recs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recs[i] = postHelloBody(srv, `{"name":"queued"}`)
+		}(i)
+	}
+	return recs, wg.Wait
+}
+
+func TestMaxConcurrentQueuesRequestsOverTheLimit(t *testing.T) {
+	greeter := &busyGreeter{release: make(chan struct{})}
+	registry := prometheus.NewRegistry()
+	srv, err := New(Config{ListenAddr: ":0", MaxConcurrent: 2, MaxQueue: 10}, greeter, nil, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	recs, wait := postConcurrently(srv, 6)
+	waitFor(t, "4 queued requests", func() bool { return queuedRequests(t, registry) == 4 })
+	if got := greeter.active.Load(); got != 2 {
+		t.Fatalf("active backend calls = %d, want 2", got)
+	}
+
+	// The health check is not subject to the limit
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("health check while saturated: status = %d, want 200", rec.Code)
+	}
+
+	close(greeter.release)
+	wait()
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK {
+			t.Errorf("request %d: status = %d, want 200 (body %s)", i, rec.Code, rec.Body.String())
+		}
+	}
+	if got := greeter.maxSeen.Load(); got != 2 {
+		t.Errorf("most concurrent backend calls = %d, want 2", got)
+	}
+	if got := queuedRequests(t, registry); got != 0 {
+		t.Errorf("queued requests after completion = %v, want 0", got)
+	}
+}
+
+func TestMaxConcurrentRejectsRequestsOnceQueueIsFull(t *testing.T) {
+	greeter := &busyGreeter{release: make(chan struct{})}
+	registry := prometheus.NewRegistry()
+	srv, err := New(Config{ListenAddr: ":0", MaxConcurrent: 1, MaxQueue: 1}, greeter, nil, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	recs, wait := postConcurrently(srv, 2)
+	waitFor(t, "1 queued request", func() bool { return queuedRequests(t, registry) == 1 })
+
+	rec := postHelloBody(srv, `{"name":"excess"}`)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	var body errorEnvelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != codeUnavailable {
+		t.Errorf("body = %s, want error code %s", rec.Body.String(), codeUnavailable)
+	}
+
+	close(greeter.release)
+	wait()
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK {
+			t.Errorf("request %d: status = %d, want 200", i, rec.Code)
+		}
+	}
+}
+
+func TestMaxConcurrentRejectsRequestsAfterQueueWait(t *testing.T) {
+	greeter := &busyGreeter{release: make(chan struct{})}
+	srv, err := New(Config{ListenAddr: ":0", MaxConcurrent: 1, MaxQueue: 5, MaxQueueWait: 20 * time.Millisecond}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	_, wait := postConcurrently(srv, 1)
+	waitFor(t, "the first call", func() bool { return greeter.active.Load() == 1 })
+
+	start := time.Now()
+	rec := postHelloBody(srv, `{"name":"late"}`)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("rejected after %v, want it to wait 20ms for a slot", elapsed)
+	}
+
+	close(greeter.release)
+	wait()
+}
+
+func TestMaxConcurrentWithoutQueueRejectsImmediately(t *testing.T) {
+	greeter := &busyGreeter{release: make(chan struct{})}
+	srv, err := New(Config{ListenAddr: ":0", MaxConcurrent: 1}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	_, wait := postConcurrently(srv, 1)
+	waitFor(t, "the first call", func() bool { return greeter.active.Load() == 1 })
+	if rec := postHelloBody(srv, `{"name":"excess"}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+
+	close(greeter.release)
+	wait()
+}
+
+func TestNewRejectsQueueWithoutConcurrencyLimit(t *testing.T) {
+	if _, err := New(Config{ListenAddr: ":0", MaxQueue: 10}, &stubGreeter{}, nil, nil); err == nil {
+		t.Fatal("expected an error for MaxQueue without MaxConcurrent")
+	}
+	if _, err := New(Config{ListenAddr: ":0", MaxConcurrent: -1}, &stubGreeter{}, nil, nil); err == nil {
+		t.Fatal("expected an error for a negative MaxConcurrent")
+	}
+}
@@ -0,0 +1,92 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	greeter := &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}
+	srv, err := New(Config{ListenAddr: ":0", APIKeys: []string{"first-key", "second-key"}}, greeter, nil, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		key      string
+		wantCode int
+		wantMsg  string
+	}{
+		{"valid key", "first-key", http.StatusOK, ""},
+		{"second valid key", "second-key", http.StatusOK, ""},
+		{"invalid key", "first-kez", http.StatusUnauthorized, "invalid API key"},
+		{"prefix of a valid key", "first", http.StatusUnauthorized, "invalid API key"},
+		{"missing key", "", http.StatusUnauthorized, "missing X-API-Key header"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", bytes.NewReader([]byte(`{"name":"alice"}`)))
+			if tc.key != "" {
+				req.Header.Set("X-API-Key", tc.key)
+			}
+			rec := httptest.NewRecorder()
+			srv.engine.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected %d got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+			if tc.wantCode != http.StatusUnauthorized {
+				return
+			}
+			var body errorEnvelope
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON body %q: %v", rec.Body.String(), err)
+			}
+			if body.Error.Code != codeUnauthenticated || body.Error.Message != tc.wantMsg {
+				t.Fatalf("unexpected error body: %+v", body)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthExemptsHealthAndMetrics(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0", APIKeys: []string{"secret"}}, &stubGreeter{}, nil, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	for _, path := range []string{"/healthz", "/metrics"} {
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200 without a key, got %d", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /version: expected 401 without a key, got %d", rec.Code)
+	}
+}
+
+func TestAPIKeyAuthDisabledWithoutKeys(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", bytes.NewReader([]byte(`{"name":"alice"}`)))
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with auth disabled, got %d", rec.Code)
+	}
+}
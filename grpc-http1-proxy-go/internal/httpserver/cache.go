@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+const (
+	// cacheHeader reports whether a response came from the response cache
+	cacheHeader = "X-Cache"
+	// defaultCacheMaxEntries bounds the response cache when Config.CacheMaxEntries is not set
+	defaultCacheMaxEntries = 1024
+)
+
+// requestKey identifies requests with the same gRPC method and the same raw body
+func requestKey(method string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return method + "\x00" + hex.EncodeToString(sum[:])
+}
+
+// responseCache is a size-bounded LRU of backend replies that expire ttl after
+// they were stored. Replies are shared between requests and must not be modified.
+// With keepStale set, expired replies stay until they are evicted or replaced, so
+// getStale can fall back on them.
+type responseCache struct {
+	ttl        time.Duration
+	maxEntries int
+	keepStale  bool             // Keep expired entries for getStale
+	now        func() time.Time // Clock, replaceable in tests
+
+	mu      sync.Mutex
+	order   *list.List               // Most recently used entry at the front
+	entries map[string]*list.Element // Values are *cacheEntry
+}
+
+type cacheEntry struct {
+	key     string
+	reply   *pb.HelloReply
+	expires time.Time
+}
+
+// newResponseCache returns a cache holding up to maxEntries replies for ttl each;
+// maxEntries <= 0 uses defaultCacheMaxEntries
+func newResponseCache(ttl time.Duration, maxEntries int) *responseCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &responseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// get returns the unexpired reply stored under key; expired entries are dropped
+// unless the cache keeps stale entries
+func (c *responseCache) get(key string) (*pb.HelloReply, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		if !c.keepStale {
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		}
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.reply, true
+}
+
+// getStale returns the reply stored under key whether or not it expired
+func (c *responseCache) getStale(key string) (*pb.HelloReply, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).reply, true
+}
+
+// add stores reply under key, evicting the least recently used entry when full
+func (c *responseCache) add(key string, reply *pb.HelloReply) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.reply, entry.expires = reply, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, reply: reply, expires: expires})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
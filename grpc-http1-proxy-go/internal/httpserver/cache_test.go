@@ -0,0 +1,188 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// newCachedServer returns a server with a response cache driven by the returned clock
+func newCachedServer(t *testing.T, greeter Greeter, ttl time.Duration, maxEntries int) (*Server, *time.Time) {
+	t.Helper()
+	srv, err := New(Config{ListenAddr: ":0", CacheTTL: ttl, CacheMaxEntries: maxEntries}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	srv.handler.cache.now = func() time.Time { return now }
+	return srv, &now
+}
+
+func TestResponseCacheHitSkipsBackend(t *testing.T) {
+	greeter := &gatedGreeter{}
+	srv, _ := newCachedServer(t, greeter, time.Minute, 0)
+
+	first := postHelloBody(srv, `{"name":"alice"}`)
+	if first.Code != http.StatusOK || first.Header().Get(cacheHeader) != "MISS" {
+		t.Fatalf("first request: got %d X-Cache=%q", first.Code, first.Header().Get(cacheHeader))
+	}
+	second := postHelloBody(srv, `{"name":"alice"}`)
+	if second.Code != http.StatusOK || second.Header().Get(cacheHeader) != "HIT" {
+		t.Fatalf("second request: got %d X-Cache=%q", second.Code, second.Header().Get(cacheHeader))
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Fatalf("cached body %s differs from %s", second.Body.String(), first.Body.String())
+	}
+	if calls := greeter.calls.Load(); calls != 1 {
+		t.Fatalf("expected 1 backend call, got %d", calls)
+	}
+}
+
+func TestResponseCacheMissForDifferentBody(t *testing.T) {
+	greeter := &gatedGreeter{}
+	srv, _ := newCachedServer(t, greeter, time.Minute, 0)
+
+	for _, body := range []string{`{"name":"alice"}`, `{"name":"bob"}`} {
+		if rec := postHelloBody(srv, body); rec.Header().Get(cacheHeader) != "MISS" {
+			t.Fatalf("%s: expected MISS got %q", body, rec.Header().Get(cacheHeader))
+		}
+	}
+	if calls := greeter.calls.Load(); calls != 2 {
+		t.Fatalf("expected 2 backend calls, got %d", calls)
+	}
+}
+
+func TestResponseCacheEntriesExpire(t *testing.T) {
+	greeter := &gatedGreeter{}
+	srv, now := newCachedServer(t, greeter, time.Second, 0)
+
+	postHelloBody(srv, `{"name":"alice"}`)
+	*now = now.Add(999 * time.Millisecond)
+	if rec := postHelloBody(srv, `{"name":"alice"}`); rec.Header().Get(cacheHeader) != "HIT" {
+		t.Fatalf("expected HIT within the TTL, got %q", rec.Header().Get(cacheHeader))
+	}
+	*now = now.Add(time.Millisecond)
+	if rec := postHelloBody(srv, `{"name":"alice"}`); rec.Header().Get(cacheHeader) != "MISS" {
+		t.Fatalf("expected MISS once the TTL passed, got %q", rec.Header().Get(cacheHeader))
+	}
+	if calls := greeter.calls.Load(); calls != 2 {
+		t.Fatalf("expected 2 backend calls, got %d", calls)
+	}
+}
+
+func TestResponseCacheSkipsErrors(t *testing.T) {
+	greeter := &gatedGreeter{err: errors.New("backend down")}
+	srv, _ := newCachedServer(t, greeter, time.Minute, 0)
+
+	for i := 0; i < 2; i++ {
+		rec := postHelloBody(srv, `{"name":"alice"}`)
+		if rec.Code != http.StatusBadGateway || rec.Header().Get(cacheHeader) != "" {
+			t.Fatalf("request %d: got %d X-Cache=%q", i, rec.Code, rec.Header().Get(cacheHeader))
+		}
+	}
+	if calls := greeter.calls.Load(); calls != 2 {
+		t.Fatalf("expected failed replies to reach the backend every time, got %d calls", calls)
+	}
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResponseCache(time.Minute, 2)
+	reply := &pb.HelloReply{Message: "hi"}
+	cache.add("a", reply)
+	cache.add("b", reply)
+	cache.get("a") // b is now the least recently used
+	cache.add("c", reply)
+
+	if _, ok := cache.get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Fatalf("expected %s to stay cached", key)
+		}
+	}
+}
+
+func TestResponseCacheDisabledByDefault(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &gatedGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if rec := postHelloBody(srv, `{"name":"alice"}`); rec.Header().Get(cacheHeader) != "" {
+		t.Fatalf("expected no X-Cache header, got %q", rec.Header().Get(cacheHeader))
+	}
+}
+
+// newStaleCachedServer is newCachedServer with ServeStaleOnError set
+func newStaleCachedServer(t *testing.T, greeter Greeter, ttl time.Duration) (*Server, *time.Time) {
+	t.Helper()
+	srv, err := New(Config{ListenAddr: ":0", CacheTTL: ttl, ServeStaleOnError: true}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	srv.handler.cache.now = func() time.Time { return now }
+	return srv, &now
+}
+
+func TestServeStaleOnErrorKeepsFreshHits(t *testing.T) {
+	greeter := &gatedGreeter{}
+	srv, _ := newStaleCachedServer(t, greeter, time.Minute)
+
+	postHelloBody(srv, `{"name":"alice"}`)
+	greeter.err = errors.New("backend down")
+	rec := postHelloBody(srv, `{"name":"alice"}`)
+	if rec.Code != http.StatusOK || rec.Header().Get(cacheHeader) != "HIT" {
+		t.Fatalf("got %d X-Cache=%q, want a fresh HIT", rec.Code, rec.Header().Get(cacheHeader))
+	}
+	if calls := greeter.calls.Load(); calls != 1 {
+		t.Fatalf("expected a fresh hit not to call the backend, got %d calls", calls)
+	}
+}
+
+func TestServeStaleOnErrorAnswersWithExpiredReply(t *testing.T) {
+	greeter := &gatedGreeter{}
+	srv, now := newStaleCachedServer(t, greeter, time.Second)
+
+	first := postHelloBody(srv, `{"name":"alice"}`)
+	*now = now.Add(time.Hour)
+	greeter.err = errors.New("backend down")
+
+	rec := postHelloBody(srv, `{"name":"alice"}`)
+	if rec.Code != http.StatusOK || rec.Header().Get(cacheHeader) != "STALE" {
+		t.Fatalf("got %d X-Cache=%q, want 200 STALE", rec.Code, rec.Header().Get(cacheHeader))
+	}
+	if rec.Body.String() != first.Body.String() {
+		t.Fatalf("stale body %s differs from %s", rec.Body.String(), first.Body.String())
+	}
+	if calls := greeter.calls.Load(); calls != 2 {
+		t.Fatalf("expected the expired entry to be refreshed from the backend first, got %d calls", calls)
+	}
+
+	// Once the backend is back, its reply replaces the stale one
+	greeter.err = nil
+	if rec := postHelloBody(srv, `{"name":"alice"}`); rec.Code != http.StatusOK || rec.Header().Get(cacheHeader) != "MISS" {
+		t.Fatalf("got %d X-Cache=%q after recovery, want 200 MISS", rec.Code, rec.Header().Get(cacheHeader))
+	}
+}
+
+func TestServeStaleOnErrorWithoutCachedReplyIs502(t *testing.T) {
+	greeter := &gatedGreeter{}
+	srv, _ := newStaleCachedServer(t, greeter, time.Second)
+
+	postHelloBody(srv, `{"name":"alice"}`)
+	greeter.err = errors.New("backend down")
+	rec := postHelloBody(srv, `{"name":"bob"}`)
+	if rec.Code != http.StatusBadGateway || rec.Header().Get(cacheHeader) != "" {
+		t.Fatalf("got %d X-Cache=%q, want 502 without X-Cache", rec.Code, rec.Header().Get(cacheHeader))
+	}
+}
+
+func TestServeStaleOnErrorNeedsCache(t *testing.T) {
+	if _, err := New(Config{ListenAddr: ":0", ServeStaleOnError: true}, &gatedGreeter{}, nil, nil); err == nil {
+		t.Fatal("expected an error for ServeStaleOnError without CacheTTL")
+	}
+}
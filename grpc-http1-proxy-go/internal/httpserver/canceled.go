@@ -0,0 +1,20 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusClientClosedRequest is the non-standard status (borrowed from nginx) recorded
+// when the client disconnects before the backend call completes. The client never
+// sees it; it keeps abandoned requests apart from real upstream failures in metrics.
+const statusClientClosedRequest = 499
+
+// clientCanceled reports whether the HTTP client has gone away, i.e. the request
+// context was canceled. A deadline set by the proxy or via X-Timeout-Ms expires a
+// derived context only, so it does not count as a cancellation.
+func clientCanceled(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.Canceled)
+}
@@ -0,0 +1,74 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// blockingGreeter signals started and then blocks until the call context ends,
+// failing the way a real gRPC client does.
+type blockingGreeter struct {
+	started chan struct{}
+}
+
+func (g *blockingGreeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	close(g.started)
+	<-ctx.Done()
+	return nil, status.FromContextError(ctx.Err()).Err()
+}
+
+func TestHandlerHelloClientCancellation(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	registry := prometheus.NewRegistry()
+	greeter := &blockingGreeter{started: make(chan struct{})}
+	srv, err := New(Config{ListenAddr: ":0"}, greeter, logger, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name":"alice"}`)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.engine.ServeHTTP(rec, req)
+	}()
+
+	// Disconnect while the backend call is in flight
+	<-greeter.started
+	cancel()
+	<-done
+
+	if rec.Code != statusClientClosedRequest {
+		t.Fatalf("expected %d got %d", statusClientClosedRequest, rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "upstream error") || strings.Contains(logs.String(), "gRPC call failed") {
+		t.Fatalf("cancellation was reported as an upstream error; body %q, logs %q", rec.Body.String(), logs.String())
+	}
+	want := "/helloworld/SayHello " + pb.Greeter_SayHello_FullMethodName + " 499"
+	if series := durationSeries(t, registry); len(series) != 1 || series[0] != want {
+		t.Fatalf("expected series %q, got %q", want, series)
+	}
+}
+
+func TestHandlerHelloDeadlineIsStillUpstreamError(t *testing.T) {
+	greeter := &blockingGreeter{started: make(chan struct{})}
+	rec := serveHelloWithTimeout(t, Config{ListenAddr: ":0"}, greeter, "10")
+
+	// The proxy's own deadline expiring is a backend failure, not a disconnect
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502 got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,57 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipWriterPool holds gzip writers for compressing responses; they are reset
+// onto the response before reuse.
+var gzipWriterPool = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// acceptsGzip reports whether an Accept-Encoding header value accepts gzip: it
+// lists gzip, or *, with a quality above zero ("gzip;q=0" refuses it).
+func acceptsGzip(header string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		ok := true
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				ok = err == nil && q > 0
+			}
+		}
+		if coding == "gzip" {
+			// An explicit gzip entry wins over the wildcard
+			return ok
+		}
+		accepted = ok
+	}
+	return accepted
+}
+
+// writeGzip writes data as the gzip-compressed body of a response with status and
+// contentType, setting Content-Encoding: gzip
+func writeGzip(c *gin.Context, status int, contentType string, data []byte) {
+	c.Header("Content-Encoding", "gzip")
+	c.Header("Content-Type", contentType)
+	c.Status(status)
+
+	zw := gzipWriterPool.Get().(*gzip.Writer)
+	defer gzipWriterPool.Put(zw)
+	zw.Reset(c.Writer)
+	// The status line is already sent, so write errors (a client gone away) can
+	// only be dropped, as c.Data does
+	zw.Write(data)
+	zw.Close()
+}
@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/grpcclient"
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// compressorGreeter remembers the compressor requested for the last call
+type compressorGreeter struct {
+	compressor string
+}
+
+func (g *compressorGreeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	g.compressor, _ = grpcclient.CompressorFromContext(ctx)
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, GZIP;q=0.5", true},
+		{"br;q=1.0, gzip;q=0", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"*, gzip;q=0", false},
+		{"identity", false},
+	}
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.header); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiateCompressionSelectsGzip(t *testing.T) {
+	greeter := &compressorGreeter{}
+	srv, err := New(Config{ListenAddr: ":0", NegotiateCompression: true}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name": "Alice"}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if greeter.compressor != "gzip" {
+		t.Errorf("backend call compressor = %q, want gzip", greeter.compressor)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	if string(body) != `{"message":"Hello, Alice"}` {
+		t.Errorf("body = %s, want the JSON reply", body)
+	}
+
+	// Without the header neither the call nor the response is compressed
+	greeter.compressor = ""
+	rec = httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name": "Alice"}`)))
+	if greeter.compressor != "" || rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("compressor = %q, Content-Encoding = %q without Accept-Encoding; want neither", greeter.compressor, rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestNegotiateCompressionOffByDefault(t *testing.T) {
+	greeter := &compressorGreeter{}
+	srv, err := New(Config{ListenAddr: ":0"}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name": "Alice"}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+	if greeter.compressor != "" || rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("compressor = %q, Content-Encoding = %q with negotiation disabled; want neither", greeter.compressor, rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Body.String() != `{"message":"Hello, Alice"}` {
+		t.Errorf("body = %s, want the plain JSON reply", rec.Body.String())
+	}
+}
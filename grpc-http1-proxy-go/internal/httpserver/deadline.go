@@ -0,0 +1,54 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// timeoutHeader is the request header HTTP clients use to ask for a deadline,
+// expressed as a positive number of milliseconds.
+const timeoutHeader = "X-Timeout-Ms"
+
+// defaultMaxRequestTimeout is the upper bound applied to client-supplied timeouts
+// when Config.MaxRequestTimeout is not set.
+const defaultMaxRequestTimeout = 30 * time.Second
+
+// errInvalidTimeout is returned when the timeout header is not a positive integer.
+var errInvalidTimeout = errors.New("invalid " + timeoutHeader + " header")
+
+// withClientDeadline derives the context used for the backend call from the
+// optional X-Timeout-Ms header.
+//
+// Parameters:
+//   - ctx: The HTTP request context. A deadline already present on it is kept
+//     when it is earlier than the one requested through the header.
+//   - header: Raw header value. Empty means the client did not ask for a deadline.
+//   - max: Upper bound for the requested timeout. Larger values are clamped.
+//
+// Returns:
+//   - context.Context: ctx with the requested deadline applied, or ctx itself
+//     when no header was sent.
+//   - context.CancelFunc: Must always be called by the caller to release resources.
+//   - error: errInvalidTimeout if the header is present but not a positive integer.
+//
+// A context without a deadline lets the gRPC client fall back to its fixed
+// per-call deadline.
+func withClientDeadline(ctx context.Context, header string, max time.Duration) (context.Context, context.CancelFunc, error) {
+	if header == "" {
+		return ctx, func() {}, nil
+	}
+	ms, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || ms <= 0 {
+		return ctx, func() {}, errInvalidTimeout
+	}
+
+	// Compare in milliseconds first so huge values cannot overflow time.Duration
+	timeout := max
+	if ms < max.Milliseconds() {
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
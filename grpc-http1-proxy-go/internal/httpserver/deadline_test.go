@@ -0,0 +1,90 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// deadlineGreeter records the deadline of the context it was called with.
+type deadlineGreeter struct {
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (g *deadlineGreeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	g.deadline, g.hasDeadline = ctx.Deadline()
+	return &pb.HelloReply{Message: "hi"}, nil
+}
+
+func serveHelloWithTimeout(t *testing.T, cfg Config, greeter Greeter, timeout string) *httptest.ResponseRecorder {
+	t.Helper()
+	srv, err := New(cfg, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", bytes.NewReader([]byte(`{"name":"alice"}`)))
+	if timeout != "" {
+		req.Header.Set(timeoutHeader, timeout)
+	}
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerHelloPropagatesTimeoutHeader(t *testing.T) {
+	greeter := &deadlineGreeter{}
+	start := time.Now()
+	rec := serveHelloWithTimeout(t, Config{ListenAddr: ":0"}, greeter, "1500")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !greeter.hasDeadline {
+		t.Fatalf("expected the backend call to carry a deadline")
+	}
+	if remaining := greeter.deadline.Sub(start); remaining < 1400*time.Millisecond || remaining > 1600*time.Millisecond {
+		t.Fatalf("expected a deadline ~1.5s away, got %v", remaining)
+	}
+}
+
+func TestHandlerHelloClampsTimeoutHeader(t *testing.T) {
+	greeter := &deadlineGreeter{}
+	start := time.Now()
+	rec := serveHelloWithTimeout(t, Config{ListenAddr: ":0", MaxRequestTimeout: 2 * time.Second}, greeter, "9223372036854775807")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	if remaining := greeter.deadline.Sub(start); !greeter.hasDeadline || remaining > 2100*time.Millisecond {
+		t.Fatalf("expected the deadline to be clamped to 2s, got %v (set: %v)", remaining, greeter.hasDeadline)
+	}
+}
+
+func TestHandlerHelloWithoutTimeoutHeaderUsesDefaultDeadline(t *testing.T) {
+	greeter := &deadlineGreeter{}
+	rec := serveHelloWithTimeout(t, Config{ListenAddr: ":0"}, greeter, "")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	// No deadline here means the gRPC client applies its configured one
+	if greeter.hasDeadline {
+		t.Fatalf("expected no handler deadline without %s, got %v", timeoutHeader, greeter.deadline)
+	}
+}
+
+func TestHandlerHelloRejectsInvalidTimeoutHeader(t *testing.T) {
+	for _, value := range []string{"soon", "0", "-5", "1.5"} {
+		greeter := &deadlineGreeter{}
+		rec := serveHelloWithTimeout(t, Config{ListenAddr: ":0"}, greeter, value)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: %q: expected 400 got %d", timeoutHeader, value, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// marshalEnumField renders a message with an enum field through the server's
+// response marshaller; HelloReply has no enum fields of its own.
+func marshalEnumField(t *testing.T, cfg Config, target string) any {
+	t.Helper()
+	srv, err := New(cfg, &stubGreeter{resp: &pb.HelloReply{}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, target, nil)
+
+	msg := &descriptorpb.FieldDescriptorProto{Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()}
+	data, err := srv.handler.responseMarshaller(c).Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	return body["type"]
+}
+
+func TestEnumFieldsRenderAsNamesByDefault(t *testing.T) {
+	if got := marshalEnumField(t, Config{ListenAddr: ":0"}, "/helloworld/SayHello"); got != "TYPE_STRING" {
+		t.Fatalf("expected enum name, got %#v", got)
+	}
+}
+
+func TestEnumFieldsRenderAsNumbersWhenConfigured(t *testing.T) {
+	cfg := Config{ListenAddr: ":0", UseEnumNumbers: true}
+	if got := marshalEnumField(t, cfg, "/helloworld/SayHello"); got != float64(descriptorpb.FieldDescriptorProto_TYPE_STRING) {
+		t.Fatalf("expected enum number, got %#v", got)
+	}
+	// The pretty-print override must not drop the enum setting
+	if got := marshalEnumField(t, cfg, "/helloworld/SayHello?pretty=1"); got != float64(descriptorpb.FieldDescriptorProto_TYPE_STRING) {
+		t.Fatalf("expected enum number with ?pretty=1, got %#v", got)
+	}
+}
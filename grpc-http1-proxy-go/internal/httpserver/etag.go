@@ -0,0 +1,27 @@
+package httpserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// weakETag returns a weak entity tag for a JSON response body, e.g. W/"3a7bd3e2360a3d29"
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag, using
+// the weak comparison RFC 9110 prescribes for If-None-Match: "*" or any listed
+// tag with the same opaque value, whether or not it is marked weak.
+func etagMatches(ifNoneMatch, etag string) bool {
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postHelloIfNoneMatch(srv *Server, body, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(body))
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestETagSetOnSuccessfulResponse(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0", EnableETag: true}, &gatedGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	first := postHelloBody(srv, `{"name":"alice"}`)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("got %d with ETag %q", first.Code, etag)
+	}
+	if again := postHelloBody(srv, `{"name":"alice"}`).Header().Get("ETag"); again != etag {
+		t.Fatalf("same body got ETag %q, then %q", etag, again)
+	}
+	if other := postHelloBody(srv, `{"name":"bob"}`).Header().Get("ETag"); other == etag {
+		t.Fatalf("different bodies share ETag %q", etag)
+	}
+}
+
+func TestETagIfNoneMatchReturnsNotModified(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0", EnableETag: true}, &gatedGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	etag := postHelloBody(srv, `{"name":"alice"}`).Header().Get("ETag")
+
+	for _, inm := range []string{etag, strings.TrimPrefix(etag, "W/"), `"other", ` + etag, "*"} {
+		rec := postHelloIfNoneMatch(srv, `{"name":"alice"}`, inm)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: got %d with body %q", inm, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %s: 304 carries ETag %q, want %q", inm, rec.Header().Get("ETag"), etag)
+		}
+	}
+
+	// A stale tag gets the full body
+	rec := postHelloIfNoneMatch(srv, `{"name":"bob"}`, etag)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "bob") {
+		t.Fatalf("stale If-None-Match: got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestETagDisabledByDefault(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &gatedGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	rec := postHelloIfNoneMatch(srv, `{"name":"alice"}`, "*")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Fatalf("got %d with ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
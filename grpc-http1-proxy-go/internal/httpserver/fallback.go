@@ -0,0 +1,72 @@
+package httpserver
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes used in the fallback error bodies
+const (
+	codeNotFound         = "NOT_FOUND"
+	codeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+)
+
+// errorDetail is the value of the "error" key in a structured JSON error body.
+type errorDetail struct {
+	Code    string `json:"code"`    // Machine-readable error code (e.g., "NOT_FOUND")
+	Message string `json:"message"` // Human-readable description
+}
+
+// errorEnvelope is a structured JSON error body: {"error": {"code": ..., "message": ...}}.
+type errorEnvelope struct {
+	Error errorDetail `json:"error"`
+}
+
+// notFoundHandler answers requests for unknown paths with a JSON 404 instead of
+// Gin's plain-text "404 page not found".
+func notFoundHandler(c *gin.Context) {
+	c.JSON(http.StatusNotFound, errorEnvelope{Error: errorDetail{
+		Code:    codeNotFound,
+		Message: "no route for " + c.Request.URL.Path,
+	}})
+}
+
+// methodNotAllowedHandler returns a handler that answers requests whose path
+// exists but not for the request method with a JSON 405 and an Allow header
+// listing the methods registered for that path, as RFC 9110 requires.
+//
+// Parameters:
+//   - engine: The Gin engine whose routes define the permitted methods. Routes are
+//     read on every rejected request, so routes registered after this call count.
+//
+// Returns:
+//   - gin.HandlerFunc: Handler writing the JSON 405.
+func methodNotAllowedHandler(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Routes are static paths, so an exact match finds every permitted method.
+		// Gin has already set Allow from its trees; it is only replaced so the
+		// list is sorted and stable across registration order.
+		if allowed := allowedMethods(engine.Routes(), c.Request.URL.Path); len(allowed) > 0 {
+			c.Header("Allow", strings.Join(allowed, ", "))
+		}
+		c.JSON(http.StatusMethodNotAllowed, errorEnvelope{Error: errorDetail{
+			Code:    codeMethodNotAllowed,
+			Message: "method " + c.Request.Method + " not allowed for " + c.Request.URL.Path,
+		}})
+	}
+}
+
+// allowedMethods returns the sorted methods routes registers for path
+func allowedMethods(routes gin.RoutesInfo, path string) []string {
+	var allowed []string
+	for _, route := range routes {
+		if route.Path == path {
+			allowed = append(allowed, route.Method)
+		}
+	}
+	sort.Strings(allowed)
+	return allowed
+}
@@ -0,0 +1,135 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func serveFallback(t *testing.T, srv *Server, method, path string) (int, errorEnvelope) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec.Code, decodeFallback(t, rec)
+}
+
+func decodeFallback(t *testing.T, rec *httptest.ResponseRecorder) errorEnvelope {
+	t.Helper()
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("expected a JSON response, got Content-Type %q: %s", ct, rec.Body.String())
+	}
+	var body errorEnvelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to parse response %q: %v", rec.Body.String(), err)
+	}
+	return body
+}
+
+func TestUnknownRouteReturnsJSON404(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	code, body := serveFallback(t, srv, http.MethodPost, "/helloworld/Unknown")
+	if code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", code)
+	}
+	if body.Error.Code != codeNotFound || !strings.Contains(body.Error.Message, "/helloworld/Unknown") {
+		t.Fatalf("unexpected error body %+v", body.Error)
+	}
+}
+
+func TestWrongMethodReturnsJSON405(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	code, body := serveFallback(t, srv, http.MethodGet, "/helloworld/SayHello")
+	if code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 got %d", code)
+	}
+	if body.Error.Code != codeMethodNotAllowed || !strings.Contains(body.Error.Message, "GET") {
+		t.Fatalf("unexpected error body %+v", body.Error)
+	}
+}
+
+func TestWrongMethodListsAllowedMethods(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0", StripPathPrefix: "/api/v1"}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		allow  string
+	}{
+		{http.MethodGet, "/helloworld/SayHello", "POST"},
+		{http.MethodDelete, "/helloworld.Greeter/SayHello", "POST"},
+		{http.MethodPost, "/healthz", "GET"},
+		{http.MethodGet, "/api/v1/helloworld/SayHello", "POST"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("%s %s: expected 405 got %d", tt.method, tt.path, rec.Code)
+		}
+		if got := rec.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: expected Allow %q got %q", tt.method, tt.path, tt.allow, got)
+		}
+		if body := decodeFallback(t, rec); body.Error.Code != codeMethodNotAllowed {
+			t.Errorf("%s %s: unexpected error body %+v", tt.method, tt.path, body.Error)
+		}
+	}
+}
+
+func TestAllowedMethodsAreSorted(t *testing.T) {
+	routes := gin.RoutesInfo{
+		{Method: http.MethodPut, Path: "/items"},
+		{Method: http.MethodGet, Path: "/items"},
+		{Method: http.MethodDelete, Path: "/other"},
+		{Method: http.MethodDelete, Path: "/items"},
+	}
+	if got := strings.Join(allowedMethods(routes, "/items"), ", "); got != "DELETE, GET, PUT" {
+		t.Fatalf("expected DELETE, GET, PUT got %q", got)
+	}
+}
+
+func TestFallbackResponsesAreRecordedInMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	srv, err := New(Config{ListenAddr: ":0"}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	serveFallback(t, srv, http.MethodPost, "/nope")
+	serveFallback(t, srv, http.MethodGet, "/helloworld/SayHello")
+
+	// Both land in the shared unmatched series, bucketed by status class
+	want := unmatchedRouteLabel + " " + noMethodLabel + " 4xx"
+	if got := durationSeries(t, registry); len(got) != 1 || got[0] != want {
+		t.Fatalf("expected only series %q, got %q", want, got)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "grpc_http1_proxy_http_request_duration_seconds" {
+			if n := family.GetMetric()[0].GetHistogram().GetSampleCount(); n != 2 {
+				t.Fatalf("expected 2 recorded requests, got %d", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldMaskMetadata is the gRPC metadata key carrying the fields the client set,
+// so backends with PATCH-like semantics can tell an omitted field from a zero value
+const fieldMaskMetadata = "x-field-mask"
+
+// presentFields returns the proto names of the top-level fields of md that appear
+// as keys of the JSON object body, in declaration order. Keys are matched by
+// JSON name or proto name like protojson does; unknown keys are skipped, as they
+// are discarded when unmarshalling. A key given as null still counts as present.
+//
+// Parameters:
+//   - md: Descriptor of the request message.
+//   - body: The request body, already accepted by protojson.Unmarshal.
+//
+// Returns:
+//   - []string: Proto field names, empty when no known field was sent.
+//   - error: Non-nil if body is not a JSON object.
+func presentFields(md protoreflect.MessageDescriptor, body []byte) ([]string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+	fields := md.Fields()
+	present := make(map[protoreflect.FieldNumber]bool, len(object))
+	for key := range object {
+		fd := fields.ByJSONName(key)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(key))
+		}
+		if fd != nil {
+			present[fd.Number()] = true
+		}
+	}
+
+	names := make([]string, 0, len(present))
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); present[fd.Number()] {
+			names = append(names, string(fd.Name()))
+		}
+	}
+	return names, nil
+}
+
+// withFieldMask attaches the present fields to the outgoing gRPC metadata as a
+// comma-separated x-field-mask entry. An empty mask is sent too, so the backend
+// can tell "no fields set" from a proxy that does not forward masks.
+func withFieldMask(ctx context.Context, fields []string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, fieldMaskMetadata, strings.Join(fields, ","))
+}
@@ -0,0 +1,94 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// metadataGreeter remembers the outgoing metadata of the last call
+type metadataGreeter struct {
+	md metadata.MD
+}
+
+func (g *metadataGreeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	g.md, _ = metadata.FromOutgoingContext(ctx)
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+func TestPresentFieldsListsOnlySentFields(t *testing.T) {
+	// FieldDescriptorProto has enough fields to show that zero values count as
+	// present, JSON and proto names both match and unknown keys are skipped
+	md := (&descriptorpb.FieldDescriptorProto{}).ProtoReflect().Descriptor()
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{`{}`, []string{}},
+		{`{"name": ""}`, []string{"name"}},
+		{`{"number": 0, "name": "id", "unknown": 1}`, []string{"name", "number"}},
+		{`{"jsonName": "ID", "proto3_optional": false}`, []string{"json_name", "proto3_optional"}},
+		{`{"typeName": null}`, []string{"type_name"}},
+	}
+	for _, tt := range tests {
+		got, err := presentFields(md, []byte(tt.body))
+		if err != nil {
+			t.Fatalf("presentFields(%s) error = %v", tt.body, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("presentFields(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestForwardFieldMaskSendsPresentFields(t *testing.T) {
+	greeter := &metadataGreeter{}
+	srv, err := New(Config{ListenAddr: ":0", ForwardFieldMask: true, EnableGET: true}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	requests := []struct {
+		req  *http.Request
+		want string
+	}{
+		{httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name": ""}`)), "name"},
+		{httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"other": "x"}`)), ""},
+		{httptest.NewRequest(http.MethodGet, "/helloworld/SayHello?name=Alice", nil), "name"},
+	}
+	for _, tt := range requests {
+		greeter.md = nil
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, tt.req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: status = %d, body %s", tt.req.Method, tt.req.URL, rec.Code, rec.Body.String())
+		}
+		if got := greeter.md.Get(fieldMaskMetadata); !reflect.DeepEqual(got, []string{tt.want}) {
+			t.Errorf("%s %s: x-field-mask = %q, want [%q]", tt.req.Method, tt.req.URL, got, tt.want)
+		}
+	}
+}
+
+func TestForwardFieldMaskOffByDefault(t *testing.T) {
+	greeter := &metadataGreeter{}
+	srv, err := New(Config{ListenAddr: ":0"}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name": "Alice"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if got := greeter.md.Get(fieldMaskMetadata); len(got) != 0 {
+		t.Errorf("x-field-mask = %q, want no metadata without ForwardFieldMask", got)
+	}
+}
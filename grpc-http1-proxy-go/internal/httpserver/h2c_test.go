@@ -0,0 +1,110 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/http2"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// h2cClient speaks HTTP/2 with prior knowledge over plain TCP
+func h2cClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+func startH2CServer(t *testing.T, cfg Config, registry *prometheus.Registry) string {
+	t.Helper()
+	srv, err := New(cfg, &stubGreeter{resp: &pb.HelloReply{Message: "Hello, Alice"}}, nil, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ts := httptest.NewServer(srv.srv.Handler)
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+func TestH2CServesHelloOverHTTP2(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	url := startH2CServer(t, Config{ListenAddr: ":0", EnableH2C: true}, registry)
+	client := h2cClient()
+
+	resp, err := client.Post(url+"/helloworld/SayHello", "application/json", strings.NewReader(`{"name":"Alice"}`))
+	if err != nil {
+		t.Fatalf("h2c request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %s", resp.Proto)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 got %d", resp.StatusCode)
+	}
+	var reply struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if reply.Message != "Hello, Alice" {
+		t.Fatalf("unexpected reply %q", reply.Message)
+	}
+
+	// Routing and metrics must behave as they do over HTTP/1.1
+	notFound, err := client.Get(url + "/nope")
+	if err != nil {
+		t.Fatalf("h2c request failed: %v", err)
+	}
+	notFound.Body.Close()
+	if notFound.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown path, got %d", notFound.StatusCode)
+	}
+	metricsResp, err := client.Get(url + "/metrics")
+	if err != nil {
+		t.Fatalf("h2c request failed: %v", err)
+	}
+	defer metricsResp.Body.Close()
+	body, _ := io.ReadAll(metricsResp.Body)
+	if metricsResp.StatusCode != http.StatusOK || !strings.Contains(string(body), "grpc_http1_proxy_http_request_duration_seconds") {
+		t.Fatalf("expected metrics over h2c, got %d:\n%s", metricsResp.StatusCode, body)
+	}
+
+	series := durationSeries(t, registry)
+	want := []string{"/helloworld/SayHello /helloworld.Greeter/SayHello 2xx", "/metrics none 2xx", "unmatched none 4xx"}
+	if strings.Join(series, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected series:\n%s", strings.Join(series, "\n"))
+	}
+}
+
+func TestH2CDisabledByDefault(t *testing.T) {
+	url := startH2CServer(t, Config{ListenAddr: ":0"}, nil)
+
+	if _, err := h2cClient().Get(url + "/healthz"); err == nil {
+		t.Fatal("expected a prior-knowledge HTTP/2 request to fail without EnableH2C")
+	}
+
+	// HTTP/1.1 keeps working either way
+	resp, err := http.Get(url + "/healthz")
+	if err != nil {
+		t.Fatalf("HTTP/1.1 request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 got %d", resp.StatusCode)
+	}
+}
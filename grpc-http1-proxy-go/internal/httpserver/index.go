@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redactedBackend replaces the backend address in the index when
+// Config.RedactBackend is set.
+const redactedBackend = "redacted"
+
+// indexRoute describes one registered HTTP route in the index document.
+type indexRoute struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// indexDocument is the JSON body served by GET / to describe a running instance.
+type indexDocument struct {
+	Service string       `json:"service"`
+	Version string       `json:"version"`
+	Backend string       `json:"backend,omitempty"`
+	Health  string       `json:"health"`
+	Ready   string       `json:"ready"`
+	Metrics string       `json:"metrics,omitempty"`
+	Routes  []indexRoute `json:"routes"`
+}
+
+// indexHandler returns a handler that lists the engine's registered routes along
+// with the health/metrics paths, the backend address and the build version.
+//
+// Parameters:
+//   - engine: The Gin engine whose routes are listed. Routes are read on every
+//     request, so routes registered after this call are included.
+//   - backend: Returns the backend address, read on every request so that a
+//     reloaded backend file is reflected.
+//   - doc: The static part of the document (everything except Routes and Backend).
+//
+// Returns:
+//   - gin.HandlerFunc: Handler writing the index as JSON with status 200.
+func indexHandler(engine *gin.Engine, backend func() string, doc indexDocument) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		routes := engine.Routes()
+		resp := doc
+		resp.Backend = backend()
+		resp.Routes = make([]indexRoute, 0, len(routes))
+		for _, route := range routes {
+			resp.Routes = append(resp.Routes, indexRoute{Method: route.Method, Path: route.Path})
+		}
+		// Sort for a stable document regardless of registration order
+		sort.Slice(resp.Routes, func(i, j int) bool {
+			if resp.Routes[i].Path != resp.Routes[j].Path {
+				return resp.Routes[i].Path < resp.Routes[j].Path
+			}
+			return resp.Routes[i].Method < resp.Routes[j].Method
+		})
+		c.JSON(http.StatusOK, resp)
+	}
+}
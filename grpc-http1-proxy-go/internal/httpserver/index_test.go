@@ -0,0 +1,130 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/grpcclient"
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func getIndex(t *testing.T, cfg Config, registry *prometheus.Registry) (*httptest.ResponseRecorder, indexDocument) {
+	t.Helper()
+	srv, err := New(cfg, &stubGreeter{resp: &pb.HelloReply{}}, nil, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var doc indexDocument
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("index is not valid JSON: %v", err)
+		}
+	}
+	return rec, doc
+}
+
+func TestIndexListsRoutes(t *testing.T) {
+	rec, doc := getIndex(t, Config{
+		ListenAddr:  ":0",
+		EnableIndex: true,
+		BackendAddr: func() string { return "backend:50051" },
+		Build:       BuildInfo{Version: "1.2.3"},
+	}, prometheus.NewRegistry())
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	found := false
+	for _, route := range doc.Routes {
+		if route.Method == http.MethodPost && route.Path == "/helloworld/SayHello" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected the hello route in the index, got %+v", doc.Routes)
+	}
+	if doc.Health != "/healthz" || doc.Metrics != "/metrics" {
+		t.Errorf("unexpected health/metrics paths: %q, %q", doc.Health, doc.Metrics)
+	}
+	if doc.Backend != "backend:50051" || doc.Version != "1.2.3" {
+		t.Errorf("unexpected backend/version: %q, %q", doc.Backend, doc.Version)
+	}
+}
+
+func TestIndexReportsReloadedBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.json")
+	writeBackends := func(addr string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(`{ "greeter": "`+addr+`" }`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeBackends("first.internal:50051")
+	client, err := grpcclient.New(context.Background(), grpcclient.Config{BackendFile: path, BackendService: "greeter"}, nil)
+	if err != nil {
+		t.Fatalf("grpcclient.New() error = %v", err)
+	}
+	defer client.Close()
+
+	srv, err := New(Config{ListenAddr: ":0", EnableIndex: true, BackendAddr: client.Address}, client, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	index := func() indexDocument {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		var doc indexDocument
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("index is not valid JSON: %v", err)
+		}
+		return doc
+	}
+	if doc := index(); doc.Backend != "first.internal:50051" {
+		t.Fatalf("backend = %q before reload, want first.internal:50051", doc.Backend)
+	}
+
+	writeBackends("second.internal:50051")
+	if err := client.ReloadBackends(); err != nil {
+		t.Fatalf("ReloadBackends() error = %v", err)
+	}
+	if doc := index(); doc.Backend != "second.internal:50051" {
+		t.Errorf("backend = %q after reload, want second.internal:50051", doc.Backend)
+	}
+}
+
+func TestIndexRedactsBackend(t *testing.T) {
+	_, doc := getIndex(t, Config{
+		ListenAddr:    ":0",
+		EnableIndex:   true,
+		BackendAddr:   func() string { return "backend:50051" },
+		RedactBackend: true,
+	}, nil)
+
+	if doc.Backend != redactedBackend {
+		t.Errorf("expected backend to be redacted, got %q", doc.Backend)
+	}
+	if doc.Metrics != "" {
+		t.Errorf("expected no metrics path without a registry, got %q", doc.Metrics)
+	}
+	if doc.Version != "dev" {
+		t.Errorf("expected default version %q, got %q", "dev", doc.Version)
+	}
+}
+
+func TestIndexDisabled(t *testing.T) {
+	rec, _ := getIndex(t, Config{ListenAddr: ":0"}, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 with the index disabled, got %d", rec.Code)
+	}
+}
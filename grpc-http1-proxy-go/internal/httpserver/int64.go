@@ -0,0 +1,169 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// int64AsNumber rewrites the 64-bit integer fields of data, the protojson
+// encoding of a message described by md, from JSON strings to JSON numbers.
+// protojson quotes them because JavaScript numbers lose precision above 2^53;
+// clients that accept that risk can opt in with Config.Int64AsNumber.
+//
+// Which values are rewritten is decided by the descriptor, not by the content:
+// a string field that happens to hold digits is left alone. Repeated fields, map
+// values, nested messages and the Int64Value/UInt64Value wrappers are covered.
+// Other well-known types keep their special JSON form.
+//
+// The object keys of the result are sorted, and indent (as used by
+// protojson.MarshalOptions.Indent) is preserved.
+//
+// Parameters:
+//   - md: Descriptor of the marshalled message.
+//   - data: protojson output for a message of type md.
+//   - indent: Indentation of data; "" for compact output.
+//
+// Returns:
+//   - []byte: The rewritten JSON, or data itself when md has no 64-bit integer fields.
+//   - error: If data is not valid JSON.
+func int64AsNumber(md protoreflect.MessageDescriptor, data []byte, indent string) ([]byte, error) {
+	if !hasInt64Fields(md, map[protoreflect.FullName]bool{}) {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // Keep other numbers exactly as protojson wrote them
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false) // protojson does not escape <, > and &
+	enc.SetIndent("", indent)
+	if err := enc.Encode(messageInt64AsNumber(md, v)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// hasInt64Fields reports whether a message of type md can contain a 64-bit
+// integer field at any depth; seen breaks cycles in recursive messages
+func hasInt64Fields(md protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) bool {
+	if isInt64Wrapper(md) {
+		return true
+	}
+	if seen[md.FullName()] || specialJSONTypes[md.FullName()] {
+		return false
+	}
+	seen[md.FullName()] = true
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+		if isInt64Kind(fd.Kind()) {
+			return true
+		}
+		if fd.Message() != nil && hasInt64Fields(fd.Message(), seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// messageInt64AsNumber rewrites the decoded JSON v of a message of type md
+func messageInt64AsNumber(md protoreflect.MessageDescriptor, v any) any {
+	if isInt64Wrapper(md) {
+		return int64ValueAsNumber(v)
+	}
+	if specialJSONTypes[md.FullName()] {
+		return v
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		value, ok := obj[fd.JSONName()]
+		if !ok {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			if entries, ok := value.(map[string]any); ok {
+				for key, entry := range entries {
+					entries[key] = valueInt64AsNumber(fd.MapValue(), entry)
+				}
+			}
+		case fd.IsList():
+			if items, ok := value.([]any); ok {
+				for j, item := range items {
+					items[j] = valueInt64AsNumber(fd, item)
+				}
+			}
+		default:
+			obj[fd.JSONName()] = valueInt64AsNumber(fd, value)
+		}
+	}
+	return obj
+}
+
+// valueInt64AsNumber rewrites a single (non-repeated) value of field fd
+func valueInt64AsNumber(fd protoreflect.FieldDescriptor, v any) any {
+	if isInt64Kind(fd.Kind()) {
+		return int64ValueAsNumber(v)
+	}
+	if fd.Message() != nil {
+		return messageInt64AsNumber(fd.Message(), v)
+	}
+	return v
+}
+
+// int64ValueAsNumber turns the quoted integer v into a JSON number; anything
+// that is not a quoted integer is returned unchanged
+func int64ValueAsNumber(v any) any {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return json.Number(s)
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return json.Number(s)
+	}
+	return v
+}
+
+// specialJSONTypes are the well-known types whose JSON form is not an object of
+// their fields; they contain no 64-bit integers that need rewriting
+var specialJSONTypes = map[protoreflect.FullName]bool{
+	"google.protobuf.Any":       true,
+	"google.protobuf.Duration":  true,
+	"google.protobuf.FieldMask": true,
+	"google.protobuf.ListValue": true,
+	"google.protobuf.Struct":    true,
+	"google.protobuf.Timestamp": true,
+	"google.protobuf.Value":     true,
+}
+
+func isInt64Kind(kind protoreflect.Kind) bool {
+	switch kind {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return true
+	}
+	return false
+}
+
+func isInt64Wrapper(md protoreflect.MessageDescriptor) bool {
+	name := md.FullName()
+	return name == "google.protobuf.Int64Value" || name == "google.protobuf.UInt64Value"
+}
@@ -0,0 +1,124 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// rewriteInt64 marshals msg with protojson and applies the int64 transform
+func rewriteInt64(t *testing.T, msg proto.Message, indent string) []byte {
+	t.Helper()
+	data, err := protojson.MarshalOptions{Indent: indent}.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	out, err := int64AsNumber(msg.ProtoReflect().Descriptor(), data, indent)
+	if err != nil {
+		t.Fatalf("int64AsNumber() error = %v", err)
+	}
+	return out
+}
+
+func TestInt64AsNumberRewritesOnlyInt64Fields(t *testing.T) {
+	// UninterpretedOption mixes uint64, int64, double and string fields; the
+	// string field holds digits so it would look like an integer to a content check
+	opt := &descriptorpb.UninterpretedOption{
+		PositiveIntValue: proto.Uint64(18446744073709551615),
+		NegativeIntValue: proto.Int64(-9007199254740993),
+		DoubleValue:      proto.Float64(1.5),
+		IdentifierValue:  proto.String("12345"),
+		AggregateValue:   proto.String("-7"),
+	}
+	out := rewriteInt64(t, opt, "")
+
+	for _, want := range []string{
+		`"positiveIntValue":18446744073709551615`,
+		`"negativeIntValue":-9007199254740993`,
+		`"doubleValue":1.5`,
+		`"identifierValue":"12345"`,
+		`"aggregateValue":"-7"`,
+	} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+}
+
+func TestInt64AsNumberRewritesRepeatedNestedFields(t *testing.T) {
+	opts := &descriptorpb.MessageOptions{
+		Deprecated: proto.Bool(true),
+		UninterpretedOption: []*descriptorpb.UninterpretedOption{
+			{PositiveIntValue: proto.Uint64(1), IdentifierValue: proto.String("2")},
+			{NegativeIntValue: proto.Int64(-3)},
+		},
+	}
+	var body struct {
+		Deprecated          bool `json:"deprecated"`
+		UninterpretedOption []map[string]any
+	}
+	dec := json.NewDecoder(bytes.NewReader(rewriteInt64(t, opts, "")))
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if !body.Deprecated || len(body.UninterpretedOption) != 2 {
+		t.Fatalf("unexpected body %+v", body)
+	}
+	if got := body.UninterpretedOption[0]["positiveIntValue"]; got != json.Number("1") {
+		t.Errorf("expected positiveIntValue as number, got %#v", got)
+	}
+	if got := body.UninterpretedOption[0]["identifierValue"]; got != "2" {
+		t.Errorf("expected identifierValue to stay a string, got %#v", got)
+	}
+	if got := body.UninterpretedOption[1]["negativeIntValue"]; got != json.Number("-3") {
+		t.Errorf("expected negativeIntValue as number, got %#v", got)
+	}
+}
+
+func TestInt64AsNumberRewritesWrappers(t *testing.T) {
+	if got := string(rewriteInt64(t, wrapperspb.Int64(42), "")); got != "42" {
+		t.Fatalf("expected 42, got %s", got)
+	}
+	// StringValue is a wrapper too, but not an integer one
+	if got := string(rewriteInt64(t, wrapperspb.String("42"), "")); got != `"42"` {
+		t.Fatalf(`expected "42", got %s`, got)
+	}
+}
+
+func TestInt64AsNumberKeepsIndent(t *testing.T) {
+	out := rewriteInt64(t, &descriptorpb.UninterpretedOption{PositiveIntValue: proto.Uint64(7)}, prettyIndent)
+	if want := "{\n  \"positiveIntValue\": 7\n}"; string(out) != want {
+		t.Fatalf("expected %q, got %q", want, out)
+	}
+}
+
+func TestInt64AsNumberLeavesMessagesWithoutInt64Untouched(t *testing.T) {
+	data := []byte(`{"message":"9007199254740993"}`)
+	out, err := int64AsNumber((&pb.HelloReply{}).ProtoReflect().Descriptor(), data, "")
+	if err != nil {
+		t.Fatalf("int64AsNumber() error = %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("expected the body unchanged, got %s", out)
+	}
+}
+
+func TestInt64AsNumberConfigKeepsHelloReplies(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0", Int64AsNumber: true}, &stubGreeter{resp: &pb.HelloReply{Message: "123"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	rec := postHello(srv, "/helloworld/SayHello")
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"message":"123"}` {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
@@ -0,0 +1,51 @@
+package httpserver
+
+import "strings"
+
+// maxErrorDetailLen caps the length of protojson error details echoed to clients
+// so a pathological payload cannot produce an oversized error response.
+const maxErrorDetailLen = 200
+
+// echoedJSONErrorCategories lists the protojson error categories that are safe to
+// echo back to HTTP clients. These errors only describe the client's own payload
+// (bad syntax, wrong-typed values, duplicate keys). Anything else is reported with
+// the generic message only, so internal details never leak into responses.
+var echoedJSONErrorCategories = []string{
+	"syntax error",
+	"unexpected token",
+	"invalid value for",
+	"duplicate field",
+	"unknown field",
+	"invalid UTF-8",
+	"unexpected EOF",
+}
+
+// describeUnmarshalError converts a protojson unmarshal error into a short,
+// client-facing description of what is wrong with the request payload.
+//
+// protojson prefixes its errors with "proto:" followed by a randomly chosen
+// space character (to discourage string matching), so the prefix is stripped
+// and all surrounding whitespace trimmed before the category check.
+//
+// Returns:
+//   - string: The sanitized description (e.g., "(line 1:9): invalid value for string field name: 123"),
+//     or "" if the error does not belong to a whitelisted category.
+func describeUnmarshalError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	// strings.TrimSpace also strips the non-breaking space protojson may emit
+	msg := strings.TrimSpace(err.Error())
+	msg = strings.TrimSpace(strings.TrimPrefix(msg, "proto:"))
+
+	for _, category := range echoedJSONErrorCategories {
+		if strings.Contains(msg, category) {
+			if runes := []rune(msg); len(runes) > maxErrorDetailLen {
+				msg = string(runes[:maxErrorDetailLen]) + "..."
+			}
+			return msg
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// unixAddrPrefix marks a ListenAddr as a Unix domain socket path, e.g. "unix:/tmp/proxy.sock"
+const unixAddrPrefix = "unix:"
+
+// unixSocketPath returns the socket path of a "unix:<path>" listen address and
+// whether addr names a Unix domain socket at all.
+func unixSocketPath(addr string) (string, bool) {
+	if !strings.HasPrefix(addr, unixAddrPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(addr, unixAddrPrefix), true
+}
+
+// listen opens the listener for addr: a Unix domain socket for "unix:<path>",
+// TCP otherwise. A socket file left behind by a previous run is removed first;
+// any other kind of file at the path is left alone and makes listening fail.
+func listen(addr string) (net.Listener, error) {
+	path, ok := unixSocketPath(addr)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("httpserver: %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("httpserver: remove stale socket: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	// The listener unlinks the socket file again when it is closed
+	return net.Listen("unix", path)
+}
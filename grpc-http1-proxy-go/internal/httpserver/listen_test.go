@@ -0,0 +1,125 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// unixClient sends every request over the Unix domain socket at path
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+// startUnixServer starts a server on the socket at path and waits until it accepts connections
+func startUnixServer(t *testing.T, path string) *Server {
+	t.Helper()
+	srv, err := New(Config{ListenAddr: "unix:" + path}, &stubGreeter{resp: &pb.HelloReply{Message: "Hello, Alice"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Start() }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		if err := <-errCh; err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			conn.Close()
+			return srv
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not start listening on %s: %v", path, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUnixSocketHelloRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxy.sock")
+	startUnixServer(t, path)
+
+	resp, err := unixClient(path).Post("http://proxy/helloworld/SayHello", "application/json", strings.NewReader(`{"name":"Alice"}`))
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 got %d", resp.StatusCode)
+	}
+	var reply struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if reply.Message != "Hello, Alice" {
+		t.Fatalf("unexpected reply %q", reply.Message)
+	}
+}
+
+func TestUnixSocketReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxy.sock")
+
+	// Leave a socket file behind, as a crashed previous run would
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	startUnixServer(t, path)
+	resp, err := unixClient(path).Get("http://proxy/healthz")
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 got %d", resp.StatusCode)
+	}
+}
+
+func TestUnixSocketRefusesToReplaceRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxy.sock")
+	if err := os.WriteFile(path, []byte("keep me"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	srv, err := New(Config{ListenAddr: "unix:" + path}, &stubGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err := srv.Start(); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Fatalf("expected a not-a-socket error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep me" {
+		t.Fatalf("regular file was modified: %q", data)
+	}
+}
+
+func TestUnixListenAddrRequiresPath(t *testing.T) {
+	if _, err := New(Config{ListenAddr: "unix:"}, &stubGreeter{}, nil, nil); err == nil {
+		t.Fatal("expected an error for a unix listen address without a path")
+	}
+}
@@ -7,14 +7,34 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// grpcMethodKey is the Gin context key under which handlers record the gRPC method
+// they resolved, so the metrics middleware can label the request with it.
+const grpcMethodKey = "httpserver.grpcMethod"
+
+// noMethodLabel is the "method" label value for requests that did not resolve to
+// a registered gRPC method (health checks, metrics scrapes, unknown routes).
+const noMethodLabel = "none"
+
+// defaultMetricsNamespace prefixes the metric names unless Config.MetricsNamespace is set.
+const defaultMetricsNamespace = "grpc_http1_proxy"
+
+// unmatchedRouteLabel is the "route" label value for requests that matched no route,
+// so arbitrary client paths cannot create new metric series.
+const unmatchedRouteLabel = "unmatched"
+
 // metrics holds Prometheus metric collectors for the HTTP server.
 // If metrics are disabled (registry is nil), all fields will be nil and
 // observe() calls will be no-ops.
 type metrics struct {
 	// httpDuration tracks the duration of HTTP requests in seconds.
-	// It's a histogram with labels for route (e.g., "/helloworld/SayHello")
-	// and status code category (e.g., "2xx", "4xx", "5xx").
+	// It's a histogram with labels for route (e.g., "/helloworld/SayHello"),
+	// gRPC method (e.g., "/helloworld.Greeter/SayHello") and status code
+	// category (e.g., "2xx", "4xx", "5xx").
 	httpDuration *prometheus.HistogramVec
+
+	// methods lists the gRPC methods allowed as "method" label values.
+	// Anything else is reported as noMethodLabel to keep cardinality bounded.
+	methods map[string]bool
 }
 
 // newMetrics creates and registers Prometheus metrics with the provided registry.
@@ -23,26 +43,35 @@ type metrics struct {
 //
 // Parameters:
 //   - registry: Prometheus registry to register metrics with. If nil, metrics are disabled.
+//   - namespace: Metric name prefix; empty uses defaultMetricsNamespace.
+//   - subsystem: Optional second prefix, placed between namespace and the metric name.
 //
 // Returns:
 //   - *metrics: A metrics collector, or a no-op collector if registry is nil.
-func newMetrics(registry *prometheus.Registry) *metrics {
+func newMetrics(registry *prometheus.Registry, namespace, subsystem string) *metrics {
 	// If no registry provided, return a no-op metrics collector
 	if registry == nil {
-		return &metrics{}
+		return &metrics{methods: make(map[string]bool)}
+	}
+
+	if namespace == "" {
+		namespace = defaultMetricsNamespace
 	}
 
 	// Create histogram metric for HTTP request duration
+	// The full name is <namespace>_[<subsystem>_]http_request_duration_seconds
 	m := &metrics{
 		httpDuration: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Namespace: "grpc_http1_proxy",                    // Metric namespace prefix
-				Name:      "http_request_duration_seconds",         // Metric name
-				Help:      "Time spent serving HTTP requests",     // Description for Prometheus
-				Buckets:   prometheus.DefBuckets,                 // Default histogram buckets (0.005s to 10s)
+				Namespace: namespace,                          // Metric namespace prefix
+				Subsystem: subsystem,                          // Optional prefix after the namespace
+				Name:      "http_request_duration_seconds",    // Metric name
+				Help:      "Time spent serving HTTP requests", // Description for Prometheus
+				Buckets:   prometheus.DefBuckets,              // Default histogram buckets (0.005s to 10s)
 			},
-			[]string{"route", "status"}, // Labels: route path and status code category
+			[]string{"route", "method", "status"}, // Labels: route path, gRPC method and status code category
 		),
+		methods: make(map[string]bool),
 	}
 
 	// Register the metric with the Prometheus registry
@@ -51,22 +80,45 @@ func newMetrics(registry *prometheus.Registry) *metrics {
 	return m
 }
 
+// registerMethod allows a gRPC method to appear as a "method" label value.
+// It must be called while routes are being registered, before serving starts.
+//
+// Parameters:
+//   - method: Full gRPC method name (e.g., "/helloworld.Greeter/SayHello")
+func (m *metrics) registerMethod(method string) {
+	if m == nil || m.methods == nil {
+		return
+	}
+	m.methods[method] = true
+}
+
 // observe records the duration of an HTTP request for metrics collection.
 // This is a no-op if metrics are disabled (m is nil or httpDuration is nil).
 //
 // Parameters:
 //   - route: The HTTP route/path that was called (e.g., "/helloworld/SayHello")
+//   - method: The gRPC method the request resolved to; unregistered values are
+//     recorded as noMethodLabel
 //   - status: The HTTP status code returned (e.g., 200, 404, 500)
 //   - d: The duration the request took to complete
-func (m *metrics) observe(route string, status int, d time.Duration) {
+func (m *metrics) observe(route, method string, status int, d time.Duration) {
 	// Early return if metrics are disabled
 	if m == nil || m.httpDuration == nil {
 		return
 	}
+	if !m.methods[method] {
+		method = noMethodLabel
+	}
 
 	// Record the observation with appropriate labels
 	// The status is converted to a category (2xx, 4xx, 5xx) for better aggregation
-	m.httpDuration.WithLabelValues(route, httpStatusLabel(status)).Observe(d.Seconds())
+	m.httpDuration.WithLabelValues(route, method, httpStatusLabel(status)).Observe(d.Seconds())
+}
+
+// setGRPCMethod records the gRPC method a handler resolved for the current request
+// so the metrics middleware can label the request with it.
+func setGRPCMethod(c *gin.Context, method string) {
+	c.Set(grpcMethodKey, method)
 }
 
 // httpStatusLabel converts an HTTP status code to a category label for metrics.
@@ -77,9 +129,12 @@ func (m *metrics) observe(route string, status int, d time.Duration) {
 //   - status: HTTP status code (e.g., 200, 404, 500)
 //
 // Returns:
-//   - string: Status code category ("2xx", "3xx", "4xx", "5xx", or "other")
+//   - string: Status code category ("2xx", "3xx", "4xx", "5xx", or "other"), or
+//     "499" for requests abandoned by the client, which are kept apart from other 4xx
 func httpStatusLabel(status int) string {
 	switch {
+	case status == statusClientClosedRequest:
+		return "499" // Client disconnected before the response
 	case status >= 500:
 		return "5xx" // Server errors
 	case status >= 400:
@@ -99,7 +154,7 @@ func httpStatusLabel(status int) string {
 // The middleware:
 //   - Records the start time before processing the request
 //   - Calls the next handler in the chain
-//   - Records the duration and status code after the request completes
+//   - Records the duration, status code and resolved gRPC method after the request completes
 //
 // Returns:
 //   - gin.HandlerFunc: A Gin middleware function for metrics collection
@@ -116,8 +171,8 @@ func (m *metrics) middleware() gin.HandlerFunc {
 		// instead of c.Request.URL.Path which would give the actual path
 		route := c.FullPath()
 		if route == "" {
-			route = c.Request.URL.Path // Fallback for routes without a pattern
+			route = unmatchedRouteLabel // Unknown paths share one series
 		}
-		m.observe(route, c.Writer.Status(), time.Since(start))
+		m.observe(route, c.GetString(grpcMethodKey), c.Writer.Status(), time.Since(start))
 	}
 }
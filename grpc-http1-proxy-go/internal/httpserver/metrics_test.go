@@ -0,0 +1,108 @@
+package httpserver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// durationSeries returns "route method status" for every series of the request
+// duration histogram, sorted
+func durationSeries(t *testing.T, registry *prometheus.Registry) []string {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	var series []string
+	for _, family := range families {
+		if family.GetName() != "grpc_http1_proxy_http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, pair := range metric.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			series = append(series, strings.Join([]string{labels["route"], labels["method"], labels["status"]}, " "))
+		}
+	}
+	sort.Strings(series)
+	return series
+}
+
+func TestMetricsLabelRequestsWithGRPCMethod(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	srv, err := New(Config{ListenAddr: ":0"}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// A second registered method on its own route, as a generic proxy would add
+	const otherMethod = "/helloworld.Greeter/SayGoodbye"
+	srv.handler.metrics.registerMethod(otherMethod)
+	srv.engine.POST("/helloworld/SayGoodbye", func(c *gin.Context) {
+		setGRPCMethod(c, otherMethod)
+		c.Status(http.StatusOK)
+	})
+	// A handler reporting a method that was never registered
+	srv.engine.POST("/helloworld/Unregistered", func(c *gin.Context) {
+		setGRPCMethod(c, "/helloworld.Greeter/Unregistered")
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/helloworld/SayHello", "/helloworld/SayGoodbye", "/helloworld/Unregistered", "/no/such/route"} {
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(`{"name":"alice"}`))))
+	}
+
+	want := []string{
+		"/helloworld/SayGoodbye /helloworld.Greeter/SayGoodbye 2xx",
+		"/helloworld/SayHello /helloworld.Greeter/SayHello 2xx",
+		"/helloworld/Unregistered none 2xx",
+		"unmatched none 4xx",
+	}
+	if got := durationSeries(t, registry); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected metric series:\ngot:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMetricsNamespaceAndSubsystem(t *testing.T) {
+	tests := []struct {
+		namespace string
+		subsystem string
+		want      string
+	}{
+		{"", "", "grpc_http1_proxy_http_request_duration_seconds"},
+		{"edge", "", "edge_http_request_duration_seconds"},
+		{"edge", "greeter", "edge_greeter_http_request_duration_seconds"},
+	}
+	for _, tt := range tests {
+		registry := prometheus.NewRegistry()
+		srv, err := New(Config{ListenAddr: ":0", MetricsNamespace: tt.namespace, MetricsSubsystem: tt.subsystem}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, registry)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		srv.engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", bytes.NewReader([]byte(`{"name":"alice"}`))))
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Gather() error = %v", err)
+		}
+		if len(families) != 1 || families[0].GetName() != tt.want {
+			var names []string
+			for _, family := range families {
+				names = append(names, family.GetName())
+			}
+			t.Errorf("namespace %q, subsystem %q: got metric families %v, want [%s]", tt.namespace, tt.subsystem, names, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMiddlewaresRunForEveryRoute(t *testing.T) {
+	var order []string
+	tag := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			order = append(order, name)
+			c.Header("X-Custom", strings.Join(order, ","))
+			c.Next()
+		}
+	}
+	srv, err := New(Config{ListenAddr: ":0", Middlewares: []gin.HandlerFunc{tag("first"), tag("second")}}, &gatedGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name":"alice"}`)),
+		httptest.NewRequest(http.MethodGet, "/healthz", nil),
+		httptest.NewRequest(http.MethodGet, "/no-such-route", nil),
+	} {
+		order = nil
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-Custom"); got != "first,second" {
+			t.Errorf("%s %s: X-Custom = %q, want first,second", req.Method, req.URL.Path, got)
+		}
+	}
+}
+
+func TestMiddlewareAbortIsCountedAndSkipsAuth(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	deny := func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "denied"})
+	}
+	greeter := &gatedGreeter{}
+	srv, err := New(Config{
+		ListenAddr:  ":0",
+		APIKeys:     []string{"secret"},
+		Middlewares: []gin.HandlerFunc{deny},
+	}, greeter, nil, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// The custom middleware answers before the API key check would return 401
+	rec := postHelloBody(srv, `{"name":"alice"}`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 got %d", rec.Code)
+	}
+	if calls := greeter.calls.Load(); calls != 0 {
+		t.Fatalf("expected no backend call, got %d", calls)
+	}
+	if got := durationSeries(t, registry); len(got) != 1 || !strings.HasSuffix(got[0], " 4xx") {
+		t.Fatalf("expected the aborted request to be recorded as 4xx, got %q", got)
+	}
+}
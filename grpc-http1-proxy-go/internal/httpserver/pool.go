@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// maxPooledBufferSize is the largest buffer returned to a pool. Buffers grown beyond
+// this by an unusually large request or response are dropped so the pools do not pin
+// that memory for the lifetime of the process.
+const maxPooledBufferSize = 2 << 20
+
+// maxRequestBodySize is the largest request body the proxy reads, to prevent memory
+// exhaustion. Larger declared bodies are rejected with 413 before any of it is read.
+const maxRequestBodySize = 1 << 20
+
+// Pools used by the request hot path to avoid per-request allocations.
+var (
+	// bodyBufferPool holds buffers for reading request bodies.
+	bodyBufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+	// responseBufferPool holds byte slices that protojson marshals responses into.
+	responseBufferPool = sync.Pool{New: func() any { return new([]byte) }}
+	// helloRequestPool holds request messages; they are reset before reuse.
+	helloRequestPool = sync.Pool{New: func() any { return new(pb.HelloRequest) }}
+)
+
+// getBodyBuffer returns an empty buffer from the pool.
+func getBodyBuffer() *bytes.Buffer {
+	return bodyBufferPool.Get().(*bytes.Buffer)
+}
+
+// putBodyBuffer resets buf and returns it to the pool unless it grew too large.
+func putBodyBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bodyBufferPool.Put(buf)
+}
+
+// getResponseBuffer returns a pooled byte slice; callers append to (*buf)[:0].
+func getResponseBuffer() *[]byte {
+	return responseBufferPool.Get().(*[]byte)
+}
+
+// putResponseBuffer returns buf to the pool unless it grew too large.
+func putResponseBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledBufferSize {
+		return
+	}
+	*buf = (*buf)[:0]
+	responseBufferPool.Put(buf)
+}
+
+// getHelloRequest returns an empty HelloRequest from the pool.
+func getHelloRequest() *pb.HelloRequest {
+	return helloRequestPool.Get().(*pb.HelloRequest)
+}
+
+// putHelloRequest clears req and returns it to the pool.
+func putHelloRequest(req *pb.HelloRequest) {
+	req.Reset()
+	helloRequestPool.Put(req)
+}
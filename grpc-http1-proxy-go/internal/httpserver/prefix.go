@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// stripPathPrefix wraps next so that requests under prefix are routed as if the
+// prefix were absent, e.g. "/api/v1/helloworld/SayHello" reaches the
+// "/helloworld/SayHello" route. The prefix must end at a segment boundary;
+// "/api/v1x/..." is left alone. Requests without the prefix are passed through
+// unchanged, so probes that bypass the ingress keep working.
+//
+// The prefix is removed before the Gin engine matches routes, so route labels,
+// the access log and fallback handlers all see the stripped path.
+//
+// Parameters:
+//   - prefix: Path prefix added by the ingress, e.g. "/api/v1". A trailing slash
+//     is ignored; "" or "/" returns next unchanged.
+//   - next: Handler receiving the rewritten request.
+//
+// Returns:
+//   - http.Handler: next, wrapped when prefix is non-empty.
+func stripPathPrefix(prefix string, next http.Handler) http.Handler {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := cutPathPrefix(r.URL.Path, prefix)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Shallow-copy the request like http.StripPrefix so the caller's URL is untouched
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = rest
+		if r.URL.RawPath != "" {
+			if rawRest, ok := cutPathPrefix(r.URL.RawPath, prefix); ok {
+				r2.URL.RawPath = rawRest
+			} else {
+				r2.URL.RawPath = ""
+			}
+		}
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// cutPathPrefix removes prefix from path if it ends at a segment boundary,
+// returning "/" when nothing is left
+func cutPathPrefix(path, prefix string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok || (rest != "" && rest[0] != '/') {
+		return path, false
+	}
+	if rest == "" {
+		rest = "/"
+	}
+	return rest, true
+}
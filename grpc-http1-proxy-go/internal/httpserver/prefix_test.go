@@ -0,0 +1,82 @@
+package httpserver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func newPrefixedServer(t *testing.T, prefix string, registry *prometheus.Registry) *Server {
+	t.Helper()
+	srv, err := New(Config{ListenAddr: ":0", StripPathPrefix: prefix}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, registry)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return srv
+}
+
+func postHello(srv *Server, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(`{"name":"alice"}`)))
+	rec := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStripPathPrefixRoutesPrefixedAndPlainPaths(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	srv := newPrefixedServer(t, "/api/v1/", registry)
+
+	for _, path := range []string{"/api/v1/helloworld/SayHello", "/helloworld/SayHello"} {
+		if rec := postHello(srv, path); rec.Code != http.StatusOK {
+			t.Fatalf("POST %s: expected 200 got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Both requests are labeled with the stripped route
+	series := durationSeries(t, registry)
+	if len(series) != 1 || series[0] != "/helloworld/SayHello /helloworld.Greeter/SayHello 2xx" {
+		t.Fatalf("unexpected series: %v", series)
+	}
+}
+
+func TestStripPathPrefixRequiresSegmentBoundary(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	srv := newPrefixedServer(t, "/api/v1", registry)
+
+	if rec := postHello(srv, "/api/v1x/helloworld/SayHello"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a path that only shares the prefix's characters, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/healthz", nil)
+	rec := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Fatalf("expected the prefixed health check to answer, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The near-miss stays unmatched instead of being routed under a stripped path
+	series := durationSeries(t, registry)
+	if strings.Join(series, ",") != "/healthz none 2xx,unmatched none 4xx" {
+		t.Fatalf("unexpected series: %v", series)
+	}
+}
+
+func TestStripPathPrefixDisabledByDefault(t *testing.T) {
+	srv := newPrefixedServer(t, "", nil)
+	if rec := postHello(srv, "/api/v1/helloworld/SayHello"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without StripPathPrefix, got %d", rec.Code)
+	}
+}
+
+func TestStripPathPrefixMustBeAbsolute(t *testing.T) {
+	_, err := New(Config{ListenAddr: ":0", StripPathPrefix: "api/v1"}, &stubGreeter{}, nil, nil)
+	if err == nil {
+		t.Fatal("expected an error for a relative prefix")
+	}
+}
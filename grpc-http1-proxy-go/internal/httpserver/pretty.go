@@ -0,0 +1,38 @@
+package httpserver
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	prettyQueryParam = "pretty" // Query parameter overriding Config.PrettyJSON per request
+	prettyIndent     = "  "     // Indentation used for pretty-printed responses
+)
+
+// responseMarshaller selects the marshal options for the response to c.
+// A ?pretty query value accepted by strconv.ParseBool overrides the configured
+// default in either direction; any other value is ignored.
+//
+// Parameters:
+//   - c: The request context, used to read the optional ?pretty query parameter.
+//
+// Returns:
+//   - protojson.MarshalOptions: h.marshaller, with Indent set to two spaces when
+//     pretty output is requested.
+func (h *handler) responseMarshaller(c *gin.Context) protojson.MarshalOptions {
+	pretty := h.prettyJSON
+	if raw, ok := c.GetQuery(prettyQueryParam); ok {
+		if v, err := strconv.ParseBool(raw); err == nil {
+			pretty = v
+		}
+	}
+	if !pretty {
+		return h.marshaller
+	}
+	opts := h.marshaller
+	opts.Indent = prettyIndent
+	return opts
+}
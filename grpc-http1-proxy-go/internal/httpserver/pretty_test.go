@@ -0,0 +1,69 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func serveHelloAt(t *testing.T, cfg Config, target string) string {
+	t.Helper()
+	srv, err := New(cfg, &stubGreeter{resp: &pb.HelloReply{Message: "Hello, Alice"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader([]byte(`{"name":"Alice"}`)))
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Both forms must carry the same reply
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if body["message"] != "Hello, Alice" {
+		t.Fatalf("unexpected reply %q", body["message"])
+	}
+	return rec.Body.String()
+}
+
+func assertCompact(t *testing.T, body string) {
+	t.Helper()
+	if strings.Contains(body, "\n") {
+		t.Fatalf("expected compact JSON, got %q", body)
+	}
+}
+
+func assertIndented(t *testing.T, body string) {
+	t.Helper()
+	lines := strings.Split(body, "\n")
+	if len(lines) < 3 || lines[0] != "{" || !strings.HasPrefix(lines[1], `  "message":`) {
+		t.Fatalf("expected JSON indented by two spaces, got %q", body)
+	}
+}
+
+func TestHandlerHelloCompactByDefault(t *testing.T) {
+	assertCompact(t, serveHelloAt(t, Config{ListenAddr: ":0"}, "/helloworld/SayHello"))
+}
+
+func TestHandlerHelloPrettyJSONConfig(t *testing.T) {
+	assertIndented(t, serveHelloAt(t, Config{ListenAddr: ":0", PrettyJSON: true}, "/helloworld/SayHello"))
+}
+
+func TestHandlerHelloPrettyQueryOverride(t *testing.T) {
+	assertIndented(t, serveHelloAt(t, Config{ListenAddr: ":0"}, "/helloworld/SayHello?pretty=1"))
+	assertCompact(t, serveHelloAt(t, Config{ListenAddr: ":0", PrettyJSON: true}, "/helloworld/SayHello?pretty=0"))
+}
+
+func TestHandlerHelloIgnoresInvalidPrettyValue(t *testing.T) {
+	assertCompact(t, serveHelloAt(t, Config{ListenAddr: ":0"}, "/helloworld/SayHello?pretty=maybe"))
+	assertIndented(t, serveHelloAt(t, Config{ListenAddr: ":0", PrettyJSON: true}, "/helloworld/SayHello?pretty=maybe"))
+}
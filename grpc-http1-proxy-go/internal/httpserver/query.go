@@ -0,0 +1,93 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// queryToJSON converts the query parameters of a GET request into the protojson
+// body of a message described by md, so GET requests share the POST path from
+// unmarshalling onwards. Parameters are matched to fields by JSON name (as sent
+// by the generated clients) or by proto name; unknown parameters, such as
+// ?pretty, are ignored like unknown JSON fields.
+//
+// The descriptor decides how a value is encoded:
+//   - bool: true/false/1/0 as accepted by strconv.ParseBool; a bare "?flag" is true
+//   - enum: a value name, or a number
+//   - numbers and strings: passed as JSON strings, which protojson accepts for
+//     every numeric kind and validates itself
+//
+// A repeated field takes every value of its parameter (?tag=a&tag=b); a singular
+// field given more than once, and message, map and bytes fields, are rejected.
+//
+// Parameters:
+//   - md: Descriptor of the request message.
+//   - query: The parsed query string.
+//
+// Returns:
+//   - []byte: A JSON object with keys sorted, ready for protojson.Unmarshal.
+//   - error: Describes the offending parameter; safe to return to the client.
+func queryToJSON(md protoreflect.MessageDescriptor, query url.Values) ([]byte, error) {
+	body := make(map[string]any, len(query))
+	fields := md.Fields()
+	for key, values := range query {
+		fd := fields.ByJSONName(key)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(key))
+		}
+		if fd == nil {
+			continue
+		}
+		if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind || fd.Kind() == protoreflect.BytesKind {
+			return nil, fmt.Errorf("field %q cannot be set from a query parameter", key)
+		}
+
+		if !fd.IsList() {
+			if len(values) > 1 {
+				return nil, fmt.Errorf("query parameter %q given more than once", key)
+			}
+			value, err := queryValue(fd, values[0])
+			if err != nil {
+				return nil, fmt.Errorf("query parameter %q: %w", key, err)
+			}
+			body[fd.JSONName()] = value
+			continue
+		}
+		items := make([]any, 0, len(values))
+		for _, raw := range values {
+			value, err := queryValue(fd, raw)
+			if err != nil {
+				return nil, fmt.Errorf("query parameter %q: %w", key, err)
+			}
+			items = append(items, value)
+		}
+		body[fd.JSONName()] = items
+	}
+	return json.Marshal(body)
+}
+
+// queryValue returns the JSON value for one query parameter value of field fd
+func queryValue(fd protoreflect.FieldDescriptor, raw string) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if raw == "" {
+			return true, nil
+		}
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", raw)
+		}
+		return v, nil
+	case protoreflect.EnumKind:
+		if n, err := strconv.ParseInt(raw, 10, 32); err == nil {
+			return n, nil
+		}
+		return raw, nil
+	default:
+		return raw, nil
+	}
+}
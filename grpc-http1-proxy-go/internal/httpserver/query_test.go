@@ -0,0 +1,153 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// recordingGreeter remembers the last request it was called with
+type recordingGreeter struct {
+	last *pb.HelloRequest
+}
+
+func (g *recordingGreeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	g.last = proto.Clone(req).(*pb.HelloRequest)
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+// queryMessage converts query into a message of the same type as msg
+func queryMessage(t *testing.T, query string, msg proto.Message) error {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	body, err := queryToJSON(msg.ProtoReflect().Descriptor(), values)
+	if err != nil {
+		return err
+	}
+	if err := protojson.Unmarshal(body, msg); err != nil {
+		t.Fatalf("protojson.Unmarshal(%s) error = %v", body, err)
+	}
+	return nil
+}
+
+func TestQueryToJSONBuildsHelloRequest(t *testing.T) {
+	req := &pb.HelloRequest{}
+	if err := queryMessage(t, "name=Alice&pretty=1", req); err != nil {
+		t.Fatalf("queryToJSON() error = %v", err)
+	}
+	if req.GetName() != "Alice" {
+		t.Fatalf("expected name Alice, got %q", req.GetName())
+	}
+}
+
+func TestQueryToJSONCoercesByDescriptor(t *testing.T) {
+	// FieldDescriptorProto mixes strings, an int32, an enum and a bool; the
+	// json_name and proto name spellings of a field are both accepted
+	field := &descriptorpb.FieldDescriptorProto{}
+	if err := queryMessage(t, "name=id&number=7&label=LABEL_REPEATED&type=9&proto3_optional=true&jsonName=ID", field); err != nil {
+		t.Fatalf("queryToJSON() error = %v", err)
+	}
+	want := &descriptorpb.FieldDescriptorProto{
+		Name:           proto.String("id"),
+		Number:         proto.Int32(7),
+		Label:          descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Type:           descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Proto3Optional: proto.Bool(true),
+		JsonName:       proto.String("ID"),
+	}
+	if !proto.Equal(field, want) {
+		t.Fatalf("got %v, want %v", field, want)
+	}
+
+	// A bare boolean parameter means true
+	field = &descriptorpb.FieldDescriptorProto{}
+	if err := queryMessage(t, "proto3Optional", field); err != nil {
+		t.Fatalf("queryToJSON() error = %v", err)
+	}
+	if !field.GetProto3Optional() {
+		t.Fatal("expected ?proto3Optional to set the field")
+	}
+}
+
+func TestQueryToJSONRepeatedParameters(t *testing.T) {
+	file := &descriptorpb.FileDescriptorProto{}
+	if err := queryMessage(t, "dependency=a.proto&dependency=b.proto&publicDependency=1&publicDependency=0", file); err != nil {
+		t.Fatalf("queryToJSON() error = %v", err)
+	}
+	if got := strings.Join(file.GetDependency(), ","); got != "a.proto,b.proto" {
+		t.Errorf("dependency = %s", got)
+	}
+	if got := file.GetPublicDependency(); len(got) != 2 || got[0] != 1 || got[1] != 0 {
+		t.Errorf("publicDependency = %v", got)
+	}
+}
+
+func TestQueryToJSONRejectsInvalidParameters(t *testing.T) {
+	tests := []struct {
+		query string
+		msg   proto.Message
+		want  string
+	}{
+		{"name=a&name=b", &pb.HelloRequest{}, `query parameter "name" given more than once`},
+		{"proto3Optional=maybe", &descriptorpb.FieldDescriptorProto{}, `invalid boolean "maybe"`},
+		{"options=x", &descriptorpb.FieldDescriptorProto{}, `field "options" cannot be set from a query parameter`},
+	}
+	for _, tt := range tests {
+		err := queryMessage(t, tt.query, tt.msg)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("query %s: error = %v, want %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestGETSayHelloUsesQueryParameters(t *testing.T) {
+	greeter := &recordingGreeter{}
+	srv, err := New(Config{ListenAddr: ":0", EnableGET: true}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	for _, path := range []string{"/helloworld/SayHello", "/helloworld.Greeter/SayHello"} {
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"?name=Alice", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+		if greeter.last.GetName() != "Alice" {
+			t.Fatalf("GET %s: backend got name %q", path, greeter.last.GetName())
+		}
+		if body := strings.TrimSpace(rec.Body.String()); body != `{"message":"Hello, Alice"}` {
+			t.Fatalf("GET %s: unexpected body %s", path, body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/helloworld/SayHello?name=a&name=b", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a repeated singular parameter, got %d", rec.Code)
+	}
+}
+
+func TestGETSayHelloDisabledByDefault(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &recordingGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/helloworld/SayHello?name=Alice", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 without EnableGET, got %d", rec.Code)
+	}
+}
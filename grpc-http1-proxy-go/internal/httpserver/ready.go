@@ -0,0 +1,21 @@
+package httpserver
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessHandler returns the readiness endpoint handler. It answers "ok" until
+// draining is set by Server.Shutdown and 503 afterwards, while the health
+// endpoint keeps answering 200 so the process is not restarted mid-drain.
+func readinessHandler(draining *atomic.Bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if draining.Load() {
+			c.String(http.StatusServiceUnavailable, "shutting down")
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	}
+}
@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func getStatus(srv *Server, path string) int {
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+func TestReadinessFailsDuringPreShutdownDelay(t *testing.T) {
+	const delay = 300 * time.Millisecond
+	srv, err := New(Config{ListenAddr: ":0", PreShutdownDelay: delay}, &stubGreeter{resp: &pb.HelloReply{}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if code := getStatus(srv, "/readyz"); code != http.StatusOK {
+		t.Fatalf("expected ready 200 before shutdown, got %d", code)
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- srv.Shutdown(context.Background()) }()
+
+	deadline := time.Now().Add(delay / 2)
+	for getStatus(srv, "/readyz") != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("readiness did not turn 503 after Shutdown was called")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if code := getStatus(srv, "/healthz"); code != http.StatusOK {
+		t.Fatalf("expected liveness 200 while draining, got %d", code)
+	}
+	if rec := postHello(srv, "/helloworld/SayHello"); rec.Code != http.StatusOK {
+		t.Fatalf("expected requests to be served while draining, got %d", rec.Code)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Fatalf("Shutdown returned after %v, before the %v delay", elapsed, delay)
+	}
+}
+
+func TestShutdownDelayHonorsContext(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0", PreShutdownDelay: time.Minute}, &stubGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_ = srv.Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("Shutdown ignored the context deadline, took %v", elapsed)
+	}
+}
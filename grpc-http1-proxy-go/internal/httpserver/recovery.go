@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// codeInternal is the error code of the JSON 500 written for a recovered panic
+const codeInternal = "INTERNAL"
+
+// recovery returns a middleware that replaces gin.Recovery: a panic in a later
+// handler is logged through logger with the panic value and stack trace, and
+// answered with a JSON 500 in the same envelope as the 404/405 fallbacks. Nothing
+// is written if the response has already started or the client connection is
+// gone, and http.ErrAbortHandler is re-panicked so net/http aborts the response
+// as the handler intended.
+//
+// Parameters:
+//   - logger: Logger for the recovered panics. Must not be nil.
+//
+// Returns:
+//   - gin.HandlerFunc: Middleware to install before any other.
+func recovery(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			logger.Error("panic while handling request",
+				slog.Any("panic", recovered),
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.String("stack", string(debug.Stack())))
+
+			// A client that hung up cannot read a response either
+			if err, ok := recovered.(error); ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)) {
+				c.Abort()
+				return
+			}
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorEnvelope{Error: errorDetail{
+				Code:    codeInternal,
+				Message: "internal server error",
+			}})
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecoveryWritesJSONEnvelopeAndLogsStack(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	explode := func(c *gin.Context) {
+		panic("boom")
+	}
+	srv, err := New(Config{ListenAddr: ":0", Middlewares: []gin.HandlerFunc{explode}}, &gatedGreeter{}, logger, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name":"x"}`)))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	var body errorEnvelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not a JSON error envelope: %v\n%s", err, rec.Body.String())
+	}
+	if body.Error.Code != codeInternal || body.Error.Message == "" {
+		t.Errorf("error = %+v, want code %s and a message", body.Error, codeInternal)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log is not one JSON record: %v\n%s", err, logs.String())
+	}
+	if entry["level"] != "ERROR" || entry["panic"] != "boom" || entry["path"] != "/helloworld/SayHello" {
+		t.Errorf("log record = %v, want an ERROR with the panic value and path", entry)
+	}
+	if stack, _ := entry["stack"].(string); !strings.Contains(stack, "recovery_test.go") {
+		t.Errorf("stack = %q, want the panicking frame", stack)
+	}
+}
+
+func TestRecoveryRepanicsAbortHandler(t *testing.T) {
+	abort := func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	}
+	srv, err := New(Config{ListenAddr: ":0", Middlewares: []gin.HandlerFunc{abort}}, &gatedGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+		}
+	}()
+	srv.engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+}
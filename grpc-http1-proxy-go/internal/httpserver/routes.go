@@ -0,0 +1,86 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// RouteSpec maps an HTTP path to a unary gRPC method. The method must have the
+// Greeter's shape: it takes a HelloRequest and returns a HelloReply.
+type RouteSpec struct {
+	HTTPPath   string // Path the method is served at, e.g. "/helloworld/SayHello"
+	GRPCMethod string // Full gRPC method name, e.g. "/helloworld.Greeter/SayHello"
+}
+
+// DefaultRoutes is used when Config.Routes is empty: Greeter.SayHello at
+// /helloworld/SayHello.
+var DefaultRoutes = []RouteSpec{
+	{HTTPPath: "/helloworld/SayHello", GRPCMethod: pb.Greeter_SayHello_FullMethodName},
+}
+
+// Invoker is implemented by Greeter clients that can call any method with the
+// Greeter's shape by its full gRPC method name. Routes to methods other than
+// Greeter.SayHello require the greeter passed to New to implement it.
+type Invoker interface {
+	// Invoke calls method with req; the same rules as for SayHello apply to req.
+	Invoke(ctx context.Context, method string, req *pb.HelloRequest) (*pb.HelloReply, error)
+}
+
+// route is a resolved RouteSpec: the paths a method is mounted at and how to call it
+type route struct {
+	paths  []string // HTTP path followed by the canonical gRPC path, without duplicates
+	method string   // Full gRPC method name, the "method" metrics label
+	call   func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error)
+}
+
+// resolveRoutes validates specs and binds each to greeter. Every route is also
+// mounted at its canonical gRPC path, so no path may be claimed by two methods.
+func resolveRoutes(specs []RouteSpec, greeter Greeter) ([]route, error) {
+	owners := make(map[string]string) // Path → method mounted at it
+	routes := make([]route, 0, len(specs))
+	for _, spec := range specs {
+		if !strings.HasPrefix(spec.HTTPPath, "/") {
+			return nil, fmt.Errorf("httpserver: route path %q must start with /", spec.HTTPPath)
+		}
+		if !validMethodName(spec.GRPCMethod) {
+			return nil, fmt.Errorf("httpserver: route %s: gRPC method %q is not of the form /package.Service/Method", spec.HTTPPath, spec.GRPCMethod)
+		}
+
+		r := route{method: spec.GRPCMethod}
+		for _, path := range []string{spec.HTTPPath, spec.GRPCMethod} {
+			owner, taken := owners[path]
+			if taken && owner != spec.GRPCMethod {
+				return nil, fmt.Errorf("httpserver: path %s is mapped to both %s and %s", path, owner, spec.GRPCMethod)
+			}
+			if !taken {
+				owners[path] = spec.GRPCMethod
+				r.paths = append(r.paths, path)
+			}
+		}
+
+		switch invoker, ok := greeter.(Invoker); {
+		case spec.GRPCMethod == pb.Greeter_SayHello_FullMethodName:
+			r.call = greeter.SayHello
+		case ok:
+			method := spec.GRPCMethod
+			r.call = func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+				return invoker.Invoke(ctx, method, req)
+			}
+		default:
+			return nil, fmt.Errorf("httpserver: route %s to %s needs a greeter that implements Invoker", spec.HTTPPath, spec.GRPCMethod)
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// validMethodName reports whether name has the form "/<package>.<Service>/<Method>"
+// (the package may be empty)
+func validMethodName(name string) bool {
+	service, method, ok := strings.Cut(strings.TrimPrefix(name, "/"), "/")
+	return strings.HasPrefix(name, "/") && ok && service != "" && method != "" &&
+		!strings.ContainsAny(method, "/.") && !strings.HasPrefix(service, ".") && !strings.HasSuffix(service, ".")
+}
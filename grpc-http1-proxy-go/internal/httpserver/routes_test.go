@@ -0,0 +1,80 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// invokingGreeter records the methods called through Invoke
+type invokingGreeter struct {
+	recordingGreeter
+	methods []string
+}
+
+func (g *invokingGreeter) Invoke(ctx context.Context, method string, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	g.methods = append(g.methods, method)
+	return &pb.HelloReply{Message: "Welcome, " + req.GetName()}, nil
+}
+
+func TestCustomRouteSpecCallsItsMethod(t *testing.T) {
+	greeter := &invokingGreeter{}
+	srv, err := New(Config{ListenAddr: ":0", Routes: []RouteSpec{
+		{HTTPPath: "/welcome", GRPCMethod: "/demo.Welcomer/Welcome"},
+		{HTTPPath: "/hello", GRPCMethod: pb.Greeter_SayHello_FullMethodName},
+	}}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"/welcome":               `{"message":"Welcome, Ada"}`,
+		"/demo.Welcomer/Welcome": `{"message":"Welcome, Ada"}`,
+		"/hello":                 `{"message":"Hello, Ada"}`,
+	} {
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"Ada"}`)))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("POST %s: status = %d, body %s, want %s", path, rec.Code, rec.Body.String(), want)
+		}
+	}
+	if len(greeter.methods) != 2 || greeter.methods[0] != "/demo.Welcomer/Welcome" {
+		t.Errorf("Invoke() methods = %v, want /demo.Welcomer/Welcome twice", greeter.methods)
+	}
+
+	// The default route is replaced, not extended
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST /helloworld/SayHello: status = %d, want 404 with custom routes", rec.Code)
+	}
+}
+
+func TestRouteSpecErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		routes  []RouteSpec
+		greeter Greeter
+		want    string
+	}{
+		{"relative path", []RouteSpec{{HTTPPath: "hello", GRPCMethod: "/demo.Welcomer/Welcome"}}, &invokingGreeter{}, "must start with /"},
+		{"bad method", []RouteSpec{{HTTPPath: "/hello", GRPCMethod: "demo.Welcomer.Welcome"}}, &invokingGreeter{}, "not of the form"},
+		{"path claimed twice", []RouteSpec{
+			{HTTPPath: "/hello", GRPCMethod: "/demo.Welcomer/Welcome"},
+			{HTTPPath: "/hello", GRPCMethod: "/demo.Welcomer/Greet"},
+		}, &invokingGreeter{}, "mapped to both"},
+		{"no invoker", []RouteSpec{{HTTPPath: "/hello", GRPCMethod: "/demo.Welcomer/Welcome"}}, &recordingGreeter{}, "implements Invoker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Config{ListenAddr: ":0", Routes: tt.routes}, tt.greeter, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
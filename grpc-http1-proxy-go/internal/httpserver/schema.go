@@ -0,0 +1,173 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schemaSuffix is appended to a method route to serve its request schema
+const schemaSuffix = "/schema"
+
+// Patterns for the string form of 64-bit integers in proto JSON, as in the
+// schemas written by protoc-http-go --json-schema
+const (
+	signedIntPattern   = "^-?[0-9]+$"
+	unsignedIntPattern = "^[0-9]+$"
+)
+
+// wellKnownSchemas describes the well-known types whose proto JSON form is not an
+// object of their fields
+var wellKnownSchemas = map[protoreflect.FullName]map[string]any{
+	"google.protobuf.Timestamp":   {"type": "string", "format": "date-time"},
+	"google.protobuf.Duration":    {"type": "string", "pattern": "^-?[0-9]+(\\.[0-9]+)?s$"},
+	"google.protobuf.FieldMask":   {"type": "string"},
+	"google.protobuf.Struct":      {"type": "object"},
+	"google.protobuf.ListValue":   {"type": "array"},
+	"google.protobuf.Value":       {},
+	"google.protobuf.Empty":       {"type": "object", "additionalProperties": false},
+	"google.protobuf.Any":         {"type": "object", "required": []string{"@type"}},
+	"google.protobuf.StringValue": {"type": "string"},
+	"google.protobuf.BytesValue":  {"type": "string", "contentEncoding": "base64"},
+	"google.protobuf.BoolValue":   {"type": "boolean"},
+	"google.protobuf.Int32Value":  {"type": "integer", "format": "int32"},
+	"google.protobuf.UInt32Value": {"type": "integer", "format": "uint32", "minimum": 0},
+	"google.protobuf.Int64Value":  {"type": []string{"integer", "string"}, "format": "int64", "pattern": signedIntPattern},
+	"google.protobuf.UInt64Value": {"type": []string{"integer", "string"}, "format": "uint64", "minimum": 0, "pattern": unsignedIntPattern},
+	"google.protobuf.FloatValue":  {"type": "number", "format": "float"},
+	"google.protobuf.DoubleValue": {"type": "number", "format": "double"},
+}
+
+// messageSchema builds the JSON Schema (draft 2020-12) of the proto JSON form of
+// md, in the shape protoc-http-go --json-schema writes: properties keyed by JSON
+// name, 64-bit integers as number or string, enums as their value names. Message
+// and enum types used by fields are placed under $defs by full name and referenced
+// with $ref, so recursive messages are described too.
+//
+// Returns:
+//   - []byte: The indented schema document.
+//   - error: Non-nil only if the document cannot be marshalled.
+func messageSchema(md protoreflect.MessageDescriptor) ([]byte, error) {
+	defs := make(map[string]any)
+	doc := objectSchema(md, defs)
+	doc["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	doc["title"] = string(md.FullName())
+	if len(defs) > 0 {
+		doc["$defs"] = defs
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// objectSchema returns the object schema of md's fields, adding the types they
+// reference to defs
+func objectSchema(md protoreflect.MessageDescriptor, defs map[string]any) map[string]any {
+	properties := make(map[string]any, md.Fields().Len())
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		properties[fd.JSONName()] = fieldSchema(fd, defs)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema returns the schema of one field: an array for repeated fields, an
+// object keyed by the map key for maps, otherwise the schema of its value
+func fieldSchema(fd protoreflect.FieldDescriptor, defs map[string]any) map[string]any {
+	switch {
+	case fd.IsMap():
+		return map[string]any{"type": "object", "additionalProperties": valueSchema(fd.MapValue(), defs)}
+	case fd.IsList():
+		return map[string]any{"type": "array", "items": valueSchema(fd, defs)}
+	default:
+		return valueSchema(fd, defs)
+	}
+}
+
+// valueSchema returns the schema of a single value of fd, ignoring its cardinality
+func valueSchema(fd protoreflect.FieldDescriptor, defs map[string]any) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return map[string]any{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]any{"type": "integer", "format": "uint32", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return map[string]any{"type": []string{"integer", "string"}, "format": "int64", "pattern": signedIntPattern}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]any{"type": []string{"integer", "string"}, "format": "uint64", "minimum": 0, "pattern": unsignedIntPattern}
+	case protoreflect.FloatKind:
+		return map[string]any{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]any{"type": "number", "format": "double"}
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		name := string(ed.FullName())
+		if _, ok := defs[name]; !ok {
+			defs[name] = enumSchema(ed)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	default: // MessageKind, GroupKind
+		md := fd.Message()
+		if wkt, ok := wellKnownSchemas[md.FullName()]; ok {
+			schema := make(map[string]any, len(wkt))
+			for k, v := range wkt {
+				schema[k] = v
+			}
+			return schema
+		}
+		name := string(md.FullName())
+		if _, ok := defs[name]; !ok {
+			defs[name] = nil // Reserve the name first so recursive fields stop here
+			defs[name] = objectSchema(md, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	}
+}
+
+// enumSchema lists the value names of ed, sorted, with their numbers in the
+// description like the generated schemas do
+func enumSchema(ed protoreflect.EnumDescriptor) map[string]any {
+	values := ed.Values()
+	names := make([]string, 0, values.Len())
+	numbers := make(map[string]protoreflect.EnumNumber, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		name := string(values.Get(i).Name())
+		names = append(names, name)
+		numbers[name] = values.Get(i).Number()
+	}
+	sort.Strings(names)
+	descriptions := make([]string, 0, len(names))
+	for _, name := range names {
+		descriptions = append(descriptions, fmt.Sprintf("%s=%d", name, numbers[name]))
+	}
+	return map[string]any{
+		"type":        "string",
+		"enum":        names,
+		"description": "Enum values: " + strings.Join(descriptions, ", "),
+	}
+}
+
+// schemaHandler returns a handler serving the request schema of method, built once
+// from its descriptor.
+func schemaHandler(method protoreflect.MethodDescriptor) (gin.HandlerFunc, error) {
+	schema, err := messageSchema(method.Input())
+	if err != nil {
+		return nil, fmt.Errorf("httpserver: build schema of %s: %w", method.FullName(), err)
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/schema+json", schema)
+	}, nil
+}
@@ -0,0 +1,104 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/typepb"
+)
+
+// decodeSchema unmarshals a schema document, failing the test if it is not a JSON object
+func decodeSchema(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not a JSON object: %v\n%s", err, data)
+	}
+	return schema
+}
+
+func TestSchemaEndpointDescribesHelloRequest(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0", ExposeSchemas: true}, &recordingGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, path := range []string{"/helloworld/SayHello/schema", "/helloworld.Greeter/SayHello/schema"} {
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, body %s", path, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/schema+json" {
+			t.Errorf("GET %s: Content-Type = %q", path, got)
+		}
+
+		schema := decodeSchema(t, rec.Body.Bytes())
+		if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" || schema["title"] != "helloworld.HelloRequest" {
+			t.Errorf("GET %s: unexpected $schema or title in %v", path, schema)
+		}
+		if schema["type"] != "object" || schema["additionalProperties"] != false {
+			t.Errorf("GET %s: schema is not a closed object: %v", path, schema)
+		}
+		want := map[string]any{"name": map[string]any{"type": "string"}}
+		if !reflect.DeepEqual(schema["properties"], want) {
+			t.Errorf("GET %s: properties = %v, want %v", path, schema["properties"], want)
+		}
+	}
+}
+
+func TestSchemaEndpointDisabledByDefault(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &recordingGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/helloworld/SayHello/schema", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without ExposeSchemas", rec.Code)
+	}
+}
+
+func TestMessageSchemaReferencesNestedTypes(t *testing.T) {
+	// DescriptorProto refers to itself through nested_type and to FieldDescriptorProto,
+	// whose label and type are enums
+	data, err := messageSchema((&descriptorpb.DescriptorProto{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatalf("messageSchema() error = %v", err)
+	}
+	schema := decodeSchema(t, data)
+	properties := schema["properties"].(map[string]any)
+	nested := properties["nestedType"].(map[string]any)
+	if nested["type"] != "array" || !reflect.DeepEqual(nested["items"], map[string]any{"$ref": "#/$defs/google.protobuf.DescriptorProto"}) {
+		t.Errorf("nestedType = %v, want an array of $ref to DescriptorProto", nested)
+	}
+
+	defs := schema["$defs"].(map[string]any)
+	field := defs["google.protobuf.FieldDescriptorProto"].(map[string]any)["properties"].(map[string]any)
+	if !reflect.DeepEqual(field["number"], map[string]any{"type": "integer", "format": "int32"}) {
+		t.Errorf("FieldDescriptorProto.number = %v", field["number"])
+	}
+	label := defs["google.protobuf.FieldDescriptorProto.Label"].(map[string]any)
+	if label["type"] != "string" || !reflect.DeepEqual(label["enum"], []any{"LABEL_OPTIONAL", "LABEL_REPEATED", "LABEL_REQUIRED"}) {
+		t.Errorf("Label = %v, want its value names sorted", label)
+	}
+}
+
+func TestMessageSchemaWellKnownTypes(t *testing.T) {
+	data, err := messageSchema((&typepb.Option{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatalf("messageSchema() error = %v", err)
+	}
+	schema := decodeSchema(t, data)
+	value := schema["properties"].(map[string]any)["value"]
+	if !reflect.DeepEqual(value, map[string]any{"type": "object", "required": []any{"@type"}}) {
+		t.Errorf("Option.value = %v, want the google.protobuf.Any form", value)
+	}
+	if _, ok := schema["$defs"]; ok {
+		t.Errorf("$defs = %v, want none for well-known types", schema["$defs"])
+	}
+}
@@ -5,17 +5,26 @@ package httpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
 
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/grpcclient"
 	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
 )
 
@@ -24,24 +33,68 @@ import (
 // This interface enables easier testing by allowing mock implementations.
 type Greeter interface {
 	// SayHello sends a greeting request to the gRPC backend and returns the response.
+	// Implementations must not retain req after returning: the handler reuses
+	// request messages across HTTP requests.
 	SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error)
 }
 
 // Config holds configuration parameters for the HTTP server.
 type Config struct {
-	ListenAddr        string        // Address and port to bind the server (e.g., ":8080")
+	ListenAddr        string        // Address and port to bind the server (e.g., ":8080"), or "unix:<path>" for a Unix domain socket
 	MetricsPath       string        // URL path for Prometheus metrics endpoint (default: "/metrics")
+	MetricsNamespace  string        // Prefix of the metric names (default: "grpc_http1_proxy")
+	MetricsSubsystem  string        // Optional prefix between the namespace and the metric names
 	HealthPath        string        // URL path for health check endpoint (default: "/healthz")
+	ReadyPath         string        // URL path for the readiness endpoint, 503 once shutdown begins (default: "/readyz")
+	PreShutdownDelay  time.Duration // Time Shutdown reports not ready before it stops accepting requests (0 disables)
 	ReadHeaderTimeout time.Duration // Maximum time to wait for request headers (default: 5s)
+	WriteTimeout      time.Duration // Maximum time from the end of the request headers to the end of the response (0 disables)
+	IdleTimeout       time.Duration // Time an idle keep-alive connection stays open (0 disables)
+	MaxHeaderBytes    int           // Largest request header block accepted (0 uses net/http's 1 MiB default)
+	MaxRequestTimeout time.Duration // Upper bound for client-supplied X-Timeout-Ms deadlines (default: 30s)
+	EnableIndex       bool          // Serve a JSON index of the registered routes at GET /
+	BackendAddr       func() string // gRPC backend address reported by the index, read per request so reloads show
+	RedactBackend     bool          // Hide BackendAddr from the index
+	Build             BuildInfo     // Build information served at GET /version and in the index
+	PrettyJSON        bool          // Indent response bodies by default (overridable per request with ?pretty=)
+	UseEnumNumbers    bool          // Render enum fields of replies as numbers instead of value names
+	Int64AsNumber     bool          // Render 64-bit integer fields of replies as JSON numbers instead of strings
+	EnableH2C         bool          // Also accept HTTP/2 over cleartext (h2c) on ListenAddr
+	StripPathPrefix   string        // Prefix added by an ingress (e.g. "/api/v1"), removed before routing
+	MaxResponseBytes  int           // Largest JSON response body; bigger replies are answered with 502 (0 disables)
+	APIKeys           []string      // Keys accepted in the X-API-Key header; empty disables authentication
+	SingleFlight      bool          // Collapse concurrent identical requests into one backend call
+	CacheTTL          time.Duration // How long successful replies are cached by request body (0 disables the cache)
+	CacheMaxEntries   int           // Most replies kept in the cache; the least recently used is evicted (default: 1024)
+	ServeStaleOnError bool          // Answer a failed backend call with the expired cached reply for the request, if any (needs CacheTTL)
+	MaxConcurrent     int           // Most proxy requests processed at once; the rest queue or get 503 (0 disables)
+	MaxQueue          int           // Most requests waiting for a slot once MaxConcurrent are processed (0 rejects them at once)
+	MaxQueueWait      time.Duration // Longest wait in the queue before 503 (0 waits until the client gives up)
+	EnableETag        bool          // Tag 200 responses with a weak ETag and answer a matching If-None-Match with 304
+	EnableGET         bool          // Also accept GET on the SayHello routes, with request fields taken from query parameters
+	ForwardFieldMask  bool          // Send the top-level fields present in the request as x-field-mask gRPC metadata
+	ExposeSchemas     bool          // Serve the JSON Schema of each method's request at GET <route>/schema
+	TLSCertFile       string        // PEM certificate served over HTTPS; reloaded when the file changes (empty serves plain HTTP)
+	TLSKeyFile        string        // PEM private key for TLSCertFile
+	Routes            []RouteSpec   // HTTP paths and the gRPC methods they call (default: DefaultRoutes)
+
+	// Gzip the replies of clients sending Accept-Encoding: gzip and send their
+	// backend calls with the gzip compressor, so the backend replies compressed too
+	NegotiateCompression bool
+
+	// Extra middleware (auth, logging, tracing) registered in order after the recovery
+	// and metrics middleware, before the X-API-Key check and the route handlers
+	Middlewares []gin.HandlerFunc
 }
 
 // Server wraps an HTTP server that proxies requests to a gRPC backend.
 // It handles routing, request/response translation, and metrics collection.
 type Server struct {
-	cfg     Config        // Server configuration
-	engine  *gin.Engine  // Gin HTTP engine
-	srv     *http.Server // Underlying HTTP server for graceful shutdown
-	handler *handler     // Request handler with business logic
+	cfg      Config        // Server configuration
+	engine   *gin.Engine  // Gin HTTP engine
+	srv      *http.Server // Underlying HTTP server for graceful shutdown
+	handler  *handler     // Request handler with business logic
+	draining *atomic.Bool // Set by Shutdown; the readiness endpoint then answers 503
 }
 
 // New creates and configures a new HTTP server with the provided settings.
@@ -59,8 +112,36 @@ type Server struct {
 //
 // The server registers the following routes:
 //   - POST /helloworld/SayHello: Main proxy endpoint for greeting requests
-//   - GET /healthz: Health check endpoint (returns "ok")
+//   - POST /helloworld.Greeter/SayHello: The same endpoint at its canonical gRPC path
+//   - GET /healthz: Liveness check endpoint (returns "ok")
+//   - GET /readyz: Readiness check endpoint ("ok", or 503 once Shutdown was called)
+//   - GET /version: Build information as JSON
 //   - GET /metrics: Prometheus metrics endpoint (if registry is provided)
+//   - GET /: JSON index of the routes above (if cfg.EnableIndex is set)
+//   - GET /helloworld/SayHello/schema: JSON Schema of HelloRequest, also under the
+//     canonical path (if cfg.ExposeSchemas is set)
+//
+// The SayHello routes above are those of DefaultRoutes. With cfg.Routes set, each
+// RouteSpec is served the same way instead, at its HTTPPath and at the canonical
+// path of its GRPCMethod; methods other than Greeter.SayHello are called through
+// greeter's Invoker implementation.
+//
+// With cfg.TLSCertFile and cfg.TLSKeyFile set, the server speaks HTTPS and picks up
+// rotated certificates on the next handshake.
+//
+// cfg.Middlewares run for every request after the recovery and metrics middleware
+// and before the X-API-Key check and the route handlers.
+//
+// With cfg.StripPathPrefix set, every route is also reachable under that prefix.
+// With cfg.MaxConcurrent set, proxy requests over the limit wait in a queue of
+// cfg.MaxQueue places for up to cfg.MaxQueueWait and are otherwise answered with a
+// JSON 503; the queue depth is exported as http_queued_requests.
+// With cfg.APIKeys set, every route except health, readiness and metrics requires a valid
+// X-API-Key header and answers a JSON 401 otherwise.
+//
+// Unknown paths return a JSON 404 and known paths with the wrong method a JSON 405,
+// both shaped as {"error": {"code": ..., "message": ...}}. A 405 lists the methods
+// registered for the path in its Allow header.
 func New(cfg Config, greeter Greeter, logger *slog.Logger, registry *prometheus.Registry) (*Server, error) {
 	// Validate required configuration
 	if cfg.ListenAddr == "" {
@@ -69,6 +150,32 @@ func New(cfg Config, greeter Greeter, logger *slog.Logger, registry *prometheus.
 	if greeter == nil {
 		return nil, errors.New("httpserver: greeter client is required")
 	}
+	if path, ok := unixSocketPath(cfg.ListenAddr); ok && path == "" {
+		return nil, errors.New("httpserver: unix listen address needs a socket path")
+	}
+	if cfg.StripPathPrefix != "" && !strings.HasPrefix(cfg.StripPathPrefix, "/") {
+		return nil, errors.New("httpserver: strip path prefix must start with /")
+	}
+	if cfg.ServeStaleOnError && cfg.CacheTTL <= 0 {
+		return nil, errors.New("httpserver: serving stale replies on error needs the response cache (CacheTTL)")
+	}
+	if cfg.MaxConcurrent < 0 || cfg.MaxQueue < 0 || cfg.MaxQueueWait < 0 {
+		return nil, errors.New("httpserver: concurrency limits must not be negative")
+	}
+	if cfg.MaxQueue > 0 && cfg.MaxConcurrent == 0 {
+		return nil, errors.New("httpserver: a request queue needs a concurrency limit (MaxConcurrent)")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, errors.New("httpserver: TLS needs both a certificate and a key file")
+	}
+	specs := cfg.Routes
+	if len(specs) == 0 {
+		specs = DefaultRoutes
+	}
+	routes, err := resolveRoutes(specs, greeter)
+	if err != nil {
+		return nil, err
+	}
 
 	// Apply defaults for optional fields
 	if logger == nil {
@@ -77,20 +184,33 @@ func New(cfg Config, greeter Greeter, logger *slog.Logger, registry *prometheus.
 	if cfg.ReadHeaderTimeout == 0 {
 		cfg.ReadHeaderTimeout = 5 * time.Second
 	}
+	if cfg.MaxRequestTimeout <= 0 {
+		cfg.MaxRequestTimeout = defaultMaxRequestTimeout
+	}
+	if cfg.Build.Version == "" {
+		cfg.Build.Version = "dev"
+	}
 
 	// Initialize metrics collection (may be nil if registry is nil)
-	metrics := newMetrics(registry)
+	metrics := newMetrics(registry, cfg.MetricsNamespace, cfg.MetricsSubsystem)
 
 	// Create request handler with JSON marshalling configuration
 	h := &handler{
-		greeter: greeter,
-		logger:  logger,
-		metrics: metrics,
+		logger:     logger,
+		metrics:    metrics,
+		maxTimeout: cfg.MaxRequestTimeout,
+		prettyJSON: cfg.PrettyJSON,
+		maxReply:   cfg.MaxResponseBytes,
+		enableETag: cfg.EnableETag,
+		int64Num:   cfg.Int64AsNumber,
+		fieldMask:  cfg.ForwardFieldMask,
+		compress:   cfg.NegotiateCompression,
 		// Configure JSON marshaller to use camelCase (not proto field names)
 		// and omit empty fields for cleaner JSON output
 		marshaller: protojson.MarshalOptions{
-			UseProtoNames:   false, // Use JSON names (camelCase) instead of proto names
-			EmitUnpopulated: false, // Don't include zero-value fields in output
+			UseProtoNames:   false,              // Use JSON names (camelCase) instead of proto names
+			EmitUnpopulated: false,              // Don't include zero-value fields in output
+			UseEnumNumbers:  cfg.UseEnumNumbers, // Numeric enum values instead of names when configured
 		},
 		// Configure JSON unmarshaller to ignore unknown fields for forward compatibility
 		unmarshaller: protojson.UnmarshalOptions{
@@ -98,125 +218,374 @@ func New(cfg Config, greeter Greeter, logger *slog.Logger, registry *prometheus.
 		},
 	}
 
+	if cfg.SingleFlight {
+		h.coalescer = &coalescer{}
+	}
+	if cfg.CacheTTL > 0 {
+		h.cache = newResponseCache(cfg.CacheTTL, cfg.CacheMaxEntries)
+		h.cache.keepStale = cfg.ServeStaleOnError
+	}
+
 	// Create Gin engine without default middleware for explicit control
 	gin.SetMode(gin.ReleaseMode) // Reduce console output in production
 	engine := gin.New()
 
-	// Add recovery middleware to handle panics gracefully
-	engine.Use(gin.Recovery())
+	// Add recovery middleware so panics are logged with their stack and answered
+	// with a JSON 500 instead of tearing down the connection
+	engine.Use(recovery(logger))
 
 	// Add metrics middleware if metrics are enabled
 	if metrics != nil {
 		engine.Use(metrics.middleware())
 	}
 
-	// Set up HTTP routing with Gin
-	// Main proxy endpoint: accepts JSON, calls gRPC, returns JSON
-	engine.POST("/helloworld/SayHello", h.hello)
+	// Caller-supplied middleware runs in the given order inside metrics, so requests
+	// it aborts are still counted, and ahead of API key checks and every route
+	// (including the JSON 404/405 fallbacks)
+	engine.Use(cfg.Middlewares...)
 
-	// Health check endpoint: simple endpoint for load balancers and monitoring
+	// Resolve the health, readiness and metrics paths up front: they are exempt from API key checks
 	healthPath := cfg.HealthPath
 	if healthPath == "" {
 		healthPath = "/healthz"
 	}
+	readyPath := cfg.ReadyPath
+	if readyPath == "" {
+		readyPath = "/readyz"
+	}
+	metricsPath := ""
+	if registry != nil {
+		metricsPath = cfg.MetricsPath
+		if metricsPath == "" {
+			metricsPath = "/metrics"
+		}
+	}
+
+	// Require an X-API-Key header when keys are configured. The middleware runs after
+	// metrics so rejected requests are still counted, and before every route
+	if len(cfg.APIKeys) > 0 {
+		engine.Use(apiKeyAuth(cfg.APIKeys, healthPath, readyPath, metricsPath))
+	}
+
+	// Answer unknown paths and wrong methods with JSON errors; global middleware
+	// (including metrics) also runs for these fallback handlers
+	engine.HandleMethodNotAllowed = true
+	engine.NoRoute(notFoundHandler)
+	engine.NoMethod(methodNotAllowedHandler(engine))
+
+	// Request schemas for self-service clients, derived from the method descriptor;
+	// every route shares the request type of Greeter.SayHello
+	var schema gin.HandlerFunc
+	if cfg.ExposeSchemas {
+		sayHello := pb.File_helloworld_helloworld_proto.Services().ByName("Greeter").Methods().ByName("SayHello")
+		if schema, err = schemaHandler(sayHello); err != nil {
+			return nil, err
+		}
+	}
+
+	// Set up HTTP routing with Gin
+	// Proxy endpoints: accept JSON, call gRPC, return JSON. Each is mounted at its
+	// HTTP path and at the canonical gRPC path, so gRPC-Web style clients that
+	// address methods as /<package>.<Service>/<Method> work too
+	// The concurrency limit covers the proxy routes only, so health checks and
+	// metrics scrapes are answered however busy the backend is
+	var limit []gin.HandlerFunc
+	if adm := newAdmission(cfg.MaxConcurrent, cfg.MaxQueue, cfg.MaxQueueWait, registry, cfg.MetricsNamespace, cfg.MetricsSubsystem); adm != nil {
+		limit = append(limit, adm.middleware())
+	}
+	for _, r := range routes {
+		metrics.registerMethod(r.method)
+		serve := append(append([]gin.HandlerFunc{}, limit...), h.serve(r))
+		for _, path := range r.paths {
+			engine.POST(path, serve...)
+
+			// GET variants for clients generated with "// http-method: GET", which send
+			// the request fields as query parameters instead of a JSON body
+			if cfg.EnableGET {
+				engine.GET(path, serve...)
+			}
+			if schema != nil {
+				engine.GET(path+schemaSuffix, schema)
+			}
+		}
+	}
+
+	// Health check endpoint: simple endpoint for load balancers and monitoring.
+	// It is the liveness probe and keeps answering 200 while the server drains.
 	engine.GET(healthPath, func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
 
+	// Readiness endpoint: turns 503 as soon as Shutdown starts, so load balancers
+	// stop sending new requests during cfg.PreShutdownDelay
+	draining := &atomic.Bool{}
+	engine.GET(readyPath, readinessHandler(draining))
+
+	// Version endpoint: reports which build is deployed
+	engine.GET("/version", versionHandler(cfg.Build))
+
 	// Prometheus metrics endpoint: exposes metrics in Prometheus format
 	if registry != nil {
-		metricsPath := cfg.MetricsPath
-		if metricsPath == "" {
-			metricsPath = "/metrics"
-		}
 		engine.GET(metricsPath, gin.WrapH(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
 	}
 
-	// Create HTTP server with configured timeout and Gin engine as handler
+	// Index endpoint: describes this instance for operators poking at the root
+	if cfg.EnableIndex {
+		backend := func() string {
+			if cfg.BackendAddr == nil {
+				return ""
+			}
+			if addr := cfg.BackendAddr(); !cfg.RedactBackend || addr == "" {
+				return addr
+			}
+			return redactedBackend
+		}
+		engine.GET("/", indexHandler(engine, backend, indexDocument{
+			Service: "grpc-http1-proxy-go",
+			Version: cfg.Build.Version,
+			Health:  healthPath,
+			Ready:   readyPath,
+			Metrics: metricsPath,
+		}))
+	}
+
+	// Create HTTP server with configured timeout and Gin engine as handler.
+	// With h2c enabled the engine is wrapped so that prior-knowledge HTTP/2 and
+	// "Upgrade: h2c" requests are served by the same routes and middleware.
+	handler := stripPathPrefix(cfg.StripPathPrefix, engine)
+	if cfg.EnableH2C {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
 	srv := &http.Server{
 		Addr:              cfg.ListenAddr,
-		Handler:           engine,
+		Handler:           handler,
 		ReadHeaderTimeout: cfg.ReadHeaderTimeout, // Prevent slowloris attacks
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	if cfg.TLSCertFile != "" {
+		reloader, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, logger)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.getCertificate,
+		}
 	}
 
-	return &Server{cfg: cfg, engine: engine, srv: srv, handler: h}, nil
+	return &Server{cfg: cfg, engine: engine, srv: srv, handler: h, draining: draining}, nil
 }
 
 // Start begins listening for HTTP requests on the configured address.
 // This method blocks until the server is stopped via Shutdown() or encounters an error.
 // It should typically be called in a goroutine.
 //
+// A ListenAddr of the form "unix:<path>" listens on a Unix domain socket instead of
+// TCP, replacing a stale socket file at path; the file is removed again on shutdown.
+// With a TLS certificate configured, connections are served over HTTPS.
+//
 // Returns:
 //   - error: Non-nil if the server fails to start or encounters a fatal error.
 //     Returns nil if the server is gracefully shut down.
 func (s *Server) Start() error {
-	if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	ln, err := listen(s.cfg.ListenAddr)
+	if err != nil {
+		return err
+	}
+	serve := s.srv.Serve
+	if s.srv.TLSConfig != nil {
+		// The certificate comes from TLSConfig.GetCertificate, not from files passed here
+		serve = func(ln net.Listener) error { return s.srv.ServeTLS(ln, "", "") }
+	}
+	if err := serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
 }
 
+// canonicalPath returns the canonical gRPC path of a method, "/<package>.<Service>/<Method>",
+// derived from its descriptor (e.g. "/helloworld.Greeter/SayHello").
+func canonicalPath(method protoreflect.MethodDescriptor) string {
+	return "/" + string(method.Parent().FullName()) + "/" + string(method.Name())
+}
+
 // Shutdown gracefully stops the HTTP server, allowing in-flight requests to complete.
 // It stops accepting new connections and waits for existing requests to finish,
 // up to the timeout specified in ctx.
 //
+// The readiness endpoint answers 503 from the moment Shutdown is called. With
+// cfg.PreShutdownDelay set, the server keeps serving for that long first, so a
+// load balancer (e.g. Kubernetes removing the pod from its endpoints) can stop
+// routing new requests here before connections are refused.
+//
 // Parameters:
-//   - ctx: Context with timeout for the shutdown operation. The server will wait
-//     for this duration before forcefully closing connections.
+//   - ctx: Context with timeout for the shutdown operation, including the
+//     pre-shutdown delay. The server will wait for this duration before
+//     forcefully closing connections.
 //
 // Returns:
 //   - error: Non-nil if shutdown fails or the context deadline is exceeded.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	if s.cfg.PreShutdownDelay > 0 {
+		s.handler.logger.Info("draining before shutdown", slog.Duration("delay", s.cfg.PreShutdownDelay))
+		timer := time.NewTimer(s.cfg.PreShutdownDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
 	return s.srv.Shutdown(ctx)
 }
 
 // handler contains the business logic for processing HTTP requests and translating
 // them into gRPC calls. It handles JSON/protobuf conversion, error handling, and metrics.
 type handler struct {
-	greeter      Greeter                    // gRPC client for making backend calls
 	logger       *slog.Logger               // Logger for error messages
 	metrics      *metrics                   // Metrics collector (may be nil)
 	marshaller   protojson.MarshalOptions   // Options for converting protobuf to JSON
 	unmarshaller protojson.UnmarshalOptions // Options for converting JSON to protobuf
+	maxTimeout   time.Duration              // Upper bound for client-supplied deadlines
+	prettyJSON   bool                       // Indent responses unless ?pretty= says otherwise
+	maxReply     int                        // Largest JSON response body in bytes (0 disables the check)
+	coalescer    *coalescer                 // Shares in-flight backend calls between identical requests (nil disables)
+	cache        *responseCache             // Recent successful replies by request body (nil disables)
+	enableETag   bool                       // Set ETag on 200 responses and honor If-None-Match
+	int64Num     bool                       // Rewrite quoted 64-bit integer fields of replies as JSON numbers
+	fieldMask    bool                       // Forward the fields present in the request body as x-field-mask metadata
+	compress     bool                       // Gzip replies and backend calls of clients accepting gzip
 }
 
-// hello handles POST requests to /helloworld/SayHello.
-// It accepts a JSON request body, converts it to a protobuf message, calls the gRPC backend,
+// serve returns the handler mounted at the paths of r
+func (h *handler) serve(r route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.hello(c, r)
+	}
+}
+
+// hello handles POST requests to the paths of route r, /helloworld/SayHello by default.
+// It accepts a JSON request body, converts it to a protobuf message, calls r's gRPC method,
 // and returns the response as JSON.
 //
+// With Config.EnableGET it also handles GET requests, whose request fields come
+// from query parameters (GET /helloworld/SayHello?name=Alice); see queryToJSON.
+//
 // Request format:
-//   POST /helloworld/SayHello
+//   POST /helloworld/SayHello[?pretty=1]
 //   Content-Type: application/json
+//   X-Timeout-Ms: 1500 (optional; deadline for the backend call, clamped to MaxRequestTimeout)
 //   Body: {"name": "Alice"}
 //
 // Response format:
 //   Status: 200 OK
 //   Content-Type: application/json
-//   Body: {"message": "Hello, Alice"}
+//   Body: {"message": "Hello, Alice"} (indented by two spaces when pretty output
+//   is enabled via Config.PrettyJSON or ?pretty=1; ?pretty=0 forces compact output)
+//
+// With Config.EnableETag the 200 response carries a weak ETag, and a request whose
+// If-None-Match matches it is answered with 304 Not Modified and no body.
+//
+// With Config.ServeStaleOnError a failed backend call is answered with the cached
+// reply for the same body even after it expired: 200 with X-Cache: STALE instead
+// of 502, so read endpoints stay available while the backend recovers.
+//
+// With Config.ForwardFieldMask the backend call carries x-field-mask metadata
+// listing the proto names of the top-level fields present in the body (or query),
+// e.g. "name", so it can apply only those fields; see presentFields.
+//
+// With Config.NegotiateCompression a request with Accept-Encoding: gzip gets its
+// 200 response gzip-compressed (Content-Encoding: gzip), and its backend call is
+// sent with the gzip compressor, which asks the backend for a gzip reply as well.
+// Every response then carries Vary: Accept-Encoding.
 //
 // Error responses:
-//   - 400 Bad Request: If request body is invalid or cannot be parsed
+//   - 400 Bad Request: If the request body (or GET query) is invalid or cannot be parsed; parse errors
+//     include a sanitized "detail" naming the offending field or token. Also returned
+//     when X-Timeout-Ms is not a positive integer
+//   - 413 Request Entity Too Large: If Content-Length declares a body over 1MB; the
+//     body is not read
 //   - 502 Bad Gateway: If the gRPC backend call fails
+//   - 499 (recorded only): If the client disconnects before the backend replies;
+//     the backend call is canceled with the request context
 //   - 500 Internal Server Error: If response cannot be marshalled to JSON
-func (h *handler) hello(c *gin.Context) {
-	// Read request body with a size limit (1MB) to prevent memory exhaustion
-	// LimitReader ensures we don't read more than 1MB even if Content-Length is larger
-	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+func (h *handler) hello(c *gin.Context, r route) {
+	setGRPCMethod(c, r.method)
+
+	// Derive the backend deadline from the optional X-Timeout-Ms header
+	// Without the header the gRPC client applies its fixed per-call deadline
+	ctx, cancel, err := withClientDeadline(c.Request.Context(), c.GetHeader(timeoutHeader), h.maxTimeout)
+	defer cancel()
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A body declared larger than the limit is rejected before reading any of it;
+	// ContentLength is -1 for chunked requests, which only the LimitReader below bounds
+	if c.Request.Method != http.MethodGet && c.Request.ContentLength > maxRequestBodySize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+		return
+	}
+
+	// Read request body with a size limit (1MB) to prevent memory exhaustion
+	// LimitReader ensures we don't read more than 1MB even if Content-Length is missing
+	// The buffer comes from a pool and is returned on every path via defer
+	// GET requests carry the fields as query parameters instead, converted to the same JSON
+	bodyBuf := getBodyBuffer()
+	defer putBodyBuffer(bodyBuf)
+	if c.Request.Method == http.MethodGet {
+		body, err := queryToJSON((*pb.HelloRequest)(nil).ProtoReflect().Descriptor(), c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters", "detail": err.Error()})
+			return
+		}
+		bodyBuf.Write(body)
+	} else if _, err := bodyBuf.ReadFrom(io.LimitReader(c.Request.Body, maxRequestBodySize)); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
 
-	// Parse JSON request body into protobuf message
-	req := &pb.HelloRequest{}
-	if err := h.unmarshaller.Unmarshal(body, req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+	// Parse JSON request body into a pooled protobuf message
+	// protojson copies strings out of the body, so the buffer can be reused safely
+	// The sanitized protojson error is echoed as "detail" so clients can see
+	// which field or value is wrong; unrecognized error categories are omitted
+	req := getHelloRequest()
+	defer putHelloRequest(req)
+	if err := h.unmarshaller.Unmarshal(bodyBuf.Bytes(), req); err != nil {
+		resp := gin.H{"error": "invalid JSON payload"}
+		if detail := describeUnmarshalError(err); detail != "" {
+			resp["detail"] = detail
+		}
+		c.JSON(http.StatusBadRequest, resp)
 		return
 	}
 
+	// protojson cannot tell an omitted field from one sent with its zero value, so
+	// the keys of the body are read again to tell the backend which fields were set
+	if h.fieldMask {
+		fields, err := presentFields(req.ProtoReflect().Descriptor(), bodyBuf.Bytes())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		ctx = withFieldMask(ctx, fields)
+	}
+
+	// Pass the client's preference for gzip on to the backend call and the response
+	gzipReply := false
+	if h.compress {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if gzipReply = acceptsGzip(c.GetHeader("Accept-Encoding")); gzipReply {
+			ctx = grpcclient.WithCompressor(ctx, gzip.Name)
+		}
+	}
+
 	// Call the gRPC backend with the parsed request
 	// The context from the HTTP request is passed through, allowing cancellation
-	// if the client disconnects
+	// if the client disconnects, together with any client-requested deadline
 	//
 	// IMPORTANT: DO NOT wrap this gRPC call in a goroutine.
 	// Gin already runs each HTTP request in its own goroutine, so wrapping
@@ -224,22 +593,107 @@ func (h *handler) hello(c *gin.Context) {
 	// any concurrency benefit. The HTTP response must wait for the gRPC result
 	// anyway. Synchronous calls ensure proper context propagation for timeouts
 	// and cancellation, and keep error handling simple.
-	resp, err := h.greeter.SayHello(c.Request.Context(), req)
+	//
+	// With the cache enabled, a reply cached for the same body is served without a
+	// backend call. With single-flight enabled, concurrent requests with the same
+	// body share one backend call and its result
+	var resp *pb.HelloReply
+	var cacheKey string
+	cached := false
+	if h.cache != nil {
+		cacheKey = requestKey(r.method, bodyBuf.Bytes())
+		resp, cached = h.cache.get(cacheKey)
+	}
+	if cached {
+		err = nil
+	} else if h.coalescer != nil {
+		key := coalesceKey(r.method, c.GetHeader(timeoutHeader), bodyBuf.Bytes())
+		resp, err = h.coalescer.call(ctx, r.call, key, req)
+	} else {
+		resp, err = r.call(ctx, req)
+	}
+	stale := false
 	if err != nil {
-		// gRPC call failed - return 502 to indicate upstream error
-		c.JSON(http.StatusBadGateway, gin.H{"error": "upstream error"})
-		h.logger.Error("gRPC call failed", slog.String("err", err.Error()))
-		return
+		// The client disconnected, which canceled the gRPC call; this is not an
+		// upstream failure, so record 499 without writing a body nobody will read
+		if clientCanceled(c) {
+			c.Status(statusClientClosedRequest)
+			h.logger.Debug("client closed request before the gRPC call completed", slog.String("err", err.Error()))
+			return
+		}
+
+		// Keep serving during backend blips with the cached reply for this body,
+		// even an expired one, when stale replies are kept
+		if h.cache != nil && h.cache.keepStale {
+			resp, stale = h.cache.getStale(cacheKey)
+		}
+		if !stale {
+			// gRPC call failed - return 502 to indicate upstream error
+			c.JSON(http.StatusBadGateway, gin.H{"error": "upstream error"})
+			h.logger.Error("gRPC call failed", slog.String("err", err.Error()))
+			return
+		}
+		h.logger.Warn("gRPC call failed, serving stale cached reply", slog.String("err", err.Error()))
 	}
 
-	// Convert protobuf response to JSON
-	data, err := h.marshaller.Marshal(resp)
+	// Convert protobuf response to JSON, appending into a pooled buffer
+	respBuf := getResponseBuffer()
+	defer putResponseBuffer(respBuf)
+	marshaller := h.responseMarshaller(c)
+	data, err := marshaller.MarshalAppend((*respBuf)[:0], resp)
 	if err != nil {
 		// This should rarely happen, but handle it gracefully
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to marshal response"})
 		return
 	}
+	*respBuf = data // Keep the grown slice for the next request
+
+	// Unquote 64-bit integers for clients that want plain JSON numbers
+	if h.int64Num {
+		if data, err = int64AsNumber(resp.ProtoReflect().Descriptor(), data, marshaller.Indent); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to marshal response"})
+			return
+		}
+	}
+
+	// Refuse to relay replies beyond the configured size; the backend misbehaved
+	if h.maxReply > 0 && len(data) > h.maxReply {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "upstream response too large"})
+		h.logger.Error("gRPC reply exceeds the maximum response size",
+			slog.Int("bytes", len(data)), slog.Int("max", h.maxReply))
+		return
+	}
+
+	// Only replies that make it to a 200 are cached
+	if h.cache != nil {
+		switch {
+		case stale:
+			c.Header(cacheHeader, "STALE")
+		case cached:
+			c.Header(cacheHeader, "HIT")
+		default:
+			h.cache.add(cacheKey, resp)
+			c.Header(cacheHeader, "MISS")
+		}
+	}
+
+	// Polling clients that already hold this exact body get a 304 without it
+	if h.enableETag {
+		etag := weakETag(data)
+		c.Header("ETag", etag)
+		if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	// Compress the body for clients that accept gzip
+	if gzipReply {
+		writeGzip(c, http.StatusOK, "application/json", data)
+		return
+	}
 
 	// Write successful response with raw JSON (already marshalled by protojson)
+	// c.Data writes synchronously, so the buffer is not used after this returns
 	c.Data(http.StatusOK, "application/json", data)
 }
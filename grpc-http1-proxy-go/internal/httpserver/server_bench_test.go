@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// echoGreeter replies with the request name so response size tracks request size.
+type echoGreeter struct{}
+
+func (echoGreeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: req.GetName()}, nil
+}
+
+// benchBody is a reusable request body so the benchmark loop itself does not allocate.
+type benchBody struct {
+	bytes.Reader
+}
+
+func (*benchBody) Close() error { return nil }
+
+// discardWriter is a minimal reusable http.ResponseWriter that drops the body.
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardWriter) WriteHeader(status int)      { w.status = status }
+
+// benchmarkHello drives the SayHello route with payload and reports ns/op and allocs/op
+// for the JSON-in/JSON-out path. Requests go through the Gin engine (with its pooled
+// contexts) to reach handler.hello; metrics are disabled so only the handler is measured.
+func benchmarkHello(b *testing.B, payload []byte) {
+	srv, err := New(Config{ListenAddr: ":0"}, echoGreeter{}, nil, nil)
+	if err != nil {
+		b.Fatalf("failed to create server: %v", err)
+	}
+
+	body := &benchBody{}
+	req, err := http.NewRequest(http.MethodPost, "/helloworld/SayHello", body)
+	if err != nil {
+		b.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := &discardWriter{header: make(http.Header)}
+
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		body.Reset(payload)
+		clear(w.header)
+		w.status = 0
+
+		srv.engine.ServeHTTP(w, req)
+
+		if w.status != http.StatusOK {
+			b.Fatalf("expected 200 got %d", w.status)
+		}
+	}
+}
+
+func BenchmarkHandlerHello(b *testing.B) {
+	benchmarkHello(b, []byte(`{"name":"alice"}`))
+}
+
+func BenchmarkHandlerHelloLargePayload(b *testing.B) {
+	// ~64KB name: exercises body reading and response marshalling with large buffers
+	benchmarkHello(b, []byte(`{"name":"`+strings.Repeat("x", 64<<10)+`"}`))
+}
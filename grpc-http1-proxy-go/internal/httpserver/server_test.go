@@ -3,10 +3,15 @@ package httpserver
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"google.golang.org/protobuf/encoding/protojson"
 
@@ -75,3 +80,256 @@ func TestHandlerHelloError(t *testing.T) {
 		t.Fatalf("unexpected body: %s", string(body))
 	}
 }
+
+func TestHandlerHelloInvalidFieldType(t *testing.T) {
+	greeter := &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}
+	srv, err := New(Config{ListenAddr: ":0"}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", bytes.NewReader([]byte(`{"name":123}`)))
+	rec := httptest.NewRecorder()
+
+	srv.engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 got %d", rec.Code)
+	}
+
+	var body struct {
+		Error  string `json:"error"`
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if body.Error != "invalid JSON payload" {
+		t.Fatalf("unexpected error message: %q", body.Error)
+	}
+	if !strings.Contains(body.Detail, "string field name") {
+		t.Fatalf("expected detail to mention the offending field and type, got %q", body.Detail)
+	}
+	if strings.HasPrefix(body.Detail, "proto:") {
+		t.Fatalf("expected protojson prefix to be stripped, got %q", body.Detail)
+	}
+}
+
+func TestHandlerHelloSyntaxError(t *testing.T) {
+	greeter := &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}
+	srv, err := New(Config{ListenAddr: ":0"}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", bytes.NewReader([]byte(`{"name" "alice"}`)))
+	rec := httptest.NewRecorder()
+
+	srv.engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 got %d", rec.Code)
+	}
+	if !bytes.Contains(rec.Body.Bytes(), []byte("syntax error")) {
+		t.Fatalf("expected syntax error detail, got %s", rec.Body.String())
+	}
+}
+
+func TestDescribeUnmarshalErrorFiltersUnknownCategories(t *testing.T) {
+	if got := describeUnmarshalError(errors.New("proto: internal resolver failure at 0xdeadbeef")); got != "" {
+		t.Fatalf("expected non-whitelisted error to be hidden, got %q", got)
+	}
+	if got := describeUnmarshalError(nil); got != "" {
+		t.Fatalf("expected empty detail for nil error, got %q", got)
+	}
+}
+
+func TestHandlerHelloRejectsOversizedContentLengthBeforeReading(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	body := strings.NewReader(`{"name":"big"}`)
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", body)
+	req.ContentLength = maxRequestBodySize + 1
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "request body too large") {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+	if body.Len() != len(`{"name":"big"}`) {
+		t.Fatalf("expected the body to be left unread, %d bytes remain", body.Len())
+	}
+}
+
+func TestHandlerHelloLimitsChunkedBodies(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// Without a Content-Length only the LimitReader bounds the read, so the
+	// truncated JSON fails to parse
+	payload := `{"name":"` + strings.Repeat("a", 2*maxRequestBodySize) + `"}`
+	body := strings.NewReader(payload)
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", body)
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 got %d", rec.Code)
+	}
+	if read := len(payload) - body.Len(); read != maxRequestBodySize {
+		t.Fatalf("expected %d bytes read, got %d", maxRequestBodySize, read)
+	}
+}
+
+func TestHandlerHelloPooledBuffersAreIsolated(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, echoGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// call returns the status code and the reply message (protojson output spacing is unstable)
+	call := func(body string) (int, string) {
+		req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, req)
+		var reply struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+			return rec.Code, "invalid JSON: " + rec.Body.String()
+		}
+		return rec.Code, reply.Message
+	}
+
+	// A reused request message must not carry the previous request's fields
+	if code, msg := call(`{"name":"alice"}`); code != http.StatusOK || msg != "alice" {
+		t.Fatalf("unexpected response %d %q", code, msg)
+	}
+	if code, msg := call(`{}`); code != http.StatusOK || msg != "" {
+		t.Fatalf("expected empty reply after pooled reuse, got %d %q", code, msg)
+	}
+
+	// Concurrent requests must each see their own body and response buffers
+	var wg sync.WaitGroup
+	errs := make(chan string, 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("user-%d-%s", i, strings.Repeat("x", i*100))
+			code, msg := call(`{"name":"` + name + `"}`)
+			if code != http.StatusOK || msg != name {
+				errs <- fmt.Sprintf("request %d: got %d %.60q", i, code, msg)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Error(e)
+	}
+}
+
+func TestCanonicalPathMatchesGRPCMethod(t *testing.T) {
+	method := pb.File_helloworld_helloworld_proto.Services().ByName("Greeter").Methods().ByName("SayHello")
+	if got := canonicalPath(method); got != pb.Greeter_SayHello_FullMethodName {
+		t.Fatalf("canonicalPath() = %q, want %q", got, pb.Greeter_SayHello_FullMethodName)
+	}
+}
+
+func TestHandlerHelloAtCanonicalPath(t *testing.T) {
+	greeter := &stubGreeter{resp: &pb.HelloReply{Message: "hi"}}
+	srv, err := New(Config{ListenAddr: ":0"}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/helloworld.Greeter/SayHello", bytes.NewReader([]byte(`{"name":"alice"}`)))
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	resp := &pb.HelloReply{}
+	if err := protojson.Unmarshal(rec.Body.Bytes(), resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Message != "hi" {
+		t.Fatalf("expected message 'hi', got %q", resp.Message)
+	}
+}
+
+func TestHandlerHelloRejectsOversizedResponse(t *testing.T) {
+	greeter := &stubGreeter{resp: &pb.HelloReply{Message: strings.Repeat("x", 2048)}}
+	for _, tc := range []struct {
+		name     string
+		max      int
+		wantCode int
+	}{
+		{"over the limit", 1024, http.StatusBadGateway},
+		{"under the limit", 4096, http.StatusOK},
+		{"disabled", 0, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, err := New(Config{ListenAddr: ":0", MaxResponseBytes: tc.max}, greeter, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create server: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", bytes.NewReader([]byte(`{"name":"alice"}`)))
+			rec := httptest.NewRecorder()
+			srv.engine.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected %d got %d", tc.wantCode, rec.Code)
+			}
+			if tc.wantCode == http.StatusBadGateway && !strings.Contains(rec.Body.String(), "upstream response too large") {
+				t.Fatalf("unexpected body: %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewAppliesConnectionLimits(t *testing.T) {
+	srv, err := New(Config{
+		ListenAddr:        ":0",
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      45 * time.Second,
+		IdleTimeout:       90 * time.Second,
+		MaxHeaderBytes:    64 << 10,
+	}, &stubGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if srv.srv.ReadHeaderTimeout != 2*time.Second {
+		t.Errorf("ReadHeaderTimeout = %v, want 2s", srv.srv.ReadHeaderTimeout)
+	}
+	if srv.srv.WriteTimeout != 45*time.Second {
+		t.Errorf("WriteTimeout = %v, want 45s", srv.srv.WriteTimeout)
+	}
+	if srv.srv.IdleTimeout != 90*time.Second {
+		t.Errorf("IdleTimeout = %v, want 90s", srv.srv.IdleTimeout)
+	}
+	if srv.srv.MaxHeaderBytes != 64<<10 {
+		t.Errorf("MaxHeaderBytes = %d, want %d", srv.srv.MaxHeaderBytes, 64<<10)
+	}
+}
+
+func TestNewLeavesConnectionLimitsAtNetHTTPDefaults(t *testing.T) {
+	srv, err := New(Config{ListenAddr: ":0"}, &stubGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if srv.srv.WriteTimeout != 0 || srv.srv.IdleTimeout != 0 || srv.srv.MaxHeaderBytes != 0 {
+		t.Fatalf("expected unset limits, got write %v idle %v header bytes %d", srv.srv.WriteTimeout, srv.srv.IdleTimeout, srv.srv.MaxHeaderBytes)
+	}
+}
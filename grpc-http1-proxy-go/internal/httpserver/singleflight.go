@@ -0,0 +1,56 @@
+package httpserver
+
+import (
+	"context"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// coalescer collapses concurrent identical backend calls into one when
+// Config.SingleFlight is set. Only calls that overlap in time share a result:
+// the key is forgotten as soon as the call returns, so a reply or an error is
+// never handed to a request that arrives afterwards.
+type coalescer struct {
+	group singleflight.Group
+}
+
+// coalesceKey identifies identical requests: the same gRPC method, the same raw
+// body and the same X-Timeout-Ms header (so callers never share a shorter deadline).
+func coalesceKey(method, timeout string, body []byte) string {
+	return requestKey(method, body) + "\x00" + timeout
+}
+
+// call invokes fn once per key among concurrent callers and returns the shared
+// reply, which callers must treat as read-only.
+//
+// The shared call runs on a clone of req, detached from the cancellation of the
+// request that started it but keeping its deadline, so one client disconnecting
+// does not fail the others. Each caller still stops waiting when its own ctx ends
+// and then returns ctx's error.
+func (c *coalescer) call(ctx context.Context, fn func(context.Context, *pb.HelloRequest) (*pb.HelloReply, error), key string, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	// req is pooled and recycled when this caller's handler returns, which may be
+	// before the shared call is done with it
+	shared := proto.Clone(req).(*pb.HelloRequest)
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		callCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			callCtx, cancel = context.WithDeadline(callCtx, deadline)
+			defer cancel()
+		}
+		return fn(callCtx, shared)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*pb.HelloReply), nil
+	}
+}
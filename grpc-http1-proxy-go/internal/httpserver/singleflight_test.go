@@ -0,0 +1,145 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+// gatedGreeter counts calls and holds each one until release is closed
+type gatedGreeter struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func (g *gatedGreeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	if g.calls.Add(1) == 1 && g.started != nil {
+		close(g.started)
+	}
+	if g.release != nil {
+		<-g.release
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &pb.HelloReply{Message: "Hello, " + req.GetName()}, nil
+}
+
+func postHelloBody(srv *Server, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSingleFlightCollapsesConcurrentIdenticalRequests(t *testing.T) {
+	greeter := &gatedGreeter{started: make(chan struct{}), release: make(chan struct{})}
+	srv, err := New(Config{ListenAddr: ":0", SingleFlight: true}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	const n = 20
+	recs := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recs[i] = postHelloBody(srv, `{"name":"alice"}`)
+		}(i)
+	}
+
+	// Hold the first backend call open long enough for every request to join it
+	<-greeter.started
+	time.Sleep(100 * time.Millisecond)
+	close(greeter.release)
+	wg.Wait()
+
+	if calls := greeter.calls.Load(); calls != 1 {
+		t.Fatalf("expected %d identical requests to make 1 backend call, got %d", n, calls)
+	}
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK || rec.Body.String() != `{"message":"Hello, alice"}` {
+			t.Fatalf("request %d: got %d %s", i, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestSingleFlightKeepsDifferentBodiesApart(t *testing.T) {
+	greeter := &gatedGreeter{}
+	srv, err := New(Config{ListenAddr: ":0", SingleFlight: true}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	for _, name := range []string{"alice", "bob"} {
+		rec := postHelloBody(srv, `{"name":"`+name+`"}`)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), name) {
+			t.Fatalf("got %d %s for %s", rec.Code, rec.Body.String(), name)
+		}
+	}
+	if calls := greeter.calls.Load(); calls != 2 {
+		t.Fatalf("expected 2 backend calls, got %d", calls)
+	}
+}
+
+func TestSingleFlightDoesNotShareErrorsAfterTheCall(t *testing.T) {
+	greeter := &gatedGreeter{err: errors.New("backend down")}
+	srv, err := New(Config{ListenAddr: ":0", SingleFlight: true}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	if rec := postHelloBody(srv, `{"name":"alice"}`); rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502 got %d", rec.Code)
+	}
+
+	// The failed call is over, so the next identical request reaches the backend again
+	greeter.err = nil
+	if rec := postHelloBody(srv, `{"name":"alice"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	if calls := greeter.calls.Load(); calls != 2 {
+		t.Fatalf("expected 2 backend calls, got %d", calls)
+	}
+}
+
+func TestSingleFlightWaiterStopsOnOwnCancellation(t *testing.T) {
+	greeter := &gatedGreeter{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(greeter.release)
+	srv, err := New(Config{ListenAddr: ":0", SingleFlight: true}, greeter, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/helloworld/SayHello", strings.NewReader(`{"name":"alice"}`)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.engine.ServeHTTP(rec, req)
+	}()
+
+	<-greeter.started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler kept waiting for the shared call after its client went away")
+	}
+	if rec.Code != statusClientClosedRequest {
+		t.Fatalf("expected %d got %d", statusClientClosedRequest, rec.Code)
+	}
+}
@@ -0,0 +1,89 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader serves a certificate/key pair from disk and reloads it when either
+// file changes, so rotated certificates take effect on the next handshake without
+// a restart. Established connections keep the certificate they negotiated.
+//
+// The files are checked on every handshake; a stat is cheap next to the handshake
+// itself, so no file watcher is needed.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certVer fileVersion
+	keyVer  fileVersion
+}
+
+// fileVersion identifies the contents of a file by modification time and size
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func statVersion(path string) (fileVersion, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileVersion{}, err
+	}
+	return fileVersion{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// newCertReloader loads the initial key pair; an unreadable or mismatched pair is an error
+func newCertReloader(certFile, keyFile string, logger *slog.Logger) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	if err := r.reload(); err != nil {
+		return nil, fmt.Errorf("httpserver: load TLS key pair: %w", err)
+	}
+	return r, nil
+}
+
+// reload reads the key pair from disk; the caller holds r.mu or owns r exclusively.
+// The versions are taken before reading so a write racing with the read is
+// picked up again on the next check.
+func (r *certReloader) reload() error {
+	certVer, err := statVersion(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyVer, err := statVersion(r.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.certVer, r.keyVer = &cert, certVer, keyVer
+	return nil
+}
+
+// getCertificate implements tls.Config.GetCertificate. When the files changed
+// since the last load they are read again; if that fails (e.g. the certificate
+// was replaced but the key not yet), the previous pair keeps being served.
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	certVer, certErr := statVersion(r.certFile)
+	keyVer, keyErr := statVersion(r.keyFile)
+	if certErr == nil && keyErr == nil && (certVer != r.certVer || keyVer != r.keyVer) {
+		if err := r.reload(); err != nil {
+			r.logger.Warn("TLS key pair reload failed, serving the previous certificate",
+				slog.String("cert", r.certFile), slog.String("err", err.Error()))
+		} else {
+			r.logger.Info("TLS key pair reloaded", slog.String("cert", r.certFile))
+		}
+	}
+	return r.cert, nil
+}
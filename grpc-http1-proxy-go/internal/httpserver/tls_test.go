@@ -0,0 +1,133 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKeyPair writes a self-signed certificate for commonName and its key to certFile and keyFile
+func writeKeyPair(t *testing.T, certFile, keyFile, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// servedCommonName performs a TLS handshake with addr and returns the subject of the served certificate
+func servedCommonName(t *testing.T, addr string) string {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("tls.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func TestTLSCertificateReloadedAfterRotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, "first")
+
+	srv, err := New(Config{ListenAddr: ":0", TLSCertFile: certFile, TLSKeyFile: keyFile}, &gatedGreeter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.srv.ServeTLS(ln, "", "")
+	defer srv.Shutdown(context.Background())
+
+	if cn := servedCommonName(t, ln.Addr().String()); cn != "first" {
+		t.Fatalf("expected the first certificate, got %q", cn)
+	}
+
+	writeKeyPair(t, certFile, keyFile, "second")
+	// Make the change visible even on filesystems with coarse timestamps
+	later := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cn := servedCommonName(t, ln.Addr().String()); cn != "second" {
+		t.Fatalf("expected the rotated certificate, got %q", cn)
+	}
+}
+
+func TestTLSKeepsPreviousCertificateWhenReloadFails(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, "first")
+	r, err := newCertReloader(certFile, keyFile, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("newCertReloader() error = %v", err)
+	}
+
+	// Only the certificate was rotated so far; it does not match the old key
+	writeKeyPair(t, certFile, filepath.Join(dir, "other.key"), "second")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(certFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := r.getCertificate(nil)
+	if err != nil {
+		t.Fatalf("getCertificate() error = %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf.Subject.CommonName != "first" {
+		t.Fatalf("expected the previous certificate, got %q", leaf.Subject.CommonName)
+	}
+}
+
+func TestTLSRequiresCertAndKey(t *testing.T) {
+	if _, err := New(Config{ListenAddr: ":0", TLSCertFile: "tls.crt"}, &gatedGreeter{}, nil, nil); err == nil {
+		t.Fatal("expected an error for a certificate without a key")
+	}
+	dir := t.TempDir()
+	_, err := New(Config{ListenAddr: ":0", TLSCertFile: filepath.Join(dir, "missing.crt"), TLSKeyFile: filepath.Join(dir, "missing.key")}, &gatedGreeter{}, nil, nil)
+	if err == nil {
+		t.Fatal("expected an error for missing key pair files")
+	}
+}
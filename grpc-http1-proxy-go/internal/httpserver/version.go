@@ -0,0 +1,22 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildInfo identifies the deployed build. The values are normally injected into
+// the main package with -ldflags and passed through Config.Build.
+type BuildInfo struct {
+	Version   string `json:"version"`   // Release version (default: "dev")
+	Commit    string `json:"commit"`    // VCS commit the binary was built from
+	BuildTime string `json:"buildTime"` // Build timestamp, typically RFC 3339
+}
+
+// versionHandler returns a handler that writes the build information as JSON.
+func versionHandler(info BuildInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, info)
+	}
+}
@@ -0,0 +1,32 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/grpc-http1-proxy-go/internal/pb"
+)
+
+func TestVersionEndpoint(t *testing.T) {
+	build := BuildInfo{Version: "1.4.0", Commit: "abc1234", BuildTime: "2024-05-01T10:00:00Z"}
+	srv, err := New(Config{ListenAddr: ":0", Build: build}, &stubGreeter{resp: &pb.HelloReply{}}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+
+	var got BuildInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("version response is not valid JSON: %v", err)
+	}
+	if got != build {
+		t.Fatalf("expected %+v, got %+v", build, got)
+	}
+}
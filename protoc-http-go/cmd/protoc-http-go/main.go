@@ -1,178 +1,917 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/breaking"
 	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/generator"
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/lint"
 	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
 	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
 )
 
+// Build information, injected at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildTime=..."
+var (
+	version   = "dev"
+	commit    = "none"
+	buildTime = "unknown"
+)
+
+// supportedLangs lists the client languages accepted by --lang
+var supportedLangs = map[string]bool{"vb": true, "go": true, "py": true}
+
+// typeMapFlag collects repeatable --type-map proto=VB entries
+type typeMapFlag map[string]string
+
+func (m typeMapFlag) String() string {
+	entries := make([]string, 0, len(m))
+	for protoType, vbType := range m {
+		entries = append(entries, protoType+"="+vbType)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func (m typeMapFlag) Set(value string) error {
+	protoType, vbType, ok := strings.Cut(value, "=")
+	protoType, vbType = strings.TrimSpace(protoType), strings.TrimSpace(vbType)
+	if !ok || protoType == "" || vbType == "" {
+		return fmt.Errorf("expected <proto type>=<VB type>, got %q", value)
+	}
+	m[protoType] = vbType
+	return nil
+}
+
+// importRootsFlag collects repeatable --proto-path directories in the order given
+type importRootsFlag []string
+
+func (r *importRootsFlag) String() string { return strings.Join(*r, ",") }
+
+func (r *importRootsFlag) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("expected a directory")
+	}
+	*r = append(*r, value)
+	return nil
+}
+
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run parses the command line, parses the proto files once and fans out to every
+// requested generator. Generation errors are collected per artifact and reported
+// at the end instead of aborting the run. "--proto -" reads a single proto from stdin.
+// Returns the process exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("protoc-http-go", flag.ContinueOnError)
+	fs.SetOutput(stderr)
 	var (
-		protoPath = flag.String("proto", "", "Path to a single .proto file or a directory containing .proto files")
-		outDir    = flag.String("out", "", "Directory where generated .vb files are written")
-		pkg       = flag.String("package", "", "Override VB.NET namespace name for generated code (optional)")
-		baseURL   = flag.String("baseurl", "", "Base URL for HTTP requests (optional, defaults to empty)")
-		framework = flag.String("framework", "net45", "Target .NET Framework mode: net45 (HttpClient+async/await) or net40hwr (HttpWebRequest+sync)")
+		protoPath  = fs.String("proto", "", "Path to a single .proto file or a directory containing .proto files, or - to read one proto from stdin")
+		descSet    = fs.String("descriptor-set", "", "Generate from a binary FileDescriptorSet written by protoc --descriptor_set_out instead of parsing --proto")
+		stdinName  = fs.String("stdin-name", "stdin", "Base name of the proto read with --proto -, used for generated file names and routes")
+		outDir     = fs.String("out", "", "Directory where generated files are written")
+		pkg        = fs.String("package", "", "Override VB.NET namespace name for generated code (optional)")
+		baseURL    = fs.String("baseurl", "", "Base URL for HTTP requests (optional, defaults to empty)")
+		framework  = fs.String("framework", "net45", "Target .NET Framework mode: net45 (HttpClient+async/await) or net40hwr (HttpWebRequest+sync)")
+		langs      = fs.String("lang", "vb", "Comma-separated client languages to generate: vb, go, py")
+		jsonSchema = fs.Bool("json-schema", true, "Generate JSON Schema files under <out>/json")
+		openAPI    = fs.Bool("openapi", false, "Generate OpenAPI 3.1 documents under <out>/openapi")
+		docsMD     = fs.Bool("docs-md", false, "Generate Markdown documents of each proto's messages, enums and HTTP routes under <out>/docs")
+		versionPos = fs.String("version-placement", generator.VersionSuffix, "Where the URL version goes in client, OpenAPI and Markdown docs routes: suffix (/<proto>/<method>/v1) or prefix (/v1/<proto>/<method>)")
+		defaultVer = fs.String("default-version", "", "URL version for RPCs without a V<n> suffix in services without a \"// default-version:\" annotation (default: v1)")
+		schemaBase = fs.String("schema-base-uri", generator.DefaultSchemaBaseURI, "Absolute base URI for the $id of generated JSON schemas")
+		typeMap    = typeMapFlag{}
+		protoRoots = importRootsFlag{}
+		showVer    = fs.Bool("version", false, "Print build information and exit")
+		partial    = fs.Bool("partial", false, "Declare generated VB message classes Partial so hand-written partial classes can extend them")
+		sealed     = fs.Bool("sealed", false, "Declare generated VB message classes NotInheritable (cannot be combined with --partial)")
+		equality   = fs.Bool("emit-equality", false, "Override Equals and GetHashCode on generated VB message classes to compare every property")
+		envelope   = fs.Bool("response-envelope", false, "Deserialize VB client responses from a { \"data\": ..., \"meta\": ... } envelope and return the data")
+		timeoutHdr = fs.Bool("timeout-header", false, "Send the VB client's timeout as an X-Timeout-Ms header so the proxy uses the same deadline")
+		indexes    = fs.Bool("index", false, "Also write an index of the generated clients per language: Index.vb, go/index.go and py/__init__.py")
+		reqSetting = fs.Bool("request-settings", false, "Give net40hwr VB clients constructor parameters for a default timeout and chunked request bodies")
+		synthMaps  = fs.Bool("synthesize-maps", false, "Give repeated fields of key/value entry messages a VB Dictionary property with conversion helpers")
+		stripEnum  = fs.Bool("strip-enum-prefix", false, "Drop the enum-name prefix of values from VB enum members (Color_RED instead of Color_COLOR_RED) when every value has it")
+		emitMock   = fs.Bool("emit-mock", false, "Follow each VB service client with <Service>ClientMock, answering the same methods from settable handlers for unit tests")
+		overwrite  = fs.Bool("overwrite", true, "Replace existing client files (.vb, .go, .py); with --overwrite=false they are kept and reported as conflicts")
+		splitSvcs  = fs.Bool("split-services", false, "Write one VB client file per service (<Service>Client.vb) holding only the types that service uses")
+		svcOnly    = fs.Bool("services-only", false, "Skip client files for protos that declare no services (JSON schemas and OpenAPI documents are still generated)")
+		strict     = fs.Bool("strict-unary", false, "Fail instead of skipping streaming RPCs, which cannot be generated")
+		failUnsup  = fs.Bool("fail-on-unsupported", false, "Fail with a list of every unsupported construct (streaming RPCs, map fields, oneofs, groups) instead of warning")
+		lintMode   = fs.Bool("lint", false, "Check the protos against the style rules instead of generating code")
+		checkMode  = fs.Bool("check", false, "Only parse the protos and report every parse error, without generating anything")
+		emitAST    = fs.Bool("emit-ast", false, "Print the parsed protos (messages, fields, enums, services, RPCs) as JSON to stdout instead of generating code")
+		lintRules  = lint.AllRules()
+		maxDepth   = fs.Int("max-depth", parser.DefaultMaxDepth, "Reject protos whose messages are nested deeper than this")
+		summaryTbl = fs.Bool("summary", false, "Print a table of the messages, enums, services and RPCs generated per proto file after generating")
+		diffMode   = fs.Bool("diff", false, "Report breaking changes between two proto files given as arguments: --diff old.proto new.proto")
 	)
-	flag.Parse()
+	fs.BoolVar(&lintRules.Package, "lint-package", true, "Lint rule: every proto declares a package")
+	fs.BoolVar(&lintRules.PascalCase, "lint-pascal-case", true, "Lint rule: service, message and enum names are PascalCase")
+	fs.BoolVar(&lintRules.SnakeCase, "lint-snake-case", true, "Lint rule: field names are snake_case")
+	fs.BoolVar(&lintRules.EnumZero, "lint-enum-zero", true, "Lint rule: every enum has a zero value")
+	fs.Var(&protoRoots, "proto-path", "Directory to resolve proto imports against, like protoc -I (repeatable; searched in order)")
+	fs.Var(typeMap, "type-map", "Map a proto type to a VB type, e.g. int64=Decimal (repeatable; \"// type:\" field annotations win)")
+	fs.Usage = func() { printUsage(stderr) }
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *showVer {
+		fmt.Fprintf(stdout, "protoc-http-go %s (commit %s, built %s)\n", version, commit, buildTime)
+		return 0
+	}
+
+	if *maxDepth <= 0 {
+		fmt.Fprintf(stderr, "Error: --max-depth must be a positive integer, got: %d\n", *maxDepth)
+		return 1
+	}
+	parseOpts := parser.Options{MaxDepth: *maxDepth, ReportGroups: *failUnsup}
+
+	if *diffMode {
+		if fs.NArg() != 2 {
+			fmt.Fprintf(stderr, "Error: --diff needs exactly two proto files, got %d arguments\n", fs.NArg())
+			return 1
+		}
+		return runDiff(fs.Arg(0), fs.Arg(1), parseOpts, stdout, stderr)
+	}
+
+	if *checkMode {
+		if *protoPath == "" {
+			printUsage(stderr)
+			return 1
+		}
+		return runCheck(*protoPath, parseOpts, stdout, stderr)
+	}
+
+	if *emitAST {
+		if *protoPath == "" {
+			printUsage(stderr)
+			return 1
+		}
+		return runEmitAST(*protoPath, *stdinName, stdin, parseOpts, stdout, stderr)
+	}
+
+	if *lintMode {
+		if *protoPath == "" {
+			printUsage(stderr)
+			return 1
+		}
+		return runLint(*protoPath, lintRules, parseOpts, stdout, stderr)
+	}
 
-	if *protoPath == "" || *outDir == "" {
-		fmt.Fprintf(os.Stderr, "Usage: %s --proto <path> --out <dir> [--package <name>] [--baseurl <url>] [--framework <mode>]\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "\nArguments:\n")
-		fmt.Fprintf(os.Stderr, "  --proto     Path to a single .proto file or directory containing .proto files\n")
-		fmt.Fprintf(os.Stderr, "  --out       Directory where generated .vb files are written\n")
-		fmt.Fprintf(os.Stderr, "  --package   Override VB.NET namespace name for generated code (optional)\n")
-		fmt.Fprintf(os.Stderr, "  --baseurl   Base URL for HTTP requests (optional)\n")
-		fmt.Fprintf(os.Stderr, "  --framework Target .NET Framework mode: net45 or net40hwr (default: net45)\n")
-		os.Exit(1)
+	if (*protoPath == "" && *descSet == "") || *outDir == "" {
+		printUsage(stderr)
+		return 1
+	}
+	if *protoPath != "" && *descSet != "" {
+		fmt.Fprintf(stderr, "Error: --proto and --descriptor-set are mutually exclusive\n")
+		return 1
+	}
+	if *descSet != "" && len(protoRoots) > 0 {
+		fmt.Fprintf(stderr, "Error: --proto-path cannot be used with --descriptor-set, whose imports are already resolved\n")
+		return 1
 	}
 
 	// Validate framework mode
 	if *framework != "net45" && *framework != "net40hwr" {
-		fmt.Fprintf(os.Stderr, "Error: --framework must be either 'net45' or 'net40hwr', got: %s\n", *framework)
-		os.Exit(1)
+		fmt.Fprintf(stderr, "Error: --framework must be either 'net45' or 'net40hwr', got: %s\n", *framework)
+		return 1
+	}
+
+	if *versionPos != generator.VersionSuffix && *versionPos != generator.VersionPrefix {
+		fmt.Fprintf(stderr, "Error: --version-placement must be either 'suffix' or 'prefix', got: %s\n", *versionPos)
+		return 1
+	}
+
+	if *partial && *sealed {
+		fmt.Fprintf(stderr, "Error: --partial and --sealed are mutually exclusive\n")
+		return 1
+	}
+
+	// net45 clients get an injected HttpClient, whose timeout the caller already controls
+	if *reqSetting && *framework != "net40hwr" {
+		fmt.Fprintf(stderr, "Error: --request-settings requires --framework net40hwr\n")
+		return 1
+	}
+
+	// Validate the schema base URI; $id must be absolute for cross-file $refs to resolve
+	if u, err := url.Parse(*schemaBase); err != nil || u.Scheme == "" || u.Host == "" {
+		fmt.Fprintf(stderr, "Error: --schema-base-uri must be an absolute URI such as https://schemas.example.org/v1, got: %s\n", *schemaBase)
+		return 1
+	}
+
+	// Validate the default version
+	if *defaultVer != "" {
+		version, ok := types.NormalizeVersion(*defaultVer)
+		if !ok {
+			fmt.Fprintf(stderr, "Error: --default-version must look like v1, v2, ...; got: %s\n", *defaultVer)
+			return 1
+		}
+		*defaultVer = version
+	}
+
+	// Validate requested languages
+	requested := make(map[string]bool)
+	for _, lang := range strings.Split(*langs, ",") {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" {
+			continue
+		}
+		if !supportedLangs[lang] {
+			fmt.Fprintf(stderr, "Error: --lang must be a comma-separated list of vb, go, py; got: %s\n", lang)
+			return 1
+		}
+		requested[lang] = true
 	}
 
 	// Ensure output directory exists
 	if err := os.MkdirAll(*outDir, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(stderr, "Error creating output directory: %v\n", err)
+		return 1
 	}
 
-	// Check if protoPath is a file or directory
-	info, err := os.Stat(*protoPath)
+	// Parse all proto files once; every generator works from the same parse
+	var parsedFiles []*types.ProtoFile
+	var err error
+	if *descSet != "" {
+		parsedFiles, err = parser.ParseDescriptorSetFile(*descSet, parseOpts)
+		if err != nil {
+			err = fmt.Errorf("Error reading descriptor set %s: %v", *descSet, err)
+		}
+	} else {
+		parsedFiles, err = parseProtoInputs(*protoPath, *stdinName, stdin, parseOpts)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error accessing proto path: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	if len(protoRoots) > 0 {
+		// Imported files are generated too, so the types they declare exist in the output
+		imported, err := parser.ResolveImports(parsedFiles, protoRoots, parseOpts)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error resolving imports: %v\n", err)
+			return 1
+		}
+		parsedFiles = append(parsedFiles, imported...)
+	}
+	var allFiles []*types.ProtoFile
+	var unsupported []string
+	for _, parsedFile := range parsedFiles {
+		if *failUnsup {
+			unsupported = append(unsupported, unsupportedFeatures(parsedFile)...)
+		} else {
+			for _, feature := range parsedFile.Unsupported {
+				fmt.Fprintf(stderr, "Warning: %s:%d: %s is not supported; generated code may not match the wire format\n", parsedFile.FileName, feature.Line, feature.Description)
+			}
+			if !checkStreamingRPCs(parsedFile, *strict, stderr) {
+				return 1
+			}
+		}
+		types.ApplyTypeMap(parsedFile, typeMap)
+		types.ApplyDefaultVersion(parsedFile, *defaultVer)
+		allFiles = append(allFiles, parsedFile)
+	}
+	if len(unsupported) > 0 {
+		fmt.Fprintf(stderr, "Error: %d unsupported proto feature(s) (--fail-on-unsupported):\n", len(unsupported))
+		for _, feature := range unsupported {
+			fmt.Fprintf(stderr, "  %s\n", feature)
+		}
+		return 1
+	}
+	// Index the types of all files so references resolve across files; the same
+	// fully-qualified name declared twice cannot be resolved and stops the run
+	if _, err := types.BuildSymbolTable(allFiles); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
 	}
 
-	var protoFiles []string
-	if info.IsDir() {
-		// Find all .proto files in directory recursively
-		err := filepath.Walk(*protoPath, func(path string, info os.FileInfo, err error) error {
+	gen := &generator.Generator{
+		PackageOverride:  *pkg,
+		BaseURL:          *baseURL,
+		FrameworkMode:    *framework,
+		Partial:          *partial,
+		Sealed:           *sealed,
+		Equality:         *equality,
+		ResponseEnvelope: *envelope,
+		TimeoutHeader:    *timeoutHdr,
+		RequestSettings:  *reqSetting,
+		SynthesizeMaps:   *synthMaps,
+		StripEnumPrefix:  *stripEnum,
+		VersionPlacement: *versionPos,
+		EmitMock:         *emitMock,
+		NoOverwrite:      !*overwrite,
+	}
+
+	var failures, conflicts []string
+	fail := func(artifact string, err error) {
+		if errors.Is(err, generator.ErrOutputExists) {
+			conflicts = append(conflicts, artifact)
+			return
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", artifact, err))
+	}
+	var summary []string
+	// Counts of the proto files a VB, Go or Python client was generated from, for --summary
+	stats := make(map[string]fileStats)
+	tally := func(protoFile *types.ProtoFile) {
+		if _, ok := stats[protoFile.FileName]; !ok {
+			stats[protoFile.FileName] = statsOf(protoFile)
+		}
+	}
+
+	if requested["vb"] {
+		count := generateVB(gen, allFiles, *outDir, *svcOnly, *splitSvcs, stdout, fail, tally)
+		summary = append(summary, fmt.Sprintf("%d VB files", count))
+	}
+
+	if requested["go"] {
+		count := 0
+		for _, protoFile := range allFiles {
+			outputPath := filepath.Join(*outDir, "go", generator.GoPackageName(protoFile), protoFile.BaseName+".go")
+			if *svcOnly && len(protoFile.Services) == 0 {
+				printSkippedWithoutServices(stdout, outputPath)
+				continue
+			}
+			if err := gen.GenerateGoFile(protoFile, outputPath); err != nil {
+				fail(outputPath, err)
+				continue
+			}
+			fmt.Fprintf(stdout, "Generated: %s\n", outputPath)
+			tally(protoFile)
+			count++
+		}
+		summary = append(summary, fmt.Sprintf("%d Go files", count))
+	}
+
+	if requested["py"] {
+		count := 0
+		for _, protoFile := range allFiles {
+			outputPath := filepath.Join(*outDir, "py", generator.PythonModuleName(protoFile)+".py")
+			if *svcOnly && len(protoFile.Services) == 0 {
+				printSkippedWithoutServices(stdout, outputPath)
+				continue
+			}
+			if err := gen.GeneratePythonFile(protoFile, outputPath); err != nil {
+				fail(outputPath, err)
+				continue
+			}
+			fmt.Fprintf(stdout, "Generated: %s\n", outputPath)
+			tally(protoFile)
+			count++
+		}
+		summary = append(summary, fmt.Sprintf("%d Python files", count))
+	}
+
+	// Indexes are built from the client files now on disk, so they come after them
+	if *indexes {
+		fmt.Fprintln(stdout, "\nGenerating client indexes...")
+		for _, index := range []struct {
+			lang     string
+			generate func(string) (string, error)
+			dir      string
+		}{
+			{"vb", generator.GenerateVBIndex, *outDir},
+			{"go", generator.GenerateGoIndex, filepath.Join(*outDir, "go")},
+			{"py", generator.GeneratePythonIndex, filepath.Join(*outDir, "py")},
+		} {
+			if !requested[index.lang] {
+				continue
+			}
+			indexPath, err := index.generate(index.dir)
 			if err != nil {
-				return err
+				fail(index.lang+" client index", err)
+				continue
+			}
+			fmt.Fprintf(stdout, "Generated: %s\n", indexPath)
+		}
+	}
+
+	if *jsonSchema {
+		fmt.Fprintln(stdout, "\nGenerating JSON schemas...")
+		count := 0
+		for _, protoFile := range allFiles {
+			schemaPath, err := generator.GenerateJSONSchema(protoFile, *outDir, *schemaBase)
+			if err != nil {
+				fail("JSON schema for "+protoFile.FileName, err)
+				continue
+			}
+			fmt.Fprintf(stdout, "Generated JSON Schema: %s\n", schemaPath)
+			count++
+		}
+		summary = append(summary, fmt.Sprintf("%d JSON schema files", count))
+	}
+
+	if *openAPI {
+		fmt.Fprintln(stdout, "\nGenerating OpenAPI documents...")
+		count := 0
+		for _, protoFile := range allFiles {
+			docPath, err := gen.GenerateOpenAPI(protoFile, *outDir, *baseURL)
+			if err != nil {
+				fail("OpenAPI document for "+protoFile.FileName, err)
+				continue
+			}
+			fmt.Fprintf(stdout, "Generated OpenAPI: %s\n", docPath)
+			count++
+		}
+		summary = append(summary, fmt.Sprintf("%d OpenAPI files", count))
+	}
+
+	if *docsMD {
+		fmt.Fprintln(stdout, "\nGenerating Markdown docs...")
+		count := 0
+		for _, protoFile := range allFiles {
+			docPath, err := gen.GenerateMarkdownDocs(protoFile, *outDir)
+			if err != nil {
+				fail("Markdown docs for "+protoFile.FileName, err)
+				continue
+			}
+			fmt.Fprintf(stdout, "Generated Markdown docs: %s\n", docPath)
+			count++
+		}
+		summary = append(summary, fmt.Sprintf("%d Markdown files", count))
+	}
+
+	if len(conflicts) > 0 {
+		fmt.Fprintf(stderr, "\n%d existing file(s) not overwritten (--overwrite=false):\n", len(conflicts))
+		for _, conflict := range conflicts {
+			fmt.Fprintf(stderr, "  %s\n", conflict)
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Fprintf(stderr, "\n%d artifact(s) failed to generate:\n", len(failures))
+		for _, failure := range failures {
+			fmt.Fprintf(stderr, "  %s\n", failure)
+		}
+	}
+	if len(conflicts) > 0 || len(failures) > 0 {
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "\nSuccessfully generated %s from %d proto files\n", strings.Join(summary, ", "), len(allFiles))
+	if *summaryTbl {
+		printSummary(stdout, allFiles, stats)
+	}
+	return 0
+}
+
+// fileStats counts what is generated from one proto file
+type fileStats struct {
+	messages, enums, services, rpcs, skipped int
+}
+
+func (s *fileStats) add(other fileStats) {
+	s.messages += other.messages
+	s.enums += other.enums
+	s.services += other.services
+	s.rpcs += other.rpcs
+	s.skipped += other.skipped
+}
+
+// statsOf tallies the messages and enums of protoFile, nested ones included, its
+// services and its RPCs; streaming RPCs count as skipped since only unary RPCs
+// are generated. run calls it once a client for protoFile has been written.
+func statsOf(protoFile *types.ProtoFile) fileStats {
+	var stats fileStats
+	stats.enums = len(protoFile.Enums)
+	var countMessages func(messages map[string]*types.ProtoMessage)
+	countMessages = func(messages map[string]*types.ProtoMessage) {
+		for _, message := range messages {
+			stats.messages++
+			stats.enums += len(message.NestedEnums)
+			countMessages(message.NestedMessages)
+		}
+	}
+	countMessages(protoFile.Messages)
+	stats.services = len(protoFile.Services)
+	for _, service := range protoFile.Services {
+		for _, rpc := range service.RPCs {
+			if rpc.IsUnary {
+				stats.rpcs++
+			} else {
+				stats.skipped++
+			}
+		}
+	}
+	return stats
+}
+
+// printSummary writes a table of per-file counts followed by the totals, as a
+// quick check that the expected surface was generated. stats holds the counts of
+// the files a client was generated from; the others, such as those skipped by
+// --services-only, are listed with zero counts.
+func printSummary(w io.Writer, protoFiles []*types.ProtoFile, stats map[string]fileStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "File\tMessages\tEnums\tServices\tRPCs\tSkipped (streaming)")
+	var total fileStats
+	for _, protoFile := range protoFiles {
+		counts := stats[protoFile.FileName]
+		total.add(counts)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\n", protoFile.FileName, counts.messages, counts.enums, counts.services, counts.rpcs, counts.skipped)
+	}
+	fmt.Fprintf(tw, "Total\t%d\t%d\t%d\t%d\t%d\n", total.messages, total.enums, total.services, total.rpcs, total.skipped)
+	tw.Flush()
+}
+
+// checkStreamingRPCs reports the streaming RPCs the generators will skip: with strict
+// set they are listed in one error and false is returned, otherwise a warning is
+// written per RPC
+func checkStreamingRPCs(protoFile *types.ProtoFile, strict bool, stderr io.Writer) bool {
+	if strict {
+		if streaming := types.StreamingRPCs(protoFile); len(streaming) > 0 {
+			fmt.Fprintf(stderr, "Error: %s: streaming RPCs are not supported (--strict-unary): %s\n", protoFile.FileName, strings.Join(streaming, ", "))
+			return false
+		}
+		return true
+	}
+	for _, service := range protoFile.Services {
+		for _, rpc := range service.RPCs {
+			if !rpc.IsUnary {
+				fmt.Fprintf(stderr, "Warning: %s:%d: skipping %s RPC %s.%s; only unary RPCs are generated\n", protoFile.FileName, rpc.Line, rpc.StreamingKind(), service.Name, rpc.Name)
 			}
-			if filepath.Ext(path) == ".proto" {
-				protoFiles = append(protoFiles, path)
+		}
+	}
+	return true
+}
+
+// unsupportedFeatures lists everything in protoFile the generators cannot represent,
+// as "file:line: description": the parser's findings followed by streaming RPCs
+func unsupportedFeatures(protoFile *types.ProtoFile) []string {
+	var features []string
+	for _, feature := range protoFile.Unsupported {
+		features = append(features, fmt.Sprintf("%s:%d: %s", protoFile.FileName, feature.Line, feature.Description))
+	}
+	for _, service := range protoFile.Services {
+		for _, rpc := range service.RPCs {
+			if !rpc.IsUnary {
+				features = append(features, fmt.Sprintf("%s:%d: %s RPC %s.%s", protoFile.FileName, rpc.Line, rpc.StreamingKind(), service.Name, rpc.Name))
 			}
-			return nil
-		})
+		}
+	}
+	return features
+}
+
+// runLint parses every proto under protoPath and reports style violations as
+// "file:line: message (rule)". Returns 1 if any file fails to parse or violates a rule.
+func runLint(protoPath string, rules lint.Rules, parseOpts parser.Options, stdout, stderr io.Writer) int {
+	protoFiles, err := findProtoFiles(protoPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+
+	violations := 0
+	for _, protoFile := range protoFiles {
+		parsedFile, err := parser.ParseProtoFileWithOptions(protoFile, parseOpts)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error scanning directory: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(stderr, "Error parsing %s: %v\n", protoFile, err)
+			return 1
 		}
-		if len(protoFiles) == 0 {
-			fmt.Fprintf(os.Stderr, "No .proto files found in directory: %s\n", *protoPath)
-			os.Exit(1)
+		for _, v := range lint.Check(parsedFile, rules) {
+			fmt.Fprintln(stdout, v)
+			violations++
 		}
-	} else {
-		if filepath.Ext(*protoPath) != ".proto" {
-			fmt.Fprintf(os.Stderr, "File must have .proto extension: %s\n", *protoPath)
-			os.Exit(1)
+	}
+
+	if violations > 0 {
+		fmt.Fprintf(stderr, "\n%d lint violations in %d proto files\n", violations, len(protoFiles))
+		return 1
+	}
+	fmt.Fprintf(stdout, "No lint violations in %d proto files\n", len(protoFiles))
+	return 0
+}
+
+// runCheck parses every proto file under protoPath without generating anything,
+// for pre-commit hooks. Unlike generation it does not stop at the first file that
+// fails: every parse error is reported with its file path. Returns 1 if any
+// file fails to parse.
+func runCheck(protoPath string, parseOpts parser.Options, stdout, stderr io.Writer) int {
+	protoFiles, err := findProtoFiles(protoPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+
+	failed := 0
+	for _, protoFile := range protoFiles {
+		if _, err := parser.ParseProtoFileWithOptions(protoFile, parseOpts); err != nil {
+			fmt.Fprintf(stderr, "Error parsing %s: %v\n", protoFile, err)
+			failed++
 		}
-		protoFiles = append(protoFiles, *protoPath)
 	}
 
-	// Parse all proto files
-	var allFiles []*types.ProtoFile
+	if failed > 0 {
+		fmt.Fprintf(stderr, "\n%d of %d proto files failed to parse\n", failed, len(protoFiles))
+		return 1
+	}
+	fmt.Fprintf(stdout, "All %d proto files parsed\n", len(protoFiles))
+	return 0
+}
+
+// runEmitAST parses the protos under protoPath ("-" reads one from stdin) and
+// writes them to stdout as a JSON array of generator.ASTFile. Returns 1 if a proto
+// fails to parse.
+func runEmitAST(protoPath, stdinName string, stdin io.Reader, parseOpts parser.Options, stdout, stderr io.Writer) int {
+	protoFiles, err := parseProtoInputs(protoPath, stdinName, stdin, parseOpts)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	data, err := generator.GenerateASTJSON(protoFiles)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	stdout.Write(data)
+	return 0
+}
+
+// runDiff parses oldPath and newPath and reports the breaking changes between
+// them, grouped by category. Returns 1 if either file fails to parse or any
+// breaking change is found.
+func runDiff(oldPath, newPath string, parseOpts parser.Options, stdout, stderr io.Writer) int {
+	oldFile, err := parser.ParseProtoFileWithOptions(oldPath, parseOpts)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error parsing %s: %v\n", oldPath, err)
+		return 1
+	}
+	newFile, err := parser.ParseProtoFileWithOptions(newPath, parseOpts)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error parsing %s: %v\n", newPath, err)
+		return 1
+	}
+
+	changes := breaking.Compare(oldFile, newFile)
+	if len(changes) == 0 {
+		fmt.Fprintf(stdout, "No breaking changes from %s to %s\n", oldPath, newPath)
+		return 0
+	}
+	category := ""
+	for _, change := range changes {
+		if change.Category != category {
+			category = change.Category
+			fmt.Fprintf(stdout, "%s:\n", category)
+		}
+		fmt.Fprintf(stdout, "  %s\n", change)
+	}
+	fmt.Fprintf(stderr, "\n%d breaking changes from %s to %s\n", len(changes), oldPath, newPath)
+	return 1
+}
+
+// printUsage writes the command-line help
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: protoc-http-go (--proto <path> | --descriptor-set <file>) --out <dir> [--package <name>] [--baseurl <url>] [--framework <mode>] [--lang <langs>] [--json-schema] [--schema-base-uri <uri>] [--openapi] [--docs-md] [--type-map <proto>=<vb>] [--proto-path <dir> ...] [--default-version <v>] [--version-placement <pos>] [--partial | --sealed] [--emit-equality] [--response-envelope] [--timeout-header] [--request-settings] [--synthesize-maps] [--strip-enum-prefix] [--emit-mock] [--index] [--overwrite=false] [--services-only] [--split-services] [--summary] [--stdin-name <name>] [--max-depth <n>] [--strict-unary] [--fail-on-unsupported] [--version]\n")
+	fmt.Fprintf(w, "       protoc-http-go --check --proto <path>\n")
+	fmt.Fprintf(w, "       protoc-http-go --lint --proto <path> [--lint-<rule>=false ...]\n")
+	fmt.Fprintf(w, "       protoc-http-go --emit-ast --proto <path>\n")
+	fmt.Fprintf(w, "       protoc-http-go --diff <old.proto> <new.proto>\n")
+	fmt.Fprintf(w, "\nArguments:\n")
+	fmt.Fprintf(w, "  --proto       Path to a single .proto file or directory containing .proto files; - reads one proto from stdin\n")
+	fmt.Fprintf(w, "  --descriptor-set Binary FileDescriptorSet from protoc --descriptor_set_out to generate from instead of --proto\n")
+	fmt.Fprintf(w, "  --stdin-name  Base name of the proto read with --proto - (default: stdin)\n")
+	fmt.Fprintf(w, "  --out         Directory where generated files are written\n")
+	fmt.Fprintf(w, "  --package     Override VB.NET namespace name for generated code (optional)\n")
+	fmt.Fprintf(w, "  --baseurl     Base URL for HTTP requests (optional)\n")
+	fmt.Fprintf(w, "  --framework   Target .NET Framework mode: net45 or net40hwr (default: net45)\n")
+	fmt.Fprintf(w, "  --lang        Comma-separated client languages: vb, go, py (default: vb)\n")
+	fmt.Fprintf(w, "  --json-schema Generate JSON Schema files (default: true; disable with --json-schema=false)\n")
+	fmt.Fprintf(w, "  --schema-base-uri Base URI for the $id of JSON schemas (default: %s)\n", generator.DefaultSchemaBaseURI)
+	fmt.Fprintf(w, "  --openapi     Generate OpenAPI 3.1 documents (default: false)\n")
+	fmt.Fprintf(w, "  --docs-md     Generate Markdown docs listing message fields, enum values and RPC routes (default: false)\n")
+	fmt.Fprintf(w, "  --proto-path  Directory to resolve imports such as \"common/common.proto\" against (repeatable, searched in order)\n")
+	fmt.Fprintf(w, "  --type-map    Map a proto type to a VB type, e.g. int64=Decimal (repeatable)\n")
+	fmt.Fprintf(w, "  --default-version URL version for RPCs without a V<n> suffix (default: v1; \"// default-version:\" on a service wins)\n")
+	fmt.Fprintf(w, "  --version-placement Put the URL version after the method (suffix) or before the proto name (prefix) in client, OpenAPI and docs routes (default: suffix)\n")
+	fmt.Fprintf(w, "  --partial     Declare VB message classes Partial Public Class (default: false)\n")
+	fmt.Fprintf(w, "  --sealed      Declare VB message classes Public NotInheritable Class; excludes --partial (default: false)\n")
+	fmt.Fprintf(w, "  --emit-equality Generate Equals/GetHashCode comparing every property of VB message classes (default: false)\n")
+	fmt.Fprintf(w, "  --response-envelope Unwrap VB client responses from Envelope(Of T).Data (default: false)\n")
+	fmt.Fprintf(w, "  --timeout-header Send timeoutMs, or the HttpClient/HttpWebRequest timeout, as X-Timeout-Ms (default: false)\n")
+	fmt.Fprintf(w, "  --request-settings Add defaultTimeoutMs and sendChunked constructor parameters to net40hwr clients (default: false)\n")
+	fmt.Fprintf(w, "  --synthesize-maps Add a <Field>Dictionary property to repeated fields of key/value entry messages (default: false)\n")
+	fmt.Fprintf(w, "  --strip-enum-prefix Name VB enum members Color_RED instead of Color_COLOR_RED when every value starts with COLOR_ (default: false)\n")
+	fmt.Fprintf(w, "  --emit-mock   Generate <Service>ClientMock with the client's methods answered by settable <Rpc>Handler delegates (default: false)\n")
+	fmt.Fprintf(w, "  --index       Write Index.vb, go/index.go and py/__init__.py referencing every generated client (default: false)\n")
+	fmt.Fprintf(w, "  --overwrite   Replace existing .vb, .go and .py client files; =false keeps them and fails listing the conflicts (default: true)\n")
+	fmt.Fprintf(w, "  --services-only Skip VB, Go and Python client files for protos without services (default: false)\n")
+	fmt.Fprintf(w, "  --split-services Write one VB file per service, <Service>Client.vb, with only the types it uses (default: false)\n")
+	fmt.Fprintf(w, "  --summary     Print per-file counts of messages, enums, services, RPCs and skipped streaming RPCs (default: false)\n")
+	fmt.Fprintf(w, "  --max-depth   Reject messages nested deeper than this (default: %d)\n", parser.DefaultMaxDepth)
+	fmt.Fprintf(w, "  --strict-unary Fail instead of skipping streaming RPCs (default: warn and skip)\n")
+	fmt.Fprintf(w, "  --fail-on-unsupported Fail with a list of streaming RPCs, map fields and oneofs (default: warn)\n")
+	fmt.Fprintf(w, "  --version     Print build information and exit\n")
+	fmt.Fprintf(w, "  --check       Only parse the protos, reporting every parse error; exits 1 if any file fails\n")
+	fmt.Fprintf(w, "  --lint        Check style rules instead of generating code; exits 1 on violations\n")
+	fmt.Fprintf(w, "                Rules (all on by default): --lint-package, --lint-pascal-case, --lint-snake-case, --lint-enum-zero\n")
+	fmt.Fprintf(w, "  --emit-ast    Print the parsed protos as a JSON array to stdout instead of generating code\n")
+	fmt.Fprintf(w, "  --diff        Report breaking changes from the first proto file to the second; exits 1 if any\n")
+}
+
+// parseProtoInputs parses the proto files under protoPath, or the proto read from
+// stdin when protoPath is "-". The stdin proto is named <stdinName>.proto.
+func parseProtoInputs(protoPath, stdinName string, stdin io.Reader, parseOpts parser.Options) ([]*types.ProtoFile, error) {
+	if protoPath == "-" {
+		stdinName = strings.TrimSuffix(strings.TrimSpace(stdinName), ".proto")
+		if stdinName == "" || strings.ContainsAny(stdinName, `/\`) {
+			return nil, fmt.Errorf("Error: --stdin-name must be a plain file name, got: %q", stdinName)
+		}
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading proto from stdin: %v", err)
+		}
+		fileName := stdinName + ".proto"
+		parsedFile, err := parser.ParseProtoContent(fileName, string(content), parseOpts)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing %s (stdin): %v", fileName, err)
+		}
+		return []*types.ProtoFile{parsedFile}, nil
+	}
+
+	protoFiles, err := findProtoFiles(protoPath)
+	if err != nil {
+		return nil, err
+	}
+	var parsedFiles []*types.ProtoFile
 	for _, protoFile := range protoFiles {
-		parsedFile, err := parser.ParseProtoFile(protoFile)
+		parsedFile, err := parser.ParseProtoFileWithOptions(protoFile, parseOpts)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", protoFile, err)
-			os.Exit(1)
+			return nil, fmt.Errorf("Error parsing %s: %v", protoFile, err)
 		}
-		allFiles = append(allFiles, parsedFile)
+		parsedFiles = append(parsedFiles, parsedFile)
+	}
+	return parsedFiles, nil
+}
+
+// findProtoFiles returns protoPath itself when it is a .proto file, or all .proto
+// files below it when it is a directory
+func findProtoFiles(protoPath string) ([]string, error) {
+	info, err := os.Stat(protoPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error accessing proto path: %v", err)
 	}
 
-	// Group proto files by directory
+	if !info.IsDir() {
+		if filepath.Ext(protoPath) != ".proto" {
+			return nil, fmt.Errorf("File must have .proto extension: %s", protoPath)
+		}
+		return []string{protoPath}, nil
+	}
+
+	// Find all .proto files in directory recursively
+	var protoFiles []string
+	err = filepath.Walk(protoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(path) == ".proto" {
+			protoFiles = append(protoFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error scanning directory: %v", err)
+	}
+	if len(protoFiles) == 0 {
+		return nil, fmt.Errorf("No .proto files found in directory: %s", protoPath)
+	}
+	return protoFiles, nil
+}
+
+// printSkippedWithoutServices reports a client file left out by --services-only
+func printSkippedWithoutServices(stdout io.Writer, outputPath string) {
+	fmt.Fprintf(stdout, "Skipped: %s (no services, --services-only)\n", outputPath)
+}
+
+// vbOutput is one .vb file to generate and the (possibly split) proto file it comes from
+type vbOutput struct {
+	path  string
+	file  *types.ProtoFile
+	split bool // Produced by --split-services; always generated, even without services
+}
+
+// vbOutputs lists the .vb files for protoFile: one named after the proto, or with
+// splitServices one "<Service>Client.vb" per service plus a "<proto>.vb" for the
+// types the services share or do not use
+func vbOutputs(protoFile *types.ProtoFile, outDir string, splitServices bool) ([]vbOutput, error) {
+	commonPath := filepath.Join(outDir, protoFile.BaseName+".vb")
+	if !splitServices || len(protoFile.Services) == 0 {
+		return []vbOutput{{path: commonPath, file: protoFile}}, nil
+	}
+	services, common, err := types.SplitByService(protoFile)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []vbOutput
+	for _, file := range services {
+		outputs = append(outputs, vbOutput{path: filepath.Join(outDir, file.Services[0].Name+"Client.vb"), file: file, split: true})
+	}
+	if common != nil {
+		outputs = append(outputs, vbOutput{path: commonPath, file: common, split: true})
+	}
+	return outputs, nil
+}
+
+// generateVB writes the shared HTTP utilities and one .vb file per proto file (or
+// per service with splitServices), returning the number of files written and
+// passing each proto file a .vb file was written for to written. With
+// servicesOnly, protos without services get no .vb file.
+func generateVB(gen *generator.Generator, allFiles []*types.ProtoFile, outDir string, servicesOnly, splitServices bool, stdout io.Writer, fail func(string, error), written func(*types.ProtoFile)) int {
+	// Group proto files by directory, with the .vb files each of them becomes
 	filesByDir := make(map[string][]*types.ProtoFile)
+	outputsByDir := make(map[string][]vbOutput)
 	for _, protoFile := range allFiles {
 		dir := filepath.Dir(protoFile.FileName)
+		outputs, err := vbOutputs(protoFile, outDir, splitServices)
+		if err != nil {
+			fail(protoFile.FileName, err)
+			continue
+		}
 		filesByDir[dir] = append(filesByDir[dir], protoFile)
-	}
-
-	// Generate VB.NET code
-	gen := &generator.Generator{
-		PackageOverride: *pkg,
-		BaseURL:         *baseURL,
-		FrameworkMode:   *framework,
+		outputsByDir[dir] = append(outputsByDir[dir], outputs...)
 	}
 
 	generatedCount := 0
 
-	// For each directory with multiple proto files with services, generate shared utility
+	// For each directory with multiple client files, generate shared utility
 	for dir, files := range filesByDir {
-		// Count files with services
-		filesWithServices := 0
-		anyBytes := false
-		for _, f := range files {
-			if len(f.Services) > 0 {
-				filesWithServices++
+		// Count client files; a split proto always shares one utility between its
+		// files, so the helpers and ApiException are declared once per namespace
+		clientFiles, anySplit := 0, false
+		for _, output := range outputsByDir[dir] {
+			if len(output.file.Services) > 0 {
+				clientFiles++
 			}
+			anySplit = anySplit || output.split
+		}
+		anyBytes, anyInt64 := false, false
+		for _, f := range files {
 			if types.ProtoHasBytesField(f) {
 				anyBytes = true
 			}
+			if types.ProtoHasInt64Field(f) {
+				anyInt64 = true
+			}
 		}
 
-		if filesWithServices > 1 {
+		if clientFiles > 1 || anySplit {
 			// Multiple files with services - generate shared utility
 			utilityName := deriveUtilityName(dir)
 			namespace := determineCommonNamespace(files, gen.PackageOverride)
 
-			utilityPath := filepath.Join(*outDir, utilityName+".vb")
-			if err := gen.GenerateSharedUtility(utilityName, namespace, utilityPath, anyBytes); err != nil {
-				fmt.Fprintf(os.Stderr, "Error generating shared utility %s: %v\n", utilityPath, err)
-				os.Exit(1)
+			utilityPath := filepath.Join(outDir, utilityName+".vb")
+			if err := gen.GenerateSharedUtility(utilityName, namespace, utilityPath, anyBytes, anyInt64); err != nil {
+				// Clients in this directory would reference the missing utility
+				fail(utilityPath, err)
+				delete(outputsByDir, dir)
+				continue
 			}
-			fmt.Printf("Generated: %s\n", utilityPath)
+			fmt.Fprintf(stdout, "Generated: %s\n", utilityPath)
 			generatedCount++
 
 			// Mark files to use shared utility
-			for _, f := range files {
-				if len(f.Services) > 0 {
-					f.UseSharedUtility = true
-					f.SharedUtilityName = utilityName
-					f.SharedUtilityNamespace = namespace
+			for _, output := range outputsByDir[dir] {
+				if len(output.file.Services) > 0 || output.split {
+					output.file.UseSharedUtility = true
+					output.file.SharedUtilityName = utilityName
+					output.file.SharedUtilityNamespace = namespace
 				}
 			}
 		}
 	}
 
-	// Generate individual proto files
-	for _, protoFile := range allFiles {
-		outputPath := filepath.Join(*outDir, protoFile.BaseName+".vb")
-		if err := gen.GenerateFile(protoFile, outputPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error generating %s: %v\n", outputPath, err)
-			os.Exit(1)
-		}
-		fmt.Printf("Generated: %s\n", outputPath)
-		generatedCount++
-	}
-
-	// Generate JSON schemas for all proto files
-	fmt.Println("\nGenerating JSON schemas...")
-	generatedSchemas := 0
-
+	// Generate individual files, in the order of the proto files
 	for _, protoFile := range allFiles {
-		schemaPath, err := generator.GenerateJSONSchema(protoFile, *outDir)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to generate JSON schema for %s: %v\n",
-				protoFile.FileName, err)
-			continue
+		for _, output := range outputsByDir[filepath.Dir(protoFile.FileName)] {
+			if output.file.FileName != protoFile.FileName {
+				continue
+			}
+			if servicesOnly && len(output.file.Services) == 0 && !output.split {
+				printSkippedWithoutServices(stdout, output.path)
+				continue
+			}
+			if err := gen.GenerateFile(output.file, output.path); err != nil {
+				fail(output.path, err)
+				continue
+			}
+			fmt.Fprintf(stdout, "Generated: %s\n", output.path)
+			written(protoFile)
+			generatedCount++
 		}
-		fmt.Printf("Generated JSON Schema: %s\n", schemaPath)
-		generatedSchemas++
 	}
 
-	fmt.Printf("\nSuccessfully generated %d VB files and %d JSON schema files from %d proto files\n",
-		generatedCount, generatedSchemas, len(protoFiles))
+	return generatedCount
 }
 
 // deriveUtilityName derives the shared utility class name from directory path
@@ -0,0 +1,1272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/generator"
+)
+
+const helloProto = "../../proto/simple/helloworld.proto"
+
+func TestRunGeneratesAllRequestedArtifacts(t *testing.T) {
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{
+		"--proto", helloProto,
+		"--out", outDir,
+		"--lang", "vb,go,py",
+		"--json-schema",
+		"--openapi",
+		"--baseurl", "http://localhost:8080",
+	}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+
+	for _, rel := range []string{
+		"helloworld.vb",
+		filepath.Join("go", "helloworld", "helloworld.go"),
+		filepath.Join("py", "helloworld.py"),
+		filepath.Join("json", "helloworld.json"),
+		filepath.Join("openapi", "helloworld.json"),
+	} {
+		if _, err := os.Stat(filepath.Join(outDir, rel)); err != nil {
+			t.Errorf("expected %s to be generated: %v", rel, err)
+		}
+	}
+	if !strings.Contains(stdout.String(), "1 VB files, 1 Go files, 1 Python files, 1 JSON schema files, 1 OpenAPI files") {
+		t.Errorf("unexpected summary:\n%s", stdout.String())
+	}
+
+	// The Go client must type-check on its own
+	goPath := filepath.Join(outDir, "go", "helloworld", "helloworld.go")
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, goPath, nil, 0)
+	if err != nil {
+		t.Fatalf("generated Go client does not parse: %v", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("helloworld", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated Go client does not type-check: %v", err)
+	}
+
+	// The OpenAPI document describes the proxy route for SayHello
+	data, err := os.ReadFile(filepath.Join(outDir, "openapi", "helloworld.json"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var doc struct {
+		Paths   map[string]map[string]interface{} `json:"paths"`
+		Servers []struct{ URL string }            `json:"servers"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("OpenAPI document is not valid JSON: %v", err)
+	}
+	if _, ok := doc.Paths["/helloworld/say-hello/v1"]["post"]; !ok {
+		t.Errorf("expected POST /helloworld/say-hello/v1 in OpenAPI paths, got %v", doc.Paths)
+	}
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != "http://localhost:8080" {
+		t.Errorf("expected --baseurl as the OpenAPI server, got %+v", doc.Servers)
+	}
+}
+
+func TestRunDefaultsToVBAndSchema(t *testing.T) {
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"--proto", helloProto, "--out", outDir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "json", "helloworld.json")); err != nil {
+		t.Errorf("expected JSON schema by default: %v", err)
+	}
+	for _, dir := range []string{"go", "openapi"} {
+		if _, err := os.Stat(filepath.Join(outDir, dir)); !os.IsNotExist(err) {
+			t.Errorf("expected no %s output by default", dir)
+		}
+	}
+}
+
+func TestRunRejectsUnknownLanguage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", helloProto, "--out", t.TempDir(), "--lang", "vb,rust"}, nil, &stdout, &stderr)
+	if code == 0 {
+		t.Fatalf("expected non-zero exit for unsupported language")
+	}
+	if !strings.Contains(stderr.String(), "rust") {
+		t.Errorf("expected error to name the unsupported language, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunReportsFailuresPerArtifact(t *testing.T) {
+	outDir := t.TempDir()
+	// A directory where the Go client file should go makes only that artifact fail
+	blocker := filepath.Join(outDir, "go", "helloworld", "helloworld.go")
+	if err := os.MkdirAll(blocker, 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", helloProto, "--out", outDir, "--lang", "vb,go", "--openapi"}, nil, &stdout, &stderr)
+	if code == 0 {
+		t.Fatalf("expected non-zero exit when an artifact fails")
+	}
+	if !strings.Contains(stderr.String(), "1 artifact(s) failed") || !strings.Contains(stderr.String(), blocker) {
+		t.Errorf("expected the failing artifact to be reported, got:\n%s", stderr.String())
+	}
+	// The other artifacts are still produced
+	for _, rel := range []string{"helloworld.vb", filepath.Join("openapi", "helloworld.json")} {
+		if _, err := os.Stat(filepath.Join(outDir, rel)); err != nil {
+			t.Errorf("expected %s despite the Go failure: %v", rel, err)
+		}
+	}
+}
+
+func TestRunTypeMapDefersToFieldAnnotations(t *testing.T) {
+	protoDir := t.TempDir()
+	protoPath := filepath.Join(protoDir, "ledger.proto")
+	content := `syntax = "proto3";
+package ledger;
+
+message Entry {
+  int64 amount = 1;
+  // type: Long
+  int64 sequence = 2;
+}
+`
+	if err := os.WriteFile(protoPath, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", protoPath, "--out", outDir, "--type-map", "int64=Decimal"}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "ledger.vb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	vb := string(data)
+	if !strings.Contains(vb, "Public Property Amount As Decimal") {
+		t.Errorf("expected --type-map to change Amount to Decimal:\n%s", vb)
+	}
+	if !strings.Contains(vb, "Public Property Sequence As Long") {
+		t.Errorf("expected the field annotation to win for Sequence:\n%s", vb)
+	}
+}
+
+func TestRunRejectsMalformedTypeMap(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", helloProto, "--out", t.TempDir(), "--type-map", "int64"}, nil, &stdout, &stderr)
+	if code == 0 {
+		t.Fatalf("expected non-zero exit for a malformed --type-map")
+	}
+	if !strings.Contains(stderr.String(), "int64") {
+		t.Errorf("expected error to mention the bad entry, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunDefaultVersionDefersToServiceAnnotation(t *testing.T) {
+	protoPath := filepath.Join(t.TempDir(), "quotes.proto")
+	content := `syntax = "proto3";
+package quotes;
+
+message QuoteRequest {
+  string symbol = 1;
+}
+
+message QuoteReply {
+  double price = 1;
+}
+
+// default-version: v2
+service Pinned {
+  rpc GetQuote(QuoteRequest) returns (QuoteReply);
+}
+
+service Plain {
+  rpc GetQuote(QuoteRequest) returns (QuoteReply);
+  rpc GetQuoteV5(QuoteRequest) returns (QuoteReply);
+}
+`
+	if err := os.WriteFile(protoPath, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", protoPath, "--out", outDir, "--default-version", "v3"}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "quotes.vb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	vb := string(data)
+	for _, want := range []string{`"/quotes/get-quote/v2"`, `"/quotes/get-quote/v3"`, `"/quotes/get-quote/v5"`} {
+		if !strings.Contains(vb, want) {
+			t.Errorf("expected generated client to contain %s:\n%s", want, vb)
+		}
+	}
+	if strings.Contains(vb, `"/quotes/get-quote/v1"`) {
+		t.Errorf("expected no v1 route once every service has a default:\n%s", vb)
+	}
+}
+
+func TestRunRejectsInvalidDefaultVersion(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", helloProto, "--out", t.TempDir(), "--default-version", "2"}, nil, &stdout, &stderr)
+	if code == 0 {
+		t.Fatalf("expected non-zero exit for an invalid --default-version")
+	}
+	if !strings.Contains(stderr.String(), "--default-version") {
+		t.Errorf("expected error to name the flag, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunPrintsVersion(t *testing.T) {
+	defer func(v, c, b string) { version, commit, buildTime = v, c, b }(version, commit, buildTime)
+	version, commit, buildTime = "1.4.0", "abc1234", "2024-05-01T10:00:00Z"
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--version"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	want := "protoc-http-go 1.4.0 (commit abc1234, built 2024-05-01T10:00:00Z)\n"
+	if stdout.String() != want {
+		t.Errorf("unexpected version output %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunLintReportsViolations(t *testing.T) {
+	protoPath := filepath.Join(t.TempDir(), "bad.proto")
+	content := `syntax = "proto3";
+
+message order {
+  string orderId = 1;
+}
+`
+	if err := os.WriteFile(protoPath, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--lint", "--proto", protoPath}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1 for lint violations; stderr:\n%s", code, stderr.String())
+	}
+	for _, want := range []string{
+		protoPath + ":1: file does not declare a package (package)",
+		protoPath + ":3: message name order should be PascalCase (pascal-case)",
+		protoPath + ":4: field name orderId should be snake_case (snake-case)",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in lint output:\n%s", want, stdout.String())
+		}
+	}
+
+	// Each rule can be switched off individually
+	stdout.Reset()
+	stderr.Reset()
+	args := []string{"--lint", "--proto", protoPath, "--lint-package=false", "--lint-pascal-case=false", "--lint-snake-case=false"}
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d with all failing rules disabled; output:\n%s", code, stdout.String())
+	}
+}
+
+func TestRunCheckReportsEveryParseError(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.proto":     "syntax = \"proto3\";\n\nmessage Order {\n  string id = 1;\n}\n",
+		"bad.proto":      "syntax = \"proto3\";\n\nmessage Order {\n  string id = 1;\n",
+		"nested/x.proto": "syntax = \"proto2\";\n\nmessage Broken {\n  optional group Result = 1 {\n    optional string id = 2;\n  }\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--check", "--proto", dir}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1 for protos that do not parse; stderr:\n%s", code, stderr.String())
+	}
+	for _, want := range []string{
+		"Error parsing " + filepath.Join(dir, "bad.proto") + ":",
+		"Error parsing " + filepath.Join(dir, "nested", "x.proto") + ":",
+		"2 of 3 proto files failed to parse",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("expected %q in check output:\n%s", want, stderr.String())
+		}
+	}
+	if strings.Contains(stderr.String(), "good.proto") {
+		t.Errorf("valid proto reported as failing:\n%s", stderr.String())
+	}
+
+	// Nothing is generated, even with --out
+	outDir := filepath.Join(t.TempDir(), "out")
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--check", "--proto", filepath.Join(dir, "good.proto"), "--out", outDir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d for a valid proto, stderr:\n%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "All 1 proto files parsed") {
+		t.Errorf("unexpected check summary:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Errorf("--check created the output directory: %v", err)
+	}
+}
+
+func TestRunLintPassesCleanProtos(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--lint", "--proto", helloProto}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, output:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "No lint violations in 1 proto files") {
+		t.Errorf("unexpected lint summary:\n%s", stdout.String())
+	}
+}
+
+func TestRunWarnsAboutSkippedStreamingRPCs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", t.TempDir()}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	for _, want := range []string{
+		"skipping server streaming RPC Greeter.SayHelloStreamReply",
+		"skipping bidirectional streaming RPC Greeter.SayHelloBidiStream",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("expected warning %q, got:\n%s", want, stderr.String())
+		}
+	}
+}
+
+func TestRunStrictUnaryRejectsStreamingRPCs(t *testing.T) {
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", outDir, "--strict-unary"}, nil, &stdout, &stderr); code == 0 {
+		t.Fatalf("expected non-zero exit with --strict-unary")
+	}
+	if !strings.Contains(stderr.String(), "Greeter.SayHelloStreamReply (server streaming), Greeter.SayHelloBidiStream (bidirectional streaming)") {
+		t.Errorf("expected the error to list the streaming RPCs, got:\n%s", stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "helloworld.vb")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be generated with --strict-unary")
+	}
+}
+
+func TestRunAppliesSchemaBaseURI(t *testing.T) {
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", outDir, "--schema-base-uri", "https://schemas.acme.test/v2"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr.String())
+	}
+	content, err := os.ReadFile(filepath.Join(outDir, "json", "helloworld.json"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(content), `"$id": "https://schemas.acme.test/v2/helloworld.json"`) {
+		t.Fatalf("expected custom $id, got:\n%s", content)
+	}
+}
+
+func TestRunRejectsRelativeSchemaBaseURI(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", helloProto, "--out", t.TempDir(), "--schema-base-uri", "schemas/v1"}, nil, &stdout, &stderr)
+	if code == 0 {
+		t.Fatalf("expected a relative base URI to be rejected")
+	}
+	if !strings.Contains(stderr.String(), "--schema-base-uri must be an absolute URI") {
+		t.Fatalf("unexpected stderr: %s", stderr.String())
+	}
+}
+
+func writeDiffProtos(t *testing.T, oldContent, newContent string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.proto")
+	newPath := filepath.Join(dir, "new.proto")
+	if err := os.WriteFile(oldPath, []byte(oldContent), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(newPath, []byte(newContent), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return oldPath, newPath
+}
+
+const diffBaseProto = `syntax = "proto3";
+package orders;
+
+message Order {
+  string id = 1;
+  string note = 2;
+}
+
+service Orders {
+  rpc Get(Order) returns (Order);
+  rpc Cancel(Order) returns (Order);
+}
+`
+
+func TestRunDiffReportsBreakingChanges(t *testing.T) {
+	newContent := strings.NewReplacer(
+		"  string note = 2;\n", "",
+		"  rpc Cancel(Order) returns (Order);\n", "",
+	).Replace(diffBaseProto)
+	oldPath, newPath := writeDiffProtos(t, diffBaseProto, newContent)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--diff", oldPath, newPath}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1; stderr:\n%s", code, stderr.String())
+	}
+	want := "removed-field:\n  Order.note: field 2 removed\nremoved-rpc:\n  Orders.Cancel: rpc removed\n"
+	if stdout.String() != want {
+		t.Errorf("unexpected report:\n%s\nwant:\n%s", stdout.String(), want)
+	}
+	if !strings.Contains(stderr.String(), "2 breaking changes") {
+		t.Errorf("expected a summary on stderr, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunDiffAcceptsAdditions(t *testing.T) {
+	newContent := strings.Replace(diffBaseProto, "  string note = 2;\n", "  string note = 2;\n  bool rush = 3;\n", 1)
+	oldPath, newPath := writeDiffProtos(t, diffBaseProto, newContent)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--diff", oldPath, newPath}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stdout:\n%s\nstderr:\n%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "No breaking changes") {
+		t.Errorf("unexpected output:\n%s", stdout.String())
+	}
+}
+
+func TestRunDiffNeedsTwoFiles(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--diff", helloProto}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "exactly two proto files") {
+		t.Errorf("unexpected error:\n%s", stderr.String())
+	}
+}
+
+func TestRunMaxDepthRejectsDeepNesting(t *testing.T) {
+	protoPath := filepath.Join(t.TempDir(), "deep.proto")
+	content := `syntax = "proto3";
+package deep;
+
+message A {
+  message B {
+    message C {
+      string id = 1;
+    }
+  }
+}
+`
+	if err := os.WriteFile(protoPath, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", protoPath, "--out", t.TempDir(), "--max-depth", "2"}, nil, &stdout, &stderr); code == 0 {
+		t.Fatalf("expected non-zero exit when nesting exceeds --max-depth")
+	}
+	if !strings.Contains(stderr.String(), "message C is nested 3 levels deep, more than the maximum of 2") {
+		t.Errorf("unexpected error:\n%s", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--proto", protoPath, "--out", t.TempDir(), "--max-depth", "3"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+}
+
+func TestRunRejectsNonPositiveMaxDepth(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", t.TempDir(), "--max-depth", "0"}, nil, &stdout, &stderr); code == 0 {
+		t.Fatalf("expected non-zero exit for --max-depth 0")
+	}
+	if !strings.Contains(stderr.String(), "--max-depth must be a positive integer") {
+		t.Errorf("unexpected error:\n%s", stderr.String())
+	}
+}
+
+func TestRunPartialDeclaresPartialClasses(t *testing.T) {
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", outDir, "--partial"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "helloworld.vb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), "Partial Public Class HelloRequest\n") {
+		t.Errorf("expected partial message classes:\n%s", data)
+	}
+}
+
+func TestRunEmitEqualityOverridesEquals(t *testing.T) {
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", outDir, "--emit-equality"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "helloworld.vb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{"Dim other = TryCast(obj, HelloRequest)\n", "Public Overrides Function GetHashCode() As Integer\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+}
+
+func TestRunRejectsPartialWithSealed(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", t.TempDir(), "--partial", "--sealed"}, nil, &stdout, &stderr); code == 0 {
+		t.Fatalf("expected non-zero exit for --partial with --sealed")
+	}
+	if !strings.Contains(stderr.String(), "mutually exclusive") {
+		t.Errorf("unexpected error:\n%s", stderr.String())
+	}
+}
+
+func TestRunRequestSettingsNeedsNet40HWR(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", t.TempDir(), "--request-settings"}, nil, &stdout, &stderr); code == 0 {
+		t.Fatalf("expected non-zero exit for --request-settings with net45")
+	}
+	if !strings.Contains(stderr.String(), "--request-settings requires --framework net40hwr") {
+		t.Errorf("unexpected error:\n%s", stderr.String())
+	}
+
+	outDir := t.TempDir()
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--proto", helloProto, "--out", outDir, "--framework", "net40hwr", "--request-settings"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "helloworld.vb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), "Optional defaultTimeoutMs As Integer? = Nothing, Optional sendChunked As Boolean = False)") {
+		t.Errorf("expected the request settings constructor parameters in:\n%s", data)
+	}
+}
+
+func TestRunOverwriteFalseKeepsExistingFiles(t *testing.T) {
+	outDir := t.TempDir()
+	existing := filepath.Join(outDir, "helloworld.vb")
+	if err := os.WriteFile(existing, []byte("' hand-edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--proto", helloProto, "--out", outDir, "--lang", "vb,py", "--json-schema=false"}
+	if code := run(append(args, "--overwrite=false"), nil, &stdout, &stderr); code == 0 {
+		t.Fatalf("expected non-zero exit when an output file exists")
+	}
+	if !strings.Contains(stderr.String(), "1 existing file(s) not overwritten (--overwrite=false):\n  "+existing+"\n") {
+		t.Errorf("expected the conflict to be listed, got:\n%s", stderr.String())
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "' hand-edited\n" {
+		t.Errorf("existing file was modified:\n%s", data)
+	}
+	// Files that did not exist are still written
+	if _, err := os.Stat(filepath.Join(outDir, "py", "helloworld.py")); err != nil {
+		t.Errorf("expected the Python client to be generated: %v", err)
+	}
+
+	// The default replaces the file as before
+	stderr.Reset()
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(existing); !strings.Contains(string(data), "GreeterClient") {
+		t.Errorf("expected the default run to regenerate the file, got:\n%s", data)
+	}
+}
+
+func TestRunGeneratesCommaSeparatedEnumValues(t *testing.T) {
+	protoPath := filepath.Join(t.TempDir(), "colors.proto")
+	content := `syntax = "proto3";
+package colors;
+
+enum Color {
+  RED = 0, GREEN = 1, BLUE = 2;
+}
+`
+	if err := os.WriteFile(protoPath, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", protoPath, "--out", outDir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+
+	vb, err := os.ReadFile(filepath.Join(outDir, "colors.vb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{"Color_RED = 0", "Color_GREEN = 1", "Color_BLUE = 2"} {
+		if !strings.Contains(string(vb), want) {
+			t.Errorf("expected VB enum to contain %s:\n%s", want, vb)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "json", "colors.json"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var schema struct {
+		Defs map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("invalid schema JSON: %v", err)
+	}
+	if got := strings.Join(schema.Defs["Color"].Enum, ","); got != "BLUE,GREEN,RED" {
+		t.Errorf("schema enum = %s, want BLUE,GREEN,RED", got)
+	}
+}
+
+func TestRunReadsProtoFromStdin(t *testing.T) {
+	content, err := os.ReadFile(helloProto)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", "-", "--out", outDir, "--stdin-name", "greeter"}, bytes.NewReader(content), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+
+	vb, err := os.ReadFile(filepath.Join(outDir, "greeter.vb"))
+	if err != nil {
+		t.Fatalf("expected greeter.vb to be generated: %v", err)
+	}
+	if !strings.Contains(string(vb), `"/greeter/say-hello/v1"`) {
+		t.Errorf("expected routes to use the --stdin-name base name:\n%s", vb)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "json", "greeter.json")); err != nil {
+		t.Errorf("expected greeter.json schema: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "from 1 proto files") {
+		t.Errorf("unexpected summary:\n%s", stdout.String())
+	}
+}
+
+func TestRunStdinDefaultsToStdinName(t *testing.T) {
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	proto := "syntax = \"proto3\";\npackage demo;\nmessage Ping { string id = 1; }\n"
+	if code := run([]string{"--proto", "-", "--out", outDir}, strings.NewReader(proto), &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "stdin.vb")); err != nil {
+		t.Errorf("expected stdin.vb to be generated: %v", err)
+	}
+}
+
+func TestRunStdinReportsParseErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	proto := "syntax = \"proto2\";\nmessage Outer {\n  repeated group Result = 1 {\n    optional string url = 2;\n  }\n}\n"
+	if code := run([]string{"--proto", "-", "--out", t.TempDir()}, strings.NewReader(proto), &stdout, &stderr); code == 0 {
+		t.Fatal("expected a non-zero exit code for an unparseable stdin proto")
+	}
+	if !strings.Contains(stderr.String(), "stdin.proto (stdin)") {
+		t.Errorf("expected the error to name the stdin proto, got:\n%s", stderr.String())
+	}
+
+	if code := run([]string{"--proto", "-", "--out", t.TempDir(), "--stdin-name", "a/b"}, strings.NewReader(""), &stdout, &stderr); code == 0 {
+		t.Fatal("expected --stdin-name with a path separator to be rejected")
+	}
+}
+
+func TestRunRejectsDuplicateTypesAcrossFiles(t *testing.T) {
+	protoDir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.proto": "syntax = \"proto3\";\npackage common;\nmessage Ticker { string symbol = 1; }\n",
+		"b.proto": "syntax = \"proto3\";\npackage common;\nmessage Ticker { string venue = 1; }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(protoDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", protoDir, "--out", t.TempDir()}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "type common.Ticker is defined in both") {
+		t.Errorf("unexpected stderr:\n%s", stderr.String())
+	}
+}
+
+func TestRunServicesOnlySkipsMessageOnlyProtos(t *testing.T) {
+	protoDir := t.TempDir()
+	for name, content := range map[string]string{
+		"types.proto": "syntax = \"proto3\";\npackage common;\nmessage Ticker { string symbol = 1; }\n",
+		"quotes.proto": "syntax = \"proto3\";\npackage quotes;\nmessage Req { string symbol = 1; }\nmessage Resp { double price = 1; }\n" +
+			"service Quotes { rpc Get(Req) returns (Resp); }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(protoDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", protoDir, "--out", outDir, "--lang", "vb,go", "--services-only"}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+
+	for _, rel := range []string{"types.vb", filepath.Join("go", "common", "types.go")} {
+		if _, err := os.Stat(filepath.Join(outDir, rel)); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to be generated, stat error = %v", rel, err)
+		}
+		if !strings.Contains(stdout.String(), "Skipped: "+filepath.Join(outDir, rel)+" (no services, --services-only)") {
+			t.Errorf("expected %s to be reported as skipped:\n%s", rel, stdout.String())
+		}
+	}
+	for _, rel := range []string{"quotes.vb", filepath.Join("json", "types.json")} {
+		if _, err := os.Stat(filepath.Join(outDir, rel)); err != nil {
+			t.Errorf("expected %s to be generated: %v", rel, err)
+		}
+	}
+}
+
+func TestRunIndexReferencesEveryClient(t *testing.T) {
+	protoDir := t.TempDir()
+	for name, content := range map[string]string{
+		"orders.proto": "syntax = \"proto3\";\npackage shop.orders;\nmessage Req { string id = 1; }\nmessage Resp { string status = 1; }\n" +
+			"service Orders { rpc Get(Req) returns (Resp); }\nservice Admin { rpc Reset(Req) returns (Resp); }\n",
+		"billing.proto": "syntax = \"proto3\";\npackage billing;\nmessage Req { string id = 1; }\nmessage Resp { double total = 1; }\n" +
+			"service Billing { rpc Charge(Req) returns (Resp); }\nservice Admin { rpc Reset(Req) returns (Resp); }\n",
+		"types.proto": "syntax = \"proto3\";\npackage common;\nmessage Ticker { string symbol = 1; }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(protoDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outDir, "go.mod"), []byte("module example.com/gen\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", protoDir, "--out", outDir, "--lang", "vb,go,py", "--index", "--json-schema=false"}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "Index.vb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	vbIndex := string(data)
+	for _, want := range []string{"' Namespace Billing\n'   BillingClient (billing.vb)\n'   AdminClient (billing.vb)\n", "' Namespace Shop.Orders\n'   OrdersClient (orders.vb)\n'   AdminClient (orders.vb)\n"} {
+		if !strings.Contains(vbIndex, want) {
+			t.Errorf("Index.vb lacks %q:\n%s", want, vbIndex)
+		}
+	}
+
+	data, err = os.ReadFile(filepath.Join(outDir, "py", "__init__.py"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	pyIndex := string(data)
+	for _, want := range []string{"from .billing import AdminClient as billing_AdminClient\n", "from .billing import BillingClient\n", "from .orders import OrdersClient\n", "from .orders import AdminClient as orders_AdminClient\n", "    \"orders_AdminClient\",\n"} {
+		if !strings.Contains(pyIndex, want) {
+			t.Errorf("__init__.py lacks %q:\n%s", want, pyIndex)
+		}
+	}
+	if strings.Contains(pyIndex, "types") {
+		t.Errorf("__init__.py references a module without clients:\n%s", pyIndex)
+	}
+
+	// The Go index must type-check against the generated packages it imports
+	fset := token.NewFileSet()
+	checked := map[string]*types.Package{}
+	for _, pkg := range []string{"billing", "orders"} {
+		file, err := parser.ParseFile(fset, filepath.Join(outDir, "go", pkg, pkg+".go"), nil, 0)
+		if err != nil {
+			t.Fatalf("generated Go client does not parse: %v", err)
+		}
+		conf := types.Config{Importer: importer.Default()}
+		checked["example.com/gen/go/"+pkg], err = conf.Check(pkg, fset, []*ast.File{file}, nil)
+		if err != nil {
+			t.Fatalf("generated Go client %s does not type-check: %v", pkg, err)
+		}
+	}
+	index, err := parser.ParseFile(fset, filepath.Join(outDir, "go", "index.go"), nil, 0)
+	if err != nil {
+		t.Fatalf("Go index does not parse: %v", err)
+	}
+	conf := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if pkg, ok := checked[path]; ok {
+			return pkg, nil
+		}
+		return importer.Default().Import(path)
+	})}
+	pkg, err := conf.Check("clients", fset, []*ast.File{index}, nil)
+	if err != nil {
+		t.Fatalf("Go index does not type-check: %v", err)
+	}
+	for _, name := range []string{"BillingClient", "NewBillingClient", "OrdersClient", "NewOrdersClientWithClient", "BillingAdminClient", "OrdersAdminClient"} {
+		if pkg.Scope().Lookup(name) == nil {
+			t.Errorf("Go index does not declare %s", name)
+		}
+	}
+}
+
+// importerFunc adapts a function to types.Importer
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+func TestRunIndexWithoutGoModule(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", helloProto, "--out", t.TempDir(), "--lang", "go", "--index"}, nil, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("run() = %d, want 1 without a go.mod", code)
+	}
+	if !strings.Contains(stderr.String(), "no go.mod found") {
+		t.Errorf("unexpected error:\n%s", stderr.String())
+	}
+}
+
+func TestRunFailOnUnsupportedReportsEachFeature(t *testing.T) {
+	tests := []struct {
+		name, proto, want string
+	}{
+		{"streaming", "message Req { string q = 1; }\nmessage Resp { string a = 1; }\nservice Feed { rpc Watch(Req) returns (stream Resp); }\n", "feature.proto:5: server streaming RPC Feed.Watch"},
+		{"map", "message Req { map<string, int32> counts = 1; }\n", "feature.proto:3: map field Req.counts"},
+		{"oneof", "message Req {\n  oneof choice { string a = 1; int32 b = 2; }\n}\n", "feature.proto:4: oneof Req.choice"},
+		{"group", "message Req {\n  repeated group Result = 1 { string url = 2; }\n}\n", "feature.proto:4: group Req.Result"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protoPath := filepath.Join(t.TempDir(), "feature.proto")
+			content := "syntax = \"proto3\";\npackage demo;\n" + tt.proto
+			if err := os.WriteFile(protoPath, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"--proto", protoPath, "--out", t.TempDir(), "--fail-on-unsupported"}, nil, &stdout, &stderr); code != 1 {
+				t.Fatalf("run() = %d, want 1; stderr:\n%s", code, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("stderr lacks %q:\n%s", tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunFailOnUnsupportedListsGroupsWithOtherFeatures(t *testing.T) {
+	protoPath := filepath.Join(t.TempDir(), "legacy.proto")
+	content := "syntax = \"proto2\";\npackage legacy;\nmessage Search {\n  repeated group Result = 1 {\n    optional string url = 2;\n  }\n  map<string, int32> counts = 3;\n}\n"
+	if err := os.WriteFile(protoPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", protoPath, "--out", t.TempDir(), "--fail-on-unsupported"}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1; stderr:\n%s", code, stderr.String())
+	}
+	for _, want := range []string{"2 unsupported proto feature(s)", "legacy.proto:4: group Search.Result", "legacy.proto:7: map field Search.counts"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr lacks %q:\n%s", want, stderr.String())
+		}
+	}
+	if strings.Contains(stderr.String(), "groups are not supported") {
+		t.Errorf("group stopped the parse instead of being listed:\n%s", stderr.String())
+	}
+}
+
+func TestRunFailOnUnsupportedListsAllFilesTogether(t *testing.T) {
+	protoDir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.proto": "syntax = \"proto3\";\npackage a;\nmessage A { map<string, string> tags = 1; }\n",
+		"b.proto": "syntax = \"proto3\";\npackage b;\nmessage B {\n  oneof kind { string x = 1; }\n}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(protoDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", protoDir, "--out", outDir, "--fail-on-unsupported"}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1", code)
+	}
+	for _, want := range []string{"2 unsupported proto feature(s)", "map field A.tags", "oneof B.kind"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr lacks %q:\n%s", want, stderr.String())
+		}
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) != 0 {
+		t.Errorf("expected nothing to be generated, found %d entries", len(entries))
+	}
+
+	// Without the flag the same protos only produce warnings
+	stderr.Reset()
+	if code := run([]string{"--proto", protoDir, "--out", t.TempDir()}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: ") || !strings.Contains(stderr.String(), "map field A.tags is not supported") {
+		t.Errorf("expected a warning:\n%s", stderr.String())
+	}
+}
+
+func TestRunFailOnUnsupportedAcceptsSupportedProtos(t *testing.T) {
+	protoPath := filepath.Join(t.TempDir(), "plain.proto")
+	content := "syntax = \"proto3\";\npackage demo;\nmessage Req { repeated string tags = 1; }\nmessage Resp { string a = 1; }\n" +
+		"service Plain { rpc Get(Req) returns (Resp); }\n"
+	if err := os.WriteFile(protoPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", protoPath, "--out", t.TempDir(), "--fail-on-unsupported"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+}
+
+func TestRunGeneratesFromDescriptorSet(t *testing.T) {
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := run([]string{
+		"--descriptor-set", "../../internal/parser/testdata/descriptor_set/orders.pb",
+		"--out", outDir,
+		"--lang", "vb,go",
+	}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "2 VB files, 2 Go files, 2 JSON schema files") {
+		t.Errorf("unexpected summary:\n%s", stdout.String())
+	}
+
+	vb, err := os.ReadFile(filepath.Join(outDir, "orders.vb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{
+		`<JsonProperty("id")>`,                          // json_name option
+		"Public Property UnitPrice As Decimal",          // "// type:" annotation from the source info
+		`GetJsonAsync(Of Order)("/orders/get-order/v2"`, // http-method and default-version annotations
+	} {
+		if !strings.Contains(string(vb), want) {
+			t.Errorf("orders.vb lacks %q", want)
+		}
+	}
+	// The map field is reported instead of being misread like the regex parser does
+	if strings.Contains(string(vb), "Labels") {
+		t.Error("expected the map field to be left out of orders.vb")
+	}
+	if !strings.Contains(stderr.String(), "orders.proto:25: map field Order.labels is not supported") {
+		t.Errorf("expected a warning for the map field:\n%s", stderr.String())
+	}
+}
+
+func TestRunRejectsProtoWithDescriptorSet(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--proto", helloProto, "--descriptor-set", "set.pb", "--out", t.TempDir()}, nil, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stderr.String(), "mutually exclusive") {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+}
+
+func TestRunSummaryCountsGeneratedSurface(t *testing.T) {
+	dir := t.TempDir()
+	orders := `syntax = "proto3";
+package orders;
+enum Channel { CHANNEL_UNSPECIFIED = 0; WEB = 1; }
+message Order {
+  enum Status { STATUS_UNSPECIFIED = 0; PAID = 1; }
+  message Line { string sku = 1; }
+  repeated Line lines = 1;
+}
+message GetOrderRequest { string id = 1; }
+service OrderService {
+  rpc GetOrder (GetOrderRequest) returns (Order);
+  rpc WatchOrders (GetOrderRequest) returns (stream Order);
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "orders.proto"), []byte(orders), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "common.proto"), []byte("syntax = \"proto3\";\npackage common;\nmessage Money { int64 units = 1; }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", dir, "--out", t.TempDir(), "--summary"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	rows := map[string][]string{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) == 6 {
+			rows[filepath.Base(fields[0])] = fields[1:]
+		}
+	}
+	for name, want := range map[string]string{
+		"orders.proto": "3 2 1 1 1",
+		"common.proto": "1 0 0 0 0",
+		"Total":        "4 2 1 1 1",
+	} {
+		if got := strings.Join(rows[name], " "); got != want {
+			t.Errorf("summary row %s = %q, want %q\n%s", name, got, want, stdout.String())
+		}
+	}
+}
+
+func TestRunSummaryCountsOnlyGeneratedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"greeter.proto": "syntax = \"proto3\";\npackage greeter;\nmessage HelloRequest { string name = 1; }\nmessage HelloReply { string message = 1; }\nservice Greeter { rpc SayHello (HelloRequest) returns (HelloReply); }\n",
+		"common.proto":  "syntax = \"proto3\";\npackage common;\nmessage Money { int64 units = 1; }\nenum Currency { CURRENCY_UNSPECIFIED = 0; }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", dir, "--out", t.TempDir(), "--services-only", "--summary"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	rows := map[string][]string{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) == 6 {
+			rows[filepath.Base(fields[0])] = fields[1:]
+		}
+	}
+	for name, want := range map[string]string{
+		"greeter.proto": "2 0 1 1 0",
+		"common.proto":  "0 0 0 0 0",
+		"Total":         "2 0 1 1 0",
+	} {
+		if got := strings.Join(rows[name], " "); got != want {
+			t.Errorf("summary row %s = %q, want %q\n%s", name, got, want, stdout.String())
+		}
+	}
+}
+
+func TestRunWithoutSummaryPrintsNoTable(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", helloProto, "--out", t.TempDir()}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "Skipped (streaming)") {
+		t.Fatalf("unexpected summary table:\n%s", stdout.String())
+	}
+}
+
+func TestRunSplitServicesWritesOneFilePerService(t *testing.T) {
+	protoDir := t.TempDir()
+	content := `syntax = "proto3";
+package shop;
+message GetOrderRequest { string id = 1; }
+message Order { string id = 1; int64 total = 2; }
+message GetStockRequest { string sku = 1; }
+message Stock { int32 available = 1; }
+service OrderService { rpc GetOrder (GetOrderRequest) returns (Order); }
+service StockService { rpc GetStock (GetStockRequest) returns (Stock); }
+`
+	if err := os.WriteFile(filepath.Join(protoDir, "shop.proto"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--proto", protoDir, "--out", outDir, "--split-services", "--json-schema=false"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		return string(data)
+	}
+	orders, stock := read("OrderServiceClient.vb"), read("StockServiceClient.vb")
+	for _, want := range []string{"Public Class GetOrderRequest\n", "Public Class Order\n", "Public Class OrderServiceClient\n"} {
+		if !strings.Contains(orders, want) {
+			t.Errorf("expected %q in OrderServiceClient.vb:\n%s", want, orders)
+		}
+	}
+	for _, want := range []string{"Public Class GetStockRequest\n", "Public Class Stock\n", "Public Class StockServiceClient\n"} {
+		if !strings.Contains(stock, want) {
+			t.Errorf("expected %q in StockServiceClient.vb:\n%s", want, stock)
+		}
+	}
+	if strings.Contains(orders, "Class Stock") || strings.Contains(stock, "Class Order") || strings.Contains(stock, "Class GetOrderRequest") {
+		t.Errorf("service files contain types of the other service")
+	}
+
+	// Both files share one utility, so ApiException and the int64 converter are declared once
+	if _, err := os.Stat(filepath.Join(outDir, "shop.vb")); !os.IsNotExist(err) {
+		t.Errorf("expected no shop.vb without shared types, stat error = %v", err)
+	}
+	utility := read(filepath.Base(protoDir) + "HttpUtility.vb")
+	if !strings.Contains(utility, "Class Int64StringConverter") || strings.Contains(orders, "Class ApiException") || strings.Contains(orders, "Class Int64StringConverter") {
+		t.Errorf("expected the helpers in the shared utility only")
+	}
+}
+
+func TestRunResolvesImportsFromSecondaryProtoPath(t *testing.T) {
+	protoDir, vendorDir := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(protoDir, "orders.proto"): "syntax = \"proto3\";\npackage orders;\nimport \"common/money.proto\";\n" +
+			"message GetOrderRequest { string id = 1; }\nmessage Order { common.Money total = 1; }\n" +
+			"service OrderService { rpc GetOrder(GetOrderRequest) returns (Order); }\n",
+		filepath.Join(vendorDir, "common", "money.proto"): "syntax = \"proto3\";\npackage common;\nmessage Money { int64 units = 1; }\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	args := []string{"--proto", filepath.Join(protoDir, "orders.proto"), "--out", outDir, "--proto-path", protoDir, "--proto-path", vendorDir}
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	for _, rel := range []string{"orders.vb", "money.vb", filepath.Join("json", "money.json")} {
+		if _, err := os.Stat(filepath.Join(outDir, rel)); err != nil {
+			t.Errorf("expected %s to be generated: %v", rel, err)
+		}
+	}
+
+	// Without the vendor root the import cannot be found, and every searched path is listed
+	stderr.Reset()
+	args = []string{"--proto", filepath.Join(protoDir, "orders.proto"), "--out", t.TempDir(), "--proto-path", protoDir}
+	if code := run(args, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1", code)
+	}
+	if want := `import "common/money.proto" not found; searched ` + filepath.Join(protoDir, "common", "money.proto"); !strings.Contains(stderr.String(), want) {
+		t.Errorf("expected %q in stderr:\n%s", want, stderr.String())
+	}
+	if !strings.HasPrefix(stderr.String(), "Error resolving imports: ") {
+		t.Errorf("expected the error to be prefixed once:\n%s", stderr.String())
+	}
+}
+
+func TestRunEmitASTWritesParseResultAsJSON(t *testing.T) {
+	proto := `syntax = "proto3";
+package shop;
+import "google/protobuf/timestamp.proto";
+
+message Order {
+  string order_id = 1;
+  repeated Item items = 2;
+  message Item {
+    string sku = 1;
+    int32 quantity = 2 [json_name = "qty"];
+  }
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    SHIPPED = 2;
+    PAID = 1;
+  }
+}
+
+service Orders {
+  // http-method: GET
+  rpc GetOrder(Order) returns (Order);
+  rpc WatchOrders(Order) returns (stream Order);
+}
+`
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--emit-ast", "--proto", "-", "--stdin-name", "shop"}, strings.NewReader(proto), &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr:\n%s", code, stderr.String())
+	}
+	var files []generator.ASTFile
+	if err := json.Unmarshal(stdout.Bytes(), &files); err != nil {
+		t.Fatalf("stdout is not a JSON array of files: %v\n%s", err, stdout.String())
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	file := files[0]
+	if file.FileName != "shop.proto" || file.Package != "shop" || len(file.Imports) != 1 || file.Imports[0] != "google/protobuf/timestamp.proto" {
+		t.Errorf("file = %s (package %q, imports %q), want shop.proto in package shop importing timestamp.proto", file.FileName, file.Package, file.Imports)
+	}
+
+	if len(file.Messages) != 1 || file.Messages[0].FullName != "shop.Order" {
+		t.Fatalf("messages = %+v, want shop.Order only", file.Messages)
+	}
+	order := file.Messages[0]
+	items := order.Fields[1]
+	if items.Name != "items" || items.Number != 2 || items.Type != "Item" || !items.Repeated || items.JSONName != "items" {
+		t.Errorf("items field = %+v, want repeated Item numbered 2", items)
+	}
+	if len(order.NestedMessages) != 1 || order.NestedMessages[0].FullName != "shop.Order.Item" {
+		t.Fatalf("nested messages = %+v, want shop.Order.Item", order.NestedMessages)
+	}
+	if quantity := order.NestedMessages[0].Fields[1]; quantity.Type != "int32" || quantity.Repeated || quantity.JSONName != "qty" {
+		t.Errorf("quantity field = %+v, want a singular int32 with JSON name qty", quantity)
+	}
+	if len(order.NestedEnums) != 1 {
+		t.Fatalf("nested enums = %+v, want Order.Status", order.NestedEnums)
+	}
+	values := order.NestedEnums[0].Values
+	if len(values) != 3 || values[1] != (generator.ASTEnumValue{Name: "PAID", Number: 1}) || values[2].Name != "SHIPPED" {
+		t.Errorf("Status values = %+v, want them ordered by number", values)
+	}
+
+	if len(file.Services) != 1 || len(file.Services[0].RPCs) != 2 {
+		t.Fatalf("services = %+v, want Orders with 2 RPCs", file.Services)
+	}
+	getOrder, watch := file.Services[0].RPCs[0], file.Services[0].RPCs[1]
+	if getOrder.Name != "GetOrder" || getOrder.HTTPMethod != "GET" || getOrder.InputType != "Order" {
+		t.Errorf("GetOrder = %+v, want a GET taking Order", getOrder)
+	}
+	if !watch.ServerStreaming || watch.ClientStreaming {
+		t.Errorf("WatchOrders = %+v, want server streaming", watch)
+	}
+
+	// The same input always gives the same bytes
+	var again bytes.Buffer
+	run([]string{"--emit-ast", "--proto", "-", "--stdin-name", "shop"}, strings.NewReader(proto), &again, &stderr)
+	if again.String() != stdout.String() {
+		t.Errorf("output differs between runs:\n%s\n---\n%s", stdout.String(), again.String())
+	}
+}
@@ -2,7 +2,10 @@ module github.com/yinghanhung/grpc-polyglot/protoc-http-go
 
 go 1.24.6
 
-require github.com/stretchr/testify v1.11.1
+require (
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/protobuf v1.36.11
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -0,0 +1,147 @@
+// Package breaking compares two versions of a parsed proto file and reports the
+// changes that break clients generated from the older version.
+package breaking
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// Change categories, in the order they are reported
+const (
+	CategoryRemovedMessage     = "removed-message"
+	CategoryRemovedField       = "removed-field"
+	CategoryChangedFieldNumber = "changed-field-number"
+	CategoryChangedFieldType   = "changed-field-type"
+	CategoryRemovedRPC         = "removed-rpc"
+	CategoryChangedRPC         = "changed-rpc-signature"
+)
+
+// Categories lists every change category in report order
+var Categories = []string{
+	CategoryRemovedMessage,
+	CategoryRemovedField,
+	CategoryChangedFieldNumber,
+	CategoryChangedFieldType,
+	CategoryRemovedRPC,
+	CategoryChangedRPC,
+}
+
+// Change is a single breaking difference between the old and new proto
+type Change struct {
+	Category string
+	Element  string // Qualified element name, e.g. "Order.LineItem.sku" or "OrderService.GetOrder"
+	Message  string
+}
+
+// String formats the change as "element: message"
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Element, c.Message)
+}
+
+// Compare returns the breaking changes from oldFile to newFile, ordered by
+// category (see Categories) and then by element. Additions are never breaking.
+// Fields are matched by name, because generated clients bind them by JSON name;
+// RPCs are matched by service and method name.
+func Compare(oldFile, newFile *types.ProtoFile) []Change {
+	var changes []Change
+	compareMessages(&changes, "", oldFile.Messages, newFile.Messages)
+	compareServices(&changes, oldFile.Services, newFile.Services)
+
+	rank := make(map[string]int, len(Categories))
+	for i, category := range Categories {
+		rank[category] = i
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Category != changes[j].Category {
+			return rank[changes[i].Category] < rank[changes[j].Category]
+		}
+		return changes[i].Element < changes[j].Element
+	})
+	return changes
+}
+
+func compareMessages(changes *[]Change, prefix string, oldMessages, newMessages map[string]*types.ProtoMessage) {
+	for name, oldMessage := range oldMessages {
+		qualified := prefix + name
+		newMessage, ok := newMessages[name]
+		if !ok {
+			*changes = append(*changes, Change{CategoryRemovedMessage, qualified, "message removed"})
+			continue
+		}
+		compareFields(changes, qualified, oldMessage.Fields, newMessage.Fields)
+		compareMessages(changes, qualified+".", oldMessage.NestedMessages, newMessage.NestedMessages)
+	}
+}
+
+func compareFields(changes *[]Change, message string, oldFields, newFields []*types.ProtoField) {
+	byName := make(map[string]*types.ProtoField, len(newFields))
+	for _, field := range newFields {
+		byName[field.Name] = field
+	}
+	for _, oldField := range oldFields {
+		element := message + "." + oldField.Name
+		newField, ok := byName[oldField.Name]
+		if !ok {
+			*changes = append(*changes, Change{CategoryRemovedField, element, fmt.Sprintf("field %d removed", oldField.Number)})
+			continue
+		}
+		if oldField.Number != newField.Number {
+			*changes = append(*changes, Change{CategoryChangedFieldNumber, element,
+				fmt.Sprintf("field number changed from %d to %d", oldField.Number, newField.Number)})
+		}
+		if oldType, newType := fieldType(oldField), fieldType(newField); oldType != newType {
+			*changes = append(*changes, Change{CategoryChangedFieldType, element,
+				fmt.Sprintf("type changed from %s to %s", oldType, newType)})
+		}
+	}
+}
+
+// fieldType renders the declared type, including the repeated label
+func fieldType(field *types.ProtoField) string {
+	if field.Repeated {
+		return "repeated " + field.Type
+	}
+	return field.Type
+}
+
+func compareServices(changes *[]Change, oldServices, newServices []*types.ProtoService) {
+	byName := make(map[string]*types.ProtoService, len(newServices))
+	for _, service := range newServices {
+		byName[service.Name] = service
+	}
+	for _, oldService := range oldServices {
+		newRPCs := make(map[string]*types.ProtoRPC)
+		if newService, ok := byName[oldService.Name]; ok {
+			for _, rpc := range newService.RPCs {
+				newRPCs[rpc.Name] = rpc
+			}
+		}
+		for _, oldRPC := range oldService.RPCs {
+			element := oldService.Name + "." + oldRPC.Name
+			newRPC, ok := newRPCs[oldRPC.Name]
+			if !ok {
+				*changes = append(*changes, Change{CategoryRemovedRPC, element, "rpc removed"})
+				continue
+			}
+			if oldSig, newSig := signature(oldRPC), signature(newRPC); oldSig != newSig {
+				*changes = append(*changes, Change{CategoryChangedRPC, element,
+					fmt.Sprintf("signature changed from %s to %s", oldSig, newSig)})
+			}
+		}
+	}
+}
+
+// signature renders the RPC's request and response as "(stream A) returns (B)"
+func signature(rpc *types.ProtoRPC) string {
+	input, output := rpc.InputType, rpc.OutputType
+	if rpc.ClientStreaming {
+		input = "stream " + input
+	}
+	if rpc.ServerStreaming {
+		output = "stream " + output
+	}
+	return fmt.Sprintf("(%s) returns (%s)", input, output)
+}
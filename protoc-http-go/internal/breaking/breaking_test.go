@@ -0,0 +1,137 @@
+package breaking
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+const baseProto = `syntax = "proto3";
+package shop;
+
+message Order {
+  string order_id = 1;
+  repeated int64 line_ids = 2;
+  string note = 3;
+
+  message LineItem {
+    string sku = 1;
+  }
+}
+
+message OrderReply {
+  Order order = 1;
+}
+
+service OrderService {
+  rpc GetOrder(Order) returns (OrderReply);
+  rpc CancelOrder(Order) returns (OrderReply);
+}
+`
+
+func parseProto(t *testing.T, content string) *types.ProtoFile {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "shop.proto")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	protoFile, err := parser.ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	return protoFile
+}
+
+// compareWith diffs baseProto against a copy edited by replacing old with new
+func compareWith(t *testing.T, oldNew ...string) []Change {
+	t.Helper()
+	return Compare(parseProto(t, baseProto), parseProto(t, strings.NewReplacer(oldNew...).Replace(baseProto)))
+}
+
+func assertChanges(t *testing.T, got []Change, want ...string) {
+	t.Helper()
+	lines := make([]string, len(got))
+	for i, c := range got {
+		lines[i] = c.Category + " " + c.String()
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected changes:\ngot:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestIdenticalProtosHaveNoChanges(t *testing.T) {
+	assertChanges(t, compareWith(t))
+}
+
+func TestAdditionsAreNotBreaking(t *testing.T) {
+	assertChanges(t, compareWith(t,
+		"  string note = 3;\n", "  string note = 3;\n  bool rush = 4;\n",
+		"    string sku = 1;\n", "    string sku = 1;\n    int32 quantity = 2;\n",
+		"  rpc CancelOrder", "  rpc ListOrders(Order) returns (OrderReply);\n  rpc CancelOrder",
+		"service OrderService", "message Extra {\n  string value = 1;\n}\n\nservice OrderService",
+	))
+}
+
+func TestRemovedField(t *testing.T) {
+	assertChanges(t, compareWith(t, "  string note = 3;\n", ""),
+		"removed-field Order.note: field 3 removed")
+}
+
+func TestRemovedNestedField(t *testing.T) {
+	assertChanges(t, compareWith(t, "    string sku = 1;\n", ""),
+		"removed-field Order.LineItem.sku: field 1 removed")
+}
+
+func TestRemovedMessage(t *testing.T) {
+	assertChanges(t, compareWith(t, "\n  message LineItem {\n    string sku = 1;\n  }\n", ""),
+		"removed-message Order.LineItem: message removed")
+}
+
+func TestChangedFieldNumber(t *testing.T) {
+	assertChanges(t, compareWith(t, "string note = 3;", "string note = 7;"),
+		"changed-field-number Order.note: field number changed from 3 to 7")
+}
+
+func TestChangedFieldType(t *testing.T) {
+	assertChanges(t, compareWith(t,
+		"string note = 3;", "bytes note = 3;",
+		"repeated int64 line_ids = 2;", "int64 line_ids = 2;",
+	),
+		"changed-field-type Order.line_ids: type changed from repeated int64 to int64",
+		"changed-field-type Order.note: type changed from string to bytes")
+}
+
+func TestRemovedRPC(t *testing.T) {
+	assertChanges(t, compareWith(t, "  rpc CancelOrder(Order) returns (OrderReply);\n", ""),
+		"removed-rpc OrderService.CancelOrder: rpc removed")
+}
+
+func TestRemovedServiceReportsEachRPC(t *testing.T) {
+	assertChanges(t, compareWith(t, "service OrderService", "service OrderApi"),
+		"removed-rpc OrderService.CancelOrder: rpc removed",
+		"removed-rpc OrderService.GetOrder: rpc removed")
+}
+
+func TestChangedRPCSignature(t *testing.T) {
+	assertChanges(t, compareWith(t,
+		"rpc GetOrder(Order) returns (OrderReply);", "rpc GetOrder(Order) returns (Order);",
+		"rpc CancelOrder(Order) returns (OrderReply);", "rpc CancelOrder(Order) returns (stream OrderReply);",
+	),
+		"changed-rpc-signature OrderService.CancelOrder: signature changed from (Order) returns (OrderReply) to (Order) returns (stream OrderReply)",
+		"changed-rpc-signature OrderService.GetOrder: signature changed from (Order) returns (OrderReply) to (Order) returns (Order)")
+}
+
+func TestChangesAreOrderedByCategory(t *testing.T) {
+	assertChanges(t, compareWith(t,
+		"  rpc CancelOrder(Order) returns (OrderReply);\n", "",
+		"string note = 3;", "int32 note = 3;",
+		"string order_id = 1;", "string order_id = 9;",
+	),
+		"changed-field-number Order.order_id: field number changed from 1 to 9",
+		"changed-field-type Order.note: type changed from string to int32",
+		"removed-rpc OrderService.CancelOrder: rpc removed")
+}
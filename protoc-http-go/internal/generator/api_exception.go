@@ -0,0 +1,87 @@
+package generator
+
+import "strings"
+
+// apiExceptionTypeName is the exception thrown by generated clients for non-2xx responses
+const apiExceptionTypeName = "ApiException"
+
+// emitAPIException writes the ApiException class thrown by the HTTP helpers for
+// non-2xx responses. It parses the proxy's error envelope, either
+// {"error": {"code": ..., "message": ..., "details": ...}} or {"error": "...", "detail": "..."},
+// into Code, Message and Details; a body in any other shape is kept in Details.
+// It derives from the exception the helpers threw before it existed (HttpRequestException
+// for net45, WebException for net40hwr) so existing Catch blocks keep working.
+func emitAPIException(sb *strings.Builder, indent, frameworkMode string) {
+	baseType := "HttpRequestException"
+	if frameworkMode == "net40hwr" {
+		baseType = "WebException"
+	}
+
+	lines := []string{
+		"' ApiException carries the error the proxy reported for a failed call",
+		"Public Class " + apiExceptionTypeName,
+		"    Inherits " + baseType,
+		"",
+		"    ' StatusCode is the HTTP status of the response",
+		"    Public ReadOnly Property StatusCode As Integer",
+		"    ' Code is the error code from the response body, e.g. NOT_FOUND; Nothing if absent",
+		"    Public ReadOnly Property Code As String",
+		"    ' Details holds the error details from the response body, or the raw body if it is not an error envelope",
+		"    Public ReadOnly Property Details As String",
+		"",
+		"    Public Sub New(statusCode As Integer, code As String, message As String, details As String)",
+		"        MyBase.New(message)",
+		"        Me.StatusCode = statusCode",
+		"        Me.Code = code",
+		"        Me.Details = details",
+		"    End Sub",
+		"",
+		"    ' FromResponse builds the exception for a non-2xx response from its status and body",
+		"    Public Shared Function FromResponse(statusCode As Integer, reasonPhrase As String, body As String) As " + apiExceptionTypeName,
+		"        Dim fallback As String = String.Format(\"Request failed with status {0} ({1}): {2}\", statusCode, reasonPhrase, body)",
+		"        Dim root As Newtonsoft.Json.Linq.JObject = Nothing",
+		"        Try",
+		"            root = Newtonsoft.Json.Linq.JObject.Parse(body)",
+		"        Catch ex As JsonReaderException",
+		"            Return New " + apiExceptionTypeName + "(statusCode, Nothing, fallback, body)",
+		"        End Try",
+		"",
+		"        Dim errorToken As Newtonsoft.Json.Linq.JToken = root(\"error\")",
+		"        If errorToken Is Nothing Then",
+		"            Return New " + apiExceptionTypeName + "(statusCode, Nothing, fallback, body)",
+		"        End If",
+		"        If errorToken.Type = Newtonsoft.Json.Linq.JTokenType.Object Then",
+		"            Dim detailsToken As Newtonsoft.Json.Linq.JToken = errorToken(\"details\")",
+		"            Dim details As String = Nothing",
+		"            If detailsToken IsNot Nothing Then",
+		"                details = If(detailsToken.Type = Newtonsoft.Json.Linq.JTokenType.String, CType(detailsToken, String), detailsToken.ToString(Formatting.None))",
+		"            End If",
+		"            Dim message As String = errorToken.Value(Of String)(\"message\")",
+		"            Return New " + apiExceptionTypeName + "(statusCode, errorToken.Value(Of String)(\"code\"), If(message, fallback), details)",
+		"        End If",
+		"        If errorToken.Type = Newtonsoft.Json.Linq.JTokenType.String Then",
+		"            ' Flat form: {\"error\": \"invalid JSON payload\", \"detail\": \"...\"}",
+		"            Return New " + apiExceptionTypeName + "(statusCode, Nothing, CType(errorToken, String), root.Value(Of String)(\"detail\"))",
+		"        End If",
+		"        Return New " + apiExceptionTypeName + "(statusCode, Nothing, fallback, body)",
+		"    End Function",
+	}
+	if frameworkMode == "net40hwr" {
+		lines = append(lines,
+			"",
+			"    ' GetResponseOrThrow returns the response to req, turning an HTTP error status into an ApiException",
+			"    Public Shared Function GetResponseOrThrow(req As HttpWebRequest) As HttpWebResponse",
+			"        Try",
+			"            Return CType(req.GetResponse(), HttpWebResponse)",
+			"        Catch ex As WebException When TypeOf ex.Response Is HttpWebResponse",
+			"            Dim resp As HttpWebResponse = CType(ex.Response, HttpWebResponse)",
+			"            Using reader As New StreamReader(resp.GetResponseStream(), Encoding.UTF8)",
+			"                Throw FromResponse(CInt(resp.StatusCode), resp.StatusDescription, reader.ReadToEnd())",
+			"            End Using",
+			"        End Try",
+			"    End Function",
+		)
+	}
+	lines = append(lines, "End Class", "")
+	writeIndentedLines(sb, indent, lines)
+}
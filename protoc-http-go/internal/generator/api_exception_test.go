@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPIExceptionClassGolden(t *testing.T) {
+	for _, mode := range []string{"net45", "net40hwr"} {
+		t.Run(mode, func(t *testing.T) {
+			var sb strings.Builder
+			emitAPIException(&sb, "", mode)
+			assertGolden(t, "api_exception_"+mode+".vb.golden", sb.String())
+		})
+	}
+}
+
+func TestNet45HelpersThrowAPIException(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net45"})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	// Both PostJsonAsync branches and GetJsonAsync throw the parsed error
+	throw := "Throw ApiException.FromResponse(CInt(response.StatusCode), response.ReasonPhrase, body)\n"
+	if count := strings.Count(vb, throw); count != 3 {
+		t.Fatalf("expected 3 ApiException throws, got %d\n%s", count, vb)
+	}
+	assertNotContains(t, vb, "Throw New HttpRequestException")
+	if count := strings.Count(vb, "Public Class ApiException\n"); count != 1 {
+		t.Fatalf("expected the exception class once, got %d", count)
+	}
+	assertContains(t, vb, "Public Class ApiException\n    Inherits HttpRequestException\n")
+}
+
+func TestNet40HWRHelpersThrowAPIException(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net40hwr"})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	// PostJson and GetJson read error bodies through GetResponseOrThrow
+	if count := strings.Count(vb, "Using resp As HttpWebResponse = ApiException.GetResponseOrThrow(req)\n"); count != 2 {
+		t.Fatalf("expected 2 GetResponseOrThrow calls, got %d\n%s", count, vb)
+	}
+	assertContains(t, vb, "Public Class ApiException\n    Inherits WebException\n")
+}
+
+func TestAPIExceptionOnlyWithServices(t *testing.T) {
+	vb, err := GenerateString(testInt64Proto(), Options{})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertNotContains(t, vb, "ApiException")
+}
+
+func TestSharedUtilityEmitsAPIException(t *testing.T) {
+	proto := testGetProto()
+	proto.UseSharedUtility = true
+	proto.SharedUtilityName = "ApiHttpUtility"
+	proto.SharedUtilityNamespace = "Api"
+	gen := &Generator{FrameworkMode: "net40hwr"}
+
+	vb, err := gen.GenerateString(proto)
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertNotContains(t, vb, "Public Class ApiException")
+
+	utilityPath := filepath.Join(t.TempDir(), "ApiHttpUtility.vb")
+	if err := gen.GenerateSharedUtility("ApiHttpUtility", "Api", utilityPath); err != nil {
+		t.Fatalf("GenerateSharedUtility() error = %v", err)
+	}
+	utility, err := os.ReadFile(utilityPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	assertContains(t, string(utility), "Public Class ApiException\n    Inherits WebException\n")
+	assertContains(t, string(utility), "Using resp As HttpWebResponse = ApiException.GetResponseOrThrow(req)")
+}
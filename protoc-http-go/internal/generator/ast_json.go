@@ -0,0 +1,200 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// ASTFile is the JSON form of a parsed proto file written by --emit-ast. Maps of
+// the parse result become lists in declaration order, so the output is stable.
+type ASTFile struct {
+	FileName    string           `json:"fileName"`
+	BaseName    string           `json:"baseName"`
+	Package     string           `json:"package"`
+	Imports     []string         `json:"imports"`
+	Messages    []ASTMessage     `json:"messages"`
+	Enums       []ASTEnum        `json:"enums"`
+	Services    []ASTService     `json:"services"`
+	Unsupported []ASTUnsupported `json:"unsupported"`
+}
+
+// ASTMessage is a message with its nested messages and enums
+type ASTMessage struct {
+	Name           string       `json:"name"`
+	FullName       string       `json:"fullName"` // Package-qualified dotted name, e.g. "shop.Order.Item"
+	Line           int          `json:"line"`
+	Fields         []ASTField   `json:"fields"`
+	NestedMessages []ASTMessage `json:"nestedMessages"`
+	NestedEnums    []ASTEnum    `json:"nestedEnums"`
+}
+
+// ASTField is a message field. Type is the type as written in the proto, e.g.
+// "string" or "Order.Item"; JSONName is the name used on the wire.
+type ASTField struct {
+	Name         string  `json:"name"`
+	Number       int     `json:"number"`
+	Type         string  `json:"type"`
+	Repeated     bool    `json:"repeated"`
+	JSONName     string  `json:"jsonName"`
+	TypeOverride string  `json:"typeOverride,omitempty"` // VB type from a "// type:" annotation
+	Default      *string `json:"default,omitempty"`      // proto2 [default = ...] option
+	Line         int     `json:"line"`
+}
+
+// ASTEnum is an enum with its values ordered by number
+type ASTEnum struct {
+	Name     string         `json:"name"`
+	FullName string         `json:"fullName"`
+	Line     int            `json:"line"`
+	Values   []ASTEnumValue `json:"values"`
+}
+
+// ASTEnumValue is one value of an enum
+type ASTEnumValue struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+}
+
+// ASTService is a service with its RPCs in declaration order
+type ASTService struct {
+	Name           string   `json:"name"`
+	FullName       string   `json:"fullName"`
+	DefaultVersion string   `json:"defaultVersion,omitempty"` // "// default-version:" annotation
+	Line           int      `json:"line"`
+	RPCs           []ASTRPC `json:"rpcs"`
+}
+
+// ASTRPC is an RPC of a service. HTTPMethod is the method of the generated client,
+// GET or POST.
+type ASTRPC struct {
+	Name            string `json:"name"`
+	InputType       string `json:"inputType"`
+	OutputType      string `json:"outputType"`
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
+	HTTPMethod      string `json:"httpMethod"`
+	Line            int    `json:"line"`
+}
+
+// ASTUnsupported is a construct the generators cannot represent
+type ASTUnsupported struct {
+	Description string `json:"description"`
+	Line        int    `json:"line"`
+}
+
+// GenerateASTJSON returns the parse results of protoFiles as an indented JSON array
+// of ASTFile, for tools that consume the parse without re-implementing the parser.
+func GenerateASTJSON(protoFiles []*types.ProtoFile) ([]byte, error) {
+	files := make([]ASTFile, 0, len(protoFiles))
+	for _, protoFile := range protoFiles {
+		files = append(files, NewASTFile(protoFile))
+	}
+	jsonBytes, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal AST: %w", err)
+	}
+	return append(jsonBytes, '\n'), nil
+}
+
+// NewASTFile converts the parse result of protoFile to its JSON form
+func NewASTFile(protoFile *types.ProtoFile) ASTFile {
+	file := ASTFile{
+		FileName:    protoFile.FileName,
+		BaseName:    protoFile.BaseName,
+		Package:     protoFile.Package,
+		Imports:     append([]string{}, protoFile.Imports...),
+		Messages:    []ASTMessage{},
+		Enums:       astEnums(protoFile.Package, protoFile.Enums),
+		Services:    []ASTService{},
+		Unsupported: []ASTUnsupported{},
+	}
+	for _, message := range messagesInOrder(protoFile.Messages) {
+		file.Messages = append(file.Messages, astMessage(protoFile.Package, message))
+	}
+	for _, service := range protoFile.Services {
+		s := ASTService{
+			Name:           service.Name,
+			FullName:       service.FullName(),
+			DefaultVersion: service.DefaultVersion,
+			Line:           service.Line,
+			RPCs:           []ASTRPC{},
+		}
+		for _, rpc := range service.RPCs {
+			method := "POST"
+			if rpc.IsGet() {
+				method = "GET"
+			}
+			s.RPCs = append(s.RPCs, ASTRPC{
+				Name:            rpc.Name,
+				InputType:       rpc.InputType,
+				OutputType:      rpc.OutputType,
+				ClientStreaming: rpc.ClientStreaming,
+				ServerStreaming: rpc.ServerStreaming,
+				HTTPMethod:      method,
+				Line:            rpc.Line,
+			})
+		}
+		file.Services = append(file.Services, s)
+	}
+	for _, feature := range protoFile.Unsupported {
+		file.Unsupported = append(file.Unsupported, ASTUnsupported{Description: feature.Description, Line: feature.Line})
+	}
+	return file
+}
+
+// astMessage converts message, declared in scope (its parent's full name, or the
+// package for top-level messages)
+func astMessage(scope string, message *types.ProtoMessage) ASTMessage {
+	fullName := qualifiedName(scope, message.Name)
+	m := ASTMessage{
+		Name:           message.Name,
+		FullName:       fullName,
+		Line:           message.Line,
+		Fields:         []ASTField{},
+		NestedMessages: []ASTMessage{},
+		NestedEnums:    astEnums(fullName, message.NestedEnums),
+	}
+	for _, field := range message.Fields {
+		f := ASTField{
+			Name:         field.Name,
+			Number:       field.Number,
+			Type:         field.Type,
+			Repeated:     field.Repeated,
+			JSONName:     types.FieldJSONName(field, message.Name),
+			TypeOverride: field.TypeOverride,
+			Line:         field.Line,
+		}
+		if field.HasDefault {
+			value := field.Default
+			f.Default = &value
+		}
+		m.Fields = append(m.Fields, f)
+	}
+	for _, nested := range messagesInOrder(message.NestedMessages) {
+		m.NestedMessages = append(m.NestedMessages, astMessage(fullName, nested))
+	}
+	return m
+}
+
+// astEnums converts enums declared in scope, in declaration order
+func astEnums(scope string, enums map[string]*types.ProtoEnum) []ASTEnum {
+	converted := []ASTEnum{}
+	for _, enum := range enumsInOrder(enums) {
+		e := ASTEnum{Name: enum.Name, FullName: qualifiedName(scope, enum.Name), Line: enum.Line, Values: []ASTEnumValue{}}
+		for _, value := range enumValuesInOrder(enum) {
+			e.Values = append(e.Values, ASTEnumValue{Name: value, Number: enum.Values[value]})
+		}
+		converted = append(converted, e)
+	}
+	return converted
+}
+
+// qualifiedName joins scope and name with a dot; an empty scope leaves name as is
+func qualifiedName(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+// modifiersProto has a single message with one nested message, so the output order is fixed
+func modifiersProto() *types.ProtoFile {
+	return &types.ProtoFile{
+		FileName: "profile.proto",
+		BaseName: "profile",
+		Package:  "profile",
+		Messages: map[string]*types.ProtoMessage{
+			"Profile": {
+				Name:   "Profile",
+				Fields: []*types.ProtoField{{Name: "display_name", Type: "string", Number: 1}, {Name: "tags", Type: "string", Number: 2, Repeated: true}},
+				NestedMessages: map[string]*types.ProtoMessage{
+					"Address": {
+						Name:           "Address",
+						Fields:         []*types.ProtoField{{Name: "city", Type: "string", Number: 1}},
+						NestedMessages: map[string]*types.ProtoMessage{},
+						NestedEnums:    map[string]*types.ProtoEnum{},
+					},
+				},
+				NestedEnums: map[string]*types.ProtoEnum{},
+			},
+		},
+		Enums: map[string]*types.ProtoEnum{},
+	}
+}
+
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Fatalf("output differs from %s (run go test -update to accept):\n%s", path, got)
+	}
+}
+
+func TestPartialMessageClassesGolden(t *testing.T) {
+	vb, err := GenerateString(modifiersProto(), Options{FrameworkMode: "net45", Partial: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertGolden(t, "partial_profile.vb.golden", vb)
+}
+
+func TestSealedMessageClasses(t *testing.T) {
+	vb, err := GenerateString(modifiersProto(), Options{FrameworkMode: "net45", Sealed: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, "Public NotInheritable Class Profile\n")
+	assertContains(t, vb, "Public NotInheritable Class Profile_Address\n")
+	assertNotContains(t, vb, "Partial")
+}
+
+func TestDefaultMessageClassesHaveNoModifiers(t *testing.T) {
+	vb, err := GenerateString(modifiersProto(), Options{FrameworkMode: "net45"})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, "\nPublic Class Profile\n")
+	assertNotContains(t, vb, "Partial")
+	assertNotContains(t, vb, "NotInheritable")
+}
+
+func TestPartialAndSealedAreMutuallyExclusive(t *testing.T) {
+	if _, err := GenerateString(modifiersProto(), Options{Partial: true, Sealed: true}); err == nil {
+		t.Fatal("expected an error when both Partial and Sealed are set")
+	}
+}
+
+func TestEqualityMethodsGolden(t *testing.T) {
+	protoFile := &types.ProtoFile{
+		FileName: "order.proto",
+		BaseName: "order",
+		Package:  "shop",
+		Messages: map[string]*types.ProtoMessage{
+			"Order": {
+				Name: "Order",
+				Line: 1,
+				Fields: []*types.ProtoField{
+					{Name: "id", Type: "int64", Number: 1},
+					{Name: "note", Type: "string", Number: 2},
+					{Name: "tags", Type: "string", Number: 3, Repeated: true},
+					{Name: "shipping", Type: "Address", Number: 4},
+					{Name: "stops", Type: "Address", Number: 5, Repeated: true},
+				},
+			},
+			"Address": {
+				Name:   "Address",
+				Line:   2,
+				Fields: []*types.ProtoField{{Name: "city", Type: "string", Number: 1}},
+			},
+		},
+		Enums: map[string]*types.ProtoEnum{},
+	}
+	vb, err := GenerateString(protoFile, Options{FrameworkMode: "net45", Equality: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertGolden(t, "equality_order.vb.golden", vb)
+}
+
+func TestNoEqualityMethodsByDefault(t *testing.T) {
+	vb, err := GenerateString(modifiersProto(), Options{FrameworkMode: "net45"})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertNotContains(t, vb, "Overrides Function Equals")
+	assertNotContains(t, vb, "GetHashCode")
+}
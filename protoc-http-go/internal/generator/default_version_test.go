@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+func defaultVersionProto(defaultVersion string) *types.ProtoFile {
+	return &types.ProtoFile{
+		FileName: "quote.proto",
+		BaseName: "quote",
+		Package:  "quote",
+		Messages: map[string]*types.ProtoMessage{
+			"QuoteRequest": {
+				Name:           "QuoteRequest",
+				Fields:         []*types.ProtoField{{Name: "symbol", Type: "string"}},
+				NestedMessages: map[string]*types.ProtoMessage{},
+				NestedEnums:    map[string]*types.ProtoEnum{},
+			},
+			"QuoteReply": {
+				Name:           "QuoteReply",
+				Fields:         []*types.ProtoField{{Name: "price", Type: "double"}},
+				NestedMessages: map[string]*types.ProtoMessage{},
+				NestedEnums:    map[string]*types.ProtoEnum{},
+			},
+		},
+		Enums: map[string]*types.ProtoEnum{},
+		Services: []*types.ProtoService{
+			{
+				Name:           "QuoteService",
+				Package:        "quote",
+				DefaultVersion: defaultVersion,
+				RPCs: []*types.ProtoRPC{
+					{Name: "GetQuote", InputType: "QuoteRequest", OutputType: "QuoteReply", IsUnary: true},
+					{Name: "GetQuoteV3", InputType: "QuoteRequest", OutputType: "QuoteReply", IsUnary: true},
+					{Name: "LegacyQuoteV1", InputType: "QuoteRequest", OutputType: "QuoteReply", IsUnary: true},
+				},
+			},
+		},
+	}
+}
+
+func TestRPCNameAndVersionPrefersSuffix(t *testing.T) {
+	service := defaultVersionProto("v2").Services[0]
+	cases := []struct {
+		rpc, base, version string
+	}{
+		{"GetQuote", "GetQuote", "v2"},
+		{"GetQuoteV3", "GetQuote", "v3"},
+		{"LegacyQuoteV1", "LegacyQuote", "v1"},
+	}
+	for _, tc := range cases {
+		base, version := service.RPCNameAndVersion(&types.ProtoRPC{Name: tc.rpc})
+		if base != tc.base || version != tc.version {
+			t.Errorf("RPCNameAndVersion(%q) = (%q, %q), want (%q, %q)", tc.rpc, base, version, tc.base, tc.version)
+		}
+	}
+
+	base, version := (&types.ProtoService{}).RPCNameAndVersion(&types.ProtoRPC{Name: "GetQuote"})
+	if base != "GetQuote" || version != "v1" {
+		t.Errorf("expected v1 without a default, got (%q, %q)", base, version)
+	}
+}
+
+func TestDefaultVersionAppliesToGeneratedRoutes(t *testing.T) {
+	protoFile := defaultVersionProto("v2")
+
+	vb, err := GenerateString(protoFile, Options{FrameworkMode: "net45"})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, `"/quote/get-quote/v2"`)
+	assertContains(t, vb, `"/quote/get-quote/v3"`)
+	assertContains(t, vb, `"/quote/legacy-quote/v1"`)
+	assertNotContains(t, vb, `"/quote/get-quote/v1"`)
+
+	goSource, err := (&Generator{}).generateGoSource(protoFile)
+	if err != nil {
+		t.Fatalf("generateGoSource() error = %v", err)
+	}
+	goClient := string(goSource)
+	assertContains(t, goClient, `"/quote/get-quote/v2"`)
+	assertContains(t, goClient, `"/quote/get-quote/v3"`)
+	assertContains(t, goClient, `"/quote/legacy-quote/v1"`)
+}
+
+func TestApplyDefaultVersionKeepsAnnotatedServices(t *testing.T) {
+	protoFile := defaultVersionProto("v2")
+	protoFile.Services = append(protoFile.Services, &types.ProtoService{Name: "Plain"})
+
+	types.ApplyDefaultVersion(protoFile, "v4")
+
+	if got := protoFile.Services[0].DefaultVersion; got != "v2" {
+		t.Errorf("annotated service DefaultVersion = %q, want v2", got)
+	}
+	if got := protoFile.Services[1].DefaultVersion; got != "v4" {
+		t.Errorf("plain service DefaultVersion = %q, want v4", got)
+	}
+}
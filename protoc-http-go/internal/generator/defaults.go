@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// vbTypeCharacters are the literal suffixes that give a VB numeric literal the
+// type of the property it initializes, so no narrowing conversion is needed
+var vbTypeCharacters = map[string]string{
+	"Short":    "S",
+	"UShort":   "US",
+	"Integer":  "",
+	"UInteger": "UI",
+	"Long":     "L",
+	"ULong":    "UL",
+	"Single":   "F",
+	"Double":   "R",
+	"Decimal":  "D",
+}
+
+// vbDefaultInitializer returns the " = <literal>" initializer of the property for
+// a field with a proto2 [default = ...] option, or "" when the field has none.
+// Bytes defaults and defaults that the VB type of the field (e.g. a "// type:"
+// override) cannot represent are left out, so the property keeps the VB default.
+// Enum defaults name the member generated for the value by the enum of protoFile
+// the field refers to.
+func (g *Generator) vbDefaultInitializer(protoFile *types.ProtoFile, field *types.ProtoField) string {
+	if !field.HasDefault || field.Repeated {
+		return ""
+	}
+	vbType := g.fieldElementType(field)
+	switch field.Type {
+	case "bytes":
+		return ""
+	case "string":
+		if vbType != "String" {
+			return ""
+		}
+		return " = " + vbStringLiteral(field.Default)
+	case "bool":
+		if vbType != "Boolean" {
+			return ""
+		}
+		if field.Default == "true" {
+			return " = True"
+		}
+		return " = False"
+	case "float", "double":
+		if vbType == "Single" || vbType == "Double" {
+			switch field.Default {
+			case "inf":
+				return " = " + vbType + ".PositiveInfinity"
+			case "-inf":
+				return " = " + vbType + ".NegativeInfinity"
+			case "nan":
+				return " = " + vbType + ".NaN"
+			}
+		}
+		v, err := strconv.ParseFloat(field.Default, 64)
+		suffix, ok := vbTypeCharacters[vbType]
+		if err != nil || !ok {
+			return ""
+		}
+		return " = " + strings.ToUpper(strconv.FormatFloat(v, 'g', -1, 64)) + suffix
+	}
+	if _, scalar := types.VBTypeMappings[field.Type]; scalar {
+		// Integers, already converted to decimal by the parser
+		suffix, ok := vbTypeCharacters[vbType]
+		if !ok {
+			return ""
+		}
+		return " = " + field.Default + suffix
+	}
+	// Enum values are generated as <Enum>_<VALUE> members of the enum
+	enumName := field.Type[strings.LastIndex(field.Type, ".")+1:]
+	if enum := enumNamed(protoFile, enumName); enum != nil {
+		return fmt.Sprintf(" = %s.%s", vbType, g.vbEnumMember(enum, field.Default))
+	}
+	return fmt.Sprintf(" = %s.%s_%s", vbType, enumName, field.Default)
+}
+
+// enumNamed returns the enum of protoFile, top-level or nested at any depth, with
+// the given simple name, or nil. VB enums are all generated under their simple
+// names, so the name identifies one.
+func enumNamed(protoFile *types.ProtoFile, name string) *types.ProtoEnum {
+	if enum, ok := protoFile.Enums[name]; ok {
+		return enum
+	}
+	var search func(messages map[string]*types.ProtoMessage) *types.ProtoEnum
+	search = func(messages map[string]*types.ProtoMessage) *types.ProtoEnum {
+		for _, message := range messagesInOrder(messages) {
+			if enum, ok := message.NestedEnums[name]; ok {
+				return enum
+			}
+			if enum := search(message.NestedMessages); enum != nil {
+				return enum
+			}
+		}
+		return nil
+	}
+	return search(protoFile.Messages)
+}
+
+// vbStringLiteral quotes s as a VB string expression. VB literals have no escape
+// sequences, so quotes are doubled and control characters concatenated as ChrW(n).
+func vbStringLiteral(s string) string {
+	var parts []string
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, `"`+literal.String()+`"`)
+			literal.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '"':
+			literal.WriteString(`""`)
+		case r < 0x20 || r == 0x7f:
+			flush()
+			parts = append(parts, fmt.Sprintf("ChrW(%d)", r))
+		default:
+			literal.WriteRune(r)
+		}
+	}
+	flush()
+	if len(parts) == 0 {
+		return `""`
+	}
+	return strings.Join(parts, " & ")
+}
+
+// jsonSchemaDefault returns the JSON Schema "default" of a field with a proto2
+// [default = ...] option, typed like the field's JSON value. Bytes defaults and
+// the float values inf, -inf and nan, which JSON numbers cannot express, are left
+// out.
+func jsonSchemaDefault(field *types.ProtoField) (interface{}, bool) {
+	if !field.HasDefault || field.Repeated {
+		return nil, false
+	}
+	switch field.Type {
+	case "bytes":
+		return nil, false
+	case "string":
+		return field.Default, true
+	case "bool":
+		return field.Default == "true", true
+	case "float", "double":
+		v, err := strconv.ParseFloat(field.Default, 64)
+		if err != nil || field.Default == "inf" || field.Default == "-inf" || field.Default == "nan" {
+			return nil, false
+		}
+		return v, true
+	}
+	if _, scalar := types.VBTypeMappings[field.Type]; scalar {
+		// Keeps 64-bit values exact
+		return json.Number(field.Default), true
+	}
+	return field.Default, true
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+)
+
+const defaultsProto = `syntax = "proto2";
+package settings;
+
+message Settings {
+  enum Mode { MODE_UNSPECIFIED = 0; FAST = 1; SAFE = 2; }
+  optional int32 timeout = 1 [default = 30];
+  optional string greeting = 2 [default = "say \"hi\"\n"];
+  optional bool enabled = 3 [default = true];
+  optional Mode mode = 4 [default = SAFE];
+  optional int64 quota = 5 [default = 0x10];
+  optional float ratio = 6 [default = 0.5];
+  optional double limit = 7 [default = inf];
+  optional uint32 retries = 8 [default = 3, json_name = "maxRetries"];
+  optional string name = 9;
+}
+`
+
+func TestProto2DefaultsInitializeVBProperties(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("settings.proto", defaultsProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	assertContains(t, content, "Public Property Timeout As Integer = 30\n")
+	assertContains(t, content, "Public Property Greeting As String = \"say \"\"hi\"\"\" & ChrW(10)\n")
+	assertContains(t, content, "Public Property Enabled As Boolean = True\n")
+	assertContains(t, content, "Public Property Mode As Mode = Mode.Mode_SAFE\n")
+	assertContains(t, content, "Public Property Quota As Long = 16L\n")
+	assertContains(t, content, "Public Property Ratio As Single = 0.5F\n")
+	assertContains(t, content, "Public Property Limit As Double = Double.PositiveInfinity\n")
+	assertContains(t, content, "Public Property Retries As UInteger = 3UI\n")
+	assertContains(t, content, "Public Property Name As String\n")
+}
+
+func TestProto2DefaultsAppearInJSONSchema(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("settings.proto", defaultsProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	schema, err := GenerateJSONSchemaString(protoFile)
+	if err != nil {
+		t.Fatalf("GenerateJSONSchemaString() error = %v", err)
+	}
+	var doc struct {
+		Defs map[string]struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(schema), &doc); err != nil {
+		t.Fatalf("invalid JSON schema: %v", err)
+	}
+	properties := doc.Defs["Settings"].Properties
+
+	want := map[string]interface{}{
+		"timeout":    float64(30),
+		"greeting":   "say \"hi\"\n",
+		"enabled":    true,
+		"mode":       "SAFE",
+		"quota":      float64(16),
+		"ratio":      0.5,
+		"maxRetries": float64(3),
+	}
+	for name, value := range want {
+		if got, ok := properties[name]["default"]; !ok || got != value {
+			t.Errorf("%s default = %v (set %v), want %v", name, got, ok, value)
+		}
+	}
+	// JSON numbers cannot express inf, and fields without a default get none
+	for _, name := range []string{"limit", "name"} {
+		if got, ok := properties[name]["default"]; ok {
+			t.Errorf("expected no default for %s, got %v", name, got)
+		}
+	}
+}
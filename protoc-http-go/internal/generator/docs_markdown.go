@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// GenerateMarkdownDocs writes the document built by GenerateMarkdownDocsString to
+// <basename>.md in a docs/ subdirectory of outputDir.
+//
+// Returns the path to the generated document or an error.
+func (g *Generator) GenerateMarkdownDocs(protoFile *types.ProtoFile, outputDir string) (string, error) {
+	docsDir := filepath.Join(outputDir, "docs")
+	if err := os.MkdirAll(docsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create docs directory: %w", err)
+	}
+
+	outputPath := filepath.Join(docsDir, protoFile.BaseName+".md")
+	if err := os.WriteFile(outputPath, []byte(g.GenerateMarkdownDocsString(protoFile)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write Markdown docs file: %w", err)
+	}
+	return outputPath, nil
+}
+
+// GenerateMarkdownDocsString returns a Markdown reference for protoFile: a table of
+// fields per message (proto type, VB type, whether repeated, JSON name), the values
+// of every enum, and the RPCs of every service with the HTTP route the generated
+// clients call. Nested types are listed after their parent under their dotted name.
+func (g *Generator) GenerateMarkdownDocsString(protoFile *types.ProtoFile) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", filepath.Base(protoFile.FileName))
+	if protoFile.Package != "" {
+		fmt.Fprintf(&sb, "\nPackage: `%s`\n", protoFile.Package)
+	}
+
+	if len(protoFile.Services) > 0 {
+		sb.WriteString("\n## Services\n")
+		for _, service := range protoFile.Services {
+			g.writeServiceDocs(&sb, protoFile, service)
+		}
+	}
+
+	if len(protoFile.Messages) > 0 {
+		sb.WriteString("\n## Messages\n")
+		for _, message := range messagesInOrder(protoFile.Messages) {
+			writeMessageDocs(&sb, message, "")
+		}
+	}
+
+	if len(protoFile.Enums) > 0 {
+		sb.WriteString("\n## Enums\n")
+		for _, enum := range enumsInOrder(protoFile.Enums) {
+			writeEnumDocs(&sb, enum, enum.Name)
+		}
+	}
+	return sb.String()
+}
+
+// writeServiceDocs lists the RPCs of service with their HTTP method and route;
+// streaming RPCs, which get no client method, are marked as such
+func (g *Generator) writeServiceDocs(sb *strings.Builder, protoFile *types.ProtoFile, service *types.ProtoService) {
+	fmt.Fprintf(sb, "\n### %s\n\n", service.Name)
+	sb.WriteString("| RPC | HTTP | Route | Request | Response |\n")
+	sb.WriteString("|-----|------|-------|---------|----------|\n")
+	for _, rpc := range service.RPCs {
+		if !rpc.IsUnary {
+			fmt.Fprintf(sb, "| `%s` | — | not generated (%s) | `%s` | `%s` |\n", rpc.Name, rpc.StreamingKind(), rpc.InputType, rpc.OutputType)
+			continue
+		}
+		route := g.routePath(protoFile, service, rpc)
+		method := "POST"
+		if rpc.IsGet() {
+			method = "GET"
+		}
+		fmt.Fprintf(sb, "| `%s` | %s | `%s` | `%s` | `%s` |\n", rpc.Name, method, route, rpc.InputType, rpc.OutputType)
+	}
+}
+
+// writeMessageDocs writes the field table of message, declared inside parentName
+// (dotted, "" for top-level), followed by its nested enums and messages
+func writeMessageDocs(sb *strings.Builder, message *types.ProtoMessage, parentName string) {
+	name := message.Name
+	if parentName != "" {
+		name = parentName + "." + message.Name
+	}
+
+	var vb Generator
+	fmt.Fprintf(sb, "\n### %s\n\n", name)
+	if len(message.Fields) == 0 {
+		sb.WriteString("No fields.\n")
+	} else {
+		sb.WriteString("| Field | Type | VB type | Repeated | JSON name |\n")
+		sb.WriteString("|-------|------|---------|----------|-----------|\n")
+		for _, field := range message.Fields {
+			repeated := "no"
+			if field.Repeated {
+				repeated = "yes"
+			}
+			fmt.Fprintf(sb, "| `%s` | `%s` | `%s` | %s | `%s` |\n",
+				field.Name, field.Type, vb.fieldElementType(field), repeated, types.FieldJSONName(field, message.Name))
+		}
+	}
+
+	for _, enum := range enumsInOrder(message.NestedEnums) {
+		writeEnumDocs(sb, enum, name+"."+enum.Name)
+	}
+	for _, nested := range messagesInOrder(message.NestedMessages) {
+		writeMessageDocs(sb, nested, name)
+	}
+}
+
+// writeEnumDocs writes the values of enum, titled with its dotted name
+func writeEnumDocs(sb *strings.Builder, enum *types.ProtoEnum, name string) {
+	fmt.Fprintf(sb, "\n### %s\n\n", name)
+	sb.WriteString("| Value | Number |\n")
+	sb.WriteString("|-------|--------|\n")
+	for _, value := range enumValuesInOrder(enum) {
+		fmt.Fprintf(sb, "| `%s` | %d |\n", value, enum.Values[value])
+	}
+}
@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+)
+
+const docsProto = `syntax = "proto3";
+package orders;
+
+message Order {
+  string order_id = 1;
+  repeated Item items = 2;
+  int64 total_cents = 3 [json_name = "total"];
+  message Item {
+    string sku = 1;
+  }
+  enum State {
+    STATE_UNSPECIFIED = 0;
+    SHIPPED = 1;
+  }
+}
+
+message GetOrderRequest {
+  string order_id = 1;
+}
+
+service OrderService {
+  rpc PlaceOrder(Order) returns (Order);
+  // http-method: GET
+  rpc GetOrderV2(GetOrderRequest) returns (Order);
+  rpc WatchOrders(GetOrderRequest) returns (stream Order);
+}
+`
+
+func TestMarkdownDocsListFieldsAndRoutes(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("orders.proto", docsProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	docsPath, err := (&Generator{}).GenerateMarkdownDocs(protoFile, t.TempDir())
+	if err != nil {
+		t.Fatalf("GenerateMarkdownDocs() error = %v", err)
+	}
+	if filepath.Base(docsPath) != "orders.md" || filepath.Base(filepath.Dir(docsPath)) != "docs" {
+		t.Errorf("docs written to %s, want docs/orders.md", docsPath)
+	}
+	content := readFile(t, docsPath)
+
+	assertContains(t, content, "# orders.proto\n\nPackage: `orders`\n")
+	assertContains(t, content, `### Order
+
+| Field | Type | VB type | Repeated | JSON name |
+|-------|------|---------|----------|-----------|
+| `+"`order_id` | `string` | `String` | no | `orderId` |\n"+
+		"| `items` | `Item` | `Item` | yes | `items` |\n"+
+		"| `total_cents` | `int64` | `Long` | no | `total` |\n")
+	assertContains(t, content, "\n### Order.Item\n")
+	assertContains(t, content, "\n### Order.State\n\n| Value | Number |\n|-------|--------|\n| `STATE_UNSPECIFIED` | 0 |\n| `SHIPPED` | 1 |\n")
+
+	assertContains(t, content, "| `PlaceOrder` | POST | `/orders/place-order/v1` | `Order` | `Order` |\n")
+	assertContains(t, content, "| `GetOrderV2` | GET | `/orders/get-order/v2` | `GetOrderRequest` | `Order` |\n")
+	assertContains(t, content, "| `WatchOrders` | — | not generated (server streaming) | `GetOrderRequest` | `Order` |\n")
+}
+
+func TestMarkdownDocsVersionPrefix(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("orders.proto", docsProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content := (&Generator{VersionPlacement: VersionPrefix}).GenerateMarkdownDocsString(protoFile)
+	assertContains(t, content, "| `PlaceOrder` | POST | `/v1/orders/place-order` | `Order` | `Order` |\n")
+	assertContains(t, content, "| `GetOrderV2` | GET | `/v2/orders/get-order` | `GetOrderRequest` | `Order` |\n")
+}
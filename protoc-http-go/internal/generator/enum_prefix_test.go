@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+const enumPrefixProto = `syntax = "proto2";
+package paint;
+
+enum Color {
+  COLOR_UNSPECIFIED = 0;
+  COLOR_RED = 1;
+  COLOR_GREEN = 2;
+}
+
+// SHAPE_CIRCLE has the prefix, LEGACY_SQUARE does not
+enum Shape {
+  SHAPE_CIRCLE = 0;
+  LEGACY_SQUARE = 1;
+}
+
+message Brush {
+  enum HTTPMode { HTTP_MODE_PLAIN = 0; HTTP_MODE_SECURE = 1; }
+  optional Color color = 1 [default = COLOR_RED];
+  optional Shape shape = 2 [default = LEGACY_SQUARE];
+  optional HTTPMode mode = 3 [default = HTTP_MODE_SECURE];
+}
+
+service Painter {
+  // http-method: GET
+  rpc Paint(Brush) returns (Brush);
+}
+`
+
+func TestEnumValuePrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"Color", []string{"COLOR_UNSPECIFIED", "COLOR_RED"}, "COLOR_"},
+		{"OrderStatus", []string{"ORDER_STATUS_OPEN", "ORDER_STATUS_CLOSED"}, "ORDER_STATUS_"},
+		{"HTTPMode", []string{"HTTP_MODE_PLAIN"}, "HTTP_MODE_"},
+		{"Shape", []string{"SHAPE_CIRCLE", "LEGACY_SQUARE"}, ""},
+		{"Color", []string{"COLOR_", "COLOR_RED"}, ""},
+		{"Color", []string{"RED", "GREEN"}, ""},
+	}
+	for _, tt := range tests {
+		enum := &types.ProtoEnum{Name: tt.name, Values: map[string]int{}}
+		for i, value := range tt.values {
+			enum.Values[value] = i
+		}
+		if got := types.EnumValuePrefix(enum); got != tt.want {
+			t.Errorf("EnumValuePrefix(%s %v) = %q, want %q", tt.name, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestStripEnumPrefixShortensVBEnumMembers(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("paint.proto", enumPrefixProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{StripEnumPrefix: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	assertContains(t, content, "Public Enum Color As Integer\n    Color_UNSPECIFIED = 0\n    Color_RED = 1\n    Color_GREEN = 2\nEnd Enum\n")
+	assertContains(t, content, "Public Enum HTTPMode As Integer\n    HTTPMode_PLAIN = 0\n    HTTPMode_SECURE = 1\nEnd Enum\n")
+	// Not every Shape value has the prefix, so none is stripped
+	assertContains(t, content, "Public Enum Shape As Integer\n    Shape_SHAPE_CIRCLE = 0\n    Shape_LEGACY_SQUARE = 1\nEnd Enum\n")
+
+	// Defaults name the shortened members
+	assertContains(t, content, "Public Property Color As Color = Color.Color_RED\n")
+	assertContains(t, content, "Public Property Shape As Shape = Shape.Shape_LEGACY_SQUARE\n")
+	assertContains(t, content, "Public Property Mode As HTTPMode = HTTPMode.HTTPMode_SECURE\n")
+
+	// Query strings still carry the full proto value names
+	assertContains(t, content, `Uri.EscapeDataString("COLOR_" & request.Color.ToString().Substring(6))`)
+	assertContains(t, content, `Uri.EscapeDataString(request.Shape.ToString().Substring(6))`)
+}
+
+func TestEnumMembersKeepPrefixByDefault(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("paint.proto", enumPrefixProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, content, "    Color_COLOR_RED = 1\n")
+	assertContains(t, content, "Public Property Color As Color = Color.Color_COLOR_RED\n")
+	if strings.Contains(content, `"COLOR_" &`) {
+		t.Errorf("expected no prefix restored in query strings:\n%s", content)
+	}
+}
@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// envelopeTypeName is the generic wrapper class emitted with --response-envelope
+const envelopeTypeName = "Envelope"
+
+// emitEnvelopeClass writes the generic response wrapper for backends that answer
+// every call as { "data": {...}, "meta": {...} }
+func emitEnvelopeClass(sb *strings.Builder, indent string) {
+	lines := []string{
+		"' Envelope wraps an HTTP response body as { \"data\": ..., \"meta\": ... }",
+		"Public Class " + envelopeTypeName + "(Of T)",
+		"    <JsonProperty(\"data\")>",
+		"    Public Property Data As T",
+		"    <JsonProperty(\"meta\")>",
+		"    Public Property Meta As Newtonsoft.Json.Linq.JObject",
+		"End Class",
+		"",
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(indent)
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+}
+
+// responseType returns the type the HTTP helpers deserialize into for an RPC
+// returning outputType: the output type itself, or Envelope(Of outputType)
+func (g *Generator) responseType(protoFile *types.ProtoFile, outputType string) string {
+	if !g.ResponseEnvelope {
+		return outputType
+	}
+	envelopeType := g.helperTypeName(protoFile, g.determinePackageName(protoFile), envelopeTypeName)
+	return fmt.Sprintf("%s(Of %s)", envelopeType, outputType)
+}
+
+// writeReturn writes the Return statement of an RPC method for the helper call
+// expression, unwrapping the envelope's Data when responses are enveloped
+func (g *Generator) writeReturn(sb *strings.Builder, protoFile *types.ProtoFile, outputType, indent, call string) {
+	if !g.ResponseEnvelope {
+		fmt.Fprintf(sb, "%sReturn %s\n", indent, call)
+		return
+	}
+	fmt.Fprintf(sb, "%sDim envelope As %s = %s\n", indent, g.responseType(protoFile, outputType), call)
+	fmt.Fprintf(sb, "%sIf envelope Is Nothing Then Throw New InvalidOperationException(\"Received a response without an envelope\")\n", indent)
+	fmt.Fprintf(sb, "%sReturn envelope.Data\n", indent)
+}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResponseEnvelopeUnwrapsDataNet45(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net45", ResponseEnvelope: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	// Callers still see the inner response type
+	assertContains(t, vb, "Public Async Function IndexAsync(request As SearchRequest, cancellationToken As CancellationToken, Optional timeoutMs As Integer? = Nothing) As Task(Of SearchReply)\n")
+	assertContains(t, vb, "        Dim envelope As Envelope(Of SearchReply) = Await PostJsonAsync(Of SearchRequest, Envelope(Of SearchReply))(\"/search/index/v1\", request, cancellationToken, timeoutMs).ConfigureAwait(False)\n")
+	assertContains(t, vb, "        Dim envelope As Envelope(Of SearchReply) = Await GetJsonAsync(Of Envelope(Of SearchReply))(\"/search/search/v1\", query, cancellationToken, timeoutMs).ConfigureAwait(False)\n")
+	assertContains(t, vb, "        Return envelope.Data\n")
+	assertContains(t, vb, "If envelope Is Nothing Then Throw New InvalidOperationException(")
+	assertNotContains(t, vb, "Return Await PostJsonAsync(")
+
+	if count := strings.Count(vb, "Public Class Envelope(Of T)"); count != 1 {
+		t.Fatalf("expected the envelope class to be emitted once, got %d\n%s", count, vb)
+	}
+	assertContains(t, vb, "    <JsonProperty(\"data\")>\n    Public Property Data As T\n")
+	assertContains(t, vb, "    <JsonProperty(\"meta\")>\n")
+}
+
+func TestResponseEnvelopeUnwrapsDataNet40HWR(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net40hwr", ResponseEnvelope: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, "Public Function Index(request As SearchRequest, Optional timeoutMs As Integer? = Nothing, Optional authHeaders As Dictionary(Of String, String) = Nothing) As SearchReply\n")
+	assertContains(t, vb, "        Dim envelope As Envelope(Of SearchReply) = PostJson(Of SearchRequest, Envelope(Of SearchReply))(\"/search/index/v1\", request, timeoutMs, authHeaders)\n")
+	assertContains(t, vb, "        Dim envelope As Envelope(Of SearchReply) = GetJson(Of Envelope(Of SearchReply))(\"/search/search/v1\", query, timeoutMs, authHeaders)\n")
+	assertContains(t, vb, "        Return envelope.Data\n")
+}
+
+func TestResponseEnvelopeOffByDefault(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net45"})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, "Return Await PostJsonAsync(Of SearchRequest, SearchReply)(")
+	assertNotContains(t, vb, "Envelope")
+}
+
+func TestResponseEnvelopeWithSharedUtility(t *testing.T) {
+	proto := testGetProto()
+	proto.UseSharedUtility = true
+	proto.SharedUtilityName = "ApiHttpUtility"
+	proto.SharedUtilityNamespace = "Api"
+	gen := &Generator{FrameworkMode: "net45", ResponseEnvelope: true}
+
+	vb, err := gen.GenerateString(proto)
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	// The envelope lives next to the shared utility, in its namespace
+	assertNotContains(t, vb, "Public Class Envelope(Of T)")
+	assertContains(t, vb, "Dim envelope As Api.Envelope(Of SearchReply) = Await _httpUtility.PostJsonAsync(Of SearchRequest, Api.Envelope(Of SearchReply))(")
+
+	utilityPath := filepath.Join(t.TempDir(), "ApiHttpUtility.vb")
+	if err := gen.GenerateSharedUtility("ApiHttpUtility", "Api", utilityPath); err != nil {
+		t.Fatalf("GenerateSharedUtility() error = %v", err)
+	}
+	utility, err := os.ReadFile(utilityPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	assertContains(t, string(utility), "Public Class Envelope(Of T)")
+}
+
+func TestResponseEnvelopeSkippedWithoutServices(t *testing.T) {
+	vb, err := GenerateString(testInt64Proto(), Options{ResponseEnvelope: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertNotContains(t, vb, "Envelope")
+}
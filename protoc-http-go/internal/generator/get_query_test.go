@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+func testGetProto() *types.ProtoFile {
+	return &types.ProtoFile{
+		FileName: "search.proto",
+		BaseName: "search",
+		Package:  "search",
+		Messages: map[string]*types.ProtoMessage{
+			"SearchRequest": {
+				Name: "SearchRequest",
+				Fields: []*types.ProtoField{
+					{Name: "query", Type: "string"},
+					{Name: "include_archived", Type: "bool"},
+					{Name: "page_size", Type: "int32"},
+					{Name: "min_score", Type: "double"},
+					{Name: "status", Type: "Status"},
+					{Name: "tags", Type: "string", Repeated: true},
+					{Name: "flags", Type: "bool", Repeated: true},
+					{Name: "filter", Type: "Filter"},
+				},
+				NestedMessages: map[string]*types.ProtoMessage{},
+				NestedEnums:    map[string]*types.ProtoEnum{},
+			},
+			"Filter": {
+				Name:           "Filter",
+				Fields:         []*types.ProtoField{{Name: "field", Type: "string"}},
+				NestedMessages: map[string]*types.ProtoMessage{},
+				NestedEnums:    map[string]*types.ProtoEnum{},
+			},
+			"SearchReply": {
+				Name:           "SearchReply",
+				Fields:         []*types.ProtoField{{Name: "results", Type: "string", Repeated: true}},
+				NestedMessages: map[string]*types.ProtoMessage{},
+				NestedEnums:    map[string]*types.ProtoEnum{},
+			},
+		},
+		Enums: map[string]*types.ProtoEnum{
+			"Status": {Name: "Status", Values: map[string]int{"STATUS_UNSPECIFIED": 0, "ACTIVE": 1}},
+		},
+		Services: []*types.ProtoService{
+			{
+				Name: "SearchService",
+				RPCs: []*types.ProtoRPC{
+					{Name: "Search", InputType: "SearchRequest", OutputType: "SearchReply", IsUnary: true, HTTPMethod: "GET"},
+					{Name: "Index", InputType: "SearchRequest", OutputType: "SearchReply", IsUnary: true},
+				},
+			},
+		},
+	}
+}
+
+func TestGetRPCBuildsQueryString(t *testing.T) {
+	content := generateProto(t, testGetProto())
+
+	assertContains(t, content, `Private Async Function GetJsonAsync(Of TResp)(relativePath As String, queryParams As List(Of String), cancellationToken As CancellationToken, Optional timeoutMs As Integer? = Nothing) As Task(Of TResp)`)
+	assertContains(t, content, `Dim response As HttpResponseMessage = Await Me._httpClient.GetAsync(url, combined.Token).ConfigureAwait(False)`)
+	assertContains(t, content, `Dim query As New List(Of String)()`)
+	assertContains(t, content, `If Not String.IsNullOrEmpty(request.Query) Then query.Add("query=" & Uri.EscapeDataString(request.Query))`)
+	assertContains(t, content, `If request.IncludeArchived Then query.Add("includeArchived=" & "true")`)
+	assertContains(t, content, `If request.PageSize <> 0 Then query.Add("pageSize=" & request.PageSize.ToString(Globalization.CultureInfo.InvariantCulture))`)
+	assertContains(t, content, `If request.MinScore <> 0 Then query.Add("minScore=" & request.MinScore.ToString("R", Globalization.CultureInfo.InvariantCulture))`)
+	assertContains(t, content, `If CInt(request.Status) <> 0 Then query.Add("status=" & Uri.EscapeDataString(request.Status.ToString().Substring(7)))`)
+	assertContains(t, content, `query.Add("tags=" & Uri.EscapeDataString(item))`)
+	assertContains(t, content, `query.Add("flags=" & If(item, "true", "false"))`)
+	assertContains(t, content, `' filter (Filter) cannot be sent as a query parameter`)
+	assertContains(t, content, `Return Await GetJsonAsync(Of SearchReply)("/search/search/v1", query, cancellationToken, timeoutMs).ConfigureAwait(False)`)
+
+	// Non-annotated RPCs keep POST semantics
+	assertContains(t, content, `Return Await PostJsonAsync(Of SearchRequest, SearchReply)("/search/index/v1", request, cancellationToken, timeoutMs).ConfigureAwait(False)`)
+}
+
+func TestPostOnlyServiceSkipsGetHelper(t *testing.T) {
+	proto := testGetProto()
+	proto.Services[0].RPCs = proto.Services[0].RPCs[1:]
+	content := generateProto(t, proto)
+
+	assertNotContains(t, content, `GetJsonAsync`)
+	assertNotContains(t, content, `Dim query As New List(Of String)()`)
+}
+
+func TestGetRPCNet40HWR(t *testing.T) {
+	tmpDir := t.TempDir()
+	outPath := filepath.Join(tmpDir, "search.vb")
+	gen := &Generator{FrameworkMode: "net40hwr"}
+	if err := gen.GenerateFile(testGetProto(), outPath); err != nil {
+		t.Fatalf("GenerateFile() error = %v", err)
+	}
+	content := readFile(t, outPath)
+
+	assertContains(t, content, `Private Function GetJson(Of TResp)(relativePath As String, queryParams As List(Of String), Optional timeoutMs As Integer? = Nothing, Optional authHeaders As Dictionary(Of String, String) = Nothing) As TResp`)
+	assertContains(t, content, `req.Method = "GET"`)
+	assertContains(t, content, `If request.IncludeArchived Then query.Add("includeArchived=" & "true")`)
+	assertContains(t, content, `Return GetJson(Of SearchReply)("/search/search/v1", query, timeoutMs, authHeaders)`)
+}
+
+func TestSharedUtilityEmitsGetHelpers(t *testing.T) {
+	for _, mode := range []string{"net45", "net40hwr"} {
+		t.Run(mode, func(t *testing.T) {
+			utilityPath := filepath.Join(t.TempDir(), "SharedHttpUtility.vb")
+			gen := &Generator{FrameworkMode: mode}
+			if err := gen.GenerateSharedUtility("SharedHttpUtility", "Shared", utilityPath); err != nil {
+				t.Fatalf("GenerateSharedUtility() error = %v", err)
+			}
+			content := readFile(t, utilityPath)
+			if mode == "net45" {
+				assertContains(t, content, `Public Async Function GetJsonAsync(Of TResp)`)
+				assertContains(t, content, `Await _http.GetAsync(url, combined.Token)`)
+			} else {
+				assertContains(t, content, `Public Function GetJson(Of TResp)`)
+			}
+			assertContains(t, content, `String.Format("{0}/{1}", _baseUrl, relativePath.TrimStart("/"c))`)
+		})
+	}
+
+	proto := testGetProto()
+	proto.UseSharedUtility = true
+	proto.SharedUtilityName = "SharedHttpUtility"
+	content := generateProto(t, proto)
+	assertContains(t, content, `Return Await _httpUtility.GetJsonAsync(Of SearchReply)("/search/search/v1", query, cancellationToken, timeoutMs).ConfigureAwait(False)`)
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return string(content)
+}
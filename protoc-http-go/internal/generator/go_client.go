@@ -0,0 +1,347 @@
+package generator
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// goRawJSONType is used for fields whose type is declared in another proto file;
+// the value is kept as raw JSON because the Go type is not generated here.
+const goRawJSONType = "json.RawMessage"
+
+// GoPackageName returns the Go package name for the generated client:
+// the last segment of the proto package, or the file base name when there is none.
+func GoPackageName(protoFile *types.ProtoFile) string {
+	name := protoFile.BaseName
+	if protoFile.Package != "" {
+		parts := strings.Split(protoFile.Package, ".")
+		name = parts[len(parts)-1]
+	}
+
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 || sb.String()[0] >= '0' && sb.String()[0] <= '9' {
+		return "pb" + sb.String()
+	}
+	return sb.String()
+}
+
+// GenerateGoFile generates a Go HTTP client (message structs plus one client type per
+// service) for the given proto file. The output is gofmt-formatted.
+func (g *Generator) GenerateGoFile(protoFile *types.ProtoFile, outputPath string) error {
+	content, err := g.generateGoSource(protoFile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return g.writeOutput(outputPath, content)
+}
+
+// generateGoSource renders and formats the Go client source for protoFile
+func (g *Generator) generateGoSource(protoFile *types.ProtoFile) ([]byte, error) {
+	var body strings.Builder
+
+	for _, name := range sortedKeys(protoFile.Enums) {
+		g.generateGoEnum(&body, protoFile.Enums[name], name)
+	}
+	for _, name := range sortedKeys(protoFile.Messages) {
+		g.generateGoMessage(&body, protoFile, protoFile.Messages[name], []string{name})
+	}
+
+	needsURL := false
+	for _, service := range protoFile.Services {
+		if serviceHasGetRPC(service) {
+			needsURL = true
+		}
+		g.generateGoServiceClient(&body, protoFile, service)
+	}
+
+	// Imports depend on what the body ended up using
+	var imports []string
+	hasClients := strings.Contains(body.String(), "http.Client")
+	if hasClients {
+		imports = append(imports, "bytes", "context", "encoding/json", "fmt", "io", "net/http")
+		if needsURL {
+			imports = append(imports, "net/url")
+		}
+	} else if strings.Contains(body.String(), goRawJSONType) {
+		imports = append(imports, "encoding/json")
+	}
+	sort.Strings(imports)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// Code generated by protoc-http-go from %s. DO NOT EDIT.\n\n", filepath.Base(protoFile.FileName))
+	fmt.Fprintf(&sb, "package %s\n\n", GoPackageName(protoFile))
+	if len(imports) > 0 {
+		sb.WriteString("import (\n")
+		for _, imp := range imports {
+			fmt.Fprintf(&sb, "\t%q\n", imp)
+		}
+		sb.WriteString(")\n\n")
+	}
+	sb.WriteString(body.String())
+
+	formatted, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated Go code for %s: %w", protoFile.FileName, err)
+	}
+	return formatted, nil
+}
+
+// generateGoEnum emits a string-backed Go enum; proto3 JSON encodes enums by value name
+func (g *Generator) generateGoEnum(sb *strings.Builder, enum *types.ProtoEnum, goName string) {
+	fmt.Fprintf(sb, "// %s represents the %s enum from the proto definition\n", goName, enum.Name)
+	fmt.Fprintf(sb, "type %s string\n\n", goName)
+
+	names := make([]string, 0, len(enum.Values))
+	for name := range enum.Values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if enum.Values[names[i]] != enum.Values[names[j]] {
+			return enum.Values[names[i]] < enum.Values[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	sb.WriteString("const (\n")
+	for _, name := range names {
+		fmt.Fprintf(sb, "\t%s_%s %s = %q\n", goName, name, goName, name)
+	}
+	sb.WriteString(")\n\n")
+}
+
+// generateGoMessage emits a Go struct for a message; nested types become Outer_Inner
+func (g *Generator) generateGoMessage(sb *strings.Builder, protoFile *types.ProtoFile, message *types.ProtoMessage, path []string) {
+	goName := strings.Join(path, "_")
+
+	fmt.Fprintf(sb, "// %s represents the %s message from the proto definition\n", goName, strings.Join(path, "."))
+	fmt.Fprintf(sb, "type %s struct {\n", goName)
+	for _, field := range message.Fields {
+		goType := g.goFieldType(protoFile, path, field)
+		tag := types.FieldJSONName(field, message.Name) + ",omitempty"
+		if !field.Repeated && (goType == "int64" || goType == "uint64") {
+			// proto3 JSON encodes 64-bit integers as strings
+			tag += ",string"
+		}
+		fmt.Fprintf(sb, "\t%s %s `json:\"%s\"`\n", types.GoFieldName(field.Name), goType, tag)
+	}
+	sb.WriteString("}\n\n")
+
+	for _, name := range sortedKeys(message.NestedEnums) {
+		g.generateGoEnum(sb, message.NestedEnums[name], goName+"_"+name)
+	}
+	for _, name := range sortedKeys(message.NestedMessages) {
+		g.generateGoMessage(sb, protoFile, message.NestedMessages[name], append(append([]string{}, path...), name))
+	}
+}
+
+// goFieldType maps a proto field to its Go type, resolving message and enum
+// references relative to the enclosing message path
+func (g *Generator) goFieldType(protoFile *types.ProtoFile, scope []string, field *types.ProtoField) string {
+	elem := g.goTypeRef(protoFile, scope, field.Type)
+	if field.Repeated {
+		return "[]" + elem
+	}
+	return elem
+}
+
+// goTypeRef returns the Go type for a proto type name: scalars map directly,
+// local messages become pointers, local enums their generated type, and anything
+// declared elsewhere is kept as raw JSON.
+func (g *Generator) goTypeRef(protoFile *types.ProtoFile, scope []string, protoType string) string {
+	if goType, ok := types.GoTypeMappings[protoType]; ok {
+		return goType
+	}
+	if path, isEnum, ok := resolveLocalType(protoFile, scope, protoType); ok {
+		if isEnum {
+			return strings.Join(path, "_")
+		}
+		return "*" + strings.Join(path, "_")
+	}
+	return goRawJSONType
+}
+
+// resolveLocalType resolves a (possibly dotted) type reference against the scopes
+// enclosing the referencing message, innermost first, following protobuf scoping rules.
+// Absolute references (".pkg.Type") are resolved from the file scope only.
+// It returns the full message path of the type and whether it is an enum.
+func resolveLocalType(protoFile *types.ProtoFile, scope []string, protoType string) ([]string, bool, bool) {
+	innermost := len(scope)
+	if strings.HasPrefix(protoType, ".") {
+		protoType = protoType[1:]
+		innermost = 0
+	}
+	if protoFile.Package != "" {
+		protoType = strings.TrimPrefix(protoType, protoFile.Package+".")
+	}
+	parts := strings.Split(protoType, ".")
+
+	for depth := innermost; depth >= 0; depth-- {
+		candidate := append(append([]string{}, scope[:depth]...), parts...)
+		if isEnum, ok := lookupLocalType(protoFile, candidate); ok {
+			return candidate, isEnum, true
+		}
+	}
+	return nil, false, false
+}
+
+// lookupLocalType reports whether path names a message or enum declared in protoFile
+func lookupLocalType(protoFile *types.ProtoFile, path []string) (isEnum bool, ok bool) {
+	if len(path) == 1 {
+		if _, ok := protoFile.Enums[path[0]]; ok {
+			return true, true
+		}
+	}
+	message, found := protoFile.Messages[path[0]]
+	if !found {
+		return false, false
+	}
+	for i, name := range path[1:] {
+		if i == len(path)-2 {
+			if _, ok := message.NestedEnums[name]; ok {
+				return true, true
+			}
+		}
+		if message, found = message.NestedMessages[name]; !found {
+			return false, false
+		}
+	}
+	return false, true
+}
+
+// generateGoServiceClient emits a client struct, constructors and one method per unary RPC
+func (g *Generator) generateGoServiceClient(sb *strings.Builder, protoFile *types.ProtoFile, service *types.ProtoService) {
+	clientName := service.Name + "Client"
+
+	fmt.Fprintf(sb, "// %s is an HTTP client for the %s service\n", clientName, service.Name)
+	fmt.Fprintf(sb, "type %s struct {\n\tBaseURL string\n\tHTTPClient *http.Client\n}\n\n", clientName)
+
+	fmt.Fprintf(sb, "// New%s creates a new %s with the given base URL\n", clientName, clientName)
+	fmt.Fprintf(sb, "func New%s(baseURL string) *%s {\n", clientName, clientName)
+	fmt.Fprintf(sb, "\treturn New%sWithClient(baseURL, &http.Client{})\n}\n\n", clientName)
+
+	fmt.Fprintf(sb, "// New%sWithClient creates a new %s with a custom HTTP client\n", clientName, clientName)
+	fmt.Fprintf(sb, "func New%sWithClient(baseURL string, httpClient *http.Client) *%s {\n", clientName, clientName)
+	if g.BaseURL != "" {
+		// --baseurl becomes the default when the caller passes an empty base URL
+		fmt.Fprintf(sb, "\tif baseURL == \"\" {\n\t\tbaseURL = %q\n\t}\n", g.BaseURL)
+	}
+	fmt.Fprintf(sb, "\treturn &%s{\n\t\tBaseURL: baseURL,\n\t\tHTTPClient: httpClient,\n\t}\n}\n\n", clientName)
+
+	for _, rpc := range service.RPCs {
+		if rpc.IsUnary {
+			g.generateGoRPCMethod(sb, protoFile, service, clientName, rpc)
+		}
+	}
+}
+
+// generateGoRPCMethod emits a client method that POSTs JSON (or GETs with query
+// parameters for GET-annotated RPCs) and decodes the JSON response
+func (g *Generator) generateGoRPCMethod(sb *strings.Builder, protoFile *types.ProtoFile, service *types.ProtoService, clientName string, rpc *types.ProtoRPC) {
+	inputType := strings.TrimPrefix(g.goTypeRef(protoFile, nil, rpc.InputType), "*")
+	outputType := strings.TrimPrefix(g.goTypeRef(protoFile, nil, rpc.OutputType), "*")
+	relativePath := g.routePath(protoFile, service, rpc)
+
+	fmt.Fprintf(sb, "// %s calls the %s RPC method\n", rpc.Name, rpc.Name)
+	fmt.Fprintf(sb, "func (c *%s) %s(ctx context.Context, req *%s) (*%s, error) {\n", clientName, rpc.Name, inputType, outputType)
+
+	if rpc.IsGet() {
+		sb.WriteString("\tif req == nil {\n\t\treturn nil, fmt.Errorf(\"request must not be nil\")\n\t}\n\n")
+		sb.WriteString("\t// Encode request fields as query parameters\n")
+		g.generateGoQueryParams(sb, protoFile, rpc)
+		fmt.Fprintf(sb, "\tendpoint := c.BaseURL + %q\n", relativePath)
+		sb.WriteString("\tif len(query) > 0 {\n\t\tendpoint += \"?\" + query.Encode()\n\t}\n")
+		sb.WriteString("\thttpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)\n")
+		sb.WriteString("\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"failed to create HTTP request: %w\", err)\n\t}\n\n")
+	} else {
+		sb.WriteString("\t// Serialize request to JSON\n")
+		sb.WriteString("\treqJSON, err := json.Marshal(req)\n")
+		sb.WriteString("\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"failed to marshal request: %w\", err)\n\t}\n\n")
+		sb.WriteString("\t// Create HTTP request\n")
+		fmt.Fprintf(sb, "\tendpoint := c.BaseURL + %q\n", relativePath)
+		sb.WriteString("\thttpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqJSON))\n")
+		sb.WriteString("\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"failed to create HTTP request: %w\", err)\n\t}\n")
+		sb.WriteString("\thttpReq.Header.Set(\"Content-Type\", \"application/json\")\n\n")
+	}
+
+	sb.WriteString("\thttpReq.Header.Set(\"Accept\", \"application/json\")\n\n")
+	sb.WriteString("\t// Make HTTP request\n")
+	sb.WriteString("\tresp, err := c.HTTPClient.Do(httpReq)\n")
+	sb.WriteString("\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"HTTP request failed: %w\", err)\n\t}\n")
+	sb.WriteString("\tdefer resp.Body.Close()\n\n")
+	sb.WriteString("\trespBody, err := io.ReadAll(resp.Body)\n")
+	sb.WriteString("\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"failed to read response body: %w\", err)\n\t}\n")
+	sb.WriteString("\tif resp.StatusCode != http.StatusOK {\n")
+	sb.WriteString("\t\treturn nil, fmt.Errorf(\"HTTP request failed with status %d: %s\", resp.StatusCode, string(respBody))\n\t}\n\n")
+	sb.WriteString("\t// Deserialize response\n")
+	fmt.Fprintf(sb, "\tvar response %s\n", outputType)
+	sb.WriteString("\tif err := json.Unmarshal(respBody, &response); err != nil {\n")
+	sb.WriteString("\t\treturn nil, fmt.Errorf(\"failed to unmarshal response: %w\", err)\n\t}\n")
+	sb.WriteString("\treturn &response, nil\n}\n\n")
+}
+
+// generateGoQueryParams emits statements building a url.Values named "query" from
+// the request's scalar and enum fields, skipping default values like the VB generator
+func (g *Generator) generateGoQueryParams(sb *strings.Builder, protoFile *types.ProtoFile, rpc *types.ProtoRPC) {
+	sb.WriteString("\tquery := url.Values{}\n")
+
+	message := findMessage(protoFile, rpc.InputType)
+	if message == nil {
+		return
+	}
+	scope := strings.Split(rpc.InputType, ".")
+
+	for _, field := range message.Fields {
+		key := types.FieldJSONName(field, message.Name)
+		property := "req." + types.GoFieldName(field.Name)
+		goType := g.goTypeRef(protoFile, scope, field.Type)
+
+		var valueExpr, zeroCheck string
+		switch {
+		case goType == "string":
+			valueExpr, zeroCheck = "%s", "%s != \"\""
+		case goType == "bool":
+			valueExpr, zeroCheck = "fmt.Sprint(%s)", "%s"
+		case goType == "[]byte" || goType == goRawJSONType || strings.HasPrefix(goType, "*"):
+			fmt.Fprintf(sb, "\t// %s (%s) cannot be sent as a query parameter\n", key, field.Type)
+			continue
+		case types.GoTypeMappings[field.Type] != "":
+			valueExpr, zeroCheck = "fmt.Sprint(%s)", "%s != 0"
+		default:
+			// Enum: the generated type is a string holding the value name
+			valueExpr, zeroCheck = "string(%s)", "%s != \"\""
+		}
+
+		if field.Repeated {
+			fmt.Fprintf(sb, "\tfor _, item := range %s {\n", property)
+			fmt.Fprintf(sb, "\t\tquery.Add(%q, "+valueExpr+")\n\t}\n", key, "item")
+			continue
+		}
+		fmt.Fprintf(sb, "\tif "+zeroCheck+" {\n", property)
+		fmt.Fprintf(sb, "\t\tquery.Set(%q, "+valueExpr+")\n\t}\n", key, property)
+	}
+	sb.WriteString("\n")
+}
+
+// sortedKeys returns the keys of m in sorted order for deterministic output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
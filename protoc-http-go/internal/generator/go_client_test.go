@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+func typeCheckGo(t *testing.T, src []byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated Go does not parse: %v\n%s", err, src)
+	}
+	conf := gotypes.Config{Importer: importer.Default()}
+	if _, err := conf.Check("generated", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated Go does not type-check: %v\n%s", err, src)
+	}
+}
+
+func TestGoClientPostAndGet(t *testing.T) {
+	gen := &Generator{BaseURL: "http://localhost:8080"}
+	src, err := gen.generateGoSource(testGetProto())
+	if err != nil {
+		t.Fatalf("generateGoSource() error = %v", err)
+	}
+	typeCheckGo(t, src)
+	content := string(src)
+
+	assertContains(t, content, "package search")
+	assertContains(t, content, `"net/url"`)
+	assertContains(t, content, "type Status string")
+	assertContains(t, content, `Status_ACTIVE             Status = "ACTIVE"`)
+	assertContains(t, content, "Status          Status   `json:\"status,omitempty\"`")
+	assertContains(t, content, "Filter          *Filter  `json:\"filter,omitempty\"`")
+	assertContains(t, content, `baseURL = "http://localhost:8080"`)
+
+	// GET RPC encodes fields as query parameters
+	assertContains(t, content, "func (c *SearchServiceClient) Search(ctx context.Context, req *SearchRequest) (*SearchReply, error) {")
+	assertContains(t, content, `query.Set("includeArchived", fmt.Sprint(req.IncludeArchived))`)
+	assertContains(t, content, `query.Set("status", string(req.Status))`)
+	assertContains(t, content, `query.Add("tags", item)`)
+	assertContains(t, content, "// filter (Filter) cannot be sent as a query parameter")
+	assertContains(t, content, `endpoint := c.BaseURL + "/search/search/v1"`)
+	assertContains(t, content, "http.MethodGet, endpoint, nil)")
+
+	// POST RPC sends a JSON body
+	assertContains(t, content, `endpoint := c.BaseURL + "/search/index/v1"`)
+	assertContains(t, content, "http.MethodPost, endpoint, bytes.NewReader(reqJSON))")
+}
+
+func TestGoClientResolvesNestedAndExternalTypes(t *testing.T) {
+	proto := &types.ProtoFile{
+		FileName: "nested.proto",
+		BaseName: "nested",
+		Package:  "demo.nested",
+		Messages: map[string]*types.ProtoMessage{
+			"Outer": {
+				Name: "Outer",
+				Fields: []*types.ProtoField{
+					{Name: "inner", Type: "Inner"},
+					{Name: "kind", Type: "Kind"},
+					{Name: "ticker", Type: "common.Ticker"},
+					{Name: "total", Type: "int64"},
+				},
+				NestedMessages: map[string]*types.ProtoMessage{
+					"Inner": {Name: "Inner", Fields: []*types.ProtoField{{Name: "name", Type: "string"}}},
+				},
+				NestedEnums: map[string]*types.ProtoEnum{
+					"Kind": {Name: "Kind", Values: map[string]int{"KIND_UNSPECIFIED": 0}},
+				},
+			},
+			"UsesNested": {
+				Name:   "UsesNested",
+				Fields: []*types.ProtoField{{Name: "values", Type: "demo.nested.Outer.Inner", Repeated: true}},
+			},
+		},
+		Enums: map[string]*types.ProtoEnum{},
+	}
+
+	src, err := (&Generator{}).generateGoSource(proto)
+	if err != nil {
+		t.Fatalf("generateGoSource() error = %v", err)
+	}
+	typeCheckGo(t, src)
+	content := string(src)
+
+	assertContains(t, content, "package nested")
+	assertContains(t, content, "Inner  *Outer_Inner    `json:\"inner,omitempty\"`")
+	assertContains(t, content, "Kind   Outer_Kind      `json:\"kind,omitempty\"`")
+	assertContains(t, content, "Ticker json.RawMessage `json:\"ticker,omitempty\"`")
+	assertContains(t, content, "Total  int64           `json:\"total,omitempty,string\"`")
+	assertContains(t, content, "Values []*Outer_Inner `json:\"values,omitempty\"`")
+	assertNotContains(t, content, "net/http")
+}
@@ -2,7 +2,7 @@ package generator
 
 import (
 	"fmt"
-	"os"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -24,10 +24,75 @@ type Generator struct {
 	PackageOverride string
 	BaseURL         string
 	FrameworkMode   string // "net45" or "net40hwr"
+	Partial         bool   // Declare message classes "Partial" so hand-written partial classes can extend them
+	Sealed          bool   // Declare message classes "NotInheritable"; mutually exclusive with Partial
+	Equality        bool   // Override Equals and GetHashCode on message classes to compare every property
+	// Deserialize responses into Envelope(Of TResponse) and return its Data, for
+	// backends that wrap every response as { "data": ..., "meta": ... }
+	ResponseEnvelope bool
+	// Send the caller's timeout (timeoutMs, or the HttpClient/HttpWebRequest timeout)
+	// as the X-Timeout-Ms header so the proxy gives the backend call the same deadline
+	TimeoutHeader bool
+	// Give net40hwr clients constructor parameters for a default request timeout and
+	// for sending request bodies chunked instead of with a Content-Length
+	RequestSettings bool
+	// Give repeated fields of a map entry message ("message Entry { K key = 1; V value = 2; }")
+	// a Dictionary(Of K, V) property, and the entry class conversion helpers
+	SynthesizeMaps bool
+	// Leave existing output files alone: the Generate*File methods return
+	// ErrOutputExists instead of replacing them
+	NoOverwrite bool
+	// Drop the enum-name prefix of values from VB enum members, giving Color_RED
+	// instead of Color_COLOR_RED; enums whose values do not all carry it are unchanged
+	StripEnumPrefix bool
+	// Where the URL version goes in the routes the clients call: VersionSuffix
+	// (the default when empty) or VersionPrefix
+	VersionPlacement string
+	// Follow each VB service client with <Service>ClientMock, which has the same
+	// methods answered by settable handler delegates, for unit tests
+	EmitMock bool
+}
+
+// Values of Generator.VersionPlacement
+const (
+	VersionSuffix = "suffix" // /<base>/<path>/<version>, the routes the proxy serves
+	VersionPrefix = "prefix" // /<version>/<base>/<path>, for gateways that route by version first
+)
+
+// Options configures GenerateString; it carries the same settings as a Generator
+type Options = Generator
+
+// GenerateString returns the VB.NET source for the given proto file without touching
+// the file system, for embedding the generator in other tools
+func GenerateString(protoFile *types.ProtoFile, opts Options) (string, error) {
+	return opts.GenerateString(protoFile)
 }
 
 // GenerateFile generates a complete VB.NET file for the given proto file
 func (g *Generator) GenerateFile(protoFile *types.ProtoFile, outputPath string) error {
+	source, err := g.GenerateString(protoFile)
+	if err != nil {
+		return err
+	}
+	return g.writeOutput(outputPath, []byte(source))
+}
+
+// GenerateString returns the complete VB.NET source for the given proto file.
+// An empty FrameworkMode means net45.
+func (g *Generator) GenerateString(protoFile *types.ProtoFile) (string, error) {
+	if protoFile == nil {
+		return "", fmt.Errorf("proto file is nil")
+	}
+	if g.FrameworkMode != "" && g.FrameworkMode != "net45" && g.FrameworkMode != "net40hwr" {
+		return "", fmt.Errorf("unsupported framework mode %q (expected net45 or net40hwr)", g.FrameworkMode)
+	}
+	if g.Partial && g.Sealed {
+		return "", fmt.Errorf("partial and sealed message classes are mutually exclusive")
+	}
+	if g.VersionPlacement != "" && g.VersionPlacement != VersionSuffix && g.VersionPlacement != VersionPrefix {
+		return "", fmt.Errorf("unsupported version placement %q (expected suffix or prefix)", g.VersionPlacement)
+	}
+
 	var sb strings.Builder
 
 	// Determine namespace name
@@ -37,20 +102,25 @@ func (g *Generator) GenerateFile(protoFile *types.ProtoFile, outputPath string)
 	sb.WriteString("Option Strict On\n")
 	sb.WriteString("Option Explicit On\n")
 	sb.WriteString("Option Infer On\n\n")
-	g.generateImports(&sb)
+	g.generateImports(&sb, types.ProtoHasValidation(protoFile))
 
 	sb.WriteString(fmt.Sprintf("Namespace %s\n\n", namespace))
 
 	// Generate enums
-	for _, enum := range protoFile.Enums {
+	for _, enum := range enumsInOrder(protoFile.Enums) {
 		g.generateEnum(&sb, enum)
 		sb.WriteString("\n")
 	}
 
 	// Generate messages (including nested)
 	bytesConverterType := g.bytesConverterTypeName(protoFile, namespace)
-	for _, message := range protoFile.Messages {
-		g.generateMessage(&sb, message, "", bytesConverterType)
+	int64ConverterType := g.helperTypeName(protoFile, namespace, "Int64StringConverter")
+	var maps synthesizedMaps
+	if g.SynthesizeMaps {
+		maps = g.findSynthesizedMaps(protoFile)
+	}
+	for _, message := range messagesInOrder(protoFile.Messages) {
+		g.generateMessage(&sb, protoFile, message, "", bytesConverterType, int64ConverterType, maps)
 		sb.WriteString("\n")
 	}
 
@@ -59,29 +129,41 @@ func (g *Generator) GenerateFile(protoFile *types.ProtoFile, outputPath string)
 		if protoFile.UseSharedUtility {
 			// Use shared utility
 			if g.FrameworkMode == "net40hwr" {
-				g.generateServiceClientNet40HWRWithSharedUtility(&sb, service, protoFile.BaseName, protoFile.SharedUtilityName)
+				g.generateServiceClientNet40HWRWithSharedUtility(&sb, service, protoFile, protoFile.SharedUtilityName)
 			} else {
-				g.generateServiceClientNet45WithSharedUtility(&sb, service, protoFile.BaseName, protoFile.SharedUtilityName)
+				g.generateServiceClientNet45WithSharedUtility(&sb, service, protoFile, protoFile.SharedUtilityName)
 			}
 		} else {
 			// Use embedded PostJson
 			if g.FrameworkMode == "net40hwr" {
-				g.generateServiceClientNet40HWR(&sb, service, protoFile.BaseName)
+				g.generateServiceClientNet40HWR(&sb, service, protoFile)
 			} else {
-				g.generateServiceClientNet45(&sb, service, protoFile.BaseName)
+				g.generateServiceClientNet45(&sb, service, protoFile)
 			}
 		}
 		sb.WriteString("\n")
+		if g.EmitMock {
+			g.generateServiceMock(&sb, service)
+			sb.WriteString("\n")
+		}
 	}
 
 	if !protoFile.UseSharedUtility && types.ProtoHasBytesField(protoFile) {
 		emitBytesHelpers(&sb, "")
 	}
+	if !protoFile.UseSharedUtility && types.ProtoHasInt64Field(protoFile) {
+		emitInt64Helpers(&sb, "")
+	}
+	if !protoFile.UseSharedUtility && len(protoFile.Services) > 0 {
+		emitAPIException(&sb, "", g.FrameworkMode)
+	}
+	if !protoFile.UseSharedUtility && g.ResponseEnvelope && len(protoFile.Services) > 0 {
+		emitEnvelopeClass(&sb, "")
+	}
 
 	sb.WriteString("End Namespace\n")
 
-	// Write to file
-	return os.WriteFile(outputPath, []byte(sb.String()), 0644)
+	return sb.String(), nil
 }
 
 // determinePackageName determines the VB.NET namespace name based on the proto package or file name
@@ -109,32 +191,71 @@ func (g *Generator) determinePackageName(protoFile *types.ProtoFile) string {
 func (g *Generator) generateEnum(sb *strings.Builder, enum *types.ProtoEnum) {
 	fmt.Fprintf(sb, "' %s represents the %s enum from the proto definition\n", enum.Name, enum.Name)
 	fmt.Fprintf(sb, "Public Enum %s As Integer\n", enum.Name)
-	for value, num := range enum.Values {
-		fmt.Fprintf(sb, "    %s_%s = %d\n", enum.Name, value, num)
+	for _, value := range enumValuesInOrder(enum) {
+		fmt.Fprintf(sb, "    %s = %d\n", g.vbEnumMember(enum, value), enum.Values[value])
 	}
 	sb.WriteString("End Enum\n")
 }
 
+// vbEnumMember returns the VB member of an enum value, <Enum>_<VALUE>, with the
+// prefix of strippedEnumPrefix removed from the value
+func (g *Generator) vbEnumMember(enum *types.ProtoEnum, value string) string {
+	return enum.Name + "_" + strings.TrimPrefix(value, g.strippedEnumPrefix(enum))
+}
+
+// strippedEnumPrefix returns the prefix removed from the values of enum in VB
+// member names: types.EnumValuePrefix with StripEnumPrefix, otherwise ""
+func (g *Generator) strippedEnumPrefix(enum *types.ProtoEnum) string {
+	if !g.StripEnumPrefix {
+		return ""
+	}
+	return types.EnumValuePrefix(enum)
+}
+
+// routePath returns the URL path the clients call for rpc: the proto base name, the
+// kebab-case method name and the version, which goes first with VersionPrefix
+func (g *Generator) routePath(protoFile *types.ProtoFile, service *types.ProtoService, rpc *types.ProtoRPC) string {
+	baseName, version := service.RPCNameAndVersion(rpc)
+	if g.VersionPlacement == VersionPrefix {
+		return fmt.Sprintf("/%s/%s/%s", version, protoFile.BaseName, types.KebabCase(baseName))
+	}
+	return fmt.Sprintf("/%s/%s/%s", protoFile.BaseName, types.KebabCase(baseName), version)
+}
+
+// messageClassKeywords returns the declaration keywords for message classes
+func (g *Generator) messageClassKeywords() string {
+	switch {
+	case g.Partial:
+		return "Partial Public Class"
+	case g.Sealed:
+		return "Public NotInheritable Class"
+	default:
+		return "Public Class"
+	}
+}
+
 // generateMessage generates a VB.NET Class for a proto message
-func (g *Generator) generateMessage(sb *strings.Builder, message *types.ProtoMessage, parentName, bytesConverterType string) {
+// Fields and messages found in maps get the dictionary property and conversion helpers.
+func (g *Generator) generateMessage(sb *strings.Builder, protoFile *types.ProtoFile, message *types.ProtoMessage, parentName, bytesConverterType, int64ConverterType string, maps synthesizedMaps) {
 	className := message.Name
 	if parentName != "" {
 		className = fmt.Sprintf("%s_%s", parentName, message.Name)
 	}
 
 	fmt.Fprintf(sb, "' %s represents the %s message from the proto definition\n", className, message.Name)
-	fmt.Fprintf(sb, "Public Class %s\n", className)
+	fmt.Fprintf(sb, "%s %s\n", g.messageClassKeywords(), className)
 
 	// Generate properties
 	for _, field := range message.Fields {
 		vbFieldName := types.EscapeVBIdentifier(types.GoFieldName(field.Name))
-		vbType := g.getGoType(field.Type)
+		vbType := g.propertyElementType(field, maps)
 		// Pass message name for msgHdr special handling
-		jsonTag := types.JSONTagName(field.Name, message.Name)
+		jsonTag := types.FieldJSONName(field, message.Name)
 		if field.Repeated {
 			vbType = fmt.Sprintf("List(Of %s)", vbType)
 		}
-		if field.Type == "bytes" {
+		g.writeValidationAttributes(sb, field)
+		if field.Type == "bytes" && field.TypeOverride == "" {
 			if field.Repeated {
 				fmt.Fprintf(sb, "    <JsonProperty(\"%s\", ItemConverterType:=GetType(%s))>\n", jsonTag, bytesConverterType)
 			} else {
@@ -142,32 +263,150 @@ func (g *Generator) generateMessage(sb *strings.Builder, message *types.ProtoMes
 				fmt.Fprintf(sb, "    <JsonConverter(GetType(%s))>\n", bytesConverterType)
 			}
 			fmt.Fprintf(sb, "    Public Property %s As %s  ' base64 wire / decoded text via ProtoBytesEncoding.Default\n", vbFieldName, vbType)
+		} else if types.NeedsInt64Converter(field) {
+			// Proto JSON sends 64-bit integers as strings
+			if field.Repeated {
+				fmt.Fprintf(sb, "    <JsonProperty(\"%s\", ItemConverterType:=GetType(%s))>\n", jsonTag, int64ConverterType)
+			} else {
+				fmt.Fprintf(sb, "    <JsonProperty(\"%s\")>\n", jsonTag)
+				fmt.Fprintf(sb, "    <JsonConverter(GetType(%s))>\n", int64ConverterType)
+			}
+			fmt.Fprintf(sb, "    Public Property %s As %s%s\n", vbFieldName, vbType, g.vbDefaultInitializer(protoFile, field))
 		} else {
 			fmt.Fprintf(sb, "    <JsonProperty(\"%s\")>\n", jsonTag)
-			fmt.Fprintf(sb, "    Public Property %s As %s\n", vbFieldName, vbType)
+			fmt.Fprintf(sb, "    Public Property %s As %s%s\n", vbFieldName, vbType, g.vbDefaultInitializer(protoFile, field))
 		}
 	}
 
+	for _, field := range message.Fields {
+		if entry, ok := maps.fields[field]; ok {
+			generateDictionaryProperty(sb, types.EscapeVBIdentifier(types.GoFieldName(field.Name)), entry)
+		}
+	}
+	if entry, ok := maps.entries[message]; ok {
+		generateEntryHelpers(sb, entry)
+	}
+
+	if g.Equality {
+		g.generateEquality(sb, message, className, maps)
+	}
+
 	sb.WriteString("End Class\n")
 
 	// Generate nested enums
-	for _, nestedEnum := range message.NestedEnums {
+	for _, nestedEnum := range enumsInOrder(message.NestedEnums) {
 		sb.WriteString("\n")
 		g.generateEnum(sb, nestedEnum)
 	}
 
 	// Generate nested messages recursively
-	for _, nestedMessage := range message.NestedMessages {
+	for _, nestedMessage := range messagesInOrder(message.NestedMessages) {
 		sb.WriteString("\n")
-		g.generateMessage(sb, nestedMessage, className, bytesConverterType)
+		g.generateMessage(sb, protoFile, nestedMessage, className, bytesConverterType, int64ConverterType, maps)
+	}
+}
+
+// fieldElementType returns the VB type of a single value of field, without the
+// List(Of ...) of repeated fields
+func (g *Generator) fieldElementType(field *types.ProtoField) string {
+	if field.TypeOverride != "" {
+		// "// type:" annotation or --type-map entry
+		return field.TypeOverride
+	}
+	return g.getGoType(field.Type)
+}
+
+// propertyElementType returns the element type of the property generated for
+// field: fieldElementType, except that a synthesized map field names its resolved
+// entry class, so a nested entry gets its generated name such as Resource_Entry
+func (g *Generator) propertyElementType(field *types.ProtoField, maps synthesizedMaps) string {
+	if entry, ok := maps.fields[field]; ok {
+		return entry.className
 	}
+	return g.fieldElementType(field)
+}
+
+// generateEquality writes Equals and GetHashCode overrides comparing every
+// property of the message. Lists are compared element by element, with Nothing
+// equal to an empty list as in proto; nested messages use their own overrides.
+// The hash is accumulated in a Long and masked so it cannot overflow, since VB
+// projects check integer overflow by default.
+func (g *Generator) generateEquality(sb *strings.Builder, message *types.ProtoMessage, className string, maps synthesizedMaps) {
+	sb.WriteString("\n")
+	sb.WriteString("    Public Overrides Function Equals(obj As Object) As Boolean\n")
+	fmt.Fprintf(sb, "        Dim other = TryCast(obj, %s)\n", className)
+	sb.WriteString("        If other Is Nothing Then Return False\n")
+	for _, field := range message.Fields {
+		name := types.EscapeVBIdentifier(types.GoFieldName(field.Name))
+		if field.Repeated {
+			empty := fmt.Sprintf("New List(Of %s)()", g.propertyElementType(field, maps))
+			fmt.Fprintf(sb, "        If Not System.Linq.Enumerable.SequenceEqual(If(Me.%s, %s), If(other.%s, %s)) Then Return False\n", name, empty, name, empty)
+		} else {
+			fmt.Fprintf(sb, "        If Not Object.Equals(Me.%s, other.%s) Then Return False\n", name, name)
+		}
+	}
+	sb.WriteString("        Return True\n")
+	sb.WriteString("    End Function\n\n")
+
+	sb.WriteString("    Public Overrides Function GetHashCode() As Integer\n")
+	sb.WriteString("        Dim hash As Long = 17\n")
+	for _, field := range message.Fields {
+		name := types.EscapeVBIdentifier(types.GoFieldName(field.Name))
+		comparer := fmt.Sprintf("EqualityComparer(Of %s).Default", g.propertyElementType(field, maps))
+		if field.Repeated {
+			fmt.Fprintf(sb, "        If Me.%s IsNot Nothing Then\n", name)
+			fmt.Fprintf(sb, "            For Each item In Me.%s\n", name)
+			fmt.Fprintf(sb, "                hash = (hash * 31 + %s.GetHashCode(item)) And &H7FFFFFFFL\n", comparer)
+			sb.WriteString("            Next\n")
+			sb.WriteString("        End If\n")
+		} else {
+			fmt.Fprintf(sb, "        hash = (hash * 31 + %s.GetHashCode(Me.%s)) And &H7FFFFFFFL\n", comparer, name)
+		}
+	}
+	sb.WriteString("        Return CInt(hash)\n")
+	sb.WriteString("    End Function\n")
+}
+
+// messagesInOrder returns the messages in declaration order. The parser keeps
+// messages in maps, so generating straight from them would reorder classes from
+// run to run; messages without a source line (built in code) sort by name.
+func messagesInOrder(messages map[string]*types.ProtoMessage) []*types.ProtoMessage {
+	ordered := make([]*types.ProtoMessage, 0, len(messages))
+	for _, name := range sortedKeys(messages) {
+		ordered = append(ordered, messages[name])
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Line < ordered[j].Line })
+	return ordered
+}
+
+// enumsInOrder returns the enums in declaration order, like messagesInOrder
+func enumsInOrder(enums map[string]*types.ProtoEnum) []*types.ProtoEnum {
+	ordered := make([]*types.ProtoEnum, 0, len(enums))
+	for _, name := range sortedKeys(enums) {
+		ordered = append(ordered, enums[name])
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Line < ordered[j].Line })
+	return ordered
+}
+
+// enumValuesInOrder returns the value names of enum by number, then name for aliases
+func enumValuesInOrder(enum *types.ProtoEnum) []string {
+	names := sortedKeys(enum.Values)
+	sort.SliceStable(names, func(i, j int) bool { return enum.Values[names[i]] < enum.Values[names[j]] })
+	return names
 }
 
 func (g *Generator) bytesConverterTypeName(protoFile *types.ProtoFile, namespace string) string {
+	return g.helperTypeName(protoFile, namespace, "BytesStringConverter")
+}
+
+// helperTypeName qualifies a helper class with the shared utility namespace when
+// the helpers live there rather than in the file's own namespace
+func (g *Generator) helperTypeName(protoFile *types.ProtoFile, namespace, typeName string) string {
 	if protoFile.UseSharedUtility && protoFile.SharedUtilityNamespace != "" && protoFile.SharedUtilityNamespace != namespace {
-		return protoFile.SharedUtilityNamespace + ".BytesStringConverter"
+		return protoFile.SharedUtilityNamespace + "." + typeName
 	}
-	return "BytesStringConverter"
+	return typeName
 }
 
 // getGoType maps proto types to VB.NET types
@@ -189,8 +428,9 @@ func (g *Generator) getGoType(protoType string) string {
 	return protoType
 }
 
-// generateImports generates framework-specific imports
-func (g *Generator) generateImports(sb *strings.Builder) {
+// generateImports generates framework-specific imports, plus DataAnnotations for
+// the attributes of fields with validation rules
+func (g *Generator) generateImports(sb *strings.Builder, dataAnnotations bool) {
 	sb.WriteString("Imports System\n")
 	sb.WriteString("Imports System.Text\n")
 	sb.WriteString("Imports System.Collections.Generic\n")
@@ -208,11 +448,14 @@ func (g *Generator) generateImports(sb *strings.Builder) {
 		sb.WriteString("Imports System.Threading\n")
 		sb.WriteString("Imports System.Threading.Tasks\n")
 	}
+	if dataAnnotations {
+		sb.WriteString("Imports System.ComponentModel.DataAnnotations\n")
+	}
 	sb.WriteString("\n")
 }
 
 // generateServiceClientNet45 generates VB.NET HTTP client for .NET 4.5+ mode
-func (g *Generator) generateServiceClientNet45(sb *strings.Builder, service *types.ProtoService, protoBaseName string) {
+func (g *Generator) generateServiceClientNet45(sb *strings.Builder, service *types.ProtoService, protoFile *types.ProtoFile) {
 	clientName := fmt.Sprintf("%sClient", service.Name)
 
 	fmt.Fprintf(sb, "' %s is an HTTP client for the %s service\n", clientName, service.Name)
@@ -239,10 +482,10 @@ func (g *Generator) generateServiceClientNet45(sb *strings.Builder, service *typ
 	sb.WriteString("                Using combined As CancellationTokenSource = CancellationTokenSource.CreateLinkedTokenSource(cancellationToken, timeoutCts.Token)\n")
 	sb.WriteString("                    effectiveToken = combined.Token\n")
 	sb.WriteString("                    Using content As New StringContent(json, Encoding.UTF8, \"application/json\")\n")
-	sb.WriteString("                        Dim response As HttpResponseMessage = Await Me._httpClient.PostAsync(url, content, effectiveToken).ConfigureAwait(False)\n")
+	fmt.Fprintf(sb, "                        Dim response As HttpResponseMessage = Await %s.ConfigureAwait(False)\n", g.net45Send("Me._httpClient", "Post", "content", "effectiveToken"))
 	sb.WriteString("                        If Not response.IsSuccessStatusCode Then\n")
 	sb.WriteString("                            Dim body As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)\n")
-	sb.WriteString("                            Throw New HttpRequestException($\"Request failed with status {(CInt(response.StatusCode))} ({response.ReasonPhrase}): {body}\")\n")
+	sb.WriteString("                            Throw ApiException.FromResponse(CInt(response.StatusCode), response.ReasonPhrase, body)\n")
 	sb.WriteString("                        End If\n")
 	sb.WriteString("                        Dim respJson As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)\n")
 	sb.WriteString("                        If String.IsNullOrWhiteSpace(respJson) Then\n")
@@ -254,10 +497,10 @@ func (g *Generator) generateServiceClientNet45(sb *strings.Builder, service *typ
 	sb.WriteString("            End Using\n")
 	sb.WriteString("        Else\n")
 	sb.WriteString("            Using content As New StringContent(json, Encoding.UTF8, \"application/json\")\n")
-	sb.WriteString("                Dim response As HttpResponseMessage = Await Me._httpClient.PostAsync(url, content, cancellationToken).ConfigureAwait(False)\n")
+	fmt.Fprintf(sb, "                Dim response As HttpResponseMessage = Await %s.ConfigureAwait(False)\n", g.net45Send("Me._httpClient", "Post", "content", "cancellationToken"))
 	sb.WriteString("                If Not response.IsSuccessStatusCode Then\n")
 	sb.WriteString("                    Dim body As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)\n")
-	sb.WriteString("                    Throw New HttpRequestException($\"Request failed with status {(CInt(response.StatusCode))} ({response.ReasonPhrase}): {body}\")\n")
+	sb.WriteString("                    Throw ApiException.FromResponse(CInt(response.StatusCode), response.ReasonPhrase, body)\n")
 	sb.WriteString("                End If\n")
 	sb.WriteString("                Dim respJson As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)\n")
 	sb.WriteString("                If String.IsNullOrWhiteSpace(respJson) Then\n")
@@ -268,10 +511,21 @@ func (g *Generator) generateServiceClientNet45(sb *strings.Builder, service *typ
 	sb.WriteString("        End If\n")
 	sb.WriteString("    End Function\n\n")
 
+	if g.TimeoutHeader {
+		generateSendWithTimeoutHeaderNet45(sb, "    ", "Me._httpClient")
+		sb.WriteString("\n")
+	}
+
+	// GET helper is only emitted when the service has GET-annotated RPCs
+	if serviceHasGetRPC(service) {
+		g.generateGetJsonNet45(sb, "    ", "Private", "Me._httpClient", "Me.BaseUrl")
+		sb.WriteString("\n")
+	}
+
 	// Generate methods for each RPC
 	for _, rpc := range service.RPCs {
 		if rpc.IsUnary {
-			g.generateRPCMethodNet45(sb, clientName, rpc, protoBaseName)
+			g.generateRPCMethodNet45(sb, service, rpc, protoFile)
 		}
 	}
 
@@ -279,13 +533,12 @@ func (g *Generator) generateServiceClientNet45(sb *strings.Builder, service *typ
 }
 
 // generateRPCMethodNet45 generates a VB.NET Async HTTP client method for .NET 4.5+ mode
-func (g *Generator) generateRPCMethodNet45(sb *strings.Builder, _ string, rpc *types.ProtoRPC, protoBaseName string) {
+func (g *Generator) generateRPCMethodNet45(sb *strings.Builder, service *types.ProtoService, rpc *types.ProtoRPC, protoFile *types.ProtoFile) {
 	methodName := rpc.Name + "Async"
 	inputType := g.getGoType(rpc.InputType)
 	outputType := g.getGoType(rpc.OutputType)
-	baseName, version := types.ParseRPCNameAndVersion(rpc.Name)
-	urlPath := types.KebabCase(baseName)
-	relativePath := fmt.Sprintf("\"/%s/%s/%s\"", protoBaseName, urlPath, version)
+	respType := g.responseType(protoFile, outputType)
+	relativePath := fmt.Sprintf("\"%s\"", g.routePath(protoFile, service, rpc))
 
 	// Overload 1: Simple overload without cancellation token or timeout
 	fmt.Fprintf(sb, "    Public Function %s(request As %s) As Task(Of %s)\n", methodName, inputType, outputType)
@@ -299,23 +552,39 @@ func (g *Generator) generateRPCMethodNet45(sb *strings.Builder, _ string, rpc *t
 
 	// Overload 3: Main implementation with cancellation token and optional timeout
 	fmt.Fprintf(sb, "    Public Async Function %s(request As %s, cancellationToken As CancellationToken, Optional timeoutMs As Integer? = Nothing) As Task(Of %s)\n", methodName, inputType, outputType)
-	fmt.Fprintf(sb, "        Return Await PostJsonAsync(Of %s, %s)(%s, request, cancellationToken, timeoutMs).ConfigureAwait(False)\n", inputType, outputType, relativePath)
+	if rpc.IsGet() {
+		sb.WriteString("        If request Is Nothing Then Throw New ArgumentNullException(NameOf(request))\n")
+		g.generateQueryParams(sb, protoFile, rpc, "        ")
+		g.writeReturn(sb, protoFile, outputType, "        ", fmt.Sprintf("Await GetJsonAsync(Of %s)(%s, query, cancellationToken, timeoutMs).ConfigureAwait(False)", respType, relativePath))
+	} else {
+		g.writeReturn(sb, protoFile, outputType, "        ", fmt.Sprintf("Await PostJsonAsync(Of %s, %s)(%s, request, cancellationToken, timeoutMs).ConfigureAwait(False)", inputType, respType, relativePath))
+	}
 	sb.WriteString("    End Function\n\n")
 }
 
 // generateServiceClientNet40HWR generates VB.NET HTTP client for .NET 4.0 with HttpWebRequest
-func (g *Generator) generateServiceClientNet40HWR(sb *strings.Builder, service *types.ProtoService, protoBaseName string) {
+func (g *Generator) generateServiceClientNet40HWR(sb *strings.Builder, service *types.ProtoService, protoFile *types.ProtoFile) {
 	clientName := fmt.Sprintf("%sClient", service.Name)
 
 	fmt.Fprintf(sb, "' %s is an HTTP client for the %s service\n", clientName, service.Name)
 	fmt.Fprintf(sb, "Public Class %s\n", clientName)
 	sb.WriteString("    Public Property BaseUrl As String\n")
+	if g.RequestSettings {
+		sb.WriteString("    ' Timeout of calls made without a timeoutMs argument; Nothing keeps the HttpWebRequest default\n")
+		sb.WriteString("    Public Property DefaultTimeoutMs As Integer?\n")
+		sb.WriteString("    ' Send request bodies with chunked transfer encoding instead of a Content-Length\n")
+		sb.WriteString("    Public Property SendChunked As Boolean\n")
+	}
 	sb.WriteString("\n")
 
 	// Constructor (no HttpClient injection for net40hwr mode)
-	sb.WriteString("    Public Sub New(baseUrl As String)\n")
+	fmt.Fprintf(sb, "    Public Sub New(baseUrl As String%s)\n", g.requestSettingsParams())
 	sb.WriteString("        If String.IsNullOrWhiteSpace(baseUrl) Then Throw New ArgumentException(\"baseUrl cannot be null or empty\")\n")
 	sb.WriteString("        Me.BaseUrl = baseUrl.TrimEnd(\"/\"c)\n")
+	if g.RequestSettings {
+		sb.WriteString("        Me.DefaultTimeoutMs = defaultTimeoutMs\n")
+		sb.WriteString("        Me.SendChunked = sendChunked\n")
+	}
 	sb.WriteString("    End Sub\n\n")
 
 	// Shared helper method for HttpWebRequest (synchronous) to reduce duplication
@@ -327,8 +596,9 @@ func (g *Generator) generateServiceClientNet40HWR(sb *strings.Builder, service *
 	sb.WriteString("        Dim req As HttpWebRequest = CType(WebRequest.Create(url), HttpWebRequest)\n")
 	sb.WriteString("        req.Method = \"POST\"\n")
 	sb.WriteString("        req.ContentType = \"application/json\"\n")
-	sb.WriteString("        req.ContentLength = data.Length\n")
-	sb.WriteString("        If timeoutMs.HasValue Then req.Timeout = timeoutMs.Value\n")
+	g.writeContentLengthNet40HWR(sb, "        ", "Me.SendChunked")
+	g.writeRequestTimeoutNet40HWR(sb, "        ", "Me.DefaultTimeoutMs")
+	g.writeTimeoutHeaderNet40HWR(sb, "        ")
 	sb.WriteString("        \n")
 	sb.WriteString("        ' Add authorization headers if provided\n")
 	sb.WriteString("        If authHeaders IsNot Nothing Then\n")
@@ -340,7 +610,7 @@ func (g *Generator) generateServiceClientNet40HWR(sb *strings.Builder, service *
 	sb.WriteString("        Using reqStream As Stream = req.GetRequestStream()\n")
 	sb.WriteString("            reqStream.Write(data, 0, data.Length)\n")
 	sb.WriteString("        End Using\n")
-	sb.WriteString("        Using resp As HttpWebResponse = CType(req.GetResponse(), HttpWebResponse)\n")
+	sb.WriteString("        Using resp As HttpWebResponse = ApiException.GetResponseOrThrow(req)\n")
 	sb.WriteString("            Using respStream As Stream = resp.GetResponseStream()\n")
 	sb.WriteString("                Using reader As New StreamReader(respStream, Encoding.UTF8)\n")
 	sb.WriteString("                    Dim respJson As String = reader.ReadToEnd()\n")
@@ -353,10 +623,16 @@ func (g *Generator) generateServiceClientNet40HWR(sb *strings.Builder, service *
 	sb.WriteString("        End Using\n")
 	sb.WriteString("    End Function\n\n")
 
+	// GET helper is only emitted when the service has GET-annotated RPCs
+	if serviceHasGetRPC(service) {
+		g.generateGetJsonNet40HWR(sb, "    ", "Private", "Me.BaseUrl", "Me.DefaultTimeoutMs")
+		sb.WriteString("\n")
+	}
+
 	// Generate methods for each RPC
 	for _, rpc := range service.RPCs {
 		if rpc.IsUnary {
-			g.generateRPCMethodNet40HWR(sb, clientName, rpc, protoBaseName)
+			g.generateRPCMethodNet40HWR(sb, service, rpc, protoFile)
 		}
 	}
 
@@ -364,13 +640,12 @@ func (g *Generator) generateServiceClientNet40HWR(sb *strings.Builder, service *
 }
 
 // generateRPCMethodNet40HWR generates a VB.NET synchronous HTTP client method for .NET 4.0 mode
-func (g *Generator) generateRPCMethodNet40HWR(sb *strings.Builder, _ string, rpc *types.ProtoRPC, protoBaseName string) {
+func (g *Generator) generateRPCMethodNet40HWR(sb *strings.Builder, service *types.ProtoService, rpc *types.ProtoRPC, protoFile *types.ProtoFile) {
 	methodName := rpc.Name
 	inputType := g.getGoType(rpc.InputType)
 	outputType := g.getGoType(rpc.OutputType)
-	baseName, version := types.ParseRPCNameAndVersion(rpc.Name)
-	urlPath := types.KebabCase(baseName)
-	relativePath := fmt.Sprintf("\"/%s/%s/%s\"", protoBaseName, urlPath, version)
+	respType := g.responseType(protoFile, outputType)
+	relativePath := fmt.Sprintf("\"%s\"", g.routePath(protoFile, service, rpc))
 
 	// Overload 1: Simple overload without timeout or auth headers
 	fmt.Fprintf(sb, "    Public Function %s(request As %s) As %s\n", methodName, inputType, outputType)
@@ -379,19 +654,26 @@ func (g *Generator) generateRPCMethodNet40HWR(sb *strings.Builder, _ string, rpc
 
 	// Overload 2: Main implementation with optional timeout and auth headers
 	fmt.Fprintf(sb, "    Public Function %s(request As %s, Optional timeoutMs As Integer? = Nothing, Optional authHeaders As Dictionary(Of String, String) = Nothing) As %s\n", methodName, inputType, outputType)
-	fmt.Fprintf(sb, "        Return PostJson(Of %s, %s)(%s, request, timeoutMs, authHeaders)\n", inputType, outputType, relativePath)
+	if rpc.IsGet() {
+		sb.WriteString("        If request Is Nothing Then Throw New ArgumentNullException(\"request\")\n")
+		g.generateQueryParams(sb, protoFile, rpc, "        ")
+		g.writeReturn(sb, protoFile, outputType, "        ", fmt.Sprintf("GetJson(Of %s)(%s, query, timeoutMs, authHeaders)", respType, relativePath))
+	} else {
+		g.writeReturn(sb, protoFile, outputType, "        ", fmt.Sprintf("PostJson(Of %s, %s)(%s, request, timeoutMs, authHeaders)", inputType, respType, relativePath))
+	}
 	sb.WriteString("    End Function\n\n")
 }
 
-// GenerateSharedUtility generates a standalone HTTP utility class for multiple proto files
-func (g *Generator) GenerateSharedUtility(utilityName, namespace, outputPath string, emitBytesHelpersFlag ...bool) error {
+// GenerateSharedUtility generates a standalone HTTP utility class for multiple proto files.
+// The optional flags emit, in order, the bytes helpers and the 64-bit integer converter.
+func (g *Generator) GenerateSharedUtility(utilityName, namespace, outputPath string, helperFlags ...bool) error {
 	var sb strings.Builder
 
 	// File header
 	sb.WriteString("Option Strict On\n")
 	sb.WriteString("Option Explicit On\n")
 	sb.WriteString("Option Infer On\n\n")
-	g.generateImports(&sb)
+	g.generateImports(&sb, false)
 
 	sb.WriteString(fmt.Sprintf("Namespace %s\n\n", namespace))
 	sb.WriteString(fmt.Sprintf("    Public Class %s\n", utilityName))
@@ -403,12 +685,19 @@ func (g *Generator) GenerateSharedUtility(utilityName, namespace, outputPath str
 	}
 
 	sb.WriteString("    End Class\n\n")
-	if len(emitBytesHelpersFlag) > 0 && emitBytesHelpersFlag[0] {
+	if len(helperFlags) > 0 && helperFlags[0] {
 		emitBytesHelpers(&sb, "")
 	}
+	if len(helperFlags) > 1 && helperFlags[1] {
+		emitInt64Helpers(&sb, "")
+	}
+	emitAPIException(&sb, "", g.FrameworkMode)
+	if g.ResponseEnvelope {
+		emitEnvelopeClass(&sb, "")
+	}
 	sb.WriteString("End Namespace\n")
 
-	return os.WriteFile(outputPath, []byte(sb.String()), 0644)
+	return g.writeOutput(outputPath, []byte(sb.String()))
 }
 
 // generateSharedUtilityNet45 generates the shared utility class body for NET45 mode
@@ -436,10 +725,10 @@ func (g *Generator) generateSharedUtilityNet45(sb *strings.Builder) {
 	sb.WriteString("                    Using combined As CancellationTokenSource = CancellationTokenSource.CreateLinkedTokenSource(cancellationToken, timeoutCts.Token)\n")
 	sb.WriteString("                        effectiveToken = combined.Token\n")
 	sb.WriteString("                        Using content As New StringContent(json, Encoding.UTF8, \"application/json\")\n")
-	sb.WriteString("                            Dim response As HttpResponseMessage = Await _http.PostAsync(url, content, effectiveToken).ConfigureAwait(False)\n")
+	fmt.Fprintf(sb, "                            Dim response As HttpResponseMessage = Await %s.ConfigureAwait(False)\n", g.net45Send("_http", "Post", "content", "effectiveToken"))
 	sb.WriteString("                            If Not response.IsSuccessStatusCode Then\n")
 	sb.WriteString("                                Dim body As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)\n")
-	sb.WriteString("                                Throw New HttpRequestException($\"Request failed with status {(CInt(response.StatusCode))} ({response.ReasonPhrase}): {body}\")\n")
+	sb.WriteString("                                Throw ApiException.FromResponse(CInt(response.StatusCode), response.ReasonPhrase, body)\n")
 	sb.WriteString("                            End If\n")
 	sb.WriteString("                            Dim respJson As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)\n")
 	sb.WriteString("                            If String.IsNullOrWhiteSpace(respJson) Then\n")
@@ -451,10 +740,10 @@ func (g *Generator) generateSharedUtilityNet45(sb *strings.Builder) {
 	sb.WriteString("                End Using\n")
 	sb.WriteString("            Else\n")
 	sb.WriteString("                Using content As New StringContent(json, Encoding.UTF8, \"application/json\")\n")
-	sb.WriteString("                    Dim response As HttpResponseMessage = Await _http.PostAsync(url, content, cancellationToken).ConfigureAwait(False)\n")
+	fmt.Fprintf(sb, "                    Dim response As HttpResponseMessage = Await %s.ConfigureAwait(False)\n", g.net45Send("_http", "Post", "content", "cancellationToken"))
 	sb.WriteString("                    If Not response.IsSuccessStatusCode Then\n")
 	sb.WriteString("                        Dim body As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)\n")
-	sb.WriteString("                        Throw New HttpRequestException($\"Request failed with status {(CInt(response.StatusCode))} ({response.ReasonPhrase}): {body}\")\n")
+	sb.WriteString("                        Throw ApiException.FromResponse(CInt(response.StatusCode), response.ReasonPhrase, body)\n")
 	sb.WriteString("                    End If\n")
 	sb.WriteString("                    Dim respJson As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)\n")
 	sb.WriteString("                    If String.IsNullOrWhiteSpace(respJson) Then\n")
@@ -463,18 +752,34 @@ func (g *Generator) generateSharedUtilityNet45(sb *strings.Builder) {
 	sb.WriteString("                    Return JsonConvert.DeserializeObject(Of TResp)(respJson)\n")
 	sb.WriteString("                End Using\n")
 	sb.WriteString("            End If\n")
-	sb.WriteString("        End Function\n")
+	sb.WriteString("        End Function\n\n")
+
+	// Public GetJsonAsync method used by GET-annotated RPCs
+	g.generateGetJsonNet45(sb, "        ", "Public", "_http", "_baseUrl")
+	if g.TimeoutHeader {
+		sb.WriteString("\n")
+		generateSendWithTimeoutHeaderNet45(sb, "        ", "_http")
+	}
 }
 
 // generateSharedUtilityNet40HWR generates the shared utility class body for NET40HWR mode
 func (g *Generator) generateSharedUtilityNet40HWR(sb *strings.Builder) {
 	// Fields
-	sb.WriteString("        Private ReadOnly _baseUrl As String\n\n")
+	sb.WriteString("        Private ReadOnly _baseUrl As String\n")
+	if g.RequestSettings {
+		sb.WriteString("        Private ReadOnly _defaultTimeoutMs As Integer?\n")
+		sb.WriteString("        Private ReadOnly _sendChunked As Boolean\n")
+	}
+	sb.WriteString("\n")
 
 	// Constructor
-	sb.WriteString("        Public Sub New(baseUrl As String)\n")
+	fmt.Fprintf(sb, "        Public Sub New(baseUrl As String%s)\n", g.requestSettingsParams())
 	sb.WriteString("            If String.IsNullOrWhiteSpace(baseUrl) Then Throw New ArgumentException(\"baseUrl cannot be null or empty\")\n")
 	sb.WriteString("            _baseUrl = baseUrl.TrimEnd(\"/\"c)\n")
+	if g.RequestSettings {
+		sb.WriteString("            _defaultTimeoutMs = defaultTimeoutMs\n")
+		sb.WriteString("            _sendChunked = sendChunked\n")
+	}
 	sb.WriteString("        End Sub\n\n")
 
 	// Public PostJson method (copied from embedded version but made public)
@@ -486,8 +791,9 @@ func (g *Generator) generateSharedUtilityNet40HWR(sb *strings.Builder) {
 	sb.WriteString("            Dim req As HttpWebRequest = CType(WebRequest.Create(url), HttpWebRequest)\n")
 	sb.WriteString("            req.Method = \"POST\"\n")
 	sb.WriteString("            req.ContentType = \"application/json\"\n")
-	sb.WriteString("            req.ContentLength = data.Length\n")
-	sb.WriteString("            If timeoutMs.HasValue Then req.Timeout = timeoutMs.Value\n")
+	g.writeContentLengthNet40HWR(sb, "            ", "_sendChunked")
+	g.writeRequestTimeoutNet40HWR(sb, "            ", "_defaultTimeoutMs")
+	g.writeTimeoutHeaderNet40HWR(sb, "            ")
 	sb.WriteString("            \n")
 	sb.WriteString("            ' Add authorization headers if provided\n")
 	sb.WriteString("            If authHeaders IsNot Nothing Then\n")
@@ -499,7 +805,7 @@ func (g *Generator) generateSharedUtilityNet40HWR(sb *strings.Builder) {
 	sb.WriteString("            Using reqStream As Stream = req.GetRequestStream()\n")
 	sb.WriteString("                reqStream.Write(data, 0, data.Length)\n")
 	sb.WriteString("            End Using\n")
-	sb.WriteString("            Using resp As HttpWebResponse = CType(req.GetResponse(), HttpWebResponse)\n")
+	sb.WriteString("            Using resp As HttpWebResponse = ApiException.GetResponseOrThrow(req)\n")
 	sb.WriteString("                Using respStream As Stream = resp.GetResponseStream()\n")
 	sb.WriteString("                    Using reader As New StreamReader(respStream, Encoding.UTF8)\n")
 	sb.WriteString("                        Dim respJson As String = reader.ReadToEnd()\n")
@@ -510,11 +816,14 @@ func (g *Generator) generateSharedUtilityNet40HWR(sb *strings.Builder) {
 	sb.WriteString("                    End Using\n")
 	sb.WriteString("                End Using\n")
 	sb.WriteString("            End Using\n")
-	sb.WriteString("        End Function\n")
+	sb.WriteString("        End Function\n\n")
+
+	// Public GetJson method used by GET-annotated RPCs
+	g.generateGetJsonNet40HWR(sb, "        ", "Public", "_baseUrl", "_defaultTimeoutMs")
 }
 
 // generateServiceClientNet45WithSharedUtility generates service client using shared utility for NET45 mode
-func (g *Generator) generateServiceClientNet45WithSharedUtility(sb *strings.Builder, service *types.ProtoService, protoBaseName, sharedUtilityName string) {
+func (g *Generator) generateServiceClientNet45WithSharedUtility(sb *strings.Builder, service *types.ProtoService, protoFile *types.ProtoFile, sharedUtilityName string) {
 	clientName := fmt.Sprintf("%sClient", service.Name)
 
 	fmt.Fprintf(sb, "' %s is an HTTP client for the %s service\n", clientName, service.Name)
@@ -532,7 +841,7 @@ func (g *Generator) generateServiceClientNet45WithSharedUtility(sb *strings.Buil
 	// Generate methods for each RPC
 	for _, rpc := range service.RPCs {
 		if rpc.IsUnary {
-			g.generateRPCMethodNet45WithSharedUtility(sb, rpc, protoBaseName)
+			g.generateRPCMethodNet45WithSharedUtility(sb, service, rpc, protoFile)
 		}
 	}
 
@@ -540,13 +849,12 @@ func (g *Generator) generateServiceClientNet45WithSharedUtility(sb *strings.Buil
 }
 
 // generateRPCMethodNet45WithSharedUtility generates RPC method that delegates to shared utility
-func (g *Generator) generateRPCMethodNet45WithSharedUtility(sb *strings.Builder, rpc *types.ProtoRPC, protoBaseName string) {
+func (g *Generator) generateRPCMethodNet45WithSharedUtility(sb *strings.Builder, service *types.ProtoService, rpc *types.ProtoRPC, protoFile *types.ProtoFile) {
 	methodName := rpc.Name + "Async"
 	inputType := g.getGoType(rpc.InputType)
 	outputType := g.getGoType(rpc.OutputType)
-	baseName, version := types.ParseRPCNameAndVersion(rpc.Name)
-	urlPath := types.KebabCase(baseName)
-	relativePath := fmt.Sprintf("\"/%s/%s/%s\"", protoBaseName, urlPath, version)
+	respType := g.responseType(protoFile, outputType)
+	relativePath := fmt.Sprintf("\"%s\"", g.routePath(protoFile, service, rpc))
 
 	// Overload 1: Simple overload without cancellation token or timeout
 	fmt.Fprintf(sb, "    Public Function %s(request As %s) As Task(Of %s)\n", methodName, inputType, outputType)
@@ -560,12 +868,18 @@ func (g *Generator) generateRPCMethodNet45WithSharedUtility(sb *strings.Builder,
 
 	// Overload 3: Main implementation with cancellation token and optional timeout - delegates to shared utility
 	fmt.Fprintf(sb, "    Public Async Function %s(request As %s, cancellationToken As CancellationToken, Optional timeoutMs As Integer? = Nothing) As Task(Of %s)\n", methodName, inputType, outputType)
-	fmt.Fprintf(sb, "        Return Await _httpUtility.PostJsonAsync(Of %s, %s)(%s, request, cancellationToken, timeoutMs).ConfigureAwait(False)\n", inputType, outputType, relativePath)
+	if rpc.IsGet() {
+		sb.WriteString("        If request Is Nothing Then Throw New ArgumentNullException(NameOf(request))\n")
+		g.generateQueryParams(sb, protoFile, rpc, "        ")
+		g.writeReturn(sb, protoFile, outputType, "        ", fmt.Sprintf("Await _httpUtility.GetJsonAsync(Of %s)(%s, query, cancellationToken, timeoutMs).ConfigureAwait(False)", respType, relativePath))
+	} else {
+		g.writeReturn(sb, protoFile, outputType, "        ", fmt.Sprintf("Await _httpUtility.PostJsonAsync(Of %s, %s)(%s, request, cancellationToken, timeoutMs).ConfigureAwait(False)", inputType, respType, relativePath))
+	}
 	sb.WriteString("    End Function\n\n")
 }
 
 // generateServiceClientNet40HWRWithSharedUtility generates service client using shared utility for NET40HWR mode
-func (g *Generator) generateServiceClientNet40HWRWithSharedUtility(sb *strings.Builder, service *types.ProtoService, protoBaseName, sharedUtilityName string) {
+func (g *Generator) generateServiceClientNet40HWRWithSharedUtility(sb *strings.Builder, service *types.ProtoService, protoFile *types.ProtoFile, sharedUtilityName string) {
 	clientName := fmt.Sprintf("%sClient", service.Name)
 
 	fmt.Fprintf(sb, "' %s is an HTTP client for the %s service\n", clientName, service.Name)
@@ -574,15 +888,15 @@ func (g *Generator) generateServiceClientNet40HWRWithSharedUtility(sb *strings.B
 	sb.WriteString("\n")
 
 	// Constructor (no HttpClient injection for net40hwr mode)
-	sb.WriteString("    Public Sub New(baseUrl As String)\n")
+	fmt.Fprintf(sb, "    Public Sub New(baseUrl As String%s)\n", g.requestSettingsParams())
 	sb.WriteString("        If String.IsNullOrWhiteSpace(baseUrl) Then Throw New ArgumentException(\"baseUrl cannot be null or empty\")\n")
-	fmt.Fprintf(sb, "        _httpUtility = New %s(baseUrl)\n", sharedUtilityName)
+	fmt.Fprintf(sb, "        _httpUtility = New %s(baseUrl%s)\n", sharedUtilityName, g.requestSettingsArgs())
 	sb.WriteString("    End Sub\n\n")
 
 	// Generate methods for each RPC
 	for _, rpc := range service.RPCs {
 		if rpc.IsUnary {
-			g.generateRPCMethodNet40HWRWithSharedUtility(sb, rpc, protoBaseName)
+			g.generateRPCMethodNet40HWRWithSharedUtility(sb, service, rpc, protoFile)
 		}
 	}
 
@@ -590,13 +904,12 @@ func (g *Generator) generateServiceClientNet40HWRWithSharedUtility(sb *strings.B
 }
 
 // generateRPCMethodNet40HWRWithSharedUtility generates RPC method that delegates to shared utility
-func (g *Generator) generateRPCMethodNet40HWRWithSharedUtility(sb *strings.Builder, rpc *types.ProtoRPC, protoBaseName string) {
+func (g *Generator) generateRPCMethodNet40HWRWithSharedUtility(sb *strings.Builder, service *types.ProtoService, rpc *types.ProtoRPC, protoFile *types.ProtoFile) {
 	methodName := rpc.Name
 	inputType := g.getGoType(rpc.InputType)
 	outputType := g.getGoType(rpc.OutputType)
-	baseName, version := types.ParseRPCNameAndVersion(rpc.Name)
-	urlPath := types.KebabCase(baseName)
-	relativePath := fmt.Sprintf("\"/%s/%s/%s\"", protoBaseName, urlPath, version)
+	respType := g.responseType(protoFile, outputType)
+	relativePath := fmt.Sprintf("\"%s\"", g.routePath(protoFile, service, rpc))
 
 	// Overload 1: Simple overload without timeout or auth headers
 	fmt.Fprintf(sb, "    Public Function %s(request As %s) As %s\n", methodName, inputType, outputType)
@@ -605,6 +918,12 @@ func (g *Generator) generateRPCMethodNet40HWRWithSharedUtility(sb *strings.Build
 
 	// Overload 2: Main implementation with optional timeout and auth headers - delegates to shared utility
 	fmt.Fprintf(sb, "    Public Function %s(request As %s, Optional timeoutMs As Integer? = Nothing, Optional authHeaders As Dictionary(Of String, String) = Nothing) As %s\n", methodName, inputType, outputType)
-	fmt.Fprintf(sb, "        Return _httpUtility.PostJson(Of %s, %s)(%s, request, timeoutMs, authHeaders)\n", inputType, outputType, relativePath)
+	if rpc.IsGet() {
+		sb.WriteString("        If request Is Nothing Then Throw New ArgumentNullException(\"request\")\n")
+		g.generateQueryParams(sb, protoFile, rpc, "        ")
+		g.writeReturn(sb, protoFile, outputType, "        ", fmt.Sprintf("_httpUtility.GetJson(Of %s)(%s, query, timeoutMs, authHeaders)", respType, relativePath))
+	} else {
+		g.writeReturn(sb, protoFile, outputType, "        ", fmt.Sprintf("_httpUtility.PostJson(Of %s, %s)(%s, request, timeoutMs, authHeaders)", inputType, respType, relativePath))
+	}
 	sb.WriteString("    End Function\n\n")
 }
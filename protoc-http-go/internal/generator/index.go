@@ -0,0 +1,295 @@
+package generator
+
+import (
+	"bufio"
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// File names of the indexes written by --index
+const (
+	GoIndexFile     = "index.go"
+	PythonIndexFile = "__init__.py"
+	VBIndexFile     = "Index.vb"
+)
+
+// goIndexPackage is the package name of the Go index; "go", the name of its
+// directory, is a keyword
+const goIndexPackage = "clients"
+
+// clientMarker matches the doc comment every generated client type starts with, in
+// Go ("// "), VB ("' ") and Python (the class docstring)
+var clientMarker = regexp.MustCompile(`(?m)^(?://|'|    """) ?(\w+) is an HTTP client for the \w+ service`)
+
+var (
+	goPackageClause = regexp.MustCompile(`(?m)^package (\w+)$`)
+	vbNamespace     = regexp.MustCompile(`(?m)^Namespace (\S+)$`)
+)
+
+// indexedFile is a generated file and the client types it declares
+type indexedFile struct {
+	path    string   // Path of the generated file
+	module  string   // Go package, Python module or VB namespace of the file
+	clients []string // Client type names, in declaration order
+}
+
+// scanClients reads the files matching pattern and returns those declaring at least
+// one generated client, sorted by path. module extracts a file's package, module or
+// namespace name from its path and content.
+func scanClients(pattern string, module func(path, content string) string) ([]indexedFile, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var files []indexedFile
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		content := string(data)
+		file := indexedFile{path: path, module: module(path, content)}
+		for _, match := range clientMarker.FindAllStringSubmatch(content, -1) {
+			file.clients = append(file.clients, match[1])
+		}
+		if len(file.clients) > 0 {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// clientCounts counts how often every client name occurs across files, so names
+// declared by more than one module can be qualified
+func clientCounts(files []indexedFile) map[string]int {
+	counts := make(map[string]int)
+	for _, file := range files {
+		for _, client := range file.clients {
+			counts[client]++
+		}
+	}
+	return counts
+}
+
+// GenerateGoIndex writes goDir/index.go, package "clients", re-exporting the clients
+// of every generated package under goDir as type aliases together with their
+// constructors. A client name declared by several packages is prefixed with the
+// capitalized package name, e.g. OrdersGreeterClient.
+//
+// Import paths are derived from the nearest go.mod in goDir or above it, so the
+// output directory must be inside the Go module that builds the clients.
+//
+// Returns the path of the index or an error if no client was found, there is no
+// go.mod or the index cannot be written.
+func GenerateGoIndex(goDir string) (string, error) {
+	files, err := scanClients(filepath.Join(goDir, "*", "*.go"), func(_, content string) string {
+		if match := goPackageClause.FindStringSubmatch(content); match != nil {
+			return match[1]
+		}
+		return ""
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no generated Go clients found under %s", goDir)
+	}
+	modulePath, moduleRoot, err := findGoModule(goDir)
+	if err != nil {
+		return "", err
+	}
+
+	// One import per package directory
+	imports := make(map[string]string) // Package name → import path
+	for _, file := range files {
+		absDir, err := filepath.Abs(filepath.Dir(file.path))
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(moduleRoot, absDir)
+		if err != nil {
+			return "", err
+		}
+		imports[file.module] = modulePath + "/" + filepath.ToSlash(rel)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by protoc-http-go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&sb, "// Package %s re-exports the generated HTTP clients, so they can be used through one import.\n", goIndexPackage)
+	fmt.Fprintf(&sb, "package %s\n\nimport (\n", goIndexPackage)
+	for _, pkg := range sortedKeys(imports) {
+		if path.Base(imports[pkg]) == pkg {
+			fmt.Fprintf(&sb, "\t%q\n", imports[pkg])
+		} else {
+			fmt.Fprintf(&sb, "\t%s %q\n", pkg, imports[pkg])
+		}
+	}
+	sb.WriteString(")\n")
+
+	counts := clientCounts(files)
+	for _, file := range files {
+		for _, client := range file.clients {
+			name := client
+			if counts[client] > 1 {
+				name = toTitle(file.module) + client
+			}
+			fmt.Fprintf(&sb, "\n// %s is %s.%s\n", name, file.module, client)
+			fmt.Fprintf(&sb, "type %s = %s.%s\n\n", name, file.module, client)
+			sb.WriteString("var (\n")
+			fmt.Fprintf(&sb, "\tNew%s = %s.New%s\n", name, file.module, client)
+			fmt.Fprintf(&sb, "\tNew%sWithClient = %s.New%sWithClient\n", name, file.module, client)
+			sb.WriteString(")\n")
+		}
+	}
+
+	source, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format Go index: %w", err)
+	}
+	indexPath := filepath.Join(goDir, GoIndexFile)
+	if err := os.WriteFile(indexPath, source, 0644); err != nil {
+		return "", err
+	}
+	return indexPath, nil
+}
+
+// findGoModule returns the module path and root directory of the nearest go.mod
+// in dir or one of its parents
+func findGoModule(dir string) (string, string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for current := abs; ; current = filepath.Dir(current) {
+		modulePath, err := readModulePath(filepath.Join(current, "go.mod"))
+		if err == nil {
+			return modulePath, current, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+		if filepath.Dir(current) == current {
+			return "", "", fmt.Errorf("no go.mod found in %s or its parents; the Go index needs the module path to import the clients", abs)
+		}
+	}
+}
+
+// readModulePath returns the path of the module directive in the go.mod at path
+func readModulePath(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no module directive", path)
+}
+
+// GeneratePythonIndex writes pyDir/__init__.py importing the clients of every
+// generated module in pyDir and listing them in __all__, so pyDir can be used as
+// a package. A client name declared by several modules is imported as
+// <module>_<Client>.
+//
+// Returns the path of the index or an error if no client was found or the index
+// cannot be written.
+func GeneratePythonIndex(pyDir string) (string, error) {
+	files, err := scanClients(filepath.Join(pyDir, "*.py"), func(path, _ string) string {
+		return strings.TrimSuffix(filepath.Base(path), ".py")
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no generated Python clients found in %s", pyDir)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Code generated by protoc-http-go. DO NOT EDIT.\n")
+	sb.WriteString("\"\"\"Generated HTTP clients, importable from this package.\"\"\"\n\n")
+	counts := clientCounts(files)
+	var exported []string
+	for _, file := range files {
+		for _, client := range file.clients {
+			if counts[client] > 1 {
+				alias := file.module + "_" + client
+				fmt.Fprintf(&sb, "from .%s import %s as %s\n", file.module, client, alias)
+				exported = append(exported, alias)
+				continue
+			}
+			fmt.Fprintf(&sb, "from .%s import %s\n", file.module, client)
+			exported = append(exported, client)
+		}
+	}
+	sb.WriteString("\n__all__ = [\n")
+	for _, name := range exported {
+		fmt.Fprintf(&sb, "    %s,\n", pyStringLiteral(name))
+	}
+	sb.WriteString("]\n")
+
+	indexPath := filepath.Join(pyDir, PythonIndexFile)
+	if err := os.WriteFile(indexPath, []byte(sb.String()), 0644); err != nil {
+		return "", err
+	}
+	return indexPath, nil
+}
+
+// GenerateVBIndex writes vbDir/Index.vb, a comment-only file listing the namespaces
+// of the generated VB.NET files in vbDir with the clients each declares, as a
+// reference for the Imports statements consumers need.
+//
+// Returns the path of the index or an error if no client was found or the index
+// cannot be written.
+func GenerateVBIndex(vbDir string) (string, error) {
+	files, err := scanClients(filepath.Join(vbDir, "*.vb"), func(_, content string) string {
+		if match := vbNamespace.FindStringSubmatch(content); match != nil {
+			return match[1]
+		}
+		return ""
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no generated VB.NET clients found in %s", vbDir)
+	}
+
+	byNamespace := make(map[string][]indexedFile)
+	for _, file := range files {
+		byNamespace[file.module] = append(byNamespace[file.module], file)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("' Code generated by protoc-http-go. DO NOT EDIT.\n")
+	sb.WriteString("' Index of the generated VB.NET clients by namespace; add \"Imports <namespace>\" to use them.\n")
+	for _, namespace := range sortedKeys(byNamespace) {
+		sb.WriteString("'\n")
+		fmt.Fprintf(&sb, "' Namespace %s\n", namespace)
+		for _, file := range byNamespace[namespace] {
+			for _, client := range file.clients {
+				fmt.Fprintf(&sb, "'   %s (%s)\n", client, filepath.Base(file.path))
+			}
+		}
+	}
+
+	indexPath := filepath.Join(vbDir, VBIndexFile)
+	if err := os.WriteFile(indexPath, []byte(sb.String()), 0644); err != nil {
+		return "", err
+	}
+	return indexPath, nil
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateIndexSkipsFilesWithoutClients(t *testing.T) {
+	dir := t.TempDir()
+	gen := &Generator{}
+	if err := gen.GenerateFile(testGetProto(), filepath.Join(dir, "search.vb")); err != nil {
+		t.Fatalf("GenerateFile() error = %v", err)
+	}
+	// A file of messages only, and one that is not generated at all
+	if err := os.WriteFile(filepath.Join(dir, "types.vb"), []byte("Namespace Types\nPublic Class Ticker\nEnd Class\nEnd Namespace\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	indexPath, err := GenerateVBIndex(dir)
+	if err != nil {
+		t.Fatalf("GenerateVBIndex() error = %v", err)
+	}
+	index := readFile(t, indexPath)
+	assertContains(t, index, "' Namespace Search\n'   SearchServiceClient (search.vb)\n")
+	assertNotContains(t, index, "Types")
+
+	// Running again must not pick up the index itself
+	if _, err := GenerateVBIndex(dir); err != nil {
+		t.Fatalf("second GenerateVBIndex() error = %v", err)
+	}
+	if got := readFile(t, indexPath); got != index {
+		t.Errorf("index changed when regenerated:\n%s", got)
+	}
+}
+
+func TestGenerateIndexWithoutClients(t *testing.T) {
+	dir := t.TempDir()
+	for name, generate := range map[string]func(string) (string, error){
+		"vb": GenerateVBIndex,
+		"go": GenerateGoIndex,
+		"py": GeneratePythonIndex,
+	} {
+		if _, err := generate(dir); err == nil || !strings.Contains(err.Error(), "no generated") {
+			t.Errorf("%s: error = %v, want no clients found", name, err)
+		}
+	}
+}
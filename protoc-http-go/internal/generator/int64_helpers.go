@@ -0,0 +1,53 @@
+package generator
+
+import "strings"
+
+// emitInt64Helpers writes a VB.NET converter for 64-bit integer fields. Proto JSON
+// encodes int64/uint64 (and their sint/fixed variants) as quoted strings, so the
+// converter reads both strings and numbers and writes the canonical string form.
+func emitInt64Helpers(sb *strings.Builder, indent string) {
+	lines := []string{
+		"Public Class Int64StringConverter",
+		"    Inherits JsonConverter",
+		"",
+		"    Public Overrides Function CanConvert(objectType As Type) As Boolean",
+		"        Return objectType Is GetType(Long) OrElse objectType Is GetType(ULong) OrElse",
+		"            objectType Is GetType(Nullable(Of Long)) OrElse objectType Is GetType(Nullable(Of ULong))",
+		"    End Function",
+		"",
+		"    Public Overrides Function ReadJson(reader As JsonReader, objectType As Type, existingValue As Object, serializer As JsonSerializer) As Object",
+		"        Dim isUnsigned As Boolean = objectType Is GetType(ULong) OrElse objectType Is GetType(Nullable(Of ULong))",
+		"        If reader.TokenType = JsonToken.Null Then",
+		"            If objectType Is GetType(Long) Then Return 0L",
+		"            If objectType Is GetType(ULong) Then Return 0UL",
+		"            Return Nothing",
+		"        End If",
+		"        ' String tokens are the proto JSON form; numbers are accepted for leniency",
+		"        Dim text As String = Convert.ToString(reader.Value, System.Globalization.CultureInfo.InvariantCulture)",
+		"        If isUnsigned Then",
+		"            Return ULong.Parse(text, System.Globalization.NumberStyles.None, System.Globalization.CultureInfo.InvariantCulture)",
+		"        End If",
+		"        Return Long.Parse(text, System.Globalization.NumberStyles.AllowLeadingSign, System.Globalization.CultureInfo.InvariantCulture)",
+		"    End Function",
+		"",
+		"    Public Overrides Sub WriteJson(writer As JsonWriter, value As Object, serializer As JsonSerializer)",
+		"        If value Is Nothing Then",
+		"            writer.WriteNull()",
+		"            Return",
+		"        End If",
+		"        writer.WriteValue(Convert.ToString(value, System.Globalization.CultureInfo.InvariantCulture))",
+		"    End Sub",
+		"End Class",
+		"",
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(indent)
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+}
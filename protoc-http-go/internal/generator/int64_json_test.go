@@ -0,0 +1,181 @@
+package generator
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// beyondFloat64 cannot be represented exactly as a JSON number by most clients
+const beyondFloat64 = "9007199254740993"
+
+func testInt64Proto() *types.ProtoFile {
+	return &types.ProtoFile{
+		FileName: "ledger.proto",
+		BaseName: "ledger",
+		Package:  "ledger",
+		Messages: map[string]*types.ProtoMessage{
+			"Entry": {
+				Name: "Entry",
+				Fields: []*types.ProtoField{
+					{Name: "amount", Type: "int64"},
+					{Name: "ids", Type: "fixed64", Repeated: true},
+					{Name: "balance", Type: "uint64"},
+					{Name: "count", Type: "int32"},
+					{Name: "legacy", Type: "sint64", TypeOverride: "String"},
+				},
+				NestedMessages: map[string]*types.ProtoMessage{
+					"Line": {
+						Name:           "Line",
+						Fields:         []*types.ProtoField{{Name: "delta", Type: "sfixed64"}},
+						NestedMessages: map[string]*types.ProtoMessage{},
+						NestedEnums:    map[string]*types.ProtoEnum{},
+					},
+				},
+				NestedEnums: map[string]*types.ProtoEnum{},
+			},
+		},
+		Enums:    map[string]*types.ProtoEnum{},
+		Services: []*types.ProtoService{},
+	}
+}
+
+func TestInt64FieldsUseStringConverter(t *testing.T) {
+	content := generateProto(t, testInt64Proto())
+
+	assertContains(t, content, "<JsonProperty(\"amount\")>\n    <JsonConverter(GetType(Int64StringConverter))>\n    Public Property Amount As Long\n")
+	assertContains(t, content, "<JsonConverter(GetType(Int64StringConverter))>\n    Public Property Balance As ULong\n")
+	assertContains(t, content, "<JsonConverter(GetType(Int64StringConverter))>\n    Public Property Delta As Long\n")
+	assertContains(t, content, `<JsonProperty("ids", ItemConverterType:=GetType(Int64StringConverter))>`)
+	assertContains(t, content, "<JsonProperty(\"count\")>\n    Public Property Count As Integer\n")
+	// An overridden VB type is not a Long, so it keeps the default serializer
+	assertContains(t, content, "<JsonProperty(\"legacy\")>\n    Public Property Legacy As String\n")
+
+	if count := strings.Count(content, "Public Class Int64StringConverter"); count != 1 {
+		t.Fatalf("expected the converter to be emitted once, got %d\n%s", count, content)
+	}
+	assertContains(t, content, "Long.Parse(text, System.Globalization.NumberStyles.AllowLeadingSign")
+	assertContains(t, content, "writer.WriteValue(Convert.ToString(value, System.Globalization.CultureInfo.InvariantCulture))")
+}
+
+func TestProtoWithoutInt64SkipsConverter(t *testing.T) {
+	content := generateProto(t, testNoBytesProto())
+	assertNotContains(t, content, "Int64StringConverter")
+}
+
+func TestSharedUtilityEmitsInt64Converter(t *testing.T) {
+	utilityPath := filepath.Join(t.TempDir(), "SharedHttpUtility.vb")
+	gen := &Generator{FrameworkMode: "net45"}
+	if err := gen.GenerateSharedUtility("SharedHttpUtility", "Shared", utilityPath, false, true); err != nil {
+		t.Fatalf("GenerateSharedUtility() error = %v", err)
+	}
+	utilityContent := readFile(t, utilityPath)
+	assertContains(t, utilityContent, "Public Class Int64StringConverter")
+	assertNotContains(t, utilityContent, "Public Class BytesStringConverter")
+
+	proto := testInt64Proto()
+	proto.UseSharedUtility = true
+	proto.SharedUtilityName = "SharedHttpUtility"
+	proto.SharedUtilityNamespace = "Shared"
+
+	dtoContent := generateProto(t, proto)
+	assertNotContains(t, dtoContent, "Public Class Int64StringConverter")
+	assertContains(t, dtoContent, `<JsonConverter(GetType(Shared.Int64StringConverter))>`)
+}
+
+func TestInt64SchemaAcceptsStringsAndNumbers(t *testing.T) {
+	schemaPath, err := GenerateJSONSchema(testInt64Proto(), t.TempDir())
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema() error = %v", err)
+	}
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var doc struct {
+		Defs map[string]struct {
+			Properties map[string]struct {
+				Type    interface{} `json:"type"`
+				Pattern string      `json:"pattern"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	amount := doc.Defs["Entry"].Properties["amount"]
+	if !reflect.DeepEqual(amount.Type, []interface{}{"integer", "string"}) {
+		t.Fatalf("expected int64 to accept integer or string, got %v", amount.Type)
+	}
+	pattern := regexp.MustCompile(amount.Pattern)
+	for _, value := range []string{beyondFloat64, "-42", "0"} {
+		if !pattern.MatchString(value) {
+			t.Errorf("expected %q to match the int64 string pattern %q", value, amount.Pattern)
+		}
+	}
+	if pattern.MatchString("12a") || pattern.MatchString("1.5") {
+		t.Errorf("expected non-integer strings to be rejected by %q", amount.Pattern)
+	}
+
+	balance := doc.Defs["Entry"].Properties["balance"]
+	if regexp.MustCompile(balance.Pattern).MatchString("-1") {
+		t.Errorf("expected uint64 pattern %q to reject negative values", balance.Pattern)
+	}
+	if count := doc.Defs["Entry"].Properties["count"]; count.Type != "integer" {
+		t.Errorf("expected int32 to stay an integer, got %v", count.Type)
+	}
+}
+
+// TestInt64GoClientRoundTrip decodes a string-encoded int64 with the struct tag the
+// Go generator emits and checks it is written back as the same string, without
+// any float64 precision loss.
+func TestInt64GoClientRoundTrip(t *testing.T) {
+	src, err := (&Generator{}).generateGoSource(testInt64Proto())
+	if err != nil {
+		t.Fatalf("generateGoSource() error = %v", err)
+	}
+	typeCheckGo(t, src)
+
+	file, err := parser.ParseFile(token.NewFileSet(), "generated.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated Go does not parse: %v", err)
+	}
+	var tag string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok && len(field.Names) == 1 && field.Names[0].Name == "Amount" && field.Tag != nil {
+			tag, _ = strconv.Unquote(field.Tag.Value)
+		}
+		return tag == ""
+	})
+	if tag == "" {
+		t.Fatalf("Amount field not found in generated Go:\n%s", src)
+	}
+
+	entryType := reflect.StructOf([]reflect.StructField{{Name: "Amount", Type: reflect.TypeOf(int64(0)), Tag: reflect.StructTag(tag)}})
+	entry := reflect.New(entryType)
+	wire := `{"amount":"` + beyondFloat64 + `"}`
+	if err := json.Unmarshal([]byte(wire), entry.Interface()); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", wire, err)
+	}
+	if got := entry.Elem().Field(0).Int(); strconv.FormatInt(got, 10) != beyondFloat64 {
+		t.Fatalf("expected %s after decoding, got %d", beyondFloat64, got)
+	}
+	out, err := json.Marshal(entry.Interface())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(out) != wire {
+		t.Fatalf("round trip changed the payload: got %s, want %s", out, wire)
+	}
+}
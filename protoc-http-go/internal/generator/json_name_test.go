@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// jsonNameProto has json_name fields in a regular message and in a msgHdr message
+func jsonNameProto() *types.ProtoFile {
+	return &types.ProtoFile{
+		FileName: "account.proto",
+		BaseName: "account",
+		Package:  "account",
+		Messages: map[string]*types.ProtoMessage{
+			"Account": {
+				Name: "Account",
+				Fields: []*types.ProtoField{
+					{Name: "user_name", Type: "string", JSONName: "login"},
+					{Name: "display_name", Type: "string"},
+				},
+				NestedMessages: map[string]*types.ProtoMessage{},
+				NestedEnums:    map[string]*types.ProtoEnum{},
+			},
+			"msgHdr": {
+				Name: "msgHdr",
+				Fields: []*types.ProtoField{
+					{Name: "Trace_ID", Type: "string", JSONName: "traceId"},
+					{Name: "Source_App", Type: "string"},
+				},
+				NestedMessages: map[string]*types.ProtoMessage{},
+				NestedEnums:    map[string]*types.ProtoEnum{},
+			},
+		},
+		Enums: map[string]*types.ProtoEnum{},
+	}
+}
+
+func TestFieldJSONNamePrecedence(t *testing.T) {
+	cases := []struct {
+		field   types.ProtoField
+		message string
+		want    string
+	}{
+		{types.ProtoField{Name: "user_name", JSONName: "login"}, "Account", "login"},
+		{types.ProtoField{Name: "user_name"}, "Account", "userName"},
+		{types.ProtoField{Name: "Trace_ID", JSONName: "traceId"}, "msgHdr", "traceId"},
+		{types.ProtoField{Name: "Trace_ID"}, "msgHdr", "Trace_ID"},
+	}
+	for _, tc := range cases {
+		if got := types.FieldJSONName(&tc.field, tc.message); got != tc.want {
+			t.Errorf("FieldJSONName(%+v, %q) = %q, want %q", tc.field, tc.message, got, tc.want)
+		}
+	}
+}
+
+func TestVBJsonPropertyUsesJSONName(t *testing.T) {
+	content := generateProto(t, jsonNameProto())
+
+	assertContains(t, content, "<JsonProperty(\"login\")>\n    Public Property UserName As String\n")
+	assertContains(t, content, "<JsonProperty(\"displayName\")>\n    Public Property DisplayName As String\n")
+	// msgHdr keeps exact proto names unless json_name says otherwise
+	assertContains(t, content, "<JsonProperty(\"traceId\")>\n")
+	assertContains(t, content, "<JsonProperty(\"Source_App\")>\n")
+	assertNotContains(t, content, "\"userName\"")
+	assertNotContains(t, content, "\"Trace_ID\"")
+}
+
+func TestJSONSchemaPropertiesUseJSONName(t *testing.T) {
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(generateSchemaBytes(t, jsonNameProto()), &schema); err != nil {
+		t.Fatalf("invalid schema JSON: %v", err)
+	}
+
+	want := map[string][]string{
+		"Account": {"login", "displayName"},
+		"msgHdr":  {"traceId", "Source_App"},
+	}
+	for def, keys := range want {
+		props := schema.Defs[def].Properties
+		if len(props) != len(keys) {
+			t.Errorf("%s: expected %d properties, got %v", def, len(keys), props)
+		}
+		for _, key := range keys {
+			if _, ok := props[key]; !ok {
+				t.Errorf("%s: missing property %q in %v", def, key, props)
+			}
+		}
+	}
+}
@@ -12,19 +12,30 @@ import (
 	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
 )
 
-// ScalarTypeMapJSON maps protobuf scalar types to JSON Schema type definitions
+// DefaultSchemaBaseURI is the base of the generated schemas' $id when none is given
+const DefaultSchemaBaseURI = "https://example.com/schemas"
+
+// Patterns for the string form of 64-bit integers in proto JSON
+const (
+	signedIntPattern   = "^-?[0-9]+$"
+	unsignedIntPattern = "^[0-9]+$"
+)
+
+// ScalarTypeMapJSON maps protobuf scalar types to JSON Schema type definitions.
+// 64-bit integers accept both numbers and the decimal strings proto JSON emits;
+// "pattern" only applies to the string form.
 var ScalarTypeMapJSON = map[string]map[string]interface{}{
 	"string":   {"type": "string"},
 	"int32":    {"type": "integer", "format": "int32"},
-	"int64":    {"type": "integer", "format": "int64"},
+	"int64":    {"type": []string{"integer", "string"}, "format": "int64", "pattern": signedIntPattern},
 	"uint32":   {"type": "integer", "format": "uint32", "minimum": 0},
-	"uint64":   {"type": "integer", "format": "uint64", "minimum": 0},
+	"uint64":   {"type": []string{"integer", "string"}, "format": "uint64", "minimum": 0, "pattern": unsignedIntPattern},
 	"sint32":   {"type": "integer", "format": "int32"},
-	"sint64":   {"type": "integer", "format": "int64"},
+	"sint64":   {"type": []string{"integer", "string"}, "format": "int64", "pattern": signedIntPattern},
 	"fixed32":  {"type": "integer", "format": "uint32", "minimum": 0},
-	"fixed64":  {"type": "integer", "format": "uint64", "minimum": 0},
+	"fixed64":  {"type": []string{"integer", "string"}, "format": "uint64", "minimum": 0, "pattern": unsignedIntPattern},
 	"sfixed32": {"type": "integer", "format": "int32"},
-	"sfixed64": {"type": "integer", "format": "int64"},
+	"sfixed64": {"type": []string{"integer", "string"}, "format": "int64", "pattern": signedIntPattern},
 	"bool":     {"type": "boolean"},
 	"float":    {"type": "number", "format": "float"},
 	"double":   {"type": "number", "format": "double"},
@@ -55,6 +66,35 @@ func buildEnumSchema(enum *types.ProtoEnum) map[string]interface{} {
 	}
 }
 
+// schemaTypeName returns the $defs name of a type referenced from a field of the
+// message at scope, e.g. "Status" inside Outer → "Outer.Status". References to types
+// not declared in protoFile are returned unchanged (minus any leading dot) for
+// qualifyJSONSchemaRef to handle.
+func schemaTypeName(protoFile *types.ProtoFile, scope []string, protoType string) string {
+	if path, _, ok := resolveLocalType(protoFile, scope, protoType); ok {
+		return strings.Join(path, ".")
+	}
+	return strings.TrimPrefix(protoType, ".")
+}
+
+// crossFileSchemaRef returns the $ref of a type declared in another of the files
+// parsed together with protoFile, e.g. "common.json#/$defs/Ticker", found through
+// protoFile.Symbols. It reports false for scalars, types declared in protoFile
+// and when no symbol table is attached, leaving those to qualifyJSONSchemaRef.
+func crossFileSchemaRef(protoFile *types.ProtoFile, scope []string, protoType string) (string, bool) {
+	if _, scalar := ScalarTypeMapJSON[protoType]; scalar || protoFile.Symbols == nil {
+		return "", false
+	}
+	if _, _, ok := resolveLocalType(protoFile, scope, protoType); ok {
+		return "", false
+	}
+	symbol, ok := protoFile.Symbols.Resolve(protoFile.Package, scope, protoType)
+	if !ok || symbol.File == protoFile {
+		return "", false
+	}
+	return symbol.File.BaseName + ".json#/$defs/" + strings.Join(symbol.Path, "."), true
+}
+
 // qualifyJSONSchemaRef generates a JSON Schema $ref for a proto type.
 //
 // Handles:
@@ -135,32 +175,60 @@ func getJSONSchemaType(fieldType string, isRepeated bool, currentPkg string) map
 // collectMessageSchemas recursively collects schemas for a message and its nested types.
 //
 // It builds qualified names for nested messages (e.g., "Outer.Inner") and adds them
-// to the schemas map along with any nested enums.
+// to the schemas map along with any nested enums. Field types are resolved against
+// the declarations of protoFile so refs to nested types point at their qualified $defs entry.
 func collectMessageSchemas(
+	protoFile *types.ProtoFile,
 	msg *types.ProtoMessage,
 	parentPath []string,
 	schemas map[string]interface{},
-	currentPkg string,
 ) error {
+	currentPkg := protoFile.Package
+
 	// Build qualified name
 	currentPath := append(parentPath, msg.Name)
 	qualifiedName := strings.Join(currentPath, ".")
 
 	// Build properties map
 	properties := make(map[string]interface{})
+	var required []string
 	for _, field := range msg.Fields {
 		// Pass message name for msgHdr special handling
-		fieldName := types.JSONTagName(field.Name, msg.Name)
-		fieldSchema := getJSONSchemaType(field.Type, field.Repeated, currentPkg)
+		fieldName := types.FieldJSONName(field, msg.Name)
+		var fieldSchema map[string]interface{}
+		if ref, ok := crossFileSchemaRef(protoFile, currentPath, field.Type); ok {
+			fieldSchema = map[string]interface{}{"$ref": ref}
+			if field.Repeated {
+				fieldSchema = map[string]interface{}{"type": "array", "items": fieldSchema}
+			}
+		} else {
+			fieldType := schemaTypeName(protoFile, currentPath, field.Type)
+			fieldSchema = getJSONSchemaType(fieldType, field.Repeated, currentPkg)
+		}
+		if field.TypeOverride != "" {
+			// The wire format is unchanged; record the client type as an annotation
+			fieldSchema["x-vb-type"] = field.TypeOverride
+		}
+		if value, ok := jsonSchemaDefault(field); ok {
+			// proto2 [default = ...]: the value of the field when it is not sent
+			fieldSchema["default"] = value
+		}
+		if applyJSONSchemaValidation(fieldSchema, field) {
+			required = append(required, fieldName)
+		}
 		properties[fieldName] = fieldSchema
 	}
 
 	// Create message schema
-	schemas[qualifiedName] = map[string]interface{}{
+	messageSchema := map[string]interface{}{
 		"type":                 "object",
 		"properties":           properties,
 		"additionalProperties": false,
 	}
+	if len(required) > 0 {
+		messageSchema["required"] = required
+	}
+	schemas[qualifiedName] = messageSchema
 
 	// Process nested enums
 	for _, nestedEnum := range msg.NestedEnums {
@@ -170,7 +238,7 @@ func collectMessageSchemas(
 
 	// Recursively process nested messages
 	for _, nestedMsg := range msg.NestedMessages {
-		if err := collectMessageSchemas(nestedMsg, currentPath, schemas, currentPkg); err != nil {
+		if err := collectMessageSchemas(protoFile, nestedMsg, currentPath, schemas); err != nil {
 			return err
 		}
 	}
@@ -180,19 +248,49 @@ func collectMessageSchemas(
 
 // GenerateJSONSchema generates a JSON Schema file for a proto file.
 //
-// It creates a json/ subdirectory under outputDir and writes a .json file
-// containing schemas for all messages and enums defined in the proto file.
-//
-// The generated schema follows JSON Schema Draft 2020-12 specification.
+// It creates a json/ subdirectory under outputDir and writes the document built by
+// GenerateJSONSchemaString to <basename>.json.
 //
 // Returns the path to the generated JSON schema file or an error.
-func GenerateJSONSchema(protoFile *types.ProtoFile, outputDir string) (string, error) {
+func GenerateJSONSchema(protoFile *types.ProtoFile, outputDir string, baseURI ...string) (string, error) {
+	schema, err := GenerateJSONSchemaString(protoFile, baseURI...)
+	if err != nil {
+		return "", err
+	}
+
 	// Create json/ subdirectory
 	jsonDir := filepath.Join(outputDir, "json")
 	if err := os.MkdirAll(jsonDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create json directory: %w", err)
 	}
 
+	// Write to file
+	outputPath := filepath.Join(jsonDir, protoFile.BaseName+".json")
+	if err := os.WriteFile(outputPath, []byte(schema), 0644); err != nil {
+		return "", fmt.Errorf("failed to write JSON schema file: %w", err)
+	}
+
+	return outputPath, nil
+}
+
+// GenerateJSONSchemaString returns the JSON Schema document for a proto file, with
+// schemas for all messages and enums it defines, without touching the file system.
+//
+// The generated schema follows JSON Schema Draft 2020-12 specification. Its $id is
+// "<baseURI>/<basename>.json"; baseURI defaults to DefaultSchemaBaseURI. $schema always
+// names the draft meta-schema. Output is byte-for-byte stable across runs because
+// encoding/json writes map keys, including those of $defs, in sorted order.
+//
+// Returns the indented JSON document or an error.
+func GenerateJSONSchemaString(protoFile *types.ProtoFile, baseURI ...string) (string, error) {
+	if protoFile == nil {
+		return "", fmt.Errorf("proto file is nil")
+	}
+	base := DefaultSchemaBaseURI
+	if len(baseURI) > 0 && baseURI[0] != "" {
+		base = strings.TrimRight(baseURI[0], "/")
+	}
+
 	// Build base schema structure
 	baseName := protoFile.BaseName
 	description := fmt.Sprintf("JSON Schema definitions for all messages and enums in %s", protoFile.FileName)
@@ -202,7 +300,7 @@ func GenerateJSONSchema(protoFile *types.ProtoFile, outputDir string) (string, e
 
 	schemaDoc := map[string]interface{}{
 		"$schema":     "https://json-schema.org/draft/2020-12/schema",
-		"$id":         fmt.Sprintf("https://example.com/schemas/%s.json", baseName),
+		"$id":         fmt.Sprintf("%s/%s.json", base, baseName),
 		"title":       fmt.Sprintf("Schemas for %s", protoFile.FileName),
 		"description": description,
 		"$defs":       make(map[string]interface{}),
@@ -217,7 +315,7 @@ func GenerateJSONSchema(protoFile *types.ProtoFile, outputDir string) (string, e
 
 	// Collect all message schemas (including nested)
 	for _, msg := range protoFile.Messages {
-		if err := collectMessageSchemas(msg, []string{}, defs, protoFile.Package); err != nil {
+		if err := collectMessageSchemas(protoFile, msg, []string{}, defs); err != nil {
 			return "", fmt.Errorf("failed to collect message schemas: %w", err)
 		}
 	}
@@ -228,11 +326,5 @@ func GenerateJSONSchema(protoFile *types.ProtoFile, outputDir string) (string, e
 		return "", fmt.Errorf("failed to marshal JSON schema: %w", err)
 	}
 
-	// Write to file
-	outputPath := filepath.Join(jsonDir, baseName+".json")
-	if err := os.WriteFile(outputPath, jsonBytes, 0644); err != nil {
-		return "", fmt.Errorf("failed to write JSON schema file: %w", err)
-	}
-
-	return outputPath, nil
+	return string(jsonBytes), nil
 }
@@ -0,0 +1,211 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// manyDefsProto has enough messages and enums that map iteration order would
+// show up in the output if $defs were not sorted
+func manyDefsProto() *types.ProtoFile {
+	proto := &types.ProtoFile{
+		FileName: "catalog.proto",
+		BaseName: "catalog",
+		Package:  "catalog",
+		Messages: map[string]*types.ProtoMessage{},
+		Enums:    map[string]*types.ProtoEnum{},
+	}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("Item%02d", i)
+		proto.Messages[name] = &types.ProtoMessage{
+			Name:   name,
+			Fields: []*types.ProtoField{{Name: "id", Type: "string"}, {Name: "kind", Type: fmt.Sprintf("Kind%02d", i)}},
+		}
+		proto.Enums[fmt.Sprintf("Kind%02d", i)] = &types.ProtoEnum{
+			Name:   fmt.Sprintf("Kind%02d", i),
+			Values: map[string]int{"KIND_UNSPECIFIED": 0, "KIND_A": 1, "KIND_B": 2},
+		}
+	}
+	return proto
+}
+
+func generateSchemaBytes(t *testing.T, proto *types.ProtoFile, baseURI ...string) []byte {
+	t.Helper()
+	path, err := GenerateJSONSchema(proto, t.TempDir(), baseURI...)
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema() error = %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return content
+}
+
+func TestJSONSchemaIsDeterministic(t *testing.T) {
+	first := generateSchemaBytes(t, manyDefsProto())
+	for i := 0; i < 5; i++ {
+		if again := generateSchemaBytes(t, manyDefsProto()); !bytes.Equal(first, again) {
+			t.Fatalf("run %d produced different output:\n%s\nvs\n%s", i, first, again)
+		}
+	}
+}
+
+func TestJSONSchemaBaseURI(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		baseURI []string
+		wantID  string
+	}{
+		{"default", nil, "https://example.com/schemas/catalog.json"},
+		{"custom", []string{"https://schemas.acme.test/v1"}, "https://schemas.acme.test/v1/catalog.json"},
+		{"trailing slash", []string{"https://schemas.acme.test/v1/"}, "https://schemas.acme.test/v1/catalog.json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var doc map[string]interface{}
+			if err := json.Unmarshal(generateSchemaBytes(t, manyDefsProto(), tc.baseURI...), &doc); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if doc["$id"] != tc.wantID {
+				t.Errorf("$id = %v, want %s", doc["$id"], tc.wantID)
+			}
+			// $schema names the meta-schema and must not follow the base URI
+			if doc["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+				t.Errorf("$schema = %v", doc["$schema"])
+			}
+		})
+	}
+}
+
+// nestedEnumProto has a nested Outer.Status that shadows a top-level Status
+func nestedEnumProto() *types.ProtoFile {
+	status := &types.ProtoEnum{Name: "Status", Values: map[string]int{"OUTER_STATUS_UNSPECIFIED": 0}}
+	return &types.ProtoFile{
+		FileName: "shop.proto",
+		BaseName: "shop",
+		Package:  "shop.v1",
+		Enums: map[string]*types.ProtoEnum{
+			"Status": {Name: "Status", Values: map[string]int{"STATUS_UNSPECIFIED": 0}},
+		},
+		Messages: map[string]*types.ProtoMessage{
+			"Outer": {
+				Name:        "Outer",
+				Fields:      []*types.ProtoField{{Name: "status", Type: "Status"}, {Name: "inner", Type: "Inner"}},
+				NestedEnums: map[string]*types.ProtoEnum{"Status": status},
+				NestedMessages: map[string]*types.ProtoMessage{
+					"Inner": {Name: "Inner", Fields: []*types.ProtoField{{Name: "inner_status", Type: "Status"}}},
+				},
+			},
+			"Sibling": {
+				Name: "Sibling",
+				Fields: []*types.ProtoField{
+					{Name: "outer_status", Type: "Outer.Status"},
+					{Name: "qualified", Type: "shop.v1.Outer.Status"},
+					{Name: "absolute", Type: ".shop.v1.Outer.Status"},
+					{Name: "file_status", Type: "Status"},
+					{Name: "history", Type: "Outer.Status", Repeated: true},
+				},
+			},
+		},
+	}
+}
+
+func schemaRef(t *testing.T, defs map[string]interface{}, message, property string) string {
+	t.Helper()
+	def, ok := defs[message].(map[string]interface{})
+	if !ok {
+		t.Fatalf("no $defs entry for %s", message)
+	}
+	schema, ok := def["properties"].(map[string]interface{})[property].(map[string]interface{})
+	if !ok {
+		t.Fatalf("%s has no property %s", message, property)
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		schema = items
+	}
+	ref, _ := schema["$ref"].(string)
+	return ref
+}
+
+func TestJSONSchemaNestedEnumRefs(t *testing.T) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(generateSchemaBytes(t, nestedEnumProto()), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	defs := doc["$defs"].(map[string]interface{})
+
+	for _, tc := range []struct {
+		message, property, want string
+	}{
+		// Inside Outer the nested Status shadows the file-level one
+		{"Outer", "status", "#/$defs/Outer.Status"},
+		{"Outer", "inner", "#/$defs/Outer.Inner"},
+		// Inner is nested in Outer, so Status resolves through the enclosing scope
+		{"Outer.Inner", "innerStatus", "#/$defs/Outer.Status"},
+		// From a different message the enum is reached by qualified names only
+		{"Sibling", "outerStatus", "#/$defs/Outer.Status"},
+		{"Sibling", "qualified", "#/$defs/Outer.Status"},
+		{"Sibling", "absolute", "#/$defs/Outer.Status"},
+		{"Sibling", "fileStatus", "#/$defs/Status"},
+		{"Sibling", "history", "#/$defs/Outer.Status"},
+	} {
+		if got := schemaRef(t, defs, tc.message, tc.property); got != tc.want {
+			t.Errorf("%s.%s $ref = %q, want %q", tc.message, tc.property, got, tc.want)
+		}
+		if _, ok := defs[strings.TrimPrefix(tc.want, "#/$defs/")]; !ok {
+			t.Errorf("%s.%s refers to missing definition %s", tc.message, tc.property, tc.want)
+		}
+	}
+}
+
+func TestJSONSchemaCrossFileRefsUseSymbolTable(t *testing.T) {
+	// market_data.proto declares package common, so the file name and package differ
+	common := &types.ProtoFile{
+		FileName: "market_data.proto",
+		BaseName: "market_data",
+		Package:  "common",
+		Messages: map[string]*types.ProtoMessage{
+			"Ticker": {Name: "Ticker", Fields: []*types.ProtoField{{Name: "symbol", Type: "string"}}},
+		},
+	}
+	trading := &types.ProtoFile{
+		FileName: "trading.proto",
+		BaseName: "trading",
+		Package:  "trading",
+		Messages: map[string]*types.ProtoMessage{
+			"Ticker": {Name: "Ticker", Fields: []*types.ProtoField{{Name: "venue", Type: "string"}}},
+			"Quote": {
+				Name: "Quote",
+				Fields: []*types.ProtoField{
+					{Name: "listing", Type: "common.Ticker"},
+					{Name: "history", Type: "common.Ticker", Repeated: true},
+					{Name: "local", Type: "Ticker"},
+				},
+			},
+		},
+	}
+	if _, err := types.BuildSymbolTable([]*types.ProtoFile{common, trading}); err != nil {
+		t.Fatalf("BuildSymbolTable() error = %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(generateSchemaBytes(t, trading), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	defs := doc["$defs"].(map[string]interface{})
+	for property, want := range map[string]string{
+		"listing": "market_data.json#/$defs/Ticker",
+		"history": "market_data.json#/$defs/Ticker",
+		"local":   "#/$defs/Ticker",
+	} {
+		if got := schemaRef(t, defs, "Quote", property); got != want {
+			t.Errorf("Quote.%s $ref = %q, want %q", property, got, want)
+		}
+	}
+}
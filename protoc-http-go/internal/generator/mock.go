@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// generateServiceMock writes <Service>ClientMock, an in-memory stand-in for the
+// service client for unit tests. It has the client's public methods with the same
+// overloads; each answers from a settable <Rpc>Handler delegate instead of making an
+// HTTP call, and throws NotImplementedException while its handler is unset.
+func (g *Generator) generateServiceMock(sb *strings.Builder, service *types.ProtoService) {
+	mockName := fmt.Sprintf("%sClientMock", service.Name)
+
+	fmt.Fprintf(sb, "' %s answers %sClient calls from settable handlers, for unit tests\n", mockName, service.Name)
+	fmt.Fprintf(sb, "Public Class %s\n", mockName)
+	for _, rpc := range service.RPCs {
+		if rpc.IsUnary {
+			fmt.Fprintf(sb, "    Public Property %sHandler As Func(Of %s, %s)\n", rpc.Name, g.getGoType(rpc.InputType), g.getGoType(rpc.OutputType))
+		}
+	}
+	sb.WriteString("\n")
+
+	for _, rpc := range service.RPCs {
+		if !rpc.IsUnary {
+			continue
+		}
+		if g.FrameworkMode == "net40hwr" {
+			g.generateMockMethodNet40HWR(sb, rpc)
+		} else {
+			g.generateMockMethodNet45(sb, rpc)
+		}
+	}
+
+	sb.WriteString("End Class\n")
+}
+
+// generateMockMethodNet45 writes the Async overloads of generateRPCMethodNet45,
+// returning the handler's response as a completed task
+func (g *Generator) generateMockMethodNet45(sb *strings.Builder, rpc *types.ProtoRPC) {
+	methodName := rpc.Name + "Async"
+	inputType := g.getGoType(rpc.InputType)
+	outputType := g.getGoType(rpc.OutputType)
+
+	fmt.Fprintf(sb, "    Public Function %s(request As %s) As Task(Of %s)\n", methodName, inputType, outputType)
+	fmt.Fprintf(sb, "        Return %s(request, CancellationToken.None)\n", methodName)
+	sb.WriteString("    End Function\n\n")
+
+	fmt.Fprintf(sb, "    Public Function %s(request As %s, cancellationToken As CancellationToken) As Task(Of %s)\n", methodName, inputType, outputType)
+	fmt.Fprintf(sb, "        Return %s(request, cancellationToken, Nothing)\n", methodName)
+	sb.WriteString("    End Function\n\n")
+
+	fmt.Fprintf(sb, "    Public Function %s(request As %s, cancellationToken As CancellationToken, Optional timeoutMs As Integer? = Nothing) As Task(Of %s)\n", methodName, inputType, outputType)
+	sb.WriteString("        cancellationToken.ThrowIfCancellationRequested()\n")
+	writeMockHandlerCheck(sb, rpc)
+	fmt.Fprintf(sb, "        Return Task.FromResult(Me.%sHandler(request))\n", rpc.Name)
+	sb.WriteString("    End Function\n\n")
+}
+
+// generateMockMethodNet40HWR writes the synchronous overloads of generateRPCMethodNet40HWR
+func (g *Generator) generateMockMethodNet40HWR(sb *strings.Builder, rpc *types.ProtoRPC) {
+	inputType := g.getGoType(rpc.InputType)
+	outputType := g.getGoType(rpc.OutputType)
+
+	fmt.Fprintf(sb, "    Public Function %s(request As %s) As %s\n", rpc.Name, inputType, outputType)
+	fmt.Fprintf(sb, "        Return %s(request, Nothing, Nothing)\n", rpc.Name)
+	sb.WriteString("    End Function\n\n")
+
+	fmt.Fprintf(sb, "    Public Function %s(request As %s, Optional timeoutMs As Integer? = Nothing, Optional authHeaders As Dictionary(Of String, String) = Nothing) As %s\n", rpc.Name, inputType, outputType)
+	writeMockHandlerCheck(sb, rpc)
+	fmt.Fprintf(sb, "        Return Me.%sHandler(request)\n", rpc.Name)
+	sb.WriteString("    End Function\n\n")
+}
+
+// writeMockHandlerCheck emits the guard failing a mock call whose handler is unset
+func writeMockHandlerCheck(sb *strings.Builder, rpc *types.ProtoRPC) {
+	fmt.Fprintf(sb, "        If Me.%sHandler Is Nothing Then Throw New NotImplementedException(\"%sHandler is not set\")\n", rpc.Name, rpc.Name)
+}
@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+)
+
+const mockProto = `syntax = "proto3";
+package helloworld;
+
+message HelloRequest { string name = 1; }
+message HelloReply { string message = 1; }
+
+service Greeter {
+  rpc SayHello(HelloRequest) returns (HelloReply);
+  // http-method: GET
+  rpc GetGreeting(HelloRequest) returns (HelloReply);
+  rpc StreamHellos(HelloRequest) returns (stream HelloReply);
+}
+`
+
+var publicFunctionRegex = regexp.MustCompile(`(?m)^    Public (?:Async )?(Function .*)$`)
+
+// classPublicFunctions returns the public method signatures of the named class in
+// generated VB source, without the Async modifier
+func classPublicFunctions(t *testing.T, content, className string) []string {
+	t.Helper()
+	start := strings.Index(content, "Public Class "+className+"\n")
+	if start < 0 {
+		t.Fatalf("class %s not generated:\n%s", className, content)
+	}
+	body := content[start:]
+	body = body[:strings.Index(body, "\nEnd Class\n")]
+	var signatures []string
+	for _, match := range publicFunctionRegex.FindAllStringSubmatch(body, -1) {
+		signatures = append(signatures, match[1])
+	}
+	return signatures
+}
+
+func TestEmitMockImplementsEveryClientMethod(t *testing.T) {
+	for _, framework := range []string{"net45", "net40hwr"} {
+		protoFile, err := parser.ParseProtoContent("helloworld.proto", mockProto, parser.Options{})
+		if err != nil {
+			t.Fatalf("ParseProtoContent() error = %v", err)
+		}
+		content, err := GenerateString(protoFile, Options{FrameworkMode: framework, EmitMock: true})
+		if err != nil {
+			t.Fatalf("GenerateString(%s) error = %v", framework, err)
+		}
+
+		client := classPublicFunctions(t, content, "GreeterClient")
+		mock := classPublicFunctions(t, content, "GreeterClientMock")
+		if strings.Join(mock, "\n") != strings.Join(client, "\n") {
+			t.Errorf("%s: mock methods\n%s\nwant the client's\n%s", framework, strings.Join(mock, "\n"), strings.Join(client, "\n"))
+		}
+
+		assertContains(t, content, "    Public Property SayHelloHandler As Func(Of HelloRequest, HelloReply)\n")
+		assertContains(t, content, "    Public Property GetGreetingHandler As Func(Of HelloRequest, HelloReply)\n")
+		assertContains(t, content, `If Me.SayHelloHandler Is Nothing Then Throw New NotImplementedException("SayHelloHandler is not set")`)
+		assertNotContains(t, content, "StreamHellosHandler")
+	}
+}
+
+func TestEmitMockNet45ReturnsCompletedTask(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("helloworld.proto", mockProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{FrameworkMode: "net45", EmitMock: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, content, "        Return Task.FromResult(Me.SayHelloHandler(request))\n")
+}
+
+func TestMockNotGeneratedByDefault(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("helloworld.proto", mockProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertNotContains(t, content, "GreeterClientMock")
+}
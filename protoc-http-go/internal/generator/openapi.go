@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// GenerateOpenAPI generates an OpenAPI 3.1 document describing the HTTP routes of
+// every unary RPC in the proto file.
+//
+// It creates an openapi/ subdirectory under outputDir and writes a .json file whose
+// components.schemas reuse the JSON Schema definitions (OpenAPI 3.1 is aligned with
+// JSON Schema 2020-12). POST routes take the request message as the JSON body;
+// GET-annotated routes take its scalar fields as query parameters.
+//
+// Routes are placed like the generated clients' (see VersionPlacement).
+//
+// Returns the path to the generated OpenAPI document or an error.
+func (g *Generator) GenerateOpenAPI(protoFile *types.ProtoFile, outputDir, baseURL string) (string, error) {
+	openAPIDir := filepath.Join(outputDir, "openapi")
+	if err := os.MkdirAll(openAPIDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create openapi directory: %w", err)
+	}
+
+	// Reuse the JSON Schema definitions, re-pointing refs at components/schemas
+	schemas := make(map[string]interface{})
+	for _, enum := range protoFile.Enums {
+		schemas[enum.Name] = buildEnumSchema(enum)
+	}
+	for _, msg := range protoFile.Messages {
+		if err := collectMessageSchemas(protoFile, msg, []string{}, schemas); err != nil {
+			return "", fmt.Errorf("failed to collect message schemas: %w", err)
+		}
+	}
+	rewriteSchemaRefs(schemas)
+
+	paths := make(map[string]interface{})
+	for _, service := range protoFile.Services {
+		for _, rpc := range service.RPCs {
+			if !rpc.IsUnary {
+				continue
+			}
+			route := g.routePath(protoFile, service, rpc)
+			method := "post"
+			if rpc.IsGet() {
+				method = "get"
+			}
+			paths[route] = map[string]interface{}{
+				method: buildOpenAPIOperation(protoFile, service, rpc),
+			}
+		}
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":   fmt.Sprintf("HTTP API for %s", filepath.Base(protoFile.FileName)),
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+	if baseURL != "" {
+		doc["servers"] = []interface{}{map[string]interface{}{"url": baseURL}}
+	}
+
+	// Marshal to JSON with indentation
+	jsonBytes, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal OpenAPI document: %w", err)
+	}
+
+	outputPath := filepath.Join(openAPIDir, protoFile.BaseName+".json")
+	if err := os.WriteFile(outputPath, jsonBytes, 0644); err != nil {
+		return "", fmt.Errorf("failed to write OpenAPI file: %w", err)
+	}
+
+	return outputPath, nil
+}
+
+// buildOpenAPIOperation describes a single RPC route
+func buildOpenAPIOperation(protoFile *types.ProtoFile, service *types.ProtoService, rpc *types.ProtoRPC) map[string]interface{} {
+	operation := map[string]interface{}{
+		"operationId": service.Name + "_" + rpc.Name,
+		"tags":        []string{service.Name},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Successful response",
+				"content":     jsonContent(openAPITypeSchema(protoFile, rpc.OutputType)),
+			},
+			"default": map[string]interface{}{
+				"description": "Error response from the proxy or backend",
+			},
+		},
+	}
+
+	if !rpc.IsGet() {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(openAPITypeSchema(protoFile, rpc.InputType)),
+		}
+		return operation
+	}
+
+	// GET: request fields become query parameters (messages and bytes are not representable)
+	var parameters []interface{}
+	if message := findMessage(protoFile, rpc.InputType); message != nil {
+		scope := strings.Split(schemaTypeName(protoFile, nil, rpc.InputType), ".")
+		for _, field := range message.Fields {
+			_, scalar := ScalarTypeMapJSON[field.Type]
+			if field.Type == "bytes" || !scalar && findEnum(protoFile, message, field.Type) == nil {
+				continue
+			}
+			schema := getJSONSchemaType(schemaTypeName(protoFile, scope, field.Type), field.Repeated, protoFile.Package)
+			rewriteSchemaRefs(schema)
+			parameters = append(parameters, map[string]interface{}{
+				"name":     types.FieldJSONName(field, message.Name),
+				"in":       "query",
+				"required": false,
+				"schema":   schema,
+			})
+		}
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	return operation
+}
+
+// openAPITypeSchema returns a schema reference for a message type
+func openAPITypeSchema(protoFile *types.ProtoFile, protoType string) map[string]interface{} {
+	schema := getJSONSchemaType(schemaTypeName(protoFile, nil, protoType), false, protoFile.Package)
+	rewriteSchemaRefs(schema)
+	return schema
+}
+
+// jsonContent wraps a schema as an application/json media type
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// rewriteSchemaRefs rewrites JSON Schema "$defs" references in place so they point at
+// OpenAPI components (e.g. "#/$defs/Foo" → "#/components/schemas/Foo"). Cross-file
+// references keep their file name and switch to the sibling OpenAPI document.
+func rewriteSchemaRefs(node interface{}) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if ref, ok := value.(string); ok && key == "$ref" {
+				v[key] = strings.Replace(ref, "#/$defs/", "#/components/schemas/", 1)
+				continue
+			}
+			rewriteSchemaRefs(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			rewriteSchemaRefs(item)
+		}
+	}
+}
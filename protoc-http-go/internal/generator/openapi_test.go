@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	outDir := t.TempDir()
+	docPath, err := (&Generator{}).GenerateOpenAPI(testGetProto(), outDir, "")
+	if err != nil {
+		t.Fatalf("GenerateOpenAPI() error = %v", err)
+	}
+	if want := filepath.Join(outDir, "openapi", "search.json"); docPath != want {
+		t.Fatalf("GenerateOpenAPI() path = %s, want %s", docPath, want)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(readFile(t, docPath)), &doc); err != nil {
+		t.Fatalf("OpenAPI document is not valid JSON: %v", err)
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v, want 3.1.0", doc["openapi"])
+	}
+	if _, ok := doc["servers"]; ok {
+		t.Errorf("expected no servers without a base URL")
+	}
+
+	content := readFile(t, docPath)
+	assertContains(t, content, `"/search/index/v1"`)
+	assertContains(t, content, `"/search/search/v1"`)
+	assertContains(t, content, `"operationId": "SearchService_Search"`)
+	assertContains(t, content, `"$ref": "#/components/schemas/SearchRequest"`)
+	assertContains(t, content, `"$ref": "#/components/schemas/Status"`)
+	assertNotContains(t, content, `#/$defs/`)
+
+	paths := doc["paths"].(map[string]interface{})
+	post := paths["/search/index/v1"].(map[string]interface{})["post"].(map[string]interface{})
+	if _, ok := post["requestBody"]; !ok {
+		t.Errorf("expected POST operation to have a request body")
+	}
+
+	get := paths["/search/search/v1"].(map[string]interface{})["get"].(map[string]interface{})
+	var names []string
+	for _, p := range get["parameters"].([]interface{}) {
+		names = append(names, p.(map[string]interface{})["name"].(string))
+	}
+	want := []string{"query", "includeArchived", "pageSize", "minScore", "status", "tags", "flags"}
+	if len(names) != len(want) {
+		t.Fatalf("GET parameters = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("GET parameters = %v, want %v", names, want)
+		}
+	}
+}
+
+func TestGenerateOpenAPIVersionPrefix(t *testing.T) {
+	gen := &Generator{VersionPlacement: VersionPrefix}
+	docPath, err := gen.GenerateOpenAPI(testGetProto(), t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("GenerateOpenAPI() error = %v", err)
+	}
+	content := readFile(t, docPath)
+	assertContains(t, content, `"/v1/search/index"`)
+	assertContains(t, content, `"/v1/search/search"`)
+	assertNotContains(t, content, `"/search/index/v1"`)
+}
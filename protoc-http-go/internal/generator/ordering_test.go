@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+)
+
+const orderingProto = `syntax = "proto3";
+package shop;
+
+enum Zone { ZONE_UNSPECIFIED = 0; EAST = 1; WEST = 2; }
+enum Channel { CHANNEL_UNSPECIFIED = 0; WEB = 1; }
+
+message Order {
+  enum Status { STATUS_UNSPECIFIED = 0; OPEN = 1; CLOSED = 2; }
+  enum Priority { PRIORITY_UNSPECIFIED = 0; HIGH = 1; }
+  message Shipping { string carrier = 1; }
+  message Billing { string account = 1; }
+  message Audit { string actor = 1; }
+  Shipping shipping = 1;
+  Billing billing = 2;
+}
+
+message Cart { string id = 1; }
+`
+
+func TestVBOutputIsDeterministic(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("shop.proto", orderingProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	first, err := GenerateString(protoFile, Options{})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	// Map iteration order changes between iterations, so repeat to catch it
+	for i := 0; i < 20; i++ {
+		again, err := GenerateString(protoFile, Options{})
+		if err != nil {
+			t.Fatalf("GenerateString() error = %v", err)
+		}
+		if again != first {
+			t.Fatalf("run %d produced different output:\n%s\n---\n%s", i, first, again)
+		}
+	}
+
+	// Declarations come out in source order, enum values by number
+	assertInOrder(t, first,
+		"Public Enum Zone As Integer",
+		"    Zone_ZONE_UNSPECIFIED = 0\n    Zone_EAST = 1\n    Zone_WEST = 2\n",
+		"Public Enum Channel As Integer",
+		"Public Class Order\n",
+		"Public Enum Status As Integer",
+		"Public Enum Priority As Integer",
+		"Public Class Order_Shipping\n",
+		"Public Class Order_Billing\n",
+		"Public Class Order_Audit\n",
+		"Public Class Cart\n",
+	)
+}
+
+// assertInOrder fails unless every part occurs in s, each after the previous one
+func assertInOrder(t *testing.T, s string, parts ...string) {
+	t.Helper()
+	pos := 0
+	for _, part := range parts {
+		i := strings.Index(s[pos:], part)
+		if i < 0 {
+			t.Fatalf("expected %q after offset %d in:\n%s", part, pos, s)
+		}
+		pos += i + len(part)
+	}
+}
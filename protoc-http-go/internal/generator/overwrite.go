@@ -0,0 +1,32 @@
+package generator
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrOutputExists is returned by the Generate*File methods when NoOverwrite is set
+// and the output file is already there
+var ErrOutputExists = errors.New("output file already exists")
+
+// writeOutput writes data to outputPath, replacing an existing file unless
+// NoOverwrite is set, in which case the file is left untouched and
+// ErrOutputExists is returned
+func (g *Generator) writeOutput(outputPath string, data []byte) error {
+	if !g.NoOverwrite {
+		return os.WriteFile(outputPath, data, 0644)
+	}
+	// O_EXCL makes the check and the creation one step
+	f, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return ErrOutputExists
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
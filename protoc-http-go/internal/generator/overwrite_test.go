@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNoOverwriteKeepsExistingFile(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "search.vb")
+	if err := os.WriteFile(outputPath, []byte("' hand-edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := &Generator{NoOverwrite: true}
+	if err := gen.GenerateFile(testGetProto(), outputPath); !errors.Is(err, ErrOutputExists) {
+		t.Fatalf("GenerateFile() error = %v, want ErrOutputExists", err)
+	}
+	if got := readFile(t, outputPath); got != "' hand-edited\n" {
+		t.Errorf("existing file was modified:\n%s", got)
+	}
+
+	// A missing file is created as usual
+	newPath := filepath.Join(filepath.Dir(outputPath), "new.vb")
+	if err := gen.GenerateFile(testGetProto(), newPath); err != nil {
+		t.Fatalf("GenerateFile() error = %v", err)
+	}
+	assertContains(t, readFile(t, newPath), "SearchServiceClient")
+}
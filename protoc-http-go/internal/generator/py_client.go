@@ -0,0 +1,446 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// pyAnyType is used for fields whose type is declared in another proto file; the
+// value is kept as decoded JSON because the Python class is not generated here.
+const pyAnyType = "Any"
+
+// pyScalarTypes maps protobuf scalar types to Python types
+var pyScalarTypes = map[string]string{
+	"string":   "str",
+	"int32":    "int",
+	"int64":    "int",
+	"uint32":   "int",
+	"uint64":   "int",
+	"sint32":   "int",
+	"sint64":   "int",
+	"fixed32":  "int",
+	"fixed64":  "int",
+	"sfixed32": "int",
+	"sfixed64": "int",
+	"bool":     "bool",
+	"bytes":    "bytes",
+	"double":   "float",
+	"float":    "float",
+}
+
+// pyZeroValues are the proto3 default values of the Python scalar types
+var pyZeroValues = map[string]string{
+	"str":   `""`,
+	"int":   "0",
+	"bool":  "False",
+	"bytes": `b""`,
+	"float": "0.0",
+}
+
+// pyKeywords are reserved in Python and get a trailing underscore as identifiers
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true,
+	"def": true, "del": true, "elif": true, "else": true, "except": true, "finally": true,
+	"for": true, "from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true,
+	"raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// PythonModuleName returns the module name of the generated Python client: the
+// file base name in lower case with characters not allowed in identifiers
+// replaced by underscores (e.g. "stock-service" → "stock_service").
+func PythonModuleName(protoFile *types.ProtoFile) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(protoFile.BaseName) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	name := sb.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' || pyKeywords[name] {
+		return "pb_" + name
+	}
+	return name
+}
+
+// GeneratePythonFile generates a Python HTTP client for the given proto file:
+// @dataclass message classes with to_dict/from_dict for proto JSON, enum.IntEnum
+// enums, and one async httpx client class per service.
+func (g *Generator) GeneratePythonFile(protoFile *types.ProtoFile, outputPath string) error {
+	content := g.generatePythonSource(protoFile)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return g.writeOutput(outputPath, []byte(content))
+}
+
+// pyField describes how one proto field is declared and converted in Python
+type pyField struct {
+	name     string // Attribute name
+	key      string // JSON key
+	pyType   string // Annotation
+	def      string // Default value, or a dataclasses.field(...) call
+	toJSON   func(value string) string
+	fromJSON func(value string) string
+	repeated bool
+}
+
+// generatePythonSource renders the Python client source for protoFile
+func (g *Generator) generatePythonSource(protoFile *types.ProtoFile) string {
+	var body strings.Builder
+
+	// Enums first: message defaults refer to their members when the class is created
+	for _, name := range sortedKeys(protoFile.Enums) {
+		g.generatePyEnum(&body, protoFile.Enums[name], name)
+	}
+	for _, name := range sortedKeys(protoFile.Messages) {
+		g.generatePyNestedEnums(&body, protoFile.Messages[name], []string{name})
+	}
+	for _, name := range sortedKeys(protoFile.Messages) {
+		g.generatePyMessage(&body, protoFile, protoFile.Messages[name], []string{name})
+	}
+	for _, service := range protoFile.Services {
+		g.generatePyServiceClient(&body, protoFile, service)
+	}
+
+	// Imports depend on what the body ended up using
+	code := body.String()
+	var stdlib []string
+	if strings.Contains(code, "base64.") {
+		stdlib = append(stdlib, "import base64")
+	}
+	if strings.Contains(code, "dataclasses.") {
+		stdlib = append(stdlib, "import dataclasses")
+	}
+	if strings.Contains(code, "enum.IntEnum") {
+		stdlib = append(stdlib, "import enum")
+	}
+	var typing []string
+	for _, name := range []string{"Any", "Dict", "List", "Optional", "Tuple"} {
+		if regexp.MustCompile(`\b` + name + `\b`).MatchString(code) {
+			typing = append(typing, name)
+		}
+	}
+	if len(typing) > 0 {
+		stdlib = append(stdlib, "from typing import "+strings.Join(typing, ", "))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Code generated by protoc-http-go from %s. DO NOT EDIT.\n", filepath.Base(protoFile.FileName))
+	fmt.Fprintf(&sb, "\"\"\"HTTP client for %s.\"\"\"\n\n", filepath.Base(protoFile.FileName))
+	sb.WriteString("from __future__ import annotations\n\n")
+	for _, line := range stdlib {
+		sb.WriteString(line + "\n")
+	}
+	if strings.Contains(code, "httpx.") {
+		sb.WriteString("\nimport httpx\n")
+	}
+	if strings.Contains(code, "_enum_from_json(") {
+		sb.WriteString("\n\n")
+		sb.WriteString("def _enum_from_json(enum_type: Any, value: Any) -> Any:\n")
+		sb.WriteString("    \"\"\"Accepts an enum value by name, as proto JSON writes it, or by number\"\"\"\n")
+		sb.WriteString("    if isinstance(value, str):\n")
+		sb.WriteString("        return enum_type[value]\n")
+		sb.WriteString("    return enum_type(value)\n")
+	}
+	sb.WriteString(code)
+	return sb.String()
+}
+
+// generatePyNestedEnums emits the enums nested in message and its nested messages,
+// named Outer_Inner like the message classes
+func (g *Generator) generatePyNestedEnums(sb *strings.Builder, message *types.ProtoMessage, path []string) {
+	for _, name := range sortedKeys(message.NestedEnums) {
+		g.generatePyEnum(sb, message.NestedEnums[name], strings.Join(append(append([]string{}, path...), name), "_"))
+	}
+	for _, name := range sortedKeys(message.NestedMessages) {
+		g.generatePyNestedEnums(sb, message.NestedMessages[name], append(append([]string{}, path...), name))
+	}
+}
+
+// generatePyEnum emits an enum.IntEnum; members keep the proto value names
+func (g *Generator) generatePyEnum(sb *strings.Builder, enum *types.ProtoEnum, pyName string) {
+	sb.WriteString("\n\n")
+	fmt.Fprintf(sb, "class %s(enum.IntEnum):\n", pyName)
+	fmt.Fprintf(sb, "    \"\"\"%s represents the %s enum from the proto definition\"\"\"\n\n", pyName, enum.Name)
+	for _, value := range enumValuesInOrder(enum) {
+		fmt.Fprintf(sb, "    %s = %d\n", pyIdentifier(value), enum.Values[value])
+	}
+}
+
+// generatePyMessage emits a @dataclass for a message; nested types become Outer_Inner
+func (g *Generator) generatePyMessage(sb *strings.Builder, protoFile *types.ProtoFile, message *types.ProtoMessage, path []string) {
+	pyName := strings.Join(path, "_")
+	fields := make([]pyField, 0, len(message.Fields))
+	for _, field := range message.Fields {
+		fields = append(fields, g.pyFieldOf(protoFile, path, message, field))
+	}
+
+	sb.WriteString("\n\n")
+	sb.WriteString("@dataclasses.dataclass\n")
+	fmt.Fprintf(sb, "class %s:\n", pyName)
+	fmt.Fprintf(sb, "    \"\"\"%s represents the %s message from the proto definition\"\"\"\n\n", pyName, strings.Join(path, "."))
+	for _, field := range fields {
+		fmt.Fprintf(sb, "    %s: %s = %s\n", field.name, field.pyType, field.def)
+	}
+	if len(fields) > 0 {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("    def to_dict(self) -> Dict[str, Any]:\n")
+	sb.WriteString("        \"\"\"Returns the proto JSON form of the message, leaving out default values\"\"\"\n")
+	sb.WriteString("        data: Dict[str, Any] = {}\n")
+	for _, field := range fields {
+		value := "self." + field.name
+		fmt.Fprintf(sb, "        if %s:\n", value)
+		if field.repeated && field.toJSON("item") == "item" {
+			fmt.Fprintf(sb, "            data[%q] = list(%s)\n", field.key, value)
+		} else if field.repeated {
+			fmt.Fprintf(sb, "            data[%q] = [%s for item in %s]\n", field.key, field.toJSON("item"), value)
+		} else {
+			fmt.Fprintf(sb, "            data[%q] = %s\n", field.key, field.toJSON(value))
+		}
+	}
+	sb.WriteString("        return data\n\n")
+
+	sb.WriteString("    @classmethod\n")
+	fmt.Fprintf(sb, "    def from_dict(cls, data: Dict[str, Any]) -> %s:\n", pyName)
+	sb.WriteString("        \"\"\"Builds the message from its proto JSON form; missing fields keep their defaults\"\"\"\n")
+	sb.WriteString("        message = cls()\n")
+	for _, field := range fields {
+		fmt.Fprintf(sb, "        if data.get(%q) is not None:\n", field.key)
+		value := fmt.Sprintf("data[%q]", field.key)
+		if field.repeated && field.fromJSON("item") == "item" {
+			fmt.Fprintf(sb, "            message.%s = list(%s)\n", field.name, value)
+		} else if field.repeated {
+			fmt.Fprintf(sb, "            message.%s = [%s for item in %s]\n", field.name, field.fromJSON("item"), value)
+		} else {
+			fmt.Fprintf(sb, "            message.%s = %s\n", field.name, field.fromJSON(value))
+		}
+	}
+	sb.WriteString("        return message\n")
+
+	for _, name := range sortedKeys(message.NestedMessages) {
+		g.generatePyMessage(sb, protoFile, message.NestedMessages[name], append(append([]string{}, path...), name))
+	}
+}
+
+// pyFieldOf maps a proto field of the message at path to its Python declaration
+// and JSON conversions. 64-bit integers are written as strings and bytes as
+// base64, as proto JSON requires; enums are written by value name.
+func (g *Generator) pyFieldOf(protoFile *types.ProtoFile, path []string, message *types.ProtoMessage, field *types.ProtoField) pyField {
+	f := pyField{
+		name:     pyIdentifier(field.Name),
+		key:      types.FieldJSONName(field, message.Name),
+		repeated: field.Repeated,
+		toJSON:   func(v string) string { return v },
+		fromJSON: func(v string) string { return v },
+	}
+	elem := pyAnyType
+	def := "None"
+	if scalar, ok := pyScalarTypes[field.Type]; ok {
+		elem, def = scalar, pyZeroValues[scalar]
+		switch {
+		case field.Type == "bytes":
+			f.toJSON = func(v string) string { return fmt.Sprintf("base64.b64encode(%s).decode(\"ascii\")", v) }
+			f.fromJSON = func(v string) string { return fmt.Sprintf("base64.b64decode(%s)", v) }
+		case types.GoTypeMappings[field.Type] == "int64" || types.GoTypeMappings[field.Type] == "uint64":
+			f.toJSON = func(v string) string { return fmt.Sprintf("str(%s)", v) }
+			f.fromJSON = func(v string) string { return fmt.Sprintf("int(%s)", v) }
+		case scalar == "int" || scalar == "float":
+			f.fromJSON = func(v string) string { return fmt.Sprintf("%s(%s)", scalar, v) }
+		}
+	} else if typePath, isEnum, ok := resolveLocalType(protoFile, path, field.Type); ok {
+		elem = strings.Join(typePath, "_")
+		if isEnum {
+			def = elem + "." + pyIdentifier(enumZeroValue(protoFile, typePath))
+			f.toJSON = func(v string) string { return v + ".name" }
+			f.fromJSON = func(v string) string { return fmt.Sprintf("_enum_from_json(%s, %s)", elem, v) }
+		} else {
+			f.toJSON = func(v string) string { return v + ".to_dict()" }
+			f.fromJSON = func(v string) string { return fmt.Sprintf("%s.from_dict(%s)", elem, v) }
+		}
+	}
+
+	switch {
+	case field.Repeated:
+		f.pyType, f.def = "List["+elem+"]", "dataclasses.field(default_factory=list)"
+	case def == "None":
+		f.pyType, f.def = "Optional["+elem+"]", def
+	default:
+		f.pyType, f.def = elem, def
+	}
+	return f
+}
+
+// enumZeroValue returns the name of the value numbered 0 of the local enum at
+// path (the proto3 default), or its first value when there is none
+func enumZeroValue(protoFile *types.ProtoFile, path []string) string {
+	var enum *types.ProtoEnum
+	if len(path) == 1 {
+		enum = protoFile.Enums[path[0]]
+	} else if owner := findMessage(protoFile, strings.Join(path[:len(path)-1], ".")); owner != nil {
+		enum = owner.NestedEnums[path[len(path)-1]]
+	}
+	if enum == nil {
+		return ""
+	}
+	values := enumValuesInOrder(enum)
+	for _, value := range values {
+		if enum.Values[value] == 0 {
+			return value
+		}
+	}
+	return values[0]
+}
+
+// generatePyServiceClient emits an async httpx client class with one method per unary RPC
+func (g *Generator) generatePyServiceClient(sb *strings.Builder, protoFile *types.ProtoFile, service *types.ProtoService) {
+	clientName := service.Name + "Client"
+
+	sb.WriteString("\n\n")
+	fmt.Fprintf(sb, "class %s:\n", clientName)
+	fmt.Fprintf(sb, "    \"\"\"%s is an HTTP client for the %s service.\n\n", clientName, service.Name)
+	sb.WriteString("    Pass an httpx.AsyncClient to share its connection pool or settings; a client\n")
+	sb.WriteString("    created here is closed by aclose() or when leaving \"async with\".\n")
+	sb.WriteString("    \"\"\"\n\n")
+
+	sb.WriteString("    def __init__(self, base_url: str = \"\", client: Optional[httpx.AsyncClient] = None) -> None:\n")
+	if g.BaseURL != "" {
+		// --baseurl becomes the default when the caller passes no base URL
+		fmt.Fprintf(sb, "        self.base_url = base_url or %s\n", pyStringLiteral(g.BaseURL))
+	} else {
+		sb.WriteString("        self.base_url = base_url\n")
+	}
+	sb.WriteString("        self._owns_client = client is None\n")
+	sb.WriteString("        self._client = client if client is not None else httpx.AsyncClient()\n\n")
+
+	sb.WriteString("    async def aclose(self) -> None:\n")
+	sb.WriteString("        \"\"\"Closes the HTTP client if this client created it\"\"\"\n")
+	sb.WriteString("        if self._owns_client:\n")
+	sb.WriteString("            await self._client.aclose()\n\n")
+	fmt.Fprintf(sb, "    async def __aenter__(self) -> %s:\n", clientName)
+	sb.WriteString("        return self\n\n")
+	sb.WriteString("    async def __aexit__(self, *exc_info: Any) -> None:\n")
+	sb.WriteString("        await self.aclose()\n")
+
+	for _, rpc := range service.RPCs {
+		if rpc.IsUnary {
+			g.generatePyRPCMethod(sb, protoFile, service, rpc)
+		}
+	}
+}
+
+// generatePyRPCMethod emits a client method that POSTs JSON (or GETs with query
+// parameters for GET-annotated RPCs) and decodes the JSON response. Non-2xx
+// responses raise httpx.HTTPStatusError.
+func (g *Generator) generatePyRPCMethod(sb *strings.Builder, protoFile *types.ProtoFile, service *types.ProtoService, rpc *types.ProtoRPC) {
+	inputType, inputLocal := g.pyMessageRef(protoFile, rpc.InputType)
+	outputType, outputLocal := g.pyMessageRef(protoFile, rpc.OutputType)
+	relativePath := g.routePath(protoFile, service, rpc)
+
+	sb.WriteString("\n")
+	fmt.Fprintf(sb, "    async def %s(self, req: %s) -> %s:\n", pyIdentifier(pySnakeCase(rpc.Name)), inputType, outputType)
+	fmt.Fprintf(sb, "        \"\"\"%s calls the %s RPC method\"\"\"\n", rpc.Name, rpc.Name)
+	if rpc.IsGet() {
+		g.generatePyQueryParams(sb, protoFile, rpc)
+		sb.WriteString("        response = await self._client.get(\n")
+		fmt.Fprintf(sb, "            self.base_url + %s,\n", pyStringLiteral(relativePath))
+		sb.WriteString("            params=params,\n")
+	} else {
+		body := "req"
+		if inputLocal {
+			body = "req.to_dict()"
+		}
+		sb.WriteString("        response = await self._client.post(\n")
+		fmt.Fprintf(sb, "            self.base_url + %s,\n", pyStringLiteral(relativePath))
+		fmt.Fprintf(sb, "            json=%s,\n", body)
+	}
+	sb.WriteString("            headers={\"Accept\": \"application/json\"},\n")
+	sb.WriteString("        )\n")
+	sb.WriteString("        response.raise_for_status()\n")
+	if outputLocal {
+		fmt.Fprintf(sb, "        return %s.from_dict(response.json())\n", outputType)
+	} else {
+		sb.WriteString("        return response.json()\n")
+	}
+}
+
+// pyMessageRef returns the Python class of an RPC input or output type, and
+// whether it is generated in this file; other types are plain decoded JSON
+func (g *Generator) pyMessageRef(protoFile *types.ProtoFile, protoType string) (string, bool) {
+	if path, isEnum, ok := resolveLocalType(protoFile, nil, protoType); ok && !isEnum {
+		return strings.Join(path, "_"), true
+	}
+	return pyAnyType, false
+}
+
+// generatePyQueryParams emits statements building a list named "params" from the
+// request's scalar and enum fields, skipping default values like the VB generator
+func (g *Generator) generatePyQueryParams(sb *strings.Builder, protoFile *types.ProtoFile, rpc *types.ProtoRPC) {
+	sb.WriteString("        params: List[Tuple[str, str]] = []\n")
+	message := findMessage(protoFile, rpc.InputType)
+	if message == nil {
+		return
+	}
+	scope := strings.Split(rpc.InputType, ".")
+
+	for _, field := range message.Fields {
+		key := types.FieldJSONName(field, message.Name)
+		property := "req." + pyIdentifier(field.Name)
+
+		var valueExpr string
+		switch scalar := pyScalarTypes[field.Type]; {
+		case scalar == "str":
+			valueExpr = "%s"
+		case scalar == "bool":
+			valueExpr = "\"true\" if %s else \"false\""
+		case scalar == "int" || scalar == "float":
+			valueExpr = "str(%s)"
+		case scalar == "":
+			if _, isEnum, ok := resolveLocalType(protoFile, scope, field.Type); ok && isEnum {
+				valueExpr = "%s.name"
+				break
+			}
+			fallthrough
+		default:
+			fmt.Fprintf(sb, "        # %s (%s) cannot be sent as a query parameter\n", key, field.Type)
+			continue
+		}
+
+		if field.Repeated {
+			fmt.Fprintf(sb, "        for item in %s:\n", property)
+			fmt.Fprintf(sb, "            params.append((%q, "+valueExpr+"))\n", key, "item")
+			continue
+		}
+		fmt.Fprintf(sb, "        if %s:\n", property)
+		fmt.Fprintf(sb, "            params.append((%q, "+valueExpr+"))\n", key, property)
+	}
+}
+
+// pyIdentifier returns name, with a trailing underscore if it is a Python keyword
+func pyIdentifier(name string) string {
+	if pyKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// pySnakeCase converts an RPC name such as "GetHTTPStatusV2" to "get_http_status_v2"
+func pySnakeCase(name string) string {
+	return strings.ToLower(types.UpperSnakeCase(name))
+}
+
+// pyStringLiteral quotes s as a Python string literal; the escapes Go's %q
+// produces (\n, \xNN, \uNNNN, \UNNNNNNNN) mean the same in Python
+func pyStringLiteral(s string) string {
+	return fmt.Sprintf("%q", s)
+}
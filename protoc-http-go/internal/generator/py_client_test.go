@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+)
+
+const pythonProto = `syntax = "proto3";
+
+package shop.orders;
+
+import "common/money.proto";
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+  STATUS_SHIPPED = 2;
+}
+
+message Order {
+  message Line {
+    enum Kind {
+      KIND_UNSPECIFIED = 0;
+      KIND_GIFT = 1;
+    }
+    string sku = 1;
+    int32 quantity = 2;
+    Kind kind = 3;
+  }
+  string id = 1;
+  repeated Line lines = 2;
+  Status status = 3;
+  int64 total_cents = 4;
+  bytes receipt = 5;
+  repeated string tags = 6;
+  common.Money price = 7;
+  bool from = 8;
+}
+
+message GetOrderRequest {
+  string id = 1;
+  bool include_lines = 2;
+  Status status = 3;
+  repeated string fields = 4;
+  Order.Line line = 5;
+}
+
+message ListOrdersReply {
+  repeated Order orders = 1;
+  double average = 2;
+}
+
+service OrderService {
+  // http-method: GET
+  rpc GetOrder(GetOrderRequest) returns (Order);
+  rpc ListOrdersV2(GetOrderRequest) returns (ListOrdersReply);
+  rpc Watch(GetOrderRequest) returns (stream Order);
+}
+`
+
+func TestPythonClientGolden(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("orders.proto", pythonProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	gen := &Generator{BaseURL: "http://localhost:8080"}
+	assertGolden(t, "python_client.py.golden", gen.generatePythonSource(protoFile))
+}
+
+func TestPythonClientWithoutServices(t *testing.T) {
+	content := (&Generator{}).generatePythonSource(testGetProto())
+
+	assertContains(t, content, "class Filter:")
+	// A field named "field" must not shadow dataclasses.field
+	assertContains(t, content, `    field: str = ""`)
+	assertContains(t, content, "class SearchServiceClient:")
+	assertContains(t, content, "        self.base_url = base_url\n")
+	assertContains(t, content, `            params.append(("includeArchived", "true" if req.include_archived else "false"))`)
+	assertContains(t, content, "        # filter (Filter) cannot be sent as a query parameter")
+}
+
+func TestPythonModuleName(t *testing.T) {
+	tests := map[string]string{
+		"orders":        "orders",
+		"stock-service": "stock_service",
+		"Greeter.v1":    "greeter_v1",
+		"3d":            "pb_3d",
+		"import":        "pb_import",
+	}
+	for baseName, want := range tests {
+		protoFile, _ := parser.ParseProtoContent(baseName+".proto", `syntax = "proto3";`, parser.Options{})
+		if got := PythonModuleName(protoFile); got != want {
+			t.Errorf("PythonModuleName(%q) = %q, want %q", baseName, got, want)
+		}
+	}
+}
+
+func TestPySnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"SayHello":        "say_hello",
+		"GetOrderV2":      "get_order_v2",
+		"GetHTTPStatus":   "get_http_status",
+		"ListOrdersV2Raw": "list_orders_v2_raw",
+	}
+	for name, want := range tests {
+		if got := pySnakeCase(name); got != want {
+			t.Errorf("pySnakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// serviceHasGetRPC reports whether any unary RPC of the service is exposed as HTTP GET
+func serviceHasGetRPC(service *types.ProtoService) bool {
+	for _, rpc := range service.RPCs {
+		if rpc.IsUnary && rpc.IsGet() {
+			return true
+		}
+	}
+	return false
+}
+
+// findMessage resolves a (possibly dotted, e.g. "Outer.Inner") message type name
+// declared in the given proto file. Returns nil for unknown or cross-package types.
+func findMessage(protoFile *types.ProtoFile, typeName string) *types.ProtoMessage {
+	parts := strings.Split(typeName, ".")
+	message, ok := protoFile.Messages[parts[0]]
+	if !ok {
+		return nil
+	}
+	for _, part := range parts[1:] {
+		if message, ok = message.NestedMessages[part]; !ok {
+			return nil
+		}
+	}
+	return message
+}
+
+// findEnum resolves an enum type referenced from a field of scope.
+// It checks enums nested in scope first, then top-level enums, then dotted
+// references to enums nested in other messages (e.g. "Outer.Status").
+func findEnum(protoFile *types.ProtoFile, scope *types.ProtoMessage, typeName string) *types.ProtoEnum {
+	if scope != nil {
+		if enum, ok := scope.NestedEnums[typeName]; ok {
+			return enum
+		}
+	}
+	if enum, ok := protoFile.Enums[typeName]; ok {
+		return enum
+	}
+	if idx := strings.LastIndex(typeName, "."); idx > 0 {
+		if owner := findMessage(protoFile, typeName[:idx]); owner != nil {
+			return owner.NestedEnums[typeName[idx+1:]]
+		}
+	}
+	return nil
+}
+
+// queryValueExpr returns the VB expression that formats value (a VB expression of the
+// field's element type) as a query-string value, or "" if the type cannot be encoded.
+// Booleans become true/false, enums their proto value name, numbers use the invariant culture.
+func (g *Generator) queryValueExpr(field *types.ProtoField, enum *types.ProtoEnum, value string) string {
+	if enum != nil {
+		// VB enum members are emitted as <Enum>_<VALUE>; strip the prefix to get the proto name
+		if prefix := g.strippedEnumPrefix(enum); prefix != "" {
+			// and put back the enum-name prefix that StripEnumPrefix removed from it
+			return fmt.Sprintf("Uri.EscapeDataString(\"%s\" & %s.ToString().Substring(%d))", prefix, value, len(enum.Name)+1)
+		}
+		return fmt.Sprintf("Uri.EscapeDataString(%s.ToString().Substring(%d))", value, len(enum.Name)+1)
+	}
+	switch field.Type {
+	case "string":
+		return fmt.Sprintf("Uri.EscapeDataString(%s)", value)
+	case "bool":
+		return fmt.Sprintf("If(%s, \"true\", \"false\")", value)
+	case "double", "float":
+		return fmt.Sprintf("%s.ToString(\"R\", Globalization.CultureInfo.InvariantCulture)", value)
+	case "int32", "int64", "uint32", "uint64", "sint32", "sint64",
+		"fixed32", "fixed64", "sfixed32", "sfixed64":
+		return fmt.Sprintf("%s.ToString(Globalization.CultureInfo.InvariantCulture)", value)
+	}
+	return ""
+}
+
+// queryPresenceCheck returns the VB condition under which a singular field is sent.
+// Default values (empty string, false, zero) are skipped, matching proto3 JSON semantics.
+func queryPresenceCheck(field *types.ProtoField, enum *types.ProtoEnum, value string) string {
+	if enum != nil {
+		return fmt.Sprintf("CInt(%s) <> 0", value)
+	}
+	switch field.Type {
+	case "string":
+		return fmt.Sprintf("Not String.IsNullOrEmpty(%s)", value)
+	case "bool":
+		return value
+	}
+	return fmt.Sprintf("%s <> 0", value)
+}
+
+// generateQueryParams emits VB statements that build a "query" List(Of String) of
+// URL-encoded key=value pairs from the request message of a GET RPC.
+//
+// Scalar and enum fields are encoded using their JSON names; repeated scalars become
+// repeated parameters. Message and bytes fields cannot be represented in a query string
+// and are skipped with an explanatory comment.
+func (g *Generator) generateQueryParams(sb *strings.Builder, protoFile *types.ProtoFile, rpc *types.ProtoRPC, indent string) {
+	fmt.Fprintf(sb, "%sDim query As New List(Of String)()\n", indent)
+
+	message := findMessage(protoFile, rpc.InputType)
+	if message == nil {
+		fmt.Fprintf(sb, "%s' %s is not declared in this file; no query parameters are sent\n", indent, rpc.InputType)
+		return
+	}
+
+	for _, field := range message.Fields {
+		property := "request." + types.EscapeVBIdentifier(types.GoFieldName(field.Name))
+		key := types.FieldJSONName(field, message.Name)
+		enum := findEnum(protoFile, message, field.Type)
+
+		if field.Repeated {
+			valueExpr := g.queryValueExpr(field, enum, "item")
+			if valueExpr == "" {
+				fmt.Fprintf(sb, "%s' %s (%s) cannot be sent as a query parameter\n", indent, key, field.Type)
+				continue
+			}
+			fmt.Fprintf(sb, "%sIf %s IsNot Nothing Then\n", indent, property)
+			fmt.Fprintf(sb, "%s    For Each item In %s\n", indent, property)
+			fmt.Fprintf(sb, "%s        query.Add(\"%s=\" & %s)\n", indent, key, valueExpr)
+			fmt.Fprintf(sb, "%s    Next\n", indent)
+			fmt.Fprintf(sb, "%sEnd If\n", indent)
+			continue
+		}
+
+		valueExpr := g.queryValueExpr(field, enum, property)
+		if valueExpr == "" {
+			fmt.Fprintf(sb, "%s' %s (%s) cannot be sent as a query parameter\n", indent, key, field.Type)
+			continue
+		}
+		if field.Type == "bool" {
+			// A set boolean is always true here; false is the default and is omitted
+			valueExpr = "\"true\""
+		}
+		fmt.Fprintf(sb, "%sIf %s Then query.Add(\"%s=\" & %s)\n", indent, queryPresenceCheck(field, enum, property), key, valueExpr)
+	}
+}
+
+// generateGetJsonNet45 emits the GetJsonAsync helper used by GET RPCs in net45 mode.
+// httpClientExpr and baseURLExpr name the HttpClient and base URL members of the enclosing class.
+func (g *Generator) generateGetJsonNet45(sb *strings.Builder, indent, visibility, httpClientExpr, baseURLExpr string) {
+	lines := []string{
+		visibility + " Async Function GetJsonAsync(Of TResp)(relativePath As String, queryParams As List(Of String), cancellationToken As CancellationToken, Optional timeoutMs As Integer? = Nothing) As Task(Of TResp)",
+		"    Dim url As String = String.Format(\"{0}/{1}\", " + baseURLExpr + ", relativePath.TrimStart(\"/\"c))",
+		"    If queryParams IsNot Nothing AndAlso queryParams.Count > 0 Then",
+		"        url = url & \"?\" & String.Join(\"&\", queryParams)",
+		"    End If",
+		"    Using timeoutCts As CancellationTokenSource = If(timeoutMs.HasValue, New CancellationTokenSource(timeoutMs.Value), New CancellationTokenSource())",
+		"        Using combined As CancellationTokenSource = CancellationTokenSource.CreateLinkedTokenSource(cancellationToken, timeoutCts.Token)",
+		"            Dim response As HttpResponseMessage = Await " + g.net45Send(httpClientExpr, "Get", "Nothing", "combined.Token") + ".ConfigureAwait(False)",
+		"            If Not response.IsSuccessStatusCode Then",
+		"                Dim body As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)",
+		"                Throw ApiException.FromResponse(CInt(response.StatusCode), response.ReasonPhrase, body)",
+		"            End If",
+		"            Dim respJson As String = Await response.Content.ReadAsStringAsync().ConfigureAwait(False)",
+		"            If String.IsNullOrWhiteSpace(respJson) Then",
+		"                Throw New InvalidOperationException(\"Received empty response from server\")",
+		"            End If",
+		"            Return JsonConvert.DeserializeObject(Of TResp)(respJson)",
+		"        End Using",
+		"    End Using",
+		"End Function",
+	}
+	writeIndentedLines(sb, indent, lines)
+}
+
+// generateGetJsonNet40HWR emits the synchronous GetJson helper used by GET RPCs in net40hwr mode.
+// defaultTimeoutExpr names the constructor's default timeout used with RequestSettings.
+func (g *Generator) generateGetJsonNet40HWR(sb *strings.Builder, indent, visibility, baseURLExpr, defaultTimeoutExpr string) {
+	lines := []string{
+		visibility + " Function GetJson(Of TResp)(relativePath As String, queryParams As List(Of String), Optional timeoutMs As Integer? = Nothing, Optional authHeaders As Dictionary(Of String, String) = Nothing) As TResp",
+		"    Dim url As String = String.Format(\"{0}/{1}\", " + baseURLExpr + ", relativePath.TrimStart(\"/\"c))",
+		"    If queryParams IsNot Nothing AndAlso queryParams.Count > 0 Then",
+		"        url = url & \"?\" & String.Join(\"&\", queryParams)",
+		"    End If",
+		"    Dim req As HttpWebRequest = CType(WebRequest.Create(url), HttpWebRequest)",
+		"    req.Method = \"GET\"",
+		"    req.Accept = \"application/json\"",
+	}
+	writeIndentedLines(sb, indent, lines)
+	g.writeRequestTimeoutNet40HWR(sb, indent+"    ", defaultTimeoutExpr)
+	g.writeTimeoutHeaderNet40HWR(sb, indent+"    ")
+	lines = []string{
+		"",
+		"    ' Add authorization headers if provided",
+		"    If authHeaders IsNot Nothing Then",
+		"        For Each kvp In authHeaders",
+		"            req.Headers.Add(kvp.Key, kvp.Value)",
+		"        Next",
+		"    End If",
+		"",
+		"    Using resp As HttpWebResponse = ApiException.GetResponseOrThrow(req)",
+		"        Using respStream As Stream = resp.GetResponseStream()",
+		"            Using reader As New StreamReader(respStream, Encoding.UTF8)",
+		"                Dim respJson As String = reader.ReadToEnd()",
+		"                If String.IsNullOrWhiteSpace(respJson) Then",
+		"                    Throw New InvalidOperationException(\"Received empty response from server\")",
+		"                End If",
+		"                Return JsonConvert.DeserializeObject(Of TResp)(respJson)",
+		"            End Using",
+		"        End Using",
+		"    End Using",
+		"End Function",
+	}
+	writeIndentedLines(sb, indent, lines)
+}
+
+// writeIndentedLines writes each line prefixed with indent; empty lines stay empty
+func writeIndentedLines(sb *strings.Builder, indent string, lines []string) {
+	for _, line := range lines {
+		if line == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(indent)
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+}
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// requestSettingsParams returns the extra constructor parameters of net40hwr clients
+// and shared utilities when RequestSettings is enabled, otherwise ""
+func (g *Generator) requestSettingsParams() string {
+	if !g.RequestSettings {
+		return ""
+	}
+	return ", Optional defaultTimeoutMs As Integer? = Nothing, Optional sendChunked As Boolean = False"
+}
+
+// requestSettingsArgs returns the arguments a client passes on to its shared
+// utility's constructor, matching requestSettingsParams
+func (g *Generator) requestSettingsArgs() string {
+	if !g.RequestSettings {
+		return ""
+	}
+	return ", defaultTimeoutMs, sendChunked"
+}
+
+// writeRequestTimeoutNet40HWR emits the statements setting req.Timeout: the
+// timeoutMs argument when given, otherwise with RequestSettings the timeout passed
+// to the constructor (defaultTimeoutExpr), otherwise the HttpWebRequest default
+func (g *Generator) writeRequestTimeoutNet40HWR(sb *strings.Builder, indent, defaultTimeoutExpr string) {
+	if !g.RequestSettings {
+		fmt.Fprintf(sb, "%sIf timeoutMs.HasValue Then req.Timeout = timeoutMs.Value\n", indent)
+		return
+	}
+	lines := []string{
+		"If timeoutMs.HasValue Then",
+		"    req.Timeout = timeoutMs.Value",
+		"ElseIf " + defaultTimeoutExpr + ".HasValue Then",
+		"    req.Timeout = " + defaultTimeoutExpr + ".Value",
+		"End If",
+	}
+	writeIndentedLines(sb, indent, lines)
+}
+
+// writeContentLengthNet40HWR emits how the request body length is announced: a
+// Content-Length header, or with RequestSettings chunked transfer encoding when
+// sendChunkedExpr is True, so large bodies are streamed instead of buffered
+func (g *Generator) writeContentLengthNet40HWR(sb *strings.Builder, indent, sendChunkedExpr string) {
+	if !g.RequestSettings {
+		fmt.Fprintf(sb, "%sreq.ContentLength = data.Length\n", indent)
+		return
+	}
+	lines := []string{
+		"If " + sendChunkedExpr + " Then",
+		"    req.SendChunked = True",
+		"Else",
+		"    req.ContentLength = data.Length",
+		"End If",
+	}
+	writeIndentedLines(sb, indent, lines)
+}
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRequestSettingsNet40HWRGolden(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net40hwr", RequestSettings: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, "    Public Sub New(baseUrl As String, Optional defaultTimeoutMs As Integer? = Nothing, Optional sendChunked As Boolean = False)\n")
+	assertContains(t, vb, "        If Me.SendChunked Then\n            req.SendChunked = True\n        Else\n            req.ContentLength = data.Length\n        End If\n")
+	assertContains(t, vb, "        ElseIf Me.DefaultTimeoutMs.HasValue Then\n            req.Timeout = Me.DefaultTimeoutMs.Value\n")
+	assertGolden(t, "request_settings_net40hwr.vb.golden", vb)
+}
+
+func TestRequestSettingsWithSharedUtility(t *testing.T) {
+	gen := &Generator{FrameworkMode: "net40hwr", RequestSettings: true}
+	utilityPath := filepath.Join(t.TempDir(), "ApiHttpUtility.vb")
+	if err := gen.GenerateSharedUtility("ApiHttpUtility", "Api", utilityPath); err != nil {
+		t.Fatalf("GenerateSharedUtility() error = %v", err)
+	}
+	utility := readFile(t, utilityPath)
+	assertContains(t, utility, "        Public Sub New(baseUrl As String, Optional defaultTimeoutMs As Integer? = Nothing, Optional sendChunked As Boolean = False)\n")
+	assertContains(t, utility, "            _defaultTimeoutMs = defaultTimeoutMs\n            _sendChunked = sendChunked\n")
+	assertContains(t, utility, "            If _sendChunked Then\n                req.SendChunked = True\n")
+	assertContains(t, utility, "            ElseIf _defaultTimeoutMs.HasValue Then\n                req.Timeout = _defaultTimeoutMs.Value\n")
+
+	proto := testGetProto()
+	proto.UseSharedUtility = true
+	proto.SharedUtilityName = "ApiHttpUtility"
+	vb, err := gen.GenerateString(proto)
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, "        _httpUtility = New ApiHttpUtility(baseUrl, defaultTimeoutMs, sendChunked)\n")
+}
+
+func TestRequestSettingsOffByDefault(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net40hwr"})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, "    Public Sub New(baseUrl As String)\n")
+	assertContains(t, vb, "        req.ContentLength = data.Length\n        If timeoutMs.HasValue Then req.Timeout = timeoutMs.Value\n")
+	assertNotContains(t, vb, "SendChunked")
+	assertNotContains(t, vb, "DefaultTimeoutMs")
+}
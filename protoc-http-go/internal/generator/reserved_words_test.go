@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+func TestReservedWordFieldNamesAreEscaped(t *testing.T) {
+	protoFile := &types.ProtoFile{
+		FileName: "schedule.proto",
+		BaseName: "schedule",
+		Package:  "schedule",
+		Messages: map[string]*types.ProtoMessage{
+			"Slot": {
+				Name: "Slot",
+				Fields: []*types.ProtoField{
+					{Name: "end", Type: "int64", Number: 1},
+					{Name: "class", Type: "string", Number: 2},
+					{Name: "property", Type: "string", Number: 3, Repeated: true},
+					{Name: "endif", Type: "bool", Number: 4},
+					{Name: "rem", Type: "string", Number: 5},
+					{Name: "end_time", Type: "string", Number: 6},
+				},
+			},
+		},
+		Enums: map[string]*types.ProtoEnum{},
+	}
+	vb, err := GenerateString(protoFile, Options{FrameworkMode: "net45", Equality: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"    <JsonProperty(\"end\")>\n    <JsonConverter(GetType(Int64StringConverter))>\n    Public Property [End] As Long\n",
+		"    <JsonProperty(\"class\")>\n    Public Property [Class] As String\n",
+		"    <JsonProperty(\"property\")>\n    Public Property [Property] As List(Of String)\n",
+		// VB keywords are case-insensitive: Endif is EndIf and Rem starts a comment
+		"    <JsonProperty(\"endif\")>\n    Public Property [Endif] As Boolean\n",
+		"    <JsonProperty(\"rem\")>\n    Public Property [Rem] As String\n",
+		"    <JsonProperty(\"endTime\")>\n    Public Property EndTime As String\n",
+		"If Not Object.Equals(Me.[End], other.[End]) Then Return False\n",
+	} {
+		assertContains(t, vb, want)
+	}
+}
+
+func TestIsVBReservedKeywordIgnoresCase(t *testing.T) {
+	for name, want := range map[string]bool{"End": true, "end": true, "ENDIF": true, "GetType": true, "Gettype": true, "Ending": false, "Name": false} {
+		if got := types.IsVBReservedKeyword(name); got != want {
+			t.Errorf("IsVBReservedKeyword(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateStringReturnsVBSource(t *testing.T) {
+	source, err := GenerateString(testGetProto(), Options{FrameworkMode: "net45", BaseURL: "http://example.test"})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, source, "Namespace Search")
+	assertContains(t, source, "Public Class SearchRequest")
+	assertContains(t, source, "End Namespace")
+}
+
+func TestGenerateStringDefaultsToNet45(t *testing.T) {
+	if _, err := GenerateString(testGetProto(), Options{}); err != nil {
+		t.Fatalf("GenerateString() with zero Options error = %v", err)
+	}
+}
+
+func TestGenerateStringRejectsBadInput(t *testing.T) {
+	if _, err := GenerateString(testGetProto(), Options{FrameworkMode: "net20"}); err == nil || !strings.Contains(err.Error(), "net20") {
+		t.Fatalf("expected an unsupported framework error, got %v", err)
+	}
+	if _, err := GenerateString(nil, Options{}); err == nil {
+		t.Fatalf("expected an error for a nil proto file")
+	}
+}
+
+func TestGenerateFileWritesGenerateStringOutput(t *testing.T) {
+	// Single-message proto so map iteration order cannot make the outputs differ
+	proto := testBytesProto()
+	gen := &Generator{FrameworkMode: "net40hwr"}
+	source, err := gen.GenerateString(proto)
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	outPath := filepath.Join(t.TempDir(), proto.BaseName+".vb")
+	if err := gen.GenerateFile(proto, outPath); err != nil {
+		t.Fatalf("GenerateFile() error = %v", err)
+	}
+	if written := readFile(t, outPath); written != source {
+		t.Fatalf("GenerateFile() wrote different content than GenerateString() returned")
+	}
+}
+
+func TestGenerateJSONSchemaString(t *testing.T) {
+	schema, err := GenerateJSONSchemaString(manyDefsProto(), "https://schemas.acme.test")
+	if err != nil {
+		t.Fatalf("GenerateJSONSchemaString() error = %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc["$id"] != "https://schemas.acme.test/catalog.json" {
+		t.Errorf("$id = %v", doc["$id"])
+	}
+	if defs := doc["$defs"].(map[string]interface{}); len(defs) != 40 {
+		t.Errorf("expected 40 definitions, got %d", len(defs))
+	}
+
+	// The file variant writes exactly the string variant's document
+	path, err := GenerateJSONSchema(manyDefsProto(), t.TempDir(), "https://schemas.acme.test")
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema() error = %v", err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(written) != schema {
+		t.Fatalf("GenerateJSONSchema() wrote different content than GenerateJSONSchemaString() returned")
+	}
+
+	if _, err := GenerateJSONSchemaString(nil); err == nil {
+		t.Fatalf("expected an error for a nil proto file")
+	}
+}
@@ -0,0 +1,229 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// mapKeyTypes are the proto types allowed as map keys: integral types, bool and string
+var mapKeyTypes = map[string]bool{
+	"int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true,
+	"sfixed32": true, "sfixed64": true, "bool": true, "string": true,
+}
+
+// mapEntry is a message of the map entry pattern, modelling a map the way protos
+// did before map<K, V>: "message Entry { K key = 1; V value = 2; }" used through
+// "repeated Entry entries = 1;"
+type mapEntry struct {
+	className string // VB class of the entry message, e.g. Resource_Entry
+	keyName   string // VB property of the key
+	valueName string // VB property of the value
+	keyType   string // VB type of the key
+	valueType string // VB type of the value
+	stringKey bool   // Key is a proto string, which may be Nothing in VB
+}
+
+// dictionaryType returns the VB dictionary type the entries of e convert to
+func (e *mapEntry) dictionaryType() string {
+	return fmt.Sprintf("Dictionary(Of %s, %s)", e.keyType, e.valueType)
+}
+
+// synthesizedMaps holds the map entry pattern matches of one proto file
+type synthesizedMaps struct {
+	fields  map[*types.ProtoField]*mapEntry   // Repeated fields given a dictionary property
+	entries map[*types.ProtoMessage]*mapEntry // Entry messages given conversion helpers
+}
+
+// entryUse tracks how the fields and RPCs of a file use one message
+type entryUse struct {
+	message  *types.ProtoMessage
+	path     []string            // Name within the file, outermost message first
+	repeated []*types.ProtoField // Repeated fields of the message's type
+	other    bool                // Also used by a singular field or as an RPC request or response
+}
+
+// findSynthesizedMaps detects the map entry pattern in protoFile: a message with
+// exactly the fields key and value, neither repeated, whose key has a valid map key
+// type and which is used only as the type of repeated fields. Messages used by a
+// singular field or by an RPC are left alone, since the entry class has a meaning
+// of its own there.
+//
+// Types are resolved with protoFile.Symbols, which is built for protoFile alone
+// when it is not set yet; only messages declared in protoFile are considered.
+func (g *Generator) findSynthesizedMaps(protoFile *types.ProtoFile) synthesizedMaps {
+	maps := synthesizedMaps{
+		fields:  make(map[*types.ProtoField]*mapEntry),
+		entries: make(map[*types.ProtoMessage]*mapEntry),
+	}
+	table := protoFile.Symbols
+	if table == nil {
+		var err error
+		if table, err = types.BuildSymbolTable([]*types.ProtoFile{protoFile}); err != nil {
+			// Duplicate names; nothing can be resolved reliably
+			return maps
+		}
+	}
+
+	uses := make(map[string]*entryUse) // Full name → use of a message of protoFile
+	use := func(scope []string, ref string) *entryUse {
+		if _, scalar := types.VBTypeMappings[ref]; scalar {
+			return nil
+		}
+		symbol, ok := table.Resolve(protoFile.Package, scope, ref)
+		if !ok || symbol.IsEnum || symbol.File.FileName != protoFile.FileName {
+			return nil
+		}
+		if u, ok := uses[symbol.FullName]; ok {
+			return u
+		}
+		message := messageAtPath(protoFile, symbol.Path)
+		if message == nil {
+			return nil
+		}
+		u := &entryUse{message: message, path: symbol.Path}
+		uses[symbol.FullName] = u
+		return u
+	}
+
+	var walk func(path []string, message *types.ProtoMessage)
+	walk = func(path []string, message *types.ProtoMessage) {
+		for _, field := range message.Fields {
+			u := use(path, field.Type)
+			switch {
+			case u == nil:
+			case field.Repeated:
+				u.repeated = append(u.repeated, field)
+			default:
+				u.other = true
+			}
+		}
+		for _, nested := range messagesInOrder(message.NestedMessages) {
+			walk(append(append([]string{}, path...), nested.Name), nested)
+		}
+	}
+	for _, message := range messagesInOrder(protoFile.Messages) {
+		walk([]string{message.Name}, message)
+	}
+	for _, service := range protoFile.Services {
+		for _, rpc := range service.RPCs {
+			for _, ref := range []string{rpc.InputType, rpc.OutputType} {
+				if u := use(nil, ref); u != nil {
+					u.other = true
+				}
+			}
+		}
+	}
+
+	for _, u := range uses {
+		if u.other || len(u.repeated) == 0 {
+			continue
+		}
+		entry := g.newMapEntry(u.message, strings.Join(u.path, "_"))
+		if entry == nil {
+			continue
+		}
+		maps.entries[u.message] = entry
+		for _, field := range u.repeated {
+			maps.fields[field] = entry
+		}
+	}
+	return maps
+}
+
+// messageAtPath returns the message of protoFile declared at path, or nil
+func messageAtPath(protoFile *types.ProtoFile, path []string) *types.ProtoMessage {
+	messages := protoFile.Messages
+	var message *types.ProtoMessage
+	for _, name := range path {
+		if message = messages[name]; message == nil {
+			return nil
+		}
+		messages = message.NestedMessages
+	}
+	return message
+}
+
+// newMapEntry returns the mapEntry of message, generated as className, or nil if
+// message does not have the entry shape
+func (g *Generator) newMapEntry(message *types.ProtoMessage, className string) *mapEntry {
+	if len(message.Fields) != 2 {
+		return nil
+	}
+	var key, value *types.ProtoField
+	for _, field := range message.Fields {
+		switch field.Name {
+		case "key":
+			key = field
+		case "value":
+			value = field
+		}
+	}
+	if key == nil || value == nil || key.Repeated || value.Repeated || !mapKeyTypes[key.Type] {
+		return nil
+	}
+	return &mapEntry{
+		className: className,
+		keyName:   types.EscapeVBIdentifier(types.GoFieldName(key.Name)),
+		valueName: types.EscapeVBIdentifier(types.GoFieldName(value.Name)),
+		keyType:   g.fieldElementType(key),
+		valueType: g.fieldElementType(value),
+		stringKey: key.Type == "string" && key.TypeOverride == "",
+	}
+}
+
+// generateDictionaryProperty writes the <Field>Dictionary property viewing the
+// repeated field fieldName through entry's conversion helpers
+func generateDictionaryProperty(sb *strings.Builder, fieldName string, entry *mapEntry) {
+	dictType := entry.dictionaryType()
+	sb.WriteString("\n")
+	fmt.Fprintf(sb, "    ' %sDictionary is %s as a dictionary. Getting it converts the entries, so changes to the\n", fieldName, fieldName)
+	fmt.Fprintf(sb, "    ' returned dictionary are not written back; setting it replaces %s.\n", fieldName)
+	sb.WriteString("    <JsonIgnore>\n")
+	fmt.Fprintf(sb, "    Public Property %sDictionary As %s\n", fieldName, dictType)
+	sb.WriteString("        Get\n")
+	fmt.Fprintf(sb, "            Return %s.ToDictionary(Me.%s)\n", entry.className, fieldName)
+	sb.WriteString("        End Get\n")
+	fmt.Fprintf(sb, "        Set(value As %s)\n", dictType)
+	fmt.Fprintf(sb, "            Me.%s = %s.FromDictionary(value)\n", fieldName, entry.className)
+	sb.WriteString("        End Set\n")
+	sb.WriteString("    End Property\n")
+}
+
+// generateEntryHelpers writes the Shared ToDictionary and FromDictionary helpers of
+// an entry class
+func generateEntryHelpers(sb *strings.Builder, entry *mapEntry) {
+	dictType := entry.dictionaryType()
+	listType := fmt.Sprintf("List(Of %s)", entry.className)
+	key := "entry." + entry.keyName
+	if entry.stringKey {
+		// A missing key is the empty string in proto, but Nothing here
+		key = fmt.Sprintf("If(entry.%s, String.Empty)", entry.keyName)
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString("    ' ToDictionary converts entries to a dictionary; as in proto maps, a later entry\n")
+	sb.WriteString("    ' replaces an earlier one with the same key. Nothing gives an empty dictionary.\n")
+	fmt.Fprintf(sb, "    Public Shared Function ToDictionary(entries As %s) As %s\n", listType, dictType)
+	fmt.Fprintf(sb, "        Dim result As New %s()\n", dictType)
+	sb.WriteString("        If entries Is Nothing Then Return result\n")
+	sb.WriteString("        For Each entry In entries\n")
+	sb.WriteString("            If entry Is Nothing Then Continue For\n")
+	fmt.Fprintf(sb, "            result(%s) = entry.%s\n", key, entry.valueName)
+	sb.WriteString("        Next\n")
+	sb.WriteString("        Return result\n")
+	sb.WriteString("    End Function\n")
+
+	sb.WriteString("\n")
+	sb.WriteString("    ' FromDictionary converts a dictionary to entries. Nothing gives an empty list.\n")
+	fmt.Fprintf(sb, "    Public Shared Function FromDictionary(values As %s) As %s\n", dictType, listType)
+	fmt.Fprintf(sb, "        Dim result As New %s()\n", listType)
+	sb.WriteString("        If values Is Nothing Then Return result\n")
+	sb.WriteString("        For Each pair In values\n")
+	fmt.Fprintf(sb, "            result.Add(New %s With {.%s = pair.Key, .%s = pair.Value})\n", entry.className, entry.keyName, entry.valueName)
+	sb.WriteString("        Next\n")
+	sb.WriteString("        Return result\n")
+	sb.WriteString("    End Function\n")
+}
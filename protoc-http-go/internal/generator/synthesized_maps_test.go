@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+)
+
+const mapEntriesProto = `syntax = "proto3";
+package labels;
+
+message Resource {
+  string id = 1;
+  repeated Entry labels = 2;
+  repeated Entry annotations = 3;
+  message Entry {
+    string key = 1;
+    string value = 2;
+  }
+}
+
+message Count {
+  int64 key = 1;
+  int32 value = 2;
+}
+
+message Histogram {
+  repeated Count buckets = 1;
+}
+
+// Used on its own as well, so not a map
+message Pair {
+  string key = 1;
+  string value = 2;
+}
+
+message Pairs {
+  repeated Pair pairs = 1;
+  Pair first = 2;
+}
+
+// Three fields, so not a map
+message Triple {
+  string key = 1;
+  string value = 2;
+  string note = 3;
+}
+
+message Triples {
+  repeated Triple triples = 1;
+}
+
+service LabelService {
+  rpc Get(Resource) returns (Histogram);
+}
+`
+
+func TestFindSynthesizedMapsDetectsEntryPattern(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("labels.proto", mapEntriesProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	maps := (&Generator{}).findSynthesizedMaps(protoFile)
+
+	got := make(map[string]string)
+	for field, entry := range maps.fields {
+		got[field.Name] = entry.className + " " + entry.dictionaryType()
+	}
+	want := map[string]string{
+		"labels":      "Resource_Entry Dictionary(Of String, String)",
+		"annotations": "Resource_Entry Dictionary(Of String, String)",
+		"buckets":     "Count Dictionary(Of Long, Integer)",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("synthesized fields = %v, want %v", got, want)
+	}
+	for name, entry := range want {
+		if got[name] != entry {
+			t.Errorf("field %s: got %q, want %q", name, got[name], entry)
+		}
+	}
+	if len(maps.entries) != 2 {
+		t.Errorf("expected helpers for Resource.Entry and Count only, got %d entries", len(maps.entries))
+	}
+}
+
+func TestSynthesizeMapsGeneratesDictionaryAccessor(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("labels.proto", mapEntriesProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{SynthesizeMaps: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	// The wire property keeps the entry list, under the nested class name
+	assertContains(t, content, "    <JsonProperty(\"labels\")>\n    Public Property Labels As List(Of Resource_Entry)\n")
+	assertContains(t, content, `    <JsonIgnore>
+    Public Property LabelsDictionary As Dictionary(Of String, String)
+        Get
+            Return Resource_Entry.ToDictionary(Me.Labels)
+        End Get
+        Set(value As Dictionary(Of String, String))
+            Me.Labels = Resource_Entry.FromDictionary(value)
+        End Set
+    End Property
+`)
+	assertContains(t, content, "Public Property AnnotationsDictionary As Dictionary(Of String, String)\n")
+	assertContains(t, content, "Public Property BucketsDictionary As Dictionary(Of Long, Integer)\n")
+
+	// Helpers are generated once per entry class; only string keys are defaulted
+	assertContains(t, content, "    Public Shared Function ToDictionary(entries As List(Of Resource_Entry)) As Dictionary(Of String, String)\n")
+	assertContains(t, content, "            result(If(entry.Key, String.Empty)) = entry.Value\n")
+	assertContains(t, content, "    Public Shared Function FromDictionary(values As Dictionary(Of Long, Integer)) As List(Of Count)\n")
+	assertContains(t, content, "            result(entry.Key) = entry.Value\n")
+	assertContains(t, content, "            result.Add(New Count With {.Key = pair.Key, .Value = pair.Value})\n")
+
+	assertNotContains(t, content, "PairsDictionary")
+	assertNotContains(t, content, "TriplesDictionary")
+
+	// Without the option nothing changes
+	plain, err := GenerateString(protoFile, Options{})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertNotContains(t, plain, "Dictionary(Of")
+}
+
+func TestSynthesizeMapsWithEqualityUsesEntryClassName(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("labels.proto", mapEntriesProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{SynthesizeMaps: true, Equality: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	// Equals and GetHashCode name the nested entry class like the property does
+	assertContains(t, content, "    Public Property Labels As List(Of Resource_Entry)\n")
+	assertContains(t, content, "If(Me.Labels, New List(Of Resource_Entry)()), If(other.Labels, New List(Of Resource_Entry)())")
+	assertContains(t, content, "hash = (hash * 31 + EqualityComparer(Of Resource_Entry).Default.GetHashCode(item)) And &H7FFFFFFFL\n")
+	assertNotContains(t, content, "List(Of Entry)")
+	assertNotContains(t, content, "EqualityComparer(Of Entry)")
+}
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// timeoutHeader is the request header the proxy reads the caller's deadline from,
+// so the backend call is canceled when the client stops waiting
+const timeoutHeader = "X-Timeout-Ms"
+
+// net45Send returns the VB expression sending a request through clientExpr in
+// net45 mode. With TimeoutHeader it goes through SendWithTimeoutHeaderAsync so the
+// X-Timeout-Ms header can be set per request; content is "Nothing" for GET.
+func (g *Generator) net45Send(clientExpr, method, content, token string) string {
+	if g.TimeoutHeader {
+		return fmt.Sprintf("SendWithTimeoutHeaderAsync(HttpMethod.%s, url, %s, timeoutMs, %s)", method, content, token)
+	}
+	if method == "Get" {
+		return fmt.Sprintf("%s.GetAsync(url, %s)", clientExpr, token)
+	}
+	return fmt.Sprintf("%s.PostAsync(url, %s, %s)", clientExpr, content, token)
+}
+
+// generateSendWithTimeoutHeaderNet45 emits the helper behind net45Send. The header
+// carries timeoutMs when the caller passed one, otherwise the HttpClient.Timeout,
+// and is left out when neither limits the call.
+func generateSendWithTimeoutHeaderNet45(sb *strings.Builder, indent, clientExpr string) {
+	lines := []string{
+		"Private Async Function SendWithTimeoutHeaderAsync(method As HttpMethod, url As String, content As HttpContent, timeoutMs As Integer?, cancellationToken As CancellationToken) As Task(Of HttpResponseMessage)",
+		"    Using httpRequest As New HttpRequestMessage(method, url)",
+		"        httpRequest.Content = content",
+		"        Dim effectiveTimeout As TimeSpan = If(timeoutMs.HasValue, TimeSpan.FromMilliseconds(timeoutMs.Value), " + clientExpr + ".Timeout)",
+		"        If effectiveTimeout > TimeSpan.Zero AndAlso effectiveTimeout <> System.Threading.Timeout.InfiniteTimeSpan Then",
+		"            httpRequest.Headers.TryAddWithoutValidation(\"" + timeoutHeader + "\", CLng(effectiveTimeout.TotalMilliseconds).ToString(Globalization.CultureInfo.InvariantCulture))",
+		"        End If",
+		"        Return Await " + clientExpr + ".SendAsync(httpRequest, cancellationToken).ConfigureAwait(False)",
+		"    End Using",
+		"End Function",
+	}
+	writeIndentedLines(sb, indent, lines)
+}
+
+// writeTimeoutHeaderNet40HWR emits, after req.Timeout has been set, the statement
+// sending it as the X-Timeout-Ms header: the timeoutMs argument when given,
+// otherwise the HttpWebRequest default of 100 seconds
+func (g *Generator) writeTimeoutHeaderNet40HWR(sb *strings.Builder, indent string) {
+	if !g.TimeoutHeader {
+		return
+	}
+	fmt.Fprintf(sb, "%sIf req.Timeout <> System.Threading.Timeout.Infinite Then req.Headers(\"%s\") = req.Timeout.ToString(Globalization.CultureInfo.InvariantCulture)\n", indent, timeoutHeader)
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTimeoutHeaderNet45Golden(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net45", TimeoutHeader: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertContains(t, vb, "Await SendWithTimeoutHeaderAsync(HttpMethod.Post, url, content, timeoutMs, effectiveToken).ConfigureAwait(False)")
+	assertContains(t, vb, "Await SendWithTimeoutHeaderAsync(HttpMethod.Get, url, Nothing, timeoutMs, combined.Token).ConfigureAwait(False)")
+	assertNotContains(t, vb, ".PostAsync(")
+	assertGolden(t, "timeout_header_net45.vb.golden", vb)
+}
+
+func TestTimeoutHeaderNet40HWRGolden(t *testing.T) {
+	vb, err := GenerateString(testGetProto(), Options{FrameworkMode: "net40hwr", TimeoutHeader: true})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	assertGolden(t, "timeout_header_net40hwr.vb.golden", vb)
+}
+
+func TestTimeoutHeaderWithSharedUtility(t *testing.T) {
+	for _, mode := range []string{"net45", "net40hwr"} {
+		gen := &Generator{FrameworkMode: mode, TimeoutHeader: true}
+		utilityPath := filepath.Join(t.TempDir(), "ApiHttpUtility.vb")
+		if err := gen.GenerateSharedUtility("ApiHttpUtility", "Api", utilityPath); err != nil {
+			t.Fatalf("%s: GenerateSharedUtility() error = %v", mode, err)
+		}
+		utility := readFile(t, utilityPath)
+		if mode == "net45" {
+			assertContains(t, utility, "        Private Async Function SendWithTimeoutHeaderAsync(method As HttpMethod, url As String, content As HttpContent, timeoutMs As Integer?, cancellationToken As CancellationToken) As Task(Of HttpResponseMessage)\n")
+			assertContains(t, utility, "Dim effectiveTimeout As TimeSpan = If(timeoutMs.HasValue, TimeSpan.FromMilliseconds(timeoutMs.Value), _http.Timeout)")
+			assertContains(t, utility, "Return Await _http.SendAsync(httpRequest, cancellationToken).ConfigureAwait(False)")
+			continue
+		}
+		assertContains(t, utility, "            If timeoutMs.HasValue Then req.Timeout = timeoutMs.Value\n            If req.Timeout <> System.Threading.Timeout.Infinite Then req.Headers(\"X-Timeout-Ms\") = req.Timeout.ToString(Globalization.CultureInfo.InvariantCulture)\n")
+	}
+}
+
+func TestTimeoutHeaderOffByDefault(t *testing.T) {
+	for _, mode := range []string{"net45", "net40hwr"} {
+		vb, err := GenerateString(testGetProto(), Options{FrameworkMode: mode})
+		if err != nil {
+			t.Fatalf("GenerateString() error = %v", err)
+		}
+		assertNotContains(t, vb, "X-Timeout-Ms")
+		assertNotContains(t, vb, "SendWithTimeoutHeaderAsync")
+	}
+}
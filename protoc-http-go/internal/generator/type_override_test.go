@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+func testMoneyProto() *types.ProtoFile {
+	return &types.ProtoFile{
+		FileName: "money.proto",
+		BaseName: "money",
+		Package:  "money",
+		Messages: map[string]*types.ProtoMessage{
+			"Payment": {
+				Name: "Payment",
+				Fields: []*types.ProtoField{
+					{Name: "amount", Type: "int64"},
+					{Name: "fees", Type: "int64", Repeated: true},
+					{Name: "sequence", Type: "int64", TypeOverride: "Long"},
+					{Name: "count", Type: "int32"},
+				},
+				NestedMessages: map[string]*types.ProtoMessage{
+					"Line": {
+						Name:           "Line",
+						Fields:         []*types.ProtoField{{Name: "subtotal", Type: "int64"}},
+						NestedMessages: map[string]*types.ProtoMessage{},
+						NestedEnums:    map[string]*types.ProtoEnum{},
+					},
+				},
+				NestedEnums: map[string]*types.ProtoEnum{},
+			},
+		},
+		Enums:    map[string]*types.ProtoEnum{},
+		Services: []*types.ProtoService{},
+	}
+}
+
+func TestTypeMapOverridesVBTypes(t *testing.T) {
+	proto := testMoneyProto()
+	types.ApplyTypeMap(proto, map[string]string{"int64": "Decimal"})
+	content := generateProto(t, proto)
+
+	assertContains(t, content, "Public Property Amount As Decimal")
+	assertContains(t, content, "Public Property Fees As List(Of Decimal)")
+	assertContains(t, content, "Public Property Subtotal As Decimal")
+	assertContains(t, content, "Public Property Count As Integer")
+
+	// The field annotation wins over the global type map
+	assertContains(t, content, "Public Property Sequence As Long")
+}
+
+func TestTypeOverrideIsRecordedInJSONSchema(t *testing.T) {
+	proto := testMoneyProto()
+	types.ApplyTypeMap(proto, map[string]string{"int64": "Decimal"})
+
+	schemaPath, err := GenerateJSONSchema(proto, t.TempDir())
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema() error = %v", err)
+	}
+	content := readFile(t, schemaPath)
+
+	assertContains(t, content, `"x-vb-type": "Decimal"`)
+	assertContains(t, content, `"x-vb-type": "Long"`)
+}
+
+func TestBytesTypeOverrideSkipsConverter(t *testing.T) {
+	proto := testBytesProto()
+	proto.Messages["BytesRequest"].Fields[0].TypeOverride = "Byte()"
+	content := generateProto(t, proto)
+
+	assertContains(t, content, "Public Property Body As Byte()\n")
+	// The repeated bytes field still uses the converter
+	assertContains(t, content, `<JsonProperty("attachments", ItemConverterType:=GetType(BytesStringConverter))>`)
+}
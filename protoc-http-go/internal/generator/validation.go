@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// writeValidationAttributes writes the DataAnnotations attributes of a field with
+// validation rules, so callers can check a request with Validator.TryValidateObject
+// before sending it. Integer properties use the Integer overload of Range; every
+// other type uses the Double overload, which converts without overflowing.
+func (g *Generator) writeValidationAttributes(sb *strings.Builder, field *types.ProtoField) {
+	rules := field.Validation
+	if rules == nil {
+		return
+	}
+	vbType := g.fieldElementType(field)
+	if rules.HasRange() {
+		numericType, suffix := "Double", "R"
+		if vbType == "Integer" {
+			numericType, suffix = "Integer", ""
+		}
+		lower, upper := numericType+".MinValue", numericType+".MaxValue"
+		if rules.Min != "" {
+			lower = vbRangeBound(rules.Min, suffix)
+		}
+		if rules.Max != "" {
+			upper = vbRangeBound(rules.Max, suffix)
+		}
+		fmt.Fprintf(sb, "    <Range(%s, %s)>\n", lower, upper)
+	}
+	if rules.Required {
+		sb.WriteString("    <Required>\n")
+	}
+	if rules.DefinedOnly {
+		fmt.Fprintf(sb, "    <EnumDataType(GetType(%s))>\n", vbType)
+	}
+}
+
+// vbRangeBound returns a bound normalized by the parser as a VB literal with the
+// given type character
+func vbRangeBound(bound, suffix string) string {
+	return strings.ToUpper(bound) + suffix
+}
+
+// applyJSONSchemaValidation adds the JSON Schema keywords of a field's validation
+// rules to its schema and reports whether the field must be present. Enum
+// membership needs no keyword, since the $ref already lists the declared values.
+func applyJSONSchemaValidation(fieldSchema map[string]interface{}, field *types.ProtoField) (required bool) {
+	rules := field.Validation
+	if rules == nil {
+		return false
+	}
+	if rules.Min != "" {
+		fieldSchema["minimum"] = json.Number(rules.Min)
+	}
+	if rules.Max != "" {
+		fieldSchema["maximum"] = json.Number(rules.Max)
+	}
+	if rules.Required {
+		// proto JSON omits empty strings, so a required string must also be present
+		fieldSchema["minLength"] = 1
+	}
+	return rules.Required
+}
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+)
+
+const validationProto = `syntax = "proto3";
+package people;
+
+enum Status { STATUS_UNSPECIFIED = 0; ACTIVE = 1; }
+
+message Person {
+  // validate: range(0, 150)
+  int32 age = 1;
+  // validate: required
+  string name = 2;
+  // validate: defined
+  Status status = 3;
+  int64 balance = 4 [(validate.rules).int64 = {gte: -100}];
+  double score = 5 [(validate.rules).double = {gte: 0, lte: 0.5}];
+  string note = 6;
+}
+`
+
+func TestValidationRulesEmitDataAnnotations(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("people.proto", validationProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+
+	assertContains(t, content, "Imports System.ComponentModel.DataAnnotations\n")
+	assertContains(t, content, "    <Range(0, 150)>\n    <JsonProperty(\"age\")>\n    Public Property Age As Integer\n")
+	assertContains(t, content, "    <Required>\n    <JsonProperty(\"name\")>\n    Public Property Name As String\n")
+	assertContains(t, content, "    <EnumDataType(GetType(Status))>\n    <JsonProperty(\"status\")>\n")
+	assertContains(t, content, "    <Range(-100R, Double.MaxValue)>\n    <JsonProperty(\"balance\")>\n")
+	assertContains(t, content, "    <Range(0R, 0.5R)>\n    <JsonProperty(\"score\")>\n")
+	if strings.Contains(content, "<Required>\n    <JsonProperty(\"note\")>") {
+		t.Errorf("expected no attributes on the note property:\n%s", content)
+	}
+}
+
+func TestGenerateWithoutValidationRulesOmitsDataAnnotations(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("people.proto", "syntax = \"proto3\";\nmessage Person { int32 age = 1; }\n", parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	content, err := GenerateString(protoFile, Options{})
+	if err != nil {
+		t.Fatalf("GenerateString() error = %v", err)
+	}
+	if strings.Contains(content, "DataAnnotations") {
+		t.Errorf("expected no DataAnnotations import:\n%s", content)
+	}
+}
+
+func TestValidationRulesAppearInJSONSchema(t *testing.T) {
+	protoFile, err := parser.ParseProtoContent("people.proto", validationProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	schema, err := GenerateJSONSchemaString(protoFile)
+	if err != nil {
+		t.Fatalf("GenerateJSONSchemaString() error = %v", err)
+	}
+	var doc struct {
+		Defs map[string]struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+			Required   []string                          `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(schema), &doc); err != nil {
+		t.Fatalf("invalid JSON schema: %v", err)
+	}
+	person := doc.Defs["Person"]
+
+	if age := person.Properties["age"]; age["minimum"] != 0.0 || age["maximum"] != 150.0 {
+		t.Errorf("age schema = %v, want minimum 0 and maximum 150", age)
+	}
+	if balance := person.Properties["balance"]; balance["minimum"] != -100.0 || balance["maximum"] != nil {
+		t.Errorf("balance schema = %v, want minimum -100 and no maximum", balance)
+	}
+	if score := person.Properties["score"]; score["maximum"] != 0.5 {
+		t.Errorf("score schema = %v, want maximum 0.5", score)
+	}
+	if name := person.Properties["name"]; name["minLength"] != 1.0 {
+		t.Errorf("name schema = %v, want minLength 1", name)
+	}
+	if len(person.Required) != 1 || person.Required[0] != "name" {
+		t.Errorf("required = %v, want [name]", person.Required)
+	}
+	// Enum membership is already enforced by the $ref to the enum schema
+	if status := person.Properties["status"]; status["$ref"] == nil {
+		t.Errorf("status schema = %v, want a $ref", status)
+	}
+}
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+const versionPlacementProto = `syntax = "proto3";
+package helloworld;
+
+message HelloRequest { string name = 1; }
+message HelloReply { string message = 1; }
+
+service Greeter {
+  rpc SayHello(HelloRequest) returns (HelloReply);
+  rpc SayHelloV2(HelloRequest) returns (HelloReply);
+}
+`
+
+func parseVersionPlacementProto(t *testing.T) *types.ProtoFile {
+	t.Helper()
+	protoFile, err := parser.ParseProtoContent("helloworld.proto", versionPlacementProto, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	return protoFile
+}
+
+func TestVersionPlacementRoutes(t *testing.T) {
+	tests := []struct {
+		placement string
+		routes    []string
+	}{
+		{"", []string{`"/helloworld/say-hello/v1"`, `"/helloworld/say-hello/v2"`}},
+		{VersionSuffix, []string{`"/helloworld/say-hello/v1"`, `"/helloworld/say-hello/v2"`}},
+		{VersionPrefix, []string{`"/v1/helloworld/say-hello"`, `"/v2/helloworld/say-hello"`}},
+	}
+	for _, tt := range tests {
+		protoFile := parseVersionPlacementProto(t)
+		gen := &Generator{VersionPlacement: tt.placement}
+
+		for _, framework := range []string{"net45", "net40hwr"} {
+			gen.FrameworkMode = framework
+			vb, err := GenerateString(protoFile, *gen)
+			if err != nil {
+				t.Fatalf("GenerateString(%q, %s) error = %v", tt.placement, framework, err)
+			}
+			for _, route := range tt.routes {
+				assertContains(t, vb, route)
+			}
+		}
+
+		goSource, err := gen.generateGoSource(protoFile)
+		if err != nil {
+			t.Fatalf("generateGoSource(%q) error = %v", tt.placement, err)
+		}
+		py := gen.generatePythonSource(protoFile)
+		for _, route := range tt.routes {
+			assertContains(t, string(goSource), route)
+			assertContains(t, py, route)
+		}
+	}
+}
+
+func TestVersionPlacementRejectsUnknownValue(t *testing.T) {
+	if _, err := GenerateString(parseVersionPlacementProto(t), Options{VersionPlacement: "infix"}); err == nil {
+		t.Fatal("expected an error for an unknown version placement")
+	}
+}
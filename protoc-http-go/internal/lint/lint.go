@@ -0,0 +1,131 @@
+// Package lint checks parsed proto files against the style conventions expected
+// before clients are generated.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// Rule names, as used in violation messages and by the --lint-* flags
+const (
+	RulePackage    = "package"
+	RulePascalCase = "pascal-case"
+	RuleSnakeCase  = "snake-case"
+	RuleEnumZero   = "enum-zero"
+)
+
+var (
+	pascalCaseRegex = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	snakeCaseRegex  = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+)
+
+// Rules selects which checks Check runs
+type Rules struct {
+	Package    bool // Every proto file declares a package
+	PascalCase bool // Service, message and enum names are PascalCase
+	SnakeCase  bool // Field names are snake_case
+	EnumZero   bool // Every enum has a value numbered 0
+}
+
+// AllRules returns Rules with every check enabled
+func AllRules() Rules {
+	return Rules{Package: true, PascalCase: true, SnakeCase: true, EnumZero: true}
+}
+
+// Violation is a single rule failure at a source location
+type Violation struct {
+	File    string
+	Line    int
+	Rule    string
+	Message string
+}
+
+// String formats the violation as "file:line: message (rule)"
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", v.File, v.Line, v.Message, v.Rule)
+}
+
+// Check runs the enabled rules against protoFile and returns the violations
+// ordered by line
+func Check(protoFile *types.ProtoFile, rules Rules) []Violation {
+	c := &checker{file: protoFile.FileName, rules: rules, seen: make(map[Violation]bool)}
+
+	if rules.Package && protoFile.Package == "" {
+		c.report(1, RulePackage, "file does not declare a package")
+	}
+	for _, service := range protoFile.Services {
+		c.checkName(service.Line, "service", service.Name)
+	}
+	for _, enum := range protoFile.Enums {
+		c.checkEnum(enum)
+	}
+	for _, message := range protoFile.Messages {
+		c.checkMessage(message)
+	}
+
+	sort.SliceStable(c.violations, func(i, j int) bool {
+		if c.violations[i].Line != c.violations[j].Line {
+			return c.violations[i].Line < c.violations[j].Line
+		}
+		return c.violations[i].Message < c.violations[j].Message
+	})
+	return c.violations
+}
+
+// checker accumulates violations for one file
+type checker struct {
+	file       string
+	rules      Rules
+	violations []Violation
+	seen       map[Violation]bool
+}
+
+// report records a violation once, even if the same declaration is visited twice
+func (c *checker) report(line int, rule, message string) {
+	v := Violation{File: c.file, Line: line, Rule: rule, Message: message}
+	if c.seen[v] {
+		return
+	}
+	c.seen[v] = true
+	c.violations = append(c.violations, v)
+}
+
+func (c *checker) checkName(line int, kind, name string) {
+	if c.rules.PascalCase && !pascalCaseRegex.MatchString(name) {
+		c.report(line, RulePascalCase, fmt.Sprintf("%s name %s should be PascalCase", kind, name))
+	}
+}
+
+func (c *checker) checkEnum(enum *types.ProtoEnum) {
+	c.checkName(enum.Line, "enum", enum.Name)
+	if !c.rules.EnumZero {
+		return
+	}
+	for _, number := range enum.Values {
+		if number == 0 {
+			return
+		}
+	}
+	c.report(enum.Line, RuleEnumZero, fmt.Sprintf("enum %s has no zero value", enum.Name))
+}
+
+func (c *checker) checkMessage(message *types.ProtoMessage) {
+	c.checkName(message.Line, "message", message.Name)
+	if c.rules.SnakeCase {
+		for _, field := range message.Fields {
+			if !snakeCaseRegex.MatchString(field.Name) {
+				c.report(field.Line, RuleSnakeCase, fmt.Sprintf("field name %s should be snake_case", field.Name))
+			}
+		}
+	}
+	for _, enum := range message.NestedEnums {
+		c.checkEnum(enum)
+	}
+	for _, nested := range message.NestedMessages {
+		c.checkMessage(nested)
+	}
+}
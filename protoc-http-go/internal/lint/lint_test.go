@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+const cleanProto = `syntax = "proto3";
+package shop;
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+}
+
+message Order {
+  string order_id = 1;
+  repeated int64 line_ids = 2;
+
+  message LineItem {
+    string sku = 1;
+  }
+}
+
+service OrderService {
+  rpc GetOrder(Order) returns (Order);
+}
+`
+
+func parseProto(t *testing.T, content string) *types.ProtoFile {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "shop.proto")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	protoFile, err := parser.ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	return protoFile
+}
+
+// onlyRule enables a single rule so each test sees just its own violations
+func onlyRule(rule string) Rules {
+	return Rules{
+		Package:    rule == RulePackage,
+		PascalCase: rule == RulePascalCase,
+		SnakeCase:  rule == RuleSnakeCase,
+		EnumZero:   rule == RuleEnumZero,
+	}
+}
+
+func assertViolations(t *testing.T, got []Violation, want ...string) {
+	t.Helper()
+	lines := make([]string, len(got))
+	for i, v := range got {
+		lines[i] = strings.TrimPrefix(v.String(), v.File+":")
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected violations:\ngot:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCleanProtoPassesAllRules(t *testing.T) {
+	assertViolations(t, Check(parseProto(t, cleanProto), AllRules()))
+}
+
+func TestPackageRule(t *testing.T) {
+	protoFile := parseProto(t, strings.Replace(cleanProto, "package shop;\n", "", 1))
+	assertViolations(t, Check(protoFile, onlyRule(RulePackage)),
+		"1: file does not declare a package (package)")
+}
+
+func TestPascalCaseRule(t *testing.T) {
+	content := strings.NewReplacer(
+		"message LineItem", "message line_item",
+		"service OrderService", "service orderService",
+		"enum Status", "enum status_kind",
+	).Replace(cleanProto)
+	assertViolations(t, Check(parseProto(t, content), onlyRule(RulePascalCase)),
+		"4: enum name status_kind should be PascalCase (pascal-case)",
+		"13: message name line_item should be PascalCase (pascal-case)",
+		"18: service name orderService should be PascalCase (pascal-case)")
+}
+
+func TestSnakeCaseRule(t *testing.T) {
+	content := strings.NewReplacer("order_id", "orderId", "sku", "SKU").Replace(cleanProto)
+	assertViolations(t, Check(parseProto(t, content), onlyRule(RuleSnakeCase)),
+		"10: field name orderId should be snake_case (snake-case)",
+		"14: field name SKU should be snake_case (snake-case)")
+}
+
+func TestEnumZeroRule(t *testing.T) {
+	content := strings.Replace(cleanProto, "STATUS_UNSPECIFIED = 0;", "STATUS_UNSPECIFIED = 2;", 1)
+	assertViolations(t, Check(parseProto(t, content), onlyRule(RuleEnumZero)),
+		"4: enum Status has no zero value (enum-zero)")
+}
+
+func TestDisabledRulesReportNothing(t *testing.T) {
+	content := strings.NewReplacer(
+		"package shop;\n", "",
+		"message Order", "message order",
+		"order_id", "orderId",
+		"= 0;", "= 3;",
+	).Replace(cleanProto)
+	assertViolations(t, Check(parseProto(t, content), Rules{}))
+}
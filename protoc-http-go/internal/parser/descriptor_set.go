@@ -0,0 +1,405 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// Field numbers of the descriptor.proto fields that make up source code info paths
+const (
+	fileMessageTypeTag   = 4 // FileDescriptorProto.message_type
+	fileEnumTypeTag      = 5 // FileDescriptorProto.enum_type
+	fileServiceTag       = 6 // FileDescriptorProto.service
+	messageFieldTag      = 2 // DescriptorProto.field
+	messageNestedTypeTag = 3 // DescriptorProto.nested_type
+	messageEnumTypeTag   = 4 // DescriptorProto.enum_type
+	messageOneofDeclTag  = 8 // DescriptorProto.oneof_decl
+	serviceMethodTag     = 2 // ServiceDescriptorProto.method
+)
+
+// wellKnownTypesPrefix is the directory of the well-known type protos that
+// --include_imports adds to a descriptor set
+const wellKnownTypesPrefix = "google/protobuf/"
+
+// ParseDescriptorSetFile reads a binary FileDescriptorSet, as written by
+// "protoc --descriptor_set_out=<file>", and converts every file in it into a
+// ProtoFile. The descriptors are what protoc compiled, so none of the regex
+// parser's limitations apply.
+//
+// Declaration lines and the "// http-method:", "// type:" and "// default-version:"
+// annotations are only available when the set was written with --include_source_info.
+// Files under google/protobuf/ (well-known types pulled in by --include_imports) are
+// used to resolve references but not returned.
+func ParseDescriptorSetFile(filePath string, opts Options) ([]*types.ProtoFile, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(content, set); err != nil {
+		return nil, fmt.Errorf("%s is not a binary FileDescriptorSet: %w", filePath, err)
+	}
+	return ParseDescriptorSet(set, opts)
+}
+
+// ParseDescriptorSet converts the files of set like ParseDescriptorSetFile.
+// Message and enum references are written the way a .proto author would: the
+// shortest name that resolves to the same type from the referencing scope,
+// e.g. "Line" inside Order or "common.Money" from another package.
+func ParseDescriptorSet(set *descriptorpb.FileDescriptorSet, opts Options) ([]*types.ProtoFile, error) {
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	var all []*types.ProtoFile
+	var converters []*descriptorConverter
+	for _, fd := range set.GetFile() {
+		c := newDescriptorConverter(fd, maxDepth, opts.ReportGroups)
+		protoFile, err := c.convert()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, protoFile)
+		converters = append(converters, c)
+	}
+
+	// Types are recorded fully qualified until every file is known; then each
+	// reference is shortened against the table of the whole set
+	table, err := types.BuildSymbolTable(all)
+	if err != nil {
+		return nil, err
+	}
+	var protoFiles []*types.ProtoFile
+	for i, c := range converters {
+		c.shortenTypeNames(table)
+		if strings.HasPrefix(all[i].FileName, wellKnownTypesPrefix) {
+			continue
+		}
+		protoFiles = append(protoFiles, all[i])
+	}
+	return protoFiles, nil
+}
+
+// descriptorConverter builds the ProtoFile of one FileDescriptorProto
+type descriptorConverter struct {
+	fd        *descriptorpb.FileDescriptorProto
+	file      *types.ProtoFile
+	maxDepth  int
+	groups    bool                                             // Report groups as unsupported instead of failing
+	locations map[string]*descriptorpb.SourceCodeInfo_Location // By joined path
+
+	// References still fully qualified, with the scope they are resolved from
+	refs []typeRef
+}
+
+// typeRef is a message or enum reference waiting to be shortened
+type typeRef struct {
+	target *string  // Type field of a ProtoField or ProtoRPC
+	scope  []string // Enclosing message path; nil for RPC arguments
+}
+
+func newDescriptorConverter(fd *descriptorpb.FileDescriptorProto, maxDepth int, reportGroups bool) *descriptorConverter {
+	c := &descriptorConverter{
+		fd:        fd,
+		maxDepth:  maxDepth,
+		groups:    reportGroups,
+		locations: make(map[string]*descriptorpb.SourceCodeInfo_Location),
+	}
+	for _, loc := range fd.GetSourceCodeInfo().GetLocation() {
+		key := pathKey(loc.GetPath())
+		// protoc lists a declaration before its parts; keep the first location per path
+		if _, ok := c.locations[key]; !ok {
+			c.locations[key] = loc
+		}
+	}
+	return c
+}
+
+func (c *descriptorConverter) convert() (*types.ProtoFile, error) {
+	fileName := c.fd.GetName()
+	c.file = &types.ProtoFile{
+		FileName: fileName,
+		BaseName: strings.TrimSuffix(filepath.Base(fileName), ".proto"),
+		Package:  c.fd.GetPackage(),
+		Imports:  c.fd.GetDependency(),
+		Messages: make(map[string]*types.ProtoMessage),
+		Enums:    make(map[string]*types.ProtoEnum),
+	}
+
+	for i, ed := range c.fd.GetEnumType() {
+		enum := c.convertEnum(ed, []int32{fileEnumTypeTag, int32(i)})
+		c.file.Enums[enum.Name] = enum
+	}
+	for i, md := range c.fd.GetMessageType() {
+		message, err := c.convertMessage(md, nil, []int32{fileMessageTypeTag, int32(i)})
+		if err != nil {
+			return nil, err
+		}
+		c.file.Messages[message.Name] = message
+	}
+	for i, sd := range c.fd.GetService() {
+		service, err := c.convertService(sd, []int32{fileServiceTag, int32(i)})
+		if err != nil {
+			return nil, err
+		}
+		c.file.Services = append(c.file.Services, service)
+	}
+
+	sort.SliceStable(c.file.Unsupported, func(i, j int) bool { return c.file.Unsupported[i].Line < c.file.Unsupported[j].Line })
+	return c.file, nil
+}
+
+func (c *descriptorConverter) convertEnum(ed *descriptorpb.EnumDescriptorProto, path []int32) *types.ProtoEnum {
+	enum := &types.ProtoEnum{
+		Name:   ed.GetName(),
+		Values: make(map[string]int),
+		Line:   c.line(path),
+	}
+	for _, value := range ed.GetValue() {
+		enum.Values[value.GetName()] = int(value.GetNumber())
+	}
+	return enum
+}
+
+// convertMessage converts md, declared inside the messages of parent (outermost
+// first), with source code info path path
+func (c *descriptorConverter) convertMessage(md *descriptorpb.DescriptorProto, parent []string, path []int32) (*types.ProtoMessage, error) {
+	scope := append(append([]string{}, parent...), md.GetName())
+	if len(scope) > c.maxDepth {
+		return nil, fmt.Errorf("%s:%d: message %s is nested %d levels deep, more than the maximum of %d",
+			c.file.FileName, c.line(path), md.GetName(), len(scope), c.maxDepth)
+	}
+	message := &types.ProtoMessage{
+		Name:           md.GetName(),
+		NestedMessages: make(map[string]*types.ProtoMessage),
+		NestedEnums:    make(map[string]*types.ProtoEnum),
+		Line:           c.line(path),
+	}
+	if len(parent) > 0 {
+		message.ParentName = parent[len(parent)-1]
+	}
+
+	for i, ed := range md.GetEnumType() {
+		enum := c.convertEnum(ed, childPath(path, messageEnumTypeTag, i))
+		message.NestedEnums[enum.Name] = enum
+	}
+
+	// Map entries are synthesized messages; the map field itself is reported as unsupported
+	mapEntries := make(map[string]bool)
+	for i, nested := range md.GetNestedType() {
+		if nested.GetOptions().GetMapEntry() {
+			mapEntries[nested.GetName()] = true
+			continue
+		}
+		nestedMessage, err := c.convertMessage(nested, scope, childPath(path, messageNestedTypeTag, i))
+		if err != nil {
+			return nil, err
+		}
+		message.NestedMessages[nestedMessage.Name] = nestedMessage
+	}
+
+	for i, field := range md.GetField() {
+		fieldPath := childPath(path, messageFieldTag, i)
+		typeName := field.GetTypeName()
+		switch {
+		case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_GROUP && c.groups:
+			c.file.Unsupported = append(c.file.Unsupported, types.UnsupportedFeature{
+				Description: "group " + md.GetName() + "." + typeName[strings.LastIndex(typeName, ".")+1:],
+				Line:        c.line(fieldPath),
+			})
+			continue
+		case field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+			return nil, fmt.Errorf("%s:%d: groups are not supported (group %s in message %s); use a nested message field instead",
+				c.file.FileName, c.line(fieldPath), typeName[strings.LastIndex(typeName, ".")+1:], md.GetName())
+		case mapEntries[typeName[strings.LastIndex(typeName, ".")+1:]] && field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+			c.file.Unsupported = append(c.file.Unsupported, types.UnsupportedFeature{
+				Description: "map field " + md.GetName() + "." + field.GetName(),
+				Line:        c.line(fieldPath),
+			})
+			continue
+		}
+
+		protoField := &types.ProtoField{
+			Name:     field.GetName(),
+			Number:   int(field.GetNumber()),
+			Repeated: field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED,
+			Line:     c.line(fieldPath),
+		}
+		// protoc fills in json_name for every field; only a non-default one was set explicitly
+		if jsonName := field.GetJsonName(); jsonName != "" && jsonName != protocJSONName(field.GetName()) {
+			protoField.JSONName = jsonName
+		}
+		override, err := fieldTypeOverride(md.GetName(), field.GetName(), c.annotations(fieldPath))
+		if err != nil {
+			return nil, err
+		}
+		protoField.TypeOverride = override
+
+		if typeName != "" {
+			protoField.Type = strings.TrimPrefix(typeName, ".")
+			c.refs = append(c.refs, typeRef{target: &protoField.Type, scope: scope})
+		} else {
+			protoField.Type = strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+		}
+		// protoc stores defaults the way ProtoField.Default expects, except that bytes
+		// stay C-escaped; generators do not use bytes defaults
+		if field.DefaultValue != nil {
+			protoField.Default, protoField.HasDefault = field.GetDefaultValue(), true
+		}
+		// Only "// validate:" annotations: (validate.rules) options are extensions the
+		// descriptor set keeps as unknown fields
+		validation, err := fieldValidation(md.GetName(), protoField, "", c.annotations(fieldPath))
+		if err != nil {
+			return nil, err
+		}
+		protoField.Validation = validation
+		message.Fields = append(message.Fields, protoField)
+	}
+
+	// proto3 optional fields live in synthetic oneofs, which are not real oneofs
+	for i, oneof := range md.GetOneofDecl() {
+		synthetic := false
+		for _, field := range md.GetField() {
+			if field.OneofIndex != nil && int(field.GetOneofIndex()) == i && field.GetProto3Optional() {
+				synthetic = true
+			}
+		}
+		if !synthetic {
+			c.file.Unsupported = append(c.file.Unsupported, types.UnsupportedFeature{
+				Description: "oneof " + md.GetName() + "." + oneof.GetName(),
+				Line:        c.line(childPath(path, messageOneofDeclTag, i)),
+			})
+		}
+	}
+	return message, nil
+}
+
+func (c *descriptorConverter) convertService(sd *descriptorpb.ServiceDescriptorProto, path []int32) (*types.ProtoService, error) {
+	service := &types.ProtoService{
+		Name:    sd.GetName(),
+		Package: c.file.Package,
+		Line:    c.line(path),
+	}
+	defaultVersion, err := serviceDefaultVersion(sd.GetName(), c.annotations(path))
+	if err != nil {
+		return nil, err
+	}
+	service.DefaultVersion = defaultVersion
+
+	for i, method := range sd.GetMethod() {
+		methodPath := childPath(path, serviceMethodTag, i)
+		rpc := &types.ProtoRPC{
+			Name:            method.GetName(),
+			InputType:       strings.TrimPrefix(method.GetInputType(), "."),
+			OutputType:      strings.TrimPrefix(method.GetOutputType(), "."),
+			IsUnary:         !method.GetClientStreaming() && !method.GetServerStreaming(),
+			ClientStreaming: method.GetClientStreaming(),
+			ServerStreaming: method.GetServerStreaming(),
+			Line:            c.line(methodPath),
+		}
+		httpMethod, err := rpcHTTPMethod(sd.GetName(), method.GetName(), c.annotations(methodPath))
+		if err != nil {
+			return nil, err
+		}
+		rpc.HTTPMethod = httpMethod
+		c.refs = append(c.refs, typeRef{target: &rpc.InputType}, typeRef{target: &rpc.OutputType})
+		service.RPCs = append(service.RPCs, rpc)
+	}
+	return service, nil
+}
+
+// shortenTypeNames replaces every fully-qualified reference with the shortest
+// suffix that table resolves to the same type from the reference's scope. Types
+// missing from the set (a dependency compiled without --include_imports) only
+// lose the file's own package prefix.
+func (c *descriptorConverter) shortenTypeNames(table *types.SymbolTable) {
+	for _, ref := range c.refs {
+		fullName := *ref.target
+		parts := strings.Split(fullName, ".")
+		shortened := ""
+		for n := 1; n <= len(parts); n++ {
+			candidate := strings.Join(parts[len(parts)-n:], ".")
+			if symbol, ok := table.Resolve(c.file.Package, ref.scope, candidate); ok && symbol.FullName == fullName {
+				shortened = candidate
+				break
+			}
+		}
+		if shortened == "" && c.file.Package != "" {
+			shortened = strings.TrimPrefix(fullName, c.file.Package+".")
+		}
+		if shortened != "" {
+			*ref.target = shortened
+		}
+	}
+}
+
+// line returns the 1-based line of the declaration at path, or 0 without source info
+func (c *descriptorConverter) line(path []int32) int {
+	loc, ok := c.locations[pathKey(path)]
+	if !ok || len(loc.GetSpan()) == 0 {
+		return 0
+	}
+	return int(loc.GetSpan()[0]) + 1
+}
+
+// annotations parses the "key: value" lines of the comment block directly above
+// the declaration at path, like leadingAnnotations does for .proto text
+func (c *descriptorConverter) annotations(path []int32) map[string]string {
+	annotations := make(map[string]string)
+	loc, ok := c.locations[pathKey(path)]
+	if !ok {
+		return annotations
+	}
+	lines := strings.Split(strings.TrimRight(loc.GetLeadingComments(), "\n"), "\n")
+	// Lines closer to the declaration win over earlier duplicates
+	for i := len(lines) - 1; i >= 0; i-- {
+		if key, value, ok := parseAnnotation(lines[i]); ok {
+			if _, exists := annotations[key]; !exists {
+				annotations[key] = value
+			}
+		}
+	}
+	return annotations
+}
+
+// childPath extends a source code info path with the tag and index of a child declaration
+func childPath(path []int32, tag int32, index int) []int32 {
+	return append(append([]int32{}, path...), tag, int32(index))
+}
+
+func pathKey(path []int32) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ",")
+}
+
+// protocJSONName returns the json_name protoc derives for a field without the
+// option: underscores are dropped and the letter after each one is upper-cased
+func protocJSONName(name string) string {
+	var sb strings.Builder
+	upperNext := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upperNext = true
+		case upperNext && r >= 'a' && r <= 'z':
+			sb.WriteRune(r - 'a' + 'A')
+			upperNext = false
+		default:
+			sb.WriteRune(r)
+			upperNext = false
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// descriptorSetPath is compiled from common.proto and orders.proto in the same directory
+var descriptorSetPath = filepath.Join("testdata", "descriptor_set", "orders.pb")
+
+func findProtoFile(t *testing.T, files []*types.ProtoFile, name string) *types.ProtoFile {
+	t.Helper()
+	for _, file := range files {
+		if file.FileName == name {
+			return file
+		}
+	}
+	t.Fatalf("no file %s in the descriptor set", name)
+	return nil
+}
+
+func fieldByName(t *testing.T, message *types.ProtoMessage, name string) *types.ProtoField {
+	t.Helper()
+	for _, field := range message.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	t.Fatalf("message %s has no field %s", message.Name, name)
+	return nil
+}
+
+func TestParseDescriptorSetFile(t *testing.T) {
+	files, err := ParseDescriptorSetFile(descriptorSetPath, Options{})
+	if err != nil {
+		t.Fatalf("ParseDescriptorSetFile() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+
+	orders := findProtoFile(t, files, "orders.proto")
+	if orders.BaseName != "orders" || orders.Package != "acme.orders" {
+		t.Fatalf("unexpected file header %s/%s", orders.BaseName, orders.Package)
+	}
+	if len(orders.Imports) != 1 || orders.Imports[0] != "common.proto" {
+		t.Fatalf("unexpected imports %v", orders.Imports)
+	}
+
+	order := orders.Messages["Order"]
+	if order == nil || order.Line != 7 {
+		t.Fatalf("expected message Order on line 7, got %+v", order)
+	}
+	if _, ok := order.NestedEnums["Status"]; !ok {
+		t.Error("expected nested enum Order.Status")
+	}
+	if _, ok := order.NestedMessages["LabelsEntry"]; ok {
+		t.Error("map entry messages must not be generated")
+	}
+
+	tests := []struct {
+		field    string
+		typeName string
+		repeated bool
+		jsonName string
+	}{
+		{"order_id", "string", false, "id"},
+		{"status", "Status", false, ""},
+		{"lines", "Line", true, ""},
+		{"total", "common.Money", false, ""},
+		{"note", "string", false, ""},
+	}
+	for _, tt := range tests {
+		field := fieldByName(t, order, tt.field)
+		if field.Type != tt.typeName || field.Repeated != tt.repeated || field.JSONName != tt.jsonName {
+			t.Errorf("field %s = %+v, want type %s repeated %v json_name %q", tt.field, field, tt.typeName, tt.repeated, tt.jsonName)
+		}
+	}
+	if len(order.Fields) != 5 {
+		t.Errorf("expected the map field to be left out, got %d fields", len(order.Fields))
+	}
+	if price := fieldByName(t, order.NestedMessages["Line"], "unit_price"); price.TypeOverride != "Decimal" || price.Line != 18 {
+		t.Errorf("unit_price = %+v, want the // type: Decimal annotation on line 18", price)
+	}
+
+	// The synthetic oneof of the proto3 optional field is not reported
+	if len(orders.Unsupported) != 1 || orders.Unsupported[0].Description != "map field Order.labels" || orders.Unsupported[0].Line != 25 {
+		t.Fatalf("unexpected unsupported features %+v", orders.Unsupported)
+	}
+
+	if len(orders.Services) != 1 {
+		t.Fatalf("expected 1 service, got %d", len(orders.Services))
+	}
+	service := orders.Services[0]
+	if service.FullName() != "acme.orders.OrderService" || service.DefaultVersion != "v2" {
+		t.Fatalf("unexpected service %+v", service)
+	}
+	get, watch := service.RPCs[0], service.RPCs[1]
+	if get.InputType != "GetOrderRequest" || get.OutputType != "Order" || !get.IsGet() || !get.IsUnary || get.Line != 37 {
+		t.Errorf("unexpected rpc GetOrder %+v", get)
+	}
+	if watch.IsUnary || !watch.ServerStreaming {
+		t.Errorf("expected WatchOrders to be server streaming, got %+v", watch)
+	}
+
+	common := findProtoFile(t, files, "common.proto")
+	if currency := fieldByName(t, common.Messages["Money"], "currency"); currency.Type != "Currency" {
+		t.Errorf("expected Money.currency of type Currency, got %s", currency.Type)
+	}
+}
+
+// serviceFile returns a file whose single rpc takes and returns the given types
+func serviceFile(name, input, output string, deps ...string) *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(name),
+		Package:     proto.String("demo"),
+		Dependency:  deps,
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Req")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Demo"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Call"), InputType: proto.String(input), OutputType: proto.String(output)},
+			},
+		}},
+	}
+}
+
+func TestParseDescriptorSetSkipsWellKnownTypes(t *testing.T) {
+	empty := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("google/protobuf/empty.proto"),
+		Package:     proto.String("google.protobuf"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Empty")}},
+	}
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		empty,
+		serviceFile("demo.proto", ".demo.Req", ".google.protobuf.Empty", "google/protobuf/empty.proto"),
+	}}
+
+	files, err := ParseDescriptorSet(set, Options{})
+	if err != nil {
+		t.Fatalf("ParseDescriptorSet() error = %v", err)
+	}
+	if len(files) != 1 || files[0].FileName != "demo.proto" {
+		t.Fatalf("expected only demo.proto, got %d files", len(files))
+	}
+	if rpc := files[0].Services[0].RPCs[0]; rpc.InputType != "Req" || rpc.OutputType != "google.protobuf.Empty" {
+		t.Fatalf("unexpected rpc types %s -> %s", rpc.InputType, rpc.OutputType)
+	}
+}
+
+func TestParseDescriptorSetKeepsUnresolvedReferencesQualified(t *testing.T) {
+	// Written without --include_imports: the dependency is missing from the set
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		serviceFile("demo.proto", ".demo.Missing", ".other.Reply", "other.proto"),
+	}}
+	files, err := ParseDescriptorSet(set, Options{})
+	if err != nil {
+		t.Fatalf("ParseDescriptorSet() error = %v", err)
+	}
+	if rpc := files[0].Services[0].RPCs[0]; rpc.InputType != "Missing" || rpc.OutputType != "other.Reply" {
+		t.Fatalf("unexpected rpc types %s -> %s", rpc.InputType, rpc.OutputType)
+	}
+}
+
+func TestParseDescriptorSetRejectsGroups(t *testing.T) {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:   proto.String("legacy.proto"),
+		Syntax: proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:       proto.String("Search"),
+			NestedType: []*descriptorpb.DescriptorProto{{Name: proto.String("Result")}},
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("result"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_GROUP.Enum(),
+				TypeName: proto.String(".Search.Result"),
+			}},
+		}},
+	}
+	_, err := ParseDescriptorSet(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}}, Options{})
+	if err == nil || !strings.Contains(err.Error(), "group Result in message Search") {
+		t.Fatalf("expected a group error, got %v", err)
+	}
+}
+
+func TestParseDescriptorSetReportsGroups(t *testing.T) {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:   proto.String("legacy.proto"),
+		Syntax: proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:       proto.String("Search"),
+			NestedType: []*descriptorpb.DescriptorProto{{Name: proto.String("Result")}},
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("result"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_GROUP.Enum(),
+				TypeName: proto.String(".Search.Result"),
+			}},
+		}},
+	}
+	files, err := ParseDescriptorSet(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}}, Options{ReportGroups: true})
+	if err != nil {
+		t.Fatalf("ParseDescriptorSet() error = %v", err)
+	}
+	unsupported := files[0].Unsupported
+	if len(unsupported) != 1 || unsupported[0].Description != "group Search.Result" {
+		t.Errorf("Unsupported = %+v, want group Search.Result", unsupported)
+	}
+	if fields := files[0].Messages["Search"].Fields; len(fields) != 0 {
+		t.Errorf("group field converted: %+v", fields)
+	}
+}
+
+func TestParseDescriptorSetFileRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.proto")
+	if err := os.WriteFile(path, []byte("syntax = \"proto3\";\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseDescriptorSetFile(path, Options{}); err == nil {
+		t.Fatal("expected an error for a .proto file")
+	}
+}
+
+func TestProtocJSONName(t *testing.T) {
+	for name, want := range map[string]string{"order_id": "orderId", "MsgHdr": "MsgHdr", "a_b_c": "aBC", "x__y": "xY"} {
+		if got := protocJSONName(name); got != want {
+			t.Errorf("protocJSONName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseDescriptorSetKeepsDefaultValues(t *testing.T) {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:   proto.String("settings.proto"),
+		Syntax: proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Settings"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("timeout"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(), DefaultValue: proto.String("30")},
+				{Name: proto.String("name"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+			},
+		}},
+	}
+	files, err := ParseDescriptorSet(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}}, Options{})
+	if err != nil {
+		t.Fatalf("ParseDescriptorSet() error = %v", err)
+	}
+	settings := files[0].Messages["Settings"]
+	if timeout := fieldByName(t, settings, "timeout"); !timeout.HasDefault || timeout.Default != "30" {
+		t.Errorf("timeout = %+v, want default 30", timeout)
+	}
+	if name := fieldByName(t, settings, "name"); name.HasDefault {
+		t.Errorf("name = %+v, want no default", name)
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// FindImport resolves an import path such as "common/common.proto" against the
+// import roots in order, like protoc's -I, and returns the first existing file.
+// The error lists every path that was searched.
+func FindImport(importPath string, roots []string) (string, error) {
+	searched := make([]string, 0, len(roots))
+	for _, root := range roots {
+		candidate := filepath.Join(root, filepath.FromSlash(importPath))
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+		searched = append(searched, candidate)
+	}
+	if len(searched) == 0 {
+		return "", fmt.Errorf("import %q not found: no import roots given", importPath)
+	}
+	return "", fmt.Errorf("import %q not found; searched %s", importPath, strings.Join(searched, ", "))
+}
+
+// ResolveImports parses the files imported by files, and transitively the files
+// those import, from the import roots. Imports of the well-known types under
+// google/protobuf/ are skipped, as they are when reading a descriptor set.
+//
+// Files that are already in files (compared by absolute path) are not parsed
+// again. The returned files are the newly parsed ones, in the order they were
+// first imported; an import that is found in no root fails the whole call.
+func ResolveImports(files []*types.ProtoFile, roots []string, opts Options) ([]*types.ProtoFile, error) {
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		seen[absPath(file.FileName)] = true
+	}
+
+	var imported []*types.ProtoFile
+	queue := append([]*types.ProtoFile{}, files...)
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		for _, importPath := range file.Imports {
+			if strings.HasPrefix(importPath, wellKnownTypesPrefix) {
+				continue
+			}
+			path, err := FindImport(importPath, roots)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file.FileName, err)
+			}
+			if seen[absPath(path)] {
+				continue
+			}
+			seen[absPath(path)] = true
+
+			parsed, err := ParseProtoFileWithOptions(path, opts)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			imported = append(imported, parsed)
+			queue = append(queue, parsed)
+		}
+	}
+	return imported, nil
+}
+
+// absPath returns the absolute form of path, or path itself if it cannot be made absolute
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
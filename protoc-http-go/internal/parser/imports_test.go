@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+// writeProtoAt writes content to dir/rel, creating the parent directories
+func writeProtoAt(t *testing.T, dir, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindImportSearchesRootsInOrder(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	want := writeProtoAt(t, second, "common/common.proto", "syntax = \"proto3\";\n")
+
+	got, err := FindImport("common/common.proto", []string{first, second})
+	if err != nil {
+		t.Fatalf("FindImport() error = %v", err)
+	}
+	if got != want {
+		t.Fatalf("FindImport() = %s, want %s", got, want)
+	}
+
+	// The first root wins when both have the file
+	shadow := writeProtoAt(t, first, "common/common.proto", "syntax = \"proto3\";\n")
+	if got, _ := FindImport("common/common.proto", []string{first, second}); got != shadow {
+		t.Fatalf("FindImport() = %s, want %s from the first root", got, shadow)
+	}
+}
+
+func TestFindImportListsSearchedPaths(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	_, err := FindImport("common/missing.proto", []string{first, second})
+	if err == nil {
+		t.Fatal("expected an error for a missing import")
+	}
+	for _, root := range []string{first, second} {
+		if want := filepath.Join(root, "common", "missing.proto"); !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %s in the error, got: %v", want, err)
+		}
+	}
+}
+
+func TestResolveImportsParsesTransitiveImports(t *testing.T) {
+	protoDir, vendorDir := t.TempDir(), t.TempDir()
+	orders := writeProtoAt(t, protoDir, "orders.proto", "syntax = \"proto3\";\npackage orders;\n"+
+		"import \"common/money.proto\";\nimport \"google/protobuf/empty.proto\";\n"+
+		"message Order { common.Money total = 1; }\n")
+	writeProtoAt(t, vendorDir, "common/money.proto", "syntax = \"proto3\";\npackage common;\n"+
+		"import \"common/currency.proto\";\nmessage Money { Currency currency = 1; }\n")
+	writeProtoAt(t, vendorDir, "common/currency.proto", "syntax = \"proto3\";\npackage common;\n"+
+		"enum Currency { CURRENCY_UNSPECIFIED = 0; }\n")
+
+	file, err := ParseProtoFile(orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ResolveImports([]*types.ProtoFile{file}, []string{protoDir, vendorDir}, Options{})
+	if err != nil {
+		t.Fatalf("ResolveImports() error = %v", err)
+	}
+	var names []string
+	for _, f := range imported {
+		names = append(names, f.BaseName)
+	}
+	if strings.Join(names, ",") != "money,currency" {
+		t.Fatalf("expected money and currency to be imported, got %v", names)
+	}
+}
+
+func TestResolveImportsReportsTheImportingFile(t *testing.T) {
+	protoDir := t.TempDir()
+	orders := writeProtoAt(t, protoDir, "orders.proto", "syntax = \"proto3\";\nimport \"common/money.proto\";\n")
+	file, err := ParseProtoFile(orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ResolveImports([]*types.ProtoFile{file}, []string{protoDir}, Options{})
+	if err == nil || !strings.Contains(err.Error(), orders+": import \"common/money.proto\" not found") {
+		t.Fatalf("expected a not-found error naming %s, got %v", orders, err)
+	}
+}
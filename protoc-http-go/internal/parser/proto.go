@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -16,20 +17,51 @@ var (
 	packageRegex   = regexp.MustCompile(`(?m)^package\s+([^;]+);`)
 	importRegex    = regexp.MustCompile(`import\s+"([^"]+)";`)
 	enumRegex      = regexp.MustCompile(`enum\s+(\w+)\s*{([^}]+)}`)
-	enumValueRegex = regexp.MustCompile(`(\w+)\s*=\s*(\d+)\s*;`)
+	enumValueRegex = regexp.MustCompile(`(\w+)\s*=\s*(\d+)\s*(?:[;,]|\z)`) // ";" or C-style "," separators
 	serviceRegex   = regexp.MustCompile(`service\s+(\w+)\s*{`)
 	rpcRegex       = regexp.MustCompile(`rpc\s+(\w+)\s*\(\s*([^)]+)\s*\)\s*returns\s*\(\s*([^)]+)\s*\)\s*[{;]`)
 	messageRegex   = regexp.MustCompile(`message\s+(\w+)\s*{`)
-	fieldRegex     = regexp.MustCompile(`(repeated\s+)?([^\s=]+)\s+([^\s=]+)\s*=\s*(\d+)\s*;`)
+	fieldRegex     = regexp.MustCompile(`(repeated\s+)?([^\s=]+)\s+([^\s=]+)\s*=\s*(\d+)\s*(?:\[([^\]]*)\]\s*)?;`)
+	jsonNameRegex  = regexp.MustCompile(`(?:^|[\s,])json_name\s*=\s*"([^"]*)"`)
+	identRegex     = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	defaultRegex   = regexp.MustCompile(`(?:^|[\s,])default\s*=\s*("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|[^\s,]+)`)
+	groupRegex     = regexp.MustCompile(`\bgroup\s+(\w+)\s*=\s*\d+\s*(?:\[[^\]]*\]\s*)?{`)
+	mapFieldRegex  = regexp.MustCompile(`\bmap\s*<[^>]*>\s*(\w+)\s*=\s*\d+`)
+	oneofRegex     = regexp.MustCompile(`\boneof\s+(\w+)\s*{`)
+	optionRegex    = regexp.MustCompile(`\boption\s+[A-Za-z_(]`) // "option" and an option name; a field named option is followed by "="
+	validateRegex  = regexp.MustCompile(`^[\s,]*([\w-]+)\s*(?:\(([^)]*)\))?`)
+	pgvRuleRegex   = regexp.MustCompile(`\(validate\.rules\)\.(\w+)(?:\.(\w+))?\s*=\s*(\{[^}]*\}|[^\s,]+)`)
+	pgvPairRegex   = regexp.MustCompile(`(\w+)\s*:\s*([^\s,}]+)`)
 )
 
+// DefaultMaxDepth is the deepest message nesting accepted by ParseProtoFile
+const DefaultMaxDepth = 32
+
+// Options controls ParseProtoFileWithOptions
+type Options struct {
+	MaxDepth     int  // Deepest message nesting accepted (a top-level message is depth 1); <= 0 uses DefaultMaxDepth
+	ReportGroups bool // List proto2 groups in ProtoFile.Unsupported, skipping their bodies, instead of failing
+}
+
 // ParseProtoFile parses a single .proto file and returns a ProtoFile structure
 func ParseProtoFile(filePath string) (*types.ProtoFile, error) {
+	return ParseProtoFileWithOptions(filePath, Options{})
+}
+
+// ParseProtoFileWithOptions parses a single .proto file like ParseProtoFile, rejecting
+// files whose messages are nested deeper than opts.MaxDepth
+func ParseProtoFileWithOptions(filePath string, opts Options) (*types.ProtoFile, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	return ParseProtoContent(filePath, string(content), opts)
+}
 
+// ParseProtoContent parses proto source that did not come from a file on disk, such
+// as stdin. filePath names the source in errors and determines the base name used
+// for generated files and routes.
+func ParseProtoContent(filePath, contentStr string, opts Options) (*types.ProtoFile, error) {
 	baseName := strings.TrimSuffix(filepath.Base(filePath), ".proto")
 	protoFile := &types.ProtoFile{
 		FileName: filePath,
@@ -38,7 +70,33 @@ func ParseProtoFile(filePath string) (*types.ProtoFile, error) {
 		Enums:    make(map[string]*types.ProtoEnum),
 	}
 
-	contentStr := string(content)
+	// Option statements can hold braces, in aggregate values or strings, that would
+	// be taken for bodies; they do not affect the generated code, so blank them out
+	contentStr = maskOptionStatements(contentStr)
+
+	// Reject proto2 groups up front: their braces would be misread as message bodies.
+	// When reporting them instead, blank them out so the rest of the file still parses.
+	var groups []types.UnsupportedFeature
+	if opts.ReportGroups {
+		groups, contentStr = maskGroups(contentStr)
+	} else if err := checkUnsupportedGroups(filePath, contentStr); err != nil {
+		return nil, err
+	}
+
+	// A service inside a message body is a typo (usually a missing "}"); without this
+	// check its RPCs would silently be attributed to a top-level service
+	if err := checkNestedServices(filePath, contentStr); err != nil {
+		return nil, err
+	}
+
+	// Bound the recursion of parseMessage (and of the generators walking its result)
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if err := checkNestingDepth(filePath, contentStr, maxDepth); err != nil {
+		return nil, err
+	}
 
 	// Parse package
 	if matches := packageRegex.FindStringSubmatch(contentStr); matches != nil {
@@ -51,15 +109,21 @@ func ParseProtoFile(filePath string) (*types.ProtoFile, error) {
 		protoFile.Imports = append(protoFile.Imports, match[1])
 	}
 
-	// Parse enums
-	enumMatches := enumRegex.FindAllStringSubmatch(contentStr, -1)
-	for _, match := range enumMatches {
+	// Parse top-level enums; enums nested in messages are parsed with their message
+	fileDepths := braceDepths(contentStr)
+	enumMatches := enumRegex.FindAllStringSubmatchIndex(contentStr, -1)
+	for _, loc := range enumMatches {
+		if fileDepths[loc[0]] != 0 {
+			continue
+		}
+		match := submatches(contentStr, loc)
 		enumName := match[1]
 		enumBody := match[2]
 		
 		protoEnum := &types.ProtoEnum{
 			Name:   enumName,
 			Values: make(map[string]int),
+			Line:   lineAt(contentStr, loc[0]),
 		}
 		
 		valueMatches := enumValueRegex.FindAllStringSubmatch(enumBody, -1)
@@ -76,8 +140,7 @@ func ParseProtoFile(filePath string) (*types.ProtoFile, error) {
 	}
 
 	// Parse messages (with nested support)
-	err = parseMessages(contentStr, protoFile)
-	if err != nil {
+	if err := parseMessages(contentStr, protoFile); err != nil {
 		return nil, fmt.Errorf("failed to parse messages: %w", err)
 	}
 
@@ -86,9 +149,262 @@ func ParseProtoFile(filePath string) (*types.ProtoFile, error) {
 		return nil, fmt.Errorf("failed to parse services: %w", err)
 	}
 
+	protoFile.Unsupported = append(groups, findUnsupportedFeatures(contentStr)...)
+	sort.SliceStable(protoFile.Unsupported, func(i, j int) bool { return protoFile.Unsupported[i].Line < protoFile.Unsupported[j].Line })
+
 	return protoFile, nil
 }
 
+// checkUnsupportedGroups returns an error naming the first proto2 group declaration
+// (e.g. "repeated group Result = 1 { ... }") and the message that contains it.
+// Groups are not supported by the generator, so failing fast beats misparsing them.
+func checkUnsupportedGroups(filePath, content string) error {
+	for _, loc := range groupRegex.FindAllStringSubmatchIndex(content, -1) {
+		pos := loc[0]
+
+		if inLineComment(content, pos) {
+			continue
+		}
+
+		groupName := content[loc[2]:loc[3]]
+		line := lineAt(content, pos)
+		if messageName := enclosingMessage(content, pos); messageName != "" {
+			return fmt.Errorf("%s:%d: groups are not supported (group %s in message %s); use a nested message field instead", filePath, line, groupName, messageName)
+		}
+		return fmt.Errorf("%s:%d: groups are not supported (group %s); use a nested message field instead", filePath, line, groupName)
+	}
+	return nil
+}
+
+// maskGroups lists the proto2 groups in content as unsupported features and returns
+// content with each declaration, label and body included, blanked out
+func maskGroups(content string) ([]types.UnsupportedFeature, string) {
+	var features []types.UnsupportedFeature
+	var spans [][2]int
+	for _, loc := range groupRegex.FindAllStringSubmatchIndex(content, -1) {
+		if inLineComment(content, loc[0]) || len(spans) > 0 && loc[0] < spans[len(spans)-1][1] {
+			continue
+		}
+
+		name := content[loc[2]:loc[3]]
+		if messageName := enclosingMessage(content, loc[0]); messageName != "" {
+			name = messageName + "." + name
+		}
+		features = append(features, types.UnsupportedFeature{
+			Description: "group " + name,
+			Line:        lineAt(content, loc[0]),
+		})
+
+		// The regex ends at the group's opening brace; find the matching closing one
+		start := loc[0]
+		before := strings.TrimRight(content[:start], " \t")
+		for _, label := range []string{"optional", "required", "repeated"} {
+			if strings.HasSuffix(before, label) {
+				start = len(before) - len(label)
+				break
+			}
+		}
+		end := loc[1]
+		braceCount := 1
+		for end < len(content) && braceCount > 0 {
+			switch content[end] {
+			case '{':
+				braceCount++
+			case '}':
+				braceCount--
+			}
+			end++
+		}
+		spans = append(spans, [2]int{start, end})
+	}
+	return features, maskSpans(content, spans)
+}
+
+// checkNestedServices returns an error naming the first service declared at a
+// non-zero brace level. Services may only appear at the top level of a file.
+func checkNestedServices(filePath, content string) error {
+	depths := braceDepths(content)
+	for _, loc := range serviceRegex.FindAllStringSubmatchIndex(content, -1) {
+		pos := loc[0]
+		if depths[pos] == 0 || inLineComment(content, pos) {
+			continue
+		}
+
+		serviceName := content[loc[2]:loc[3]]
+		line := lineAt(content, pos)
+		if messageName := enclosingMessage(content, pos); messageName != "" {
+			return fmt.Errorf("%s:%d: service %s is declared inside message %s; services must be top-level (is a closing brace missing?)", filePath, line, serviceName, messageName)
+		}
+		return fmt.Errorf("%s:%d: service %s is not declared at the top level; services must be top-level (is a closing brace missing?)", filePath, line, serviceName)
+	}
+	return nil
+}
+
+// findUnsupportedFeatures lists the map fields and oneofs in content. The parser
+// reads map fields as garbage and oneof members as plain fields, so callers warn
+// about them or, with --fail-on-unsupported, refuse to generate.
+func findUnsupportedFeatures(content string) []types.UnsupportedFeature {
+	var features []types.UnsupportedFeature
+	for _, kind := range []struct {
+		label string
+		regex *regexp.Regexp
+	}{{"map field", mapFieldRegex}, {"oneof", oneofRegex}} {
+		for _, loc := range kind.regex.FindAllStringSubmatchIndex(content, -1) {
+			if inLineComment(content, loc[0]) {
+				continue
+			}
+			name := content[loc[2]:loc[3]]
+			if messageName := enclosingMessage(content, loc[0]); messageName != "" {
+				name = messageName + "." + name
+			}
+			features = append(features, types.UnsupportedFeature{
+				Description: kind.label + " " + name,
+				Line:        lineAt(content, loc[0]),
+			})
+		}
+	}
+	sort.SliceStable(features, func(i, j int) bool { return features[i].Line < features[j].Line })
+	return features
+}
+
+// inLineComment reports whether pos is inside a "//" comment
+func inLineComment(content string, pos int) bool {
+	lineStart := strings.LastIndex(content[:pos], "\n") + 1
+	return strings.Contains(content[lineStart:pos], "//")
+}
+
+// checkNestingDepth returns an error naming the first message declared more than
+// maxDepth levels deep. Message bodies are only recursed into at their own brace
+// level, so the brace depth of a declaration is its nesting depth.
+func checkNestingDepth(filePath, content string, maxDepth int) error {
+	depths := braceDepths(content)
+	for _, loc := range messageRegex.FindAllStringSubmatchIndex(content, -1) {
+		if depth := depths[loc[0]] + 1; depth > maxDepth {
+			return fmt.Errorf("%s:%d: message %s is nested %d levels deep, more than the maximum of %d",
+				filePath, lineAt(content, loc[0]), content[loc[2]:loc[3]], depth, maxDepth)
+		}
+	}
+	return nil
+}
+
+// lineAt returns the 1-based line number of byte offset pos in content
+func lineAt(content string, pos int) int {
+	return strings.Count(content[:pos], "\n") + 1
+}
+
+// enclosingMessage returns the name of the innermost message whose body contains pos,
+// or "" if pos is not inside a message
+func enclosingMessage(content string, pos int) string {
+	name := ""
+	for _, loc := range messageRegex.FindAllStringSubmatchIndex(content, -1) {
+		if loc[1] > pos {
+			break
+		}
+		// Find the matching closing brace of this message
+		depth := 1
+		end := loc[1]
+		for end < len(content) && depth > 0 {
+			switch content[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			end++
+		}
+		if end > pos {
+			// Later matches start further in, so the last containing one is innermost
+			name = content[loc[2]:loc[3]]
+		}
+	}
+	return name
+}
+
+// braceDepths returns the brace nesting level at every byte of content. An opening
+// brace has the level of the text before it, a closing brace that of the text after it.
+func braceDepths(content string) []int {
+	depths := make([]int, len(content))
+	level := 0
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '{':
+			depths[i] = level
+			level++
+		case '}':
+			level--
+			depths[i] = level
+		default:
+			depths[i] = level
+		}
+	}
+	return depths
+}
+
+// maskOptionStatements blanks out every option statement of content, from the
+// option keyword to the semicolon ending it, wherever it is declared: file,
+// message, enum, service or rpc level. Aggregate values such as
+//
+//	option (api.route) = { path: "/v1/{id}" retry: { attempts: 3 } };
+//
+// are skipped as a whole, braces and semicolons in string literals included.
+func maskOptionStatements(content string) string {
+	var spans [][2]int
+	for _, loc := range optionRegex.FindAllStringIndex(content, -1) {
+		if inLineComment(content, loc[0]) || len(spans) > 0 && loc[0] < spans[len(spans)-1][1] {
+			continue
+		}
+		if end := optionStatementEnd(content, loc[1]); end > 0 {
+			spans = append(spans, [2]int{loc[0], end})
+		}
+	}
+	return maskSpans(content, spans)
+}
+
+// optionStatementEnd returns the offset just past the ";" that ends the option
+// statement whose value continues at pos, or -1 if the statement is not terminated
+// before its enclosing body closes
+func optionStatementEnd(content string, pos int) int {
+	depth := 0
+	var quote byte // Quote character of the string literal being skipped, or 0
+	for i := pos; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++ // Skip the escaped character
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth == 0 {
+				return -1
+			}
+			depth--
+		case c == ';' && depth == 0:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// maskSpans blanks out the given [start, end) byte ranges of content, keeping
+// newlines so positions and line numbers still line up with the original
+func maskSpans(content string, spans [][2]int) string {
+	masked := []byte(content)
+	for _, span := range spans {
+		for i := span[0]; i < span[1] && i < len(masked); i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+	}
+	return string(masked)
+}
+
 // parseMessages handles parsing of messages with brace-aware nesting
 func parseMessages(content string, protoFile *types.ProtoFile) error {
 	// Find all message declarations
@@ -96,20 +412,7 @@ func parseMessages(content string, protoFile *types.ProtoFile) error {
 	messageNames := messageRegex.FindAllStringSubmatch(content, -1)
 
 	// Track brace nesting level to identify top-level messages only
-	// Build a map of character position to nesting level
-	braceLevel := make([]int, len(content))
-	currentLevel := 0
-	for i := 0; i < len(content); i++ {
-		if content[i] == '{' {
-			braceLevel[i] = currentLevel
-			currentLevel++
-		} else if content[i] == '}' {
-			currentLevel--
-			braceLevel[i] = currentLevel
-		} else {
-			braceLevel[i] = currentLevel
-		}
-	}
+	braceLevel := braceDepths(content)
 
 	for i, match := range messageStarts {
 		if i >= len(messageNames) {
@@ -151,10 +454,11 @@ func parseMessages(content string, protoFile *types.ProtoFile) error {
 		messageBody := content[startPos:endPos]
 
 		// Parse the message
-		message, err := parseMessage(messageName, messageBody)
+		message, err := parseMessage(messageName, messageBody, lineAt(content, startPos))
 		if err != nil {
 			return fmt.Errorf("failed to parse message %s: %w", messageName, err)
 		}
+		message.Line = lineAt(content, messageStartPos)
 
 		protoFile.Messages[messageName] = message
 	}
@@ -162,23 +466,43 @@ func parseMessages(content string, protoFile *types.ProtoFile) error {
 	return nil
 }
 
-// parseMessage parses a single message body
-func parseMessage(messageName, messageBody string) (*types.ProtoMessage, error) {
+// streamKeywordRegex matches the "stream" keyword in front of an rpc argument type
+var streamKeywordRegex = regexp.MustCompile(`^stream\s+`)
+
+// vbTypeNameRegex matches the VB type names accepted by "// type:" overrides,
+// e.g. Decimal, System.Guid or Byte()
+var vbTypeNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*(\(\))?$`)
+
+// parseMessage parses a single message body. bodyLine is the source line on which
+// messageBody starts, used to record declaration lines.
+func parseMessage(messageName, messageBody string, bodyLine int) (*types.ProtoMessage, error) {
 	message := &types.ProtoMessage{
 		Name:           messageName,
 		NestedMessages: make(map[string]*types.ProtoMessage),
 		NestedEnums:    make(map[string]*types.ProtoEnum),
 	}
 	
+	// Only declarations directly in this body belong to this message; deeper ones are
+	// picked up by the recursive parse of the nested message that declares them.
+	// Their spans are masked out before scanning for this message's own fields.
+	depths := braceDepths(messageBody)
+	var nestedSpans [][2]int
+
 	// Parse nested enums
-	nestedEnumMatches := enumRegex.FindAllStringSubmatch(messageBody, -1)
-	for _, match := range nestedEnumMatches {
+	nestedEnumMatches := enumRegex.FindAllStringSubmatchIndex(messageBody, -1)
+	for _, loc := range nestedEnumMatches {
+		if depths[loc[0]] != 0 {
+			continue
+		}
+		nestedSpans = append(nestedSpans, [2]int{loc[0], loc[1]})
+		match := submatches(messageBody, loc)
 		enumName := match[1]
 		enumBodyStr := match[2]
 		
 		protoEnum := &types.ProtoEnum{
 			Name:   enumName,
 			Values: make(map[string]int),
+			Line:   bodyLine + lineAt(messageBody, loc[0]) - 1,
 		}
 		
 		valueMatches := enumValueRegex.FindAllStringSubmatch(enumBodyStr, -1)
@@ -199,7 +523,7 @@ func parseMessage(messageName, messageBody string) (*types.ProtoMessage, error)
 	nestedMessageNames := messageRegex.FindAllStringSubmatch(messageBody, -1)
 	
 	for i, match := range nestedMessageStarts {
-		if i >= len(nestedMessageNames) {
+		if i >= len(nestedMessageNames) || depths[match[0]] != 0 {
 			continue
 		}
 		
@@ -229,19 +553,22 @@ func parseMessage(messageName, messageBody string) (*types.ProtoMessage, error)
 			return nil, fmt.Errorf("unmatched braces in nested message %s", nestedMessageName)
 		}
 		
+		nestedSpans = append(nestedSpans, [2]int{match[0], endPos + 1})
 		nestedMessageBody := messageBody[startPos:endPos]
 		
-		nestedMessage, err := parseMessage(nestedMessageName, nestedMessageBody)
+		nestedMessage, err := parseMessage(nestedMessageName, nestedMessageBody, bodyLine+lineAt(messageBody, startPos)-1)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse nested message %s: %w", nestedMessageName, err)
 		}
+		nestedMessage.Line = bodyLine + lineAt(messageBody, match[0]) - 1
 		
 		message.NestedMessages[nestedMessageName] = nestedMessage
 	}
 	
-	// Parse fields
-	fieldMatches := fieldRegex.FindAllStringSubmatch(messageBody, -1)
-	for _, match := range fieldMatches {
+	// Parse fields, skipping the bodies of nested messages and enums
+	fieldMatches := fieldRegex.FindAllStringSubmatchIndex(maskSpans(messageBody, nestedSpans), -1)
+	for _, loc := range fieldMatches {
+		match := submatches(messageBody, loc)
 		repeated := strings.TrimSpace(match[1]) == "repeated"
 		fieldType := strings.TrimSpace(match[2])
 		fieldName := strings.TrimSpace(match[3])
@@ -255,7 +582,39 @@ func parseMessage(messageName, messageBody string) (*types.ProtoMessage, error)
 			Type:     fieldType,
 			Number:   fieldNumber,
 			Repeated: repeated,
+			Line:     bodyLine + lineAt(messageBody, loc[0]) - 1,
+		}
+
+		// [json_name = "xyz"] replaces the default JSON key of the field
+		if opt := jsonNameRegex.FindStringSubmatch(match[5]); opt != nil {
+			if opt[1] == "" {
+				return nil, fmt.Errorf("field %s.%s: json_name must not be empty", messageName, fieldName)
+			}
+			field.JSONName = opt[1]
+		}
+
+		// proto2 [default = 30] sets the value of an unset field
+		if opt := defaultRegex.FindStringSubmatch(match[5]); opt != nil {
+			value, err := parseDefaultValue(field, opt[1])
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %w", messageName, fieldName, err)
+			}
+			field.Default, field.HasDefault = value, true
 		}
+
+		// "// type: Decimal" above the field overrides the generated VB type
+		override, err := fieldTypeOverride(messageName, fieldName, leadingAnnotations(messageBody, loc[0]))
+		if err != nil {
+			return nil, err
+		}
+		field.TypeOverride = override
+
+		// "// validate: range(0, 150)" or [(validate.rules).int32 = {gte: 0, lte: 150}]
+		validation, err := fieldValidation(messageName, field, match[5], leadingAnnotations(messageBody, loc[0]))
+		if err != nil {
+			return nil, err
+		}
+		field.Validation = validation
 		
 		message.Fields = append(message.Fields, field)
 	}
@@ -304,27 +663,46 @@ func parseServices(content string, protoFile *types.ProtoFile) error {
 
 		// Parse the service
 		service := &types.ProtoService{
-			Name: serviceName,
+			Name:    serviceName,
+			Package: protoFile.Package,
+			Line:    lineAt(content, match[0]),
+		}
+
+		// "// default-version: v2" above the service versions RPCs without a V<n> suffix
+		defaultVersion, err := serviceDefaultVersion(serviceName, leadingAnnotations(content, match[0]))
+		if err != nil {
+			return err
 		}
+		service.DefaultVersion = defaultVersion
 
 		// Parse RPCs within the service
-		rpcMatches := rpcRegex.FindAllStringSubmatch(serviceBody, -1)
-		for _, rpcMatch := range rpcMatches {
-			rpcName := rpcMatch[1]
-			inputType := strings.TrimSpace(rpcMatch[2])
-			outputType := strings.TrimSpace(rpcMatch[3])
-
-			// Skip streaming RPCs (contains 'stream' keyword)
-			if strings.Contains(inputType, "stream") || strings.Contains(outputType, "stream") {
-				continue
-			}
+		rpcMatches := rpcRegex.FindAllStringSubmatchIndex(serviceBody, -1)
+		for _, loc := range rpcMatches {
+			rpcName := serviceBody[loc[2]:loc[3]]
+			inputType := strings.TrimSpace(serviceBody[loc[4]:loc[5]])
+			outputType := strings.TrimSpace(serviceBody[loc[6]:loc[7]])
+
+			// Streaming RPCs are kept with IsUnary=false so callers can report them;
+			// generators only emit unary RPCs
+			inputType, clientStreaming := trimStreamKeyword(inputType)
+			outputType, serverStreaming := trimStreamKeyword(outputType)
 
 			rpc := &types.ProtoRPC{
-				Name:       rpcName,
-				InputType:  inputType,
-				OutputType: outputType,
-				IsUnary:    true,
+				Name:            rpcName,
+				InputType:       inputType,
+				OutputType:      outputType,
+				IsUnary:         !clientStreaming && !serverStreaming,
+				ClientStreaming: clientStreaming,
+				ServerStreaming: serverStreaming,
+				Line:            lineAt(content, startPos+loc[0]),
+			}
+
+			// Comment annotations directly above the rpc (e.g. "// http-method: GET")
+			httpMethod, err := rpcHTTPMethod(serviceName, rpcName, leadingAnnotations(serviceBody, loc[0]))
+			if err != nil {
+				return err
 			}
+			rpc.HTTPMethod = httpMethod
 
 			service.RPCs = append(service.RPCs, rpc)
 		}
@@ -333,4 +711,325 @@ func parseServices(content string, protoFile *types.ProtoFile) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// parseDefaultValue validates the literal of a [default = ...] option against the
+// field type and returns it in the form stored in ProtoField.Default: strings are
+// unquoted and integers converted to decimal (proto also accepts hex and octal).
+// Floats keep their literal, including inf, -inf and nan; enum values are taken as
+// written, since the enum may be declared in another file.
+func parseDefaultValue(field *types.ProtoField, literal string) (string, error) {
+	if field.Repeated {
+		return "", fmt.Errorf("repeated fields cannot have a default value")
+	}
+	switch field.Type {
+	case "string", "bytes":
+		value, err := unquoteProtoString(literal)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s default %s", field.Type, literal)
+		}
+		return value, nil
+	case "bool":
+		if literal != "true" && literal != "false" {
+			return "", fmt.Errorf("invalid bool default %s", literal)
+		}
+		return literal, nil
+	case "int32", "sint32", "sfixed32", "int64", "sint64", "sfixed64":
+		bits := 64
+		if strings.HasSuffix(field.Type, "32") {
+			bits = 32
+		}
+		v, err := strconv.ParseInt(literal, 0, bits)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s default %s", field.Type, literal)
+		}
+		return strconv.FormatInt(v, 10), nil
+	case "uint32", "fixed32", "uint64", "fixed64":
+		bits := 64
+		if strings.HasSuffix(field.Type, "32") {
+			bits = 32
+		}
+		v, err := strconv.ParseUint(literal, 0, bits)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s default %s", field.Type, literal)
+		}
+		return strconv.FormatUint(v, 10), nil
+	case "float", "double":
+		switch literal {
+		case "inf", "-inf", "nan":
+			return literal, nil
+		}
+		if _, err := strconv.ParseFloat(literal, 64); err != nil {
+			return "", fmt.Errorf("invalid %s default %s", field.Type, literal)
+		}
+		return literal, nil
+	}
+	// Any other type must be an enum, whose default is one of its value names
+	if !identRegex.MatchString(literal) {
+		return "", fmt.Errorf("invalid enum default %s", literal)
+	}
+	return literal, nil
+}
+
+// unquoteProtoString decodes a single- or double-quoted proto string literal
+func unquoteProtoString(literal string) (string, error) {
+	if len(literal) < 2 || (literal[0] != '"' && literal[0] != '\'') || literal[len(literal)-1] != literal[0] {
+		return "", fmt.Errorf("not a string literal")
+	}
+	// Go's escapes match proto's, except that \' is only valid in Go rune literals
+	inner := strings.ReplaceAll(literal[1:len(literal)-1], `\'`, `'`)
+	if literal[0] == '\'' {
+		inner = strings.ReplaceAll(strings.ReplaceAll(inner, `\"`, `"`), `"`, `\"`)
+	}
+	return strconv.Unquote(`"` + inner + `"`)
+}
+
+// fieldTypeOverride returns the VB type of a "// type:" annotation on a field,
+// or "" when there is none
+func fieldTypeOverride(messageName, fieldName string, annotations map[string]string) (string, error) {
+	override, ok := annotations["type"]
+	if !ok {
+		return "", nil
+	}
+	if !vbTypeNameRegex.MatchString(override) {
+		return "", fmt.Errorf("field %s.%s: invalid type override %q", messageName, fieldName, override)
+	}
+	return override, nil
+}
+
+// fieldValidation returns the validation rules of a field from its "// validate:"
+// annotation and the protoc-gen-validate rules among its options, or nil when it
+// has none. PGV rules the generators cannot express are ignored.
+//
+// Annotation rules, separated by spaces or commas:
+//
+//	range(min, max)  numeric fields; either bound may be left empty
+//	required         string fields must not be empty
+//	defined          enum fields must hold a declared value
+func fieldValidation(messageName string, field *types.ProtoField, options string, annotations map[string]string) (*types.FieldValidation, error) {
+	rules := &types.FieldValidation{}
+	applyPGVRules(rules, options)
+	if raw, ok := annotations["validate"]; ok {
+		if err := parseValidateAnnotation(rules, raw); err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", messageName, field.Name, err)
+		}
+	}
+	if *rules == (types.FieldValidation{}) {
+		return nil, nil
+	}
+
+	var err error
+	switch {
+	case field.Repeated:
+		err = fmt.Errorf("validation rules are not supported on repeated fields")
+	case rules.HasRange() && !types.NumericTypes[field.Type]:
+		err = fmt.Errorf("range needs a numeric field, not %s", field.Type)
+	case rules.Required && field.Type != "string":
+		err = fmt.Errorf("required needs a string field, not %s", field.Type)
+	case rules.DefinedOnly && types.VBTypeMappings[field.Type] != "":
+		err = fmt.Errorf("defined needs an enum field, not %s", field.Type)
+	case rules.HasRange():
+		err = normalizeRangeBounds(field.Type, rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("field %s.%s: %w", messageName, field.Name, err)
+	}
+	return rules, nil
+}
+
+// parseValidateAnnotation adds the rules of a "// validate:" annotation to rules
+func parseValidateAnnotation(rules *types.FieldValidation, raw string) error {
+	rest := raw
+	for strings.Trim(rest, " \t,") != "" {
+		loc := validateRegex.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			return fmt.Errorf("invalid validate annotation %q", raw)
+		}
+		match := submatches(rest, loc)
+		hasArgs := loc[4] >= 0
+		switch name := strings.ToLower(match[1]); {
+		case name == "range" && hasArgs:
+			lower, upper, found := strings.Cut(match[2], ",")
+			rules.Min, rules.Max = strings.TrimSpace(lower), strings.TrimSpace(upper)
+			if !found || !rules.HasRange() {
+				return fmt.Errorf("invalid validate rule %q (expected range(min, max))", strings.Trim(rest[:loc[1]], " \t,"))
+			}
+		case name == "required" && !hasArgs:
+			rules.Required = true
+		case name == "defined" && !hasArgs:
+			rules.DefinedOnly = true
+		default:
+			return fmt.Errorf("unsupported validate rule %q (expected range(min, max), required or defined)", strings.Trim(rest[:loc[1]], " \t,"))
+		}
+		rest = rest[loc[1]:]
+	}
+	return nil
+}
+
+// applyPGVRules adds the protoc-gen-validate rules of a field's options that the
+// generators support: gte/lte of numeric types, a positive string min_len (which
+// implies required) and enum defined_only. Both the aggregate form
+// "(validate.rules).int32 = {gte: 0}" and the dotted "(validate.rules).int32.gte = 0"
+// are accepted.
+func applyPGVRules(rules *types.FieldValidation, options string) {
+	for _, match := range pgvRuleRegex.FindAllStringSubmatch(options, -1) {
+		kind, pairs := match[1], map[string]string{}
+		if match[2] != "" {
+			pairs[match[2]] = match[3]
+		} else {
+			for _, pair := range pgvPairRegex.FindAllStringSubmatch(match[3], -1) {
+				pairs[pair[1]] = pair[2]
+			}
+		}
+		switch {
+		case types.NumericTypes[kind]:
+			if value, ok := pairs["gte"]; ok {
+				rules.Min = value
+			}
+			if value, ok := pairs["lte"]; ok {
+				rules.Max = value
+			}
+		case kind == "string":
+			if n, err := strconv.Atoi(pairs["min_len"]); err == nil && n > 0 {
+				rules.Required = true
+			}
+		case kind == "enum":
+			rules.DefinedOnly = pairs["defined_only"] == "true"
+		}
+	}
+}
+
+// normalizeRangeBounds checks that the bounds of rules are numbers of fieldType
+// (integers for integer fields) with Min <= Max, and rewrites them in canonical
+// decimal form so the generators can emit them as literals unchanged
+func normalizeRangeBounds(fieldType string, rules *types.FieldValidation) error {
+	var values []float64
+	for _, bound := range []*string{&rules.Min, &rules.Max} {
+		if *bound == "" {
+			continue
+		}
+		if fieldType == "float" || fieldType == "double" {
+			v, err := strconv.ParseFloat(*bound, 64)
+			if err != nil {
+				return fmt.Errorf("invalid range bound %q for %s", *bound, fieldType)
+			}
+			*bound = strconv.FormatFloat(v, 'g', -1, 64)
+			values = append(values, v)
+			continue
+		}
+		n, err := strconv.ParseInt(*bound, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid range bound %q for %s", *bound, fieldType)
+		}
+		*bound = strconv.FormatInt(n, 10)
+		values = append(values, float64(n))
+	}
+	if len(values) == 2 && values[0] > values[1] {
+		return fmt.Errorf("range minimum %s is greater than maximum %s", rules.Min, rules.Max)
+	}
+	return nil
+}
+
+// serviceDefaultVersion returns the normalized "// default-version:" annotation of
+// a service, or "" when there is none
+func serviceDefaultVersion(serviceName string, annotations map[string]string) (string, error) {
+	raw, ok := annotations["default-version"]
+	if !ok {
+		return "", nil
+	}
+	version, valid := types.NormalizeVersion(raw)
+	if !valid {
+		return "", fmt.Errorf("service %s: invalid default-version %q (expected v<n>, e.g. v2)", serviceName, raw)
+	}
+	return version, nil
+}
+
+// rpcHTTPMethod returns "GET" for an rpc annotated with "// http-method: GET" and
+// "" (POST) otherwise
+func rpcHTTPMethod(serviceName, rpcName string, annotations map[string]string) (string, error) {
+	method, ok := annotations["http-method"]
+	if !ok {
+		return "", nil
+	}
+	method = strings.ToUpper(method)
+	if method != "GET" && method != "POST" {
+		return "", fmt.Errorf("rpc %s.%s: unsupported http-method %q (expected GET or POST)", serviceName, rpcName, method)
+	}
+	if method == "GET" {
+		return method, nil
+	}
+	return "", nil
+}
+
+// trimStreamKeyword strips a leading "stream" keyword from an rpc argument type,
+// reporting whether it was present
+func trimStreamKeyword(argType string) (string, bool) {
+	if loc := streamKeywordRegex.FindStringIndex(argType); loc != nil {
+		return argType[loc[1]:], true
+	}
+	return argType, false
+}
+
+// submatches returns the submatch strings for a FindAllStringSubmatchIndex location,
+// with "" for groups that did not participate in the match
+func submatches(content string, loc []int) []string {
+	match := make([]string, len(loc)/2)
+	for i := range match {
+		if loc[2*i] >= 0 {
+			match[i] = content[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return match
+}
+
+// leadingAnnotations collects "key: value" annotations from the contiguous block of
+// "//" comment lines that directly precedes pos in content. Keys are lower-cased;
+// comment lines that are not annotations are ignored.
+//
+// Example:
+//
+//	// Lists users without a request body
+//	// http-method: GET
+//	rpc ListUsers(ListUsersRequest) returns (ListUsersResponse);
+func leadingAnnotations(content string, pos int) map[string]string {
+	annotations := make(map[string]string)
+
+	// Walk backwards line by line starting from the line above pos
+	lineStart := strings.LastIndex(content[:pos], "\n")
+	for lineStart > 0 {
+		prevStart := strings.LastIndex(content[:lineStart], "\n") + 1
+		line := strings.TrimSpace(content[prevStart:lineStart])
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		if key, value, ok := parseAnnotation(strings.TrimPrefix(line, "//")); ok {
+			// Lines closer to the declaration win over earlier duplicates
+			if _, exists := annotations[key]; !exists {
+				annotations[key] = value
+			}
+		}
+		lineStart = prevStart - 1
+	}
+
+	return annotations
+}
+
+// parseAnnotation parses a single "key: value" comment annotation.
+// Keys may contain letters, digits, and dashes; anything else is treated as prose.
+func parseAnnotation(comment string) (key, value string, ok bool) {
+	key, value, found := strings.Cut(strings.TrimSpace(comment), ":")
+	if !found {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if key == "" || value == "" {
+		return "", "", false
+	}
+	for _, r := range key {
+		if !(r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", "", false
+		}
+	}
+	return strings.ToLower(key), value, true
+}
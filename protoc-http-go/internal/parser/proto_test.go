@@ -0,0 +1,848 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+func writeProto(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.proto")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestParseHTTPMethodAnnotation(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+message Req { string name = 1; }
+message Resp { string message = 1; }
+
+service Demo {
+  // Looks up a greeting without a request body
+  // http-method: get
+  rpc Lookup(Req) returns (Resp);
+
+  // Explicit POST is the default
+  // http-method: POST
+  rpc Create(Req) returns (Resp);
+
+  rpc Update(Req) returns (Resp);
+}
+`)
+
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	if len(protoFile.Services) != 1 || len(protoFile.Services[0].RPCs) != 3 {
+		t.Fatalf("expected 1 service with 3 RPCs, got %+v", protoFile.Services)
+	}
+
+	want := map[string]bool{"Lookup": true, "Create": false, "Update": false}
+	for _, rpc := range protoFile.Services[0].RPCs {
+		if rpc.IsGet() != want[rpc.Name] {
+			t.Errorf("rpc %s: IsGet() = %v, want %v", rpc.Name, rpc.IsGet(), want[rpc.Name])
+		}
+	}
+}
+
+func TestParseHTTPMethodAnnotationRejectsUnknownMethod(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+message Req { string name = 1; }
+
+service Demo {
+  // http-method: DELETE
+  rpc Remove(Req) returns (Req);
+}
+`)
+
+	_, err := ParseProtoFile(path)
+	if err == nil || !strings.Contains(err.Error(), `unsupported http-method "DELETE"`) {
+		t.Fatalf("expected unsupported http-method error, got %v", err)
+	}
+}
+
+func TestParseAnnotation(t *testing.T) {
+	tests := []struct {
+		comment string
+		key     string
+		value   string
+		ok      bool
+	}{
+		{" http-method: GET", "http-method", "GET", true},
+		{"HTTP-Method:post ", "http-method", "post", true},
+		{" Note: this is prose", "note", "this is prose", true},
+		{" Returns the user, e.g. id: 7", "", "", false},
+		{" no annotation here", "", "", false},
+		{" empty-value:", "", "", false},
+	}
+	for _, tt := range tests {
+		key, value, ok := parseAnnotation(tt.comment)
+		if key != tt.key || value != tt.value || ok != tt.ok {
+			t.Errorf("parseAnnotation(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.comment, key, value, ok, tt.key, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestParseRejectsGroups(t *testing.T) {
+	path := writeProto(t, `syntax = "proto2";
+package demo;
+
+message SearchResponse {
+  // A group looks like: repeated group Ignored = 9 { }
+  message Page {
+    repeated group Result = 1 {
+      required string url = 2;
+    }
+  }
+  optional int32 total = 3;
+}
+`)
+
+	_, err := ParseProtoFile(path)
+	if err == nil {
+		t.Fatalf("expected an error for a proto containing a group")
+	}
+	want := path + ":7: groups are not supported (group Result in message Page)"
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestParseReportsGroupsWithOtherFeatures(t *testing.T) {
+	content := `syntax = "proto2";
+package demo;
+
+message SearchResponse {
+  repeated group Result = 1 {
+    required string url = 2;
+  }
+  map<string, int32> counts = 3;
+  optional int32 total = 4;
+}
+`
+	protoFile, err := ParseProtoContent("search.proto", content, Options{ReportGroups: true})
+	if err != nil {
+		t.Fatalf("ParseProtoContent() error = %v", err)
+	}
+	want := []types.UnsupportedFeature{
+		{Description: "group SearchResponse.Result", Line: 5},
+		{Description: "map field SearchResponse.counts", Line: 8},
+	}
+	if !reflect.DeepEqual(protoFile.Unsupported, want) {
+		t.Errorf("Unsupported = %+v, want %+v", protoFile.Unsupported, want)
+	}
+
+	// The group body is skipped: its field does not leak into the message
+	message := protoFile.Messages["SearchResponse"]
+	if message == nil {
+		t.Fatal("message SearchResponse not parsed")
+	}
+	var names []string
+	for _, field := range message.Fields {
+		names = append(names, field.Name)
+		if field.Name == "url" || field.Name == "Result" {
+			t.Errorf("group content parsed as field %s", field.Name)
+		}
+	}
+	if len(names) == 0 || names[len(names)-1] != "total" {
+		t.Errorf("fields = %v, want them to end with total", names)
+	}
+}
+
+func TestParseRejectsServiceNestedInMessage(t *testing.T) {
+	// The closing brace of HelloRequest is missing, so Greeter ends up inside it
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+message HelloRequest {
+  string name = 1;
+  // service Ignored { }
+
+service Greeter {
+  rpc SayHello (HelloRequest) returns (HelloRequest);
+}
+}
+`)
+
+	_, err := ParseProtoFile(path)
+	if err == nil {
+		t.Fatalf("expected an error for a service nested in a message")
+	}
+	want := path + ":8: service Greeter is declared inside message HelloRequest"
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestParseAllowsFieldsNamedGroup(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+message Member {
+  string group = 1;
+  repeated string group_ids = 2;
+}
+`)
+
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	if got := len(protoFile.Messages["Member"].Fields); got != 2 {
+		t.Fatalf("expected 2 fields, got %d", got)
+	}
+}
+
+func TestParseFieldTypeAnnotation(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+message Payment {
+  // Amount in the smallest currency unit
+  // type: Decimal
+  int64 amount = 1;
+  int64 count = 2;
+}
+`)
+
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	fields := protoFile.Messages["Payment"].Fields
+	if fields[0].TypeOverride != "Decimal" {
+		t.Errorf("amount TypeOverride = %q, want Decimal", fields[0].TypeOverride)
+	}
+	if fields[1].TypeOverride != "" {
+		t.Errorf("count TypeOverride = %q, want none", fields[1].TypeOverride)
+	}
+}
+
+func TestParseFieldTypeAnnotationRejectsInvalidType(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+message Payment {
+  // type: Decimal; DROP
+  int64 amount = 1;
+}
+`)
+
+	_, err := ParseProtoFile(path)
+	if err == nil || !strings.Contains(err.Error(), `field Payment.amount: invalid type override "Decimal; DROP"`) {
+		t.Fatalf("expected invalid type override error, got %v", err)
+	}
+}
+
+func TestParseRecordsDeclarationLines(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+enum Color {
+  COLOR_UNSPECIFIED = 0;
+}
+
+message Outer {
+  string name = 1;
+
+  message Inner {
+    int32 size = 1;
+  }
+}
+
+service Painter {
+  rpc Paint(Outer) returns (Outer);
+}
+`)
+
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	outer := protoFile.Messages["Outer"]
+	inner := outer.NestedMessages["Inner"]
+	for _, tc := range []struct {
+		what      string
+		got, want int
+	}{
+		{"enum Color", protoFile.Enums["Color"].Line, 4},
+		{"message Outer", outer.Line, 8},
+		{"field name", outer.Fields[0].Line, 9},
+		{"message Inner", inner.Line, 11},
+		{"field size", inner.Fields[0].Line, 12},
+		{"service Painter", protoFile.Services[0].Line, 16},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: line = %d, want %d", tc.what, tc.got, tc.want)
+		}
+	}
+}
+
+func TestParseKeepsStreamingRPCsAsNonUnary(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+service Feed {
+  rpc Get(UpstreamRequest) returns (Reply);
+  rpc Watch(Request) returns (stream Reply);
+  rpc Upload(stream Chunk) returns (Reply);
+  rpc Chat(stream Message) returns (stream Message);
+}
+`)
+
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	rpcs := protoFile.Services[0].RPCs
+	if len(rpcs) != 4 {
+		t.Fatalf("expected 4 rpcs, got %d", len(rpcs))
+	}
+
+	// A type name containing "stream" is not a streaming RPC
+	if get := rpcs[0]; !get.IsUnary || get.InputType != "UpstreamRequest" {
+		t.Errorf("Get: IsUnary = %v, InputType = %q", get.IsUnary, get.InputType)
+	}
+	for _, tc := range []struct {
+		rpc    int
+		input  string
+		output string
+		kind   string
+		line   int
+	}{
+		{1, "Request", "Reply", "server streaming", 6},
+		{2, "Chunk", "Reply", "client streaming", 7},
+		{3, "Message", "Message", "bidirectional streaming", 8},
+	} {
+		rpc := rpcs[tc.rpc]
+		if rpc.IsUnary || rpc.InputType != tc.input || rpc.OutputType != tc.output || rpc.StreamingKind() != tc.kind || rpc.Line != tc.line {
+			t.Errorf("%s: got IsUnary=%v input=%q output=%q kind=%q line=%d", rpc.Name, rpc.IsUnary, rpc.InputType, rpc.OutputType, rpc.StreamingKind(), rpc.Line)
+		}
+	}
+}
+
+func TestParseRecordsServicePackage(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		pkg      string
+		fullName string
+		path     string
+	}{
+		{"qualified", "package trading.v1;\n", "trading.v1.Quotes", "/trading.v1.Quotes/Get"},
+		{"no package", "", "Quotes", "/Quotes/Get"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeProto(t, `syntax = "proto3";
+`+tc.pkg+`
+service Quotes {
+  rpc Get(QuoteRequest) returns (QuoteReply);
+}
+`)
+			protoFile, err := ParseProtoFile(path)
+			if err != nil {
+				t.Fatalf("ParseProtoFile() error = %v", err)
+			}
+			service := protoFile.Services[0]
+			if service.Package != protoFile.Package {
+				t.Errorf("Package = %q, want %q", service.Package, protoFile.Package)
+			}
+			if got := service.FullName(); got != tc.fullName {
+				t.Errorf("FullName() = %q, want %q", got, tc.fullName)
+			}
+			if got := service.CanonicalPath(service.RPCs[0]); got != tc.path {
+				t.Errorf("CanonicalPath() = %q, want %q", got, tc.path)
+			}
+		})
+	}
+}
+
+func TestParseScopesNestedDeclarations(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package shop;
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+}
+
+message Outer {
+  enum Status {
+    OUTER_STATUS_UNSPECIFIED = 0;
+  }
+  message Inner {
+    enum Mode {
+      MODE_UNSPECIFIED = 0;
+    }
+    message Leaf {
+      string id = 1;
+    }
+    Status inner_status = 1;
+  }
+  Status status = 1;
+  Inner inner = 2;
+  oneof choice {
+    string label = 3;
+    int32 code = 4;
+  }
+}
+`)
+
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+
+	// The file-level Status is not replaced by the nested one
+	if status := protoFile.Enums["Status"]; status == nil || len(protoFile.Enums) != 1 {
+		t.Fatalf("expected only the top-level Status enum, got %v", protoFile.Enums)
+	} else if _, ok := status.Values["STATUS_UNSPECIFIED"]; !ok {
+		t.Errorf("top-level Status has values %v", status.Values)
+	}
+
+	outer := protoFile.Messages["Outer"]
+	var names []string
+	for _, field := range outer.Fields {
+		names = append(names, field.Name)
+	}
+	// Fields of nested messages and enum values stay out; oneof members stay in
+	if got := strings.Join(names, ","); got != "status,inner,label,code" {
+		t.Errorf("Outer fields = %s", got)
+	}
+	if len(outer.NestedEnums) != 1 || outer.NestedEnums["Status"] == nil {
+		t.Errorf("Outer nested enums = %v", outer.NestedEnums)
+	}
+	if len(outer.NestedMessages) != 1 || outer.NestedMessages["Inner"] == nil {
+		t.Fatalf("Outer nested messages = %v", outer.NestedMessages)
+	}
+
+	inner := outer.NestedMessages["Inner"]
+	if len(inner.Fields) != 1 || inner.Fields[0].Name != "inner_status" {
+		t.Errorf("Inner fields = %+v", inner.Fields)
+	}
+	if inner.NestedEnums["Mode"] == nil || inner.NestedMessages["Leaf"] == nil {
+		t.Errorf("Inner should own Mode and Leaf, got %v / %v", inner.NestedEnums, inner.NestedMessages)
+	}
+}
+
+func TestParseServiceDefaultVersionAnnotation(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package quotes;
+
+// Quotes are served from the second API generation.
+// default-version: V2
+service Quotes {
+  rpc Get(QuoteRequest) returns (QuoteReply);
+}
+
+service Plain {
+  rpc Get(QuoteRequest) returns (QuoteReply);
+}
+`)
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	if got := protoFile.Services[0].DefaultVersion; got != "v2" {
+		t.Errorf("Quotes DefaultVersion = %q, want v2", got)
+	}
+	if got := protoFile.Services[1].DefaultVersion; got != "" {
+		t.Errorf("Plain DefaultVersion = %q, want empty", got)
+	}
+}
+
+func TestParseServiceDefaultVersionRejectsInvalidValue(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+
+// default-version: latest
+service Quotes {
+  rpc Get(QuoteRequest) returns (QuoteReply);
+}
+`)
+	_, err := ParseProtoFile(path)
+	if err == nil {
+		t.Fatal("expected an invalid default-version to be rejected")
+	}
+	if !strings.Contains(err.Error(), `invalid default-version "latest"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseFileEnumsAfterServicesSkipNestedEnums(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package shop;
+
+message Order {
+  enum State {
+    STATE_UNSPECIFIED = 0;
+    STATE_OPEN = 1;
+  }
+  State state = 1;
+}
+
+service OrderService {
+  rpc GetOrder(Order) returns (Order) {
+    option deprecated = false;
+  }
+}
+
+enum Priority {
+  PRIORITY_UNSPECIFIED = 0;
+  PRIORITY_HIGH = 1;
+}
+`)
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+
+	if _, ok := protoFile.Enums["State"]; ok {
+		t.Errorf("nested enum State must not be collected at file level: %v", protoFile.Enums)
+	}
+	priority, ok := protoFile.Enums["Priority"]
+	if !ok || len(protoFile.Enums) != 1 {
+		t.Fatalf("expected only the file-level Priority enum, got %v", protoFile.Enums)
+	}
+	if priority.Values["PRIORITY_HIGH"] != 1 || priority.Line != 18 {
+		t.Errorf("Priority = %+v", priority)
+	}
+	if state := protoFile.Messages["Order"].NestedEnums["State"]; state == nil || len(state.Values) != 2 {
+		t.Errorf("expected State to stay nested in Order, got %v", protoFile.Messages["Order"].NestedEnums)
+	}
+}
+
+// nestedProto declares depth messages, each nested in the previous one
+func nestedProto(depth int) string {
+	var sb strings.Builder
+	sb.WriteString("syntax = \"proto3\";\n")
+	for i := 1; i <= depth; i++ {
+		sb.WriteString(fmt.Sprintf("%smessage Level%d {\n", strings.Repeat("  ", i-1), i))
+	}
+	sb.WriteString(strings.Repeat("  ", depth) + "string id = 1;\n")
+	for i := depth; i >= 1; i-- {
+		sb.WriteString(strings.Repeat("  ", i-1) + "}\n")
+	}
+	return sb.String()
+}
+
+func TestParseAcceptsNestingUpToMaxDepth(t *testing.T) {
+	protoFile, err := ParseProtoFile(writeProto(t, nestedProto(DefaultMaxDepth)))
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	message := protoFile.Messages["Level1"]
+	for i := 2; i <= DefaultMaxDepth; i++ {
+		message = message.NestedMessages[fmt.Sprintf("Level%d", i)]
+		if message == nil {
+			t.Fatalf("missing nested message Level%d", i)
+		}
+	}
+	if len(message.Fields) != 1 {
+		t.Fatalf("expected the innermost message to keep its field, got %v", message.Fields)
+	}
+}
+
+func TestParseRejectsNestingBeyondMaxDepth(t *testing.T) {
+	path := writeProto(t, nestedProto(DefaultMaxDepth+1))
+	_, err := ParseProtoFile(path)
+	if err == nil {
+		t.Fatal("expected nesting beyond DefaultMaxDepth to be rejected")
+	}
+	want := fmt.Sprintf("%s:%d: message Level33 is nested 33 levels deep, more than the maximum of 32", path, DefaultMaxDepth+2)
+	if err.Error() != want {
+		t.Fatalf("error = %q, want %q", err, want)
+	}
+
+	if _, err := ParseProtoFileWithOptions(writeProto(t, nestedProto(3)), Options{MaxDepth: 2}); err == nil {
+		t.Fatal("expected Options.MaxDepth to lower the limit")
+	}
+}
+
+func TestParseCommaSeparatedEnumValues(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+
+enum Color {
+  RED = 0, GREEN = 1, BLUE = 2;
+}
+
+message Palette {
+  enum Shade {
+    LIGHT = 0,
+    DARK = 1
+  }
+  Color primary = 1;
+}
+`)
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	color := protoFile.Enums["Color"]
+	if color == nil || len(color.Values) != 3 || color.Values["RED"] != 0 || color.Values["GREEN"] != 1 || color.Values["BLUE"] != 2 {
+		t.Fatalf("Color values = %v", color)
+	}
+	shade := protoFile.Messages["Palette"].NestedEnums["Shade"]
+	if shade == nil || len(shade.Values) != 2 || shade.Values["DARK"] != 1 {
+		t.Fatalf("Shade values = %v", shade)
+	}
+}
+
+func TestParseJSONNameOption(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+message Account {
+  string user_name = 1 [json_name = "login"];
+  repeated string tag_list = 2 [deprecated = true, json_name="tags"];
+  int32 retry_count = 3 [deprecated = true];
+  string plain = 4;
+}
+`)
+
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	fields := protoFile.Messages["Account"].Fields
+	if len(fields) != 4 {
+		t.Fatalf("expected 4 fields including those with options, got %d", len(fields))
+	}
+	want := map[string]string{"user_name": "login", "tag_list": "tags", "retry_count": "", "plain": ""}
+	for _, field := range fields {
+		if field.JSONName != want[field.Name] {
+			t.Errorf("field %s: JSONName = %q, want %q", field.Name, field.JSONName, want[field.Name])
+		}
+	}
+	if !fields[1].Repeated || fields[1].Number != 2 {
+		t.Errorf("options must not disturb the rest of the field: %+v", fields[1])
+	}
+}
+
+func TestParseJSONNameOptionRejectsEmptyName(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+message Account {
+  string user_name = 1 [json_name = ""];
+}
+`)
+	if _, err := ParseProtoFile(path); err == nil || !strings.Contains(err.Error(), "Account.user_name") {
+		t.Fatalf("expected an error naming the field, got %v", err)
+	}
+}
+
+func TestParseRecordsUnsupportedFeatures(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package demo;
+
+message Order {
+  map<string, int32> quantities = 1;
+  oneof payment {
+    string card = 2;
+    string voucher = 3;
+  }
+  // map<string, string> ignored = 4;
+  message Line {
+    map<string, string> attributes = 1;
+  }
+}
+`)
+
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	want := []string{"5: map field Order.quantities", "6: oneof Order.payment", "12: map field Line.attributes"}
+	var got []string
+	for _, feature := range protoFile.Unsupported {
+		got = append(got, fmt.Sprintf("%d: %s", feature.Line, feature.Description))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Unsupported = %q, want %q", got, want)
+	}
+}
+
+func TestParseDefaultOption(t *testing.T) {
+	path := writeProto(t, `syntax = "proto2";
+package demo;
+
+message Settings {
+  optional int32 timeout = 1 [default = -0x1F];
+  optional string greeting = 2 [default = 'it\'s "on"'];
+  optional string empty = 3 [default = ""];
+  optional bool enabled = 4 [json_name = "on", default = false];
+  optional Mode mode = 5 [default=SAFE];
+  optional double limit = 6 [default = -inf];
+  optional string name = 7;
+}
+`)
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	want := map[string]string{
+		"timeout":  "-31",
+		"greeting": `it's "on"`,
+		"empty":    "",
+		"enabled":  "false",
+		"mode":     "SAFE",
+		"limit":    "-inf",
+	}
+	for _, field := range protoFile.Messages["Settings"].Fields {
+		value, ok := want[field.Name]
+		if field.HasDefault != ok || field.Default != value {
+			t.Errorf("field %s default = %q (set %v), want %q (set %v)", field.Name, field.Default, field.HasDefault, value, ok)
+		}
+	}
+}
+
+func TestParseDefaultOptionRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"optional int32 timeout = 1 [default = 3000000000];", "Settings.timeout: invalid int32 default 3000000000"},
+		{"optional bool enabled = 1 [default = yes];", "Settings.enabled: invalid bool default yes"},
+		{"optional uint32 retries = 1 [default = -1];", "Settings.retries: invalid uint32 default -1"},
+		{"repeated int32 codes = 1 [default = 1];", "Settings.codes: repeated fields cannot have a default value"},
+	}
+	for _, tt := range tests {
+		path := writeProto(t, "syntax = \"proto2\";\nmessage Settings {\n  "+tt.field+"\n}\n")
+		if _, err := ParseProtoFile(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.field, tt.want, err)
+		}
+	}
+}
+
+func TestParseSkipsOptionStatementsWithBraces(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package shop;
+option (shop.file_meta) = { owner: "team-{orders}" };
+
+message Order {
+  option (shop.table) = {
+    name: "orders"
+    index: { columns: "id" unique: true };
+  };
+  string id = 1;
+  string option = 2;
+  enum State {
+    option allow_alias = true;
+    STATE_UNSPECIFIED = 0;
+    STATE_OPEN = 1;
+  }
+}
+
+service OrderService {
+  option deprecated = true;
+  option (shop.routing) = { prefix: "/orders/{tenant}" note: "a } brace; and \"quotes\"" };
+
+  rpc GetOrder(Order) returns (Order) {
+    option (google.api.http) = { get: "/v1/orders/{id}" };
+  }
+  // http-method: GET
+  rpc ListOrders(Order) returns (Order);
+}
+`)
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+
+	order := protoFile.Messages["Order"]
+	if order == nil || len(order.Fields) != 2 || order.Fields[0].Name != "id" || order.Fields[1].Name != "option" || order.Fields[1].Line != 11 {
+		t.Fatalf("expected Order fields id and option, got %+v", order)
+	}
+	if state := order.NestedEnums["State"]; state == nil || len(state.Values) != 2 {
+		t.Errorf("expected State with 2 values, got %+v", order.NestedEnums)
+	}
+
+	if len(protoFile.Services) != 1 {
+		t.Fatalf("expected 1 service, got %d", len(protoFile.Services))
+	}
+	rpcs := protoFile.Services[0].RPCs
+	if len(rpcs) != 2 || rpcs[0].Name != "GetOrder" || rpcs[1].Name != "ListOrders" {
+		t.Fatalf("expected RPCs GetOrder and ListOrders, got %+v", rpcs)
+	}
+	if rpcs[1].HTTPMethod != "GET" || rpcs[1].Line != 27 {
+		t.Errorf("ListOrders = %+v, want its annotation and line kept", rpcs[1])
+	}
+}
+
+func TestParseValidationRules(t *testing.T) {
+	path := writeProto(t, `syntax = "proto3";
+package people;
+
+message Person {
+  enum Status { STATUS_UNSPECIFIED = 0; ACTIVE = 1; }
+  // validate: range(0, 150)
+  int32 age = 1;
+  // Display name
+  // validate: required
+  string name = 2;
+  // validate: defined
+  Status status = 3;
+  double score = 4 [(validate.rules).double = {gte: 0.5, lte: 1e2}];
+  uint32 rank = 5 [(validate.rules).uint32.lte = 10];
+  string email = 6 [(validate.rules).string = {min_len: 1, email: true}];
+  Status level = 7 [(validate.rules).enum.defined_only = true];
+  string note = 8 [(validate.rules).string.max_len = 20];
+}
+`)
+	protoFile, err := ParseProtoFile(path)
+	if err != nil {
+		t.Fatalf("ParseProtoFile() error = %v", err)
+	}
+	want := map[string]types.FieldValidation{
+		"age":    {Min: "0", Max: "150"},
+		"name":   {Required: true},
+		"status": {DefinedOnly: true},
+		"score":  {Min: "0.5", Max: "100"},
+		"rank":   {Max: "10"},
+		"email":  {Required: true},
+		"level":  {DefinedOnly: true},
+	}
+	for _, field := range protoFile.Messages["Person"].Fields {
+		rules, ok := want[field.Name]
+		if !ok {
+			if field.Validation != nil {
+				t.Errorf("field %s: expected no validation, got %+v", field.Name, *field.Validation)
+			}
+			continue
+		}
+		if field.Validation == nil || *field.Validation != rules {
+			t.Errorf("field %s validation = %+v, want %+v", field.Name, field.Validation, rules)
+		}
+	}
+}
+
+func TestParseValidationRulesRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"// validate: range(0)\n  int32 age = 1;", `Person.age: invalid validate rule "range(0)"`},
+		{"// validate: range(150, 0)\n  int32 age = 1;", "Person.age: range minimum 150 is greater than maximum 0"},
+		{"// validate: range(0, 1.5)\n  int32 age = 1;", `Person.age: invalid range bound "1.5" for int32`},
+		{"// validate: range(0, 10)\n  string name = 1;", "Person.name: range needs a numeric field, not string"},
+		{"// validate: required\n  int32 age = 1;", "Person.age: required needs a string field, not int32"},
+		{"// validate: defined\n  string name = 1;", "Person.name: defined needs an enum field, not string"},
+		{"// validate: required\n  repeated string tags = 1;", "Person.tags: validation rules are not supported on repeated fields"},
+		{"// validate: email\n  string name = 1;", `Person.name: unsupported validate rule "email"`},
+	}
+	for _, tt := range tests {
+		path := writeProto(t, "syntax = \"proto3\";\nmessage Person {\n  "+tt.field+"\n}\n")
+		if _, err := ParseProtoFile(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.field, tt.want, err)
+		}
+	}
+}
@@ -1,13 +1,37 @@
 package types
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
 
 // ProtoField represents a field in a protobuf message
 type ProtoField struct {
-	Name     string
-	Type     string
-	Number   int
-	Repeated bool
+	Name         string
+	Type         string
+	Number       int
+	Repeated     bool
+	TypeOverride string           // VB type from a "// type: X" annotation or --type-map; "" uses VBTypeMappings
+	JSONName     string           // Explicit [json_name = "..."] option; "" uses the default JSON name
+	Default      string           // proto2 [default = ...] option: unquoted for strings, decimal for integers, the value name for enums
+	HasDefault   bool             // Whether Default is set; an empty string is a valid string default
+	Validation   *FieldValidation // Rules from a "// validate:" annotation or (validate.rules) options; nil when none
+	Line         int              // 1-based line of the declaration in the source file
+}
+
+// FieldValidation holds the client-side validation rules of a field. The
+// generators turn them into DataAnnotations attributes and JSON Schema keywords.
+type FieldValidation struct {
+	Min         string // Inclusive lower bound of a numeric field as written, e.g. "0"; "" when unbounded
+	Max         string // Inclusive upper bound of a numeric field as written, e.g. "150"; "" when unbounded
+	Required    bool   // A string field must not be empty
+	DefinedOnly bool   // An enum field must hold one of the declared values
+}
+
+// HasRange reports whether the rules bound the value of a numeric field
+func (v *FieldValidation) HasRange() bool {
+	return v.Min != "" || v.Max != ""
 }
 
 // ProtoMessage represents a protobuf message definition
@@ -17,26 +41,122 @@ type ProtoMessage struct {
 	NestedMessages map[string]*ProtoMessage
 	NestedEnums    map[string]*ProtoEnum
 	ParentName     string // Parent message name for nested messages (used for msgHdr detection)
+	Line           int    // 1-based line of the declaration in the source file
 }
 
 // ProtoEnum represents a protobuf enum definition
 type ProtoEnum struct {
 	Name   string
 	Values map[string]int
+	Line   int // 1-based line of the declaration in the source file
 }
 
 // ProtoRPC represents a single RPC method in a service
 type ProtoRPC struct {
-	Name       string
-	InputType  string
-	OutputType string
-	IsUnary    bool // Only unary RPCs are supported
+	Name            string
+	InputType       string // Request message type, without the "stream" keyword
+	OutputType      string // Response message type, without the "stream" keyword
+	IsUnary         bool   // Only unary RPCs are supported; generators skip the rest
+	ClientStreaming bool   // Request is declared as "stream T"
+	ServerStreaming bool   // Response is declared as "stream T"
+	HTTPMethod      string // "GET" when annotated with "// http-method: GET", otherwise "" (POST)
+	Line            int    // 1-based line of the declaration in the source file
+}
+
+// IsGet reports whether the RPC is exposed as an HTTP GET with query-string parameters
+func (r *ProtoRPC) IsGet() bool {
+	return strings.EqualFold(r.HTTPMethod, "GET")
+}
+
+// StreamingKind describes a non-unary RPC as "client streaming", "server streaming"
+// or "bidirectional streaming"; it returns "" for unary RPCs
+func (r *ProtoRPC) StreamingKind() string {
+	switch {
+	case r.ClientStreaming && r.ServerStreaming:
+		return "bidirectional streaming"
+	case r.ClientStreaming:
+		return "client streaming"
+	case r.ServerStreaming:
+		return "server streaming"
+	}
+	return ""
+}
+
+// StreamingRPCs returns the "Service.Method (kind)" names of every streaming RPC in the
+// file, in declaration order. Generators skip these RPCs.
+func StreamingRPCs(protoFile *ProtoFile) []string {
+	var names []string
+	for _, service := range protoFile.Services {
+		for _, rpc := range service.RPCs {
+			if !rpc.IsUnary {
+				names = append(names, fmt.Sprintf("%s.%s (%s)", service.Name, rpc.Name, rpc.StreamingKind()))
+			}
+		}
+	}
+	return names
 }
 
 // ProtoService represents a protobuf service definition
 type ProtoService struct {
-	Name string
-	RPCs []*ProtoRPC
+	Name           string
+	Package        string // Proto package of the declaring file, empty if none
+	DefaultVersion string // URL version for RPCs without a V<n> suffix ("// default-version:"); empty means v1
+	RPCs           []*ProtoRPC
+	Line           int // 1-based line of the declaration in the source file
+}
+
+// RPCNameAndVersion splits an RPC name like ParseRPCNameAndVersion, except that names
+// without a V<n> suffix get the service's DefaultVersion instead of v1
+func (s *ProtoService) RPCNameAndVersion(rpc *ProtoRPC) (baseName string, version string) {
+	if base, version, ok := splitVersionSuffix(rpc.Name); ok {
+		return base, version
+	}
+	if s.DefaultVersion != "" {
+		return rpc.Name, s.DefaultVersion
+	}
+	return rpc.Name, "v1"
+}
+
+// NormalizeVersion validates a URL version such as "v2" (or "V2") and returns it in
+// lower case, as used in routes
+func NormalizeVersion(version string) (string, bool) {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if len(version) < 2 || version[0] != 'v' {
+		return "", false
+	}
+	for _, c := range version[1:] {
+		if c < '0' || c > '9' {
+			return "", false
+		}
+	}
+	return version, true
+}
+
+// ApplyDefaultVersion sets DefaultVersion on every service that has none, so a
+// --default-version flag fills in for services without an annotation
+func ApplyDefaultVersion(protoFile *ProtoFile, version string) {
+	if version == "" {
+		return
+	}
+	for _, service := range protoFile.Services {
+		if service.DefaultVersion == "" {
+			service.DefaultVersion = version
+		}
+	}
+}
+
+// FullName returns the package-qualified service name, e.g. "trading.v1.Quotes"
+func (s *ProtoService) FullName() string {
+	if s.Package == "" {
+		return s.Name
+	}
+	return s.Package + "." + s.Name
+}
+
+// CanonicalPath returns the gRPC path of an RPC on this service, e.g.
+// "/trading.v1.Quotes/Get", as used by gRPC and gRPC-Web clients
+func (s *ProtoService) CanonicalPath(rpc *ProtoRPC) string {
+	return "/" + s.FullName() + "/" + rpc.Name
 }
 
 // ProtoFile represents a complete parsed .proto file
@@ -48,41 +168,124 @@ type ProtoFile struct {
 	Messages               map[string]*ProtoMessage
 	Enums                  map[string]*ProtoEnum
 	Services               []*ProtoService
-	UseSharedUtility       bool   // Whether to use shared HTTP utility
-	SharedUtilityName      string // Name of shared HTTP utility class
-	SharedUtilityNamespace string // Namespace containing the shared HTTP utility class
+	UseSharedUtility       bool                 // Whether to use shared HTTP utility
+	SharedUtilityName      string               // Name of shared HTTP utility class
+	SharedUtilityNamespace string               // Namespace containing the shared HTTP utility class
+	Symbols                *SymbolTable         // Types of all files parsed together, set by BuildSymbolTable; nil resolves within this file only
+	Unsupported            []UnsupportedFeature // Constructs the generators cannot represent, in source order
+}
+
+// UnsupportedFeature is a construct the parser recognized but the generators do
+// not support, such as a map field or a oneof
+type UnsupportedFeature struct {
+	Description string // e.g. "map field Order.tags"
+	Line        int    // 1-based line of the construct in the source file
 }
 
 // ProtoHasBytesField reports whether any top-level or nested message contains a bytes field.
 func ProtoHasBytesField(protoFile *ProtoFile) bool {
+	return protoHasField(protoFile, func(field *ProtoField) bool {
+		return field.Type == "bytes"
+	})
+}
+
+// NumericTypes lists the scalar types a range rule can apply to
+var NumericTypes = map[string]bool{
+	"int32":    true,
+	"int64":    true,
+	"uint32":   true,
+	"uint64":   true,
+	"sint32":   true,
+	"sint64":   true,
+	"fixed32":  true,
+	"fixed64":  true,
+	"sfixed32": true,
+	"sfixed64": true,
+	"float":    true,
+	"double":   true,
+}
+
+// ProtoHasValidation reports whether any top-level or nested message contains a
+// field with validation rules.
+func ProtoHasValidation(protoFile *ProtoFile) bool {
+	return protoHasField(protoFile, func(field *ProtoField) bool {
+		return field.Validation != nil
+	})
+}
+
+// Int64Types lists the 64-bit integer types that proto JSON encodes as strings.
+var Int64Types = map[string]bool{
+	"int64":    true,
+	"uint64":   true,
+	"sint64":   true,
+	"fixed64":  true,
+	"sfixed64": true,
+}
+
+// NeedsInt64Converter reports whether a field is a 64-bit integer that keeps its
+// default VB type (Long/ULong) and so needs the string-aware JSON converter.
+func NeedsInt64Converter(field *ProtoField) bool {
+	return Int64Types[field.Type] && field.TypeOverride == ""
+}
+
+// ProtoHasInt64Field reports whether any top-level or nested message contains a
+// field that needs the string-aware 64-bit integer converter.
+func ProtoHasInt64Field(protoFile *ProtoFile) bool {
+	return protoHasField(protoFile, NeedsInt64Converter)
+}
+
+func protoHasField(protoFile *ProtoFile, match func(*ProtoField) bool) bool {
 	if protoFile == nil {
 		return false
 	}
 	for _, message := range protoFile.Messages {
-		if messageHasBytesField(message) {
+		if messageHasField(message, match) {
 			return true
 		}
 	}
 	return false
 }
 
-func messageHasBytesField(message *ProtoMessage) bool {
+func messageHasField(message *ProtoMessage, match func(*ProtoField) bool) bool {
 	if message == nil {
 		return false
 	}
 	for _, field := range message.Fields {
-		if field.Type == "bytes" {
+		if match(field) {
 			return true
 		}
 	}
 	for _, nested := range message.NestedMessages {
-		if messageHasBytesField(nested) {
+		if messageHasField(nested, match) {
 			return true
 		}
 	}
 	return false
 }
 
+// ApplyTypeMap sets TypeOverride on every field (including fields of nested messages)
+// whose proto type appears in typeMap. Fields that already carry an override from a
+// "// type:" annotation keep it, so per-field annotations take precedence.
+func ApplyTypeMap(protoFile *ProtoFile, typeMap map[string]string) {
+	if len(typeMap) == 0 {
+		return
+	}
+	for _, message := range protoFile.Messages {
+		applyTypeMapToMessage(message, typeMap)
+	}
+}
+
+func applyTypeMapToMessage(message *ProtoMessage, typeMap map[string]string) {
+	for _, field := range message.Fields {
+		if override, ok := typeMap[field.Type]; ok && field.TypeOverride == "" {
+			field.TypeOverride = override
+		}
+	}
+	for _, nested := range message.NestedMessages {
+		applyTypeMapToMessage(nested, typeMap)
+	}
+}
+
 // GoTypeMappings maps protobuf scalar types to Go types
 var GoTypeMappings = map[string]string{
 	"string":   "string",
@@ -153,6 +356,15 @@ func JSONTagName(protoFieldName string, messageName string) string {
 	return string(result)
 }
 
+// FieldJSONName returns the JSON key of a field of the named message: an explicit
+// json_name option wins, then the msgHdr exact-name rule, then camelCase
+func FieldJSONName(field *ProtoField, messageName string) string {
+	if field.JSONName != "" {
+		return field.JSONName
+	}
+	return JSONTagName(field.Name, messageName)
+}
+
 func toUpper(r rune) rune {
 	if r >= 'a' && r <= 'z' {
 		return r - 'a' + 'A'
@@ -191,6 +403,42 @@ func GoFieldName(protoFieldName string) string {
 	return string(result)
 }
 
+// EnumValuePrefix returns the prefix that the proto style guide puts on the values
+// of enum: its name in UPPER_SNAKE_CASE followed by "_", e.g. "ORDER_STATUS_" for
+// OrderStatus. It returns "" unless every value carries the prefix and has more
+// after it, so values stripped of it keep distinct, non-empty names.
+func EnumValuePrefix(enum *ProtoEnum) string {
+	if enum == nil || len(enum.Values) == 0 {
+		return ""
+	}
+	prefix := UpperSnakeCase(enum.Name) + "_"
+
+	for value := range enum.Values {
+		if len(value) <= len(prefix) || !strings.HasPrefix(value, prefix) {
+			return ""
+		}
+	}
+	return prefix
+}
+
+// UpperSnakeCase converts PascalCase/camelCase to UPPER_SNAKE_CASE, keeping
+// acronyms together: "GetHTTPStatusV2" becomes "GET_HTTP_STATUS_V2"
+func UpperSnakeCase(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
+
 // KebabCase converts PascalCase/camelCase to kebab-case for URLs
 // Special case: "N2" pattern converts to "-n2-" not "-n-2-"
 func KebabCase(s string) string {
@@ -228,8 +476,17 @@ func KebabCase(s string) string {
 // ParseRPCNameAndVersion splits an RPC name into a base method name and URL version suffix (v1, v2, ...)
 // Examples: SayHello -> ("SayHello", "v1"), SayHelloV2 -> ("SayHello", "v2")
 func ParseRPCNameAndVersion(name string) (baseName string, version string) {
+	if base, version, ok := splitVersionSuffix(name); ok {
+		return base, version
+	}
+	return name, "v1"
+}
+
+// splitVersionSuffix splits a trailing V<n> (or v<n>) suffix off name, reporting
+// whether there was one
+func splitVersionSuffix(name string) (baseName string, version string, ok bool) {
 	if name == "" {
-		return name, "v1"
+		return name, "", false
 	}
 	// Scan from the end for trailing digits
 	i := len(name) - 1
@@ -247,11 +504,11 @@ func ParseRPCNameAndVersion(name string) (baseName string, version string) {
 			base := name[:i]
 			digits := name[i+1:]
 			if base != "" && digits != "" {
-				return base, "v" + strings.ToLower(digits)
+				return base, "v" + strings.ToLower(digits), true
 			}
 		}
 	}
-	return name, "v1"
+	return name, "", false
 }
 
 // VBReservedKeywords contains all 148 VB.NET reserved keywords that must be escaped with square brackets
@@ -282,9 +539,24 @@ var VBReservedKeywords = map[string]bool{
 	"WriteOnly": true, "Xor": true,
 }
 
+// vbReservedKeywordsLower indexes VBReservedKeywords by lower-case name, since VB
+// keywords are case-insensitive ("Endif" and "Rem" are as reserved as EndIf and REM)
+var vbReservedKeywordsLower = func() map[string]bool {
+	lower := make(map[string]bool, len(VBReservedKeywords))
+	for keyword := range VBReservedKeywords {
+		lower[strings.ToLower(keyword)] = true
+	}
+	return lower
+}()
+
+// IsVBReservedKeyword reports whether name is a VB.NET reserved keyword, in any letter case
+func IsVBReservedKeyword(name string) bool {
+	return vbReservedKeywordsLower[strings.ToLower(name)]
+}
+
 // EscapeVBIdentifier escapes VB.NET reserved keywords by wrapping them in square brackets
 func EscapeVBIdentifier(name string) string {
-	if VBReservedKeywords[name] {
+	if IsVBReservedKeyword(name) {
 		return "[" + name + "]"
 	}
 	return name
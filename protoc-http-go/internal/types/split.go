@@ -0,0 +1,101 @@
+package types
+
+// SplitByService divides protoFile into one file per service, each holding the
+// service and the top-level messages and enums only it references, directly or
+// through message fields. A nested type counts as a reference to its top-level
+// message, since nested types are generated along with their parent.
+//
+// Types that several services reference, or that no service references, go to
+// the returned common file instead of being repeated: the per-service files share
+// a namespace, so a type declared in two of them would not compile. common is nil
+// when there are no such types. Types from other files are left to those files.
+//
+// The split files keep the FileName, BaseName and Package of protoFile so that
+// namespaces and routes are unchanged. Types are resolved with protoFile.Symbols,
+// which is built for protoFile alone when it is not set yet.
+func SplitByService(protoFile *ProtoFile) (services []*ProtoFile, common *ProtoFile, err error) {
+	table := protoFile.Symbols
+	if table == nil {
+		if table, err = BuildSymbolTable([]*ProtoFile{protoFile}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// users counts, per top-level type name, the services that reference it
+	users := make(map[string]int)
+	closures := make([]map[string]bool, len(protoFile.Services))
+	for i, service := range protoFile.Services {
+		closure := make(map[string]bool)
+		for _, rpc := range service.RPCs {
+			for _, ref := range []string{rpc.InputType, rpc.OutputType} {
+				collectReference(protoFile, table, nil, ref, closure)
+			}
+		}
+		for name := range closure {
+			users[name]++
+		}
+		closures[i] = closure
+	}
+
+	for i, service := range protoFile.Services {
+		file := splitFile(protoFile, func(name string) bool { return closures[i][name] && users[name] == 1 })
+		file.Services = []*ProtoService{service}
+		services = append(services, file)
+	}
+	common = splitFile(protoFile, func(name string) bool { return users[name] != 1 })
+	if len(common.Messages) == 0 && len(common.Enums) == 0 {
+		common = nil
+	}
+	return services, common, nil
+}
+
+// collectReference adds the top-level type of protoFile that ref (seen from the
+// message at scope) resolves to, and everything its fields reference, to closure
+func collectReference(protoFile *ProtoFile, table *SymbolTable, scope []string, ref string, closure map[string]bool) {
+	symbol, ok := table.Resolve(protoFile.Package, scope, ref)
+	if !ok || symbol.File != protoFile {
+		return
+	}
+	topLevel := symbol.Path[0]
+	if closure[topLevel] {
+		return
+	}
+	closure[topLevel] = true
+	if message, ok := protoFile.Messages[topLevel]; ok {
+		collectFieldReferences(protoFile, table, []string{topLevel}, message, closure)
+	}
+}
+
+// collectFieldReferences walks the fields of message, declared at path, and of
+// its nested messages
+func collectFieldReferences(protoFile *ProtoFile, table *SymbolTable, path []string, message *ProtoMessage, closure map[string]bool) {
+	for _, field := range message.Fields {
+		if _, scalar := VBTypeMappings[field.Type]; !scalar {
+			collectReference(protoFile, table, path, field.Type, closure)
+		}
+	}
+	for _, name := range sortedKeys(message.NestedMessages) {
+		nestedPath := append(append([]string{}, path...), name)
+		collectFieldReferences(protoFile, table, nestedPath, message.NestedMessages[name], closure)
+	}
+}
+
+// splitFile returns a copy of protoFile without services, holding the top-level
+// messages and enums whose names keep accepts
+func splitFile(protoFile *ProtoFile, keep func(name string) bool) *ProtoFile {
+	file := *protoFile
+	file.Services = nil
+	file.Messages = make(map[string]*ProtoMessage)
+	file.Enums = make(map[string]*ProtoEnum)
+	for name, message := range protoFile.Messages {
+		if keep(name) {
+			file.Messages[name] = message
+		}
+	}
+	for name, enum := range protoFile.Enums {
+		if keep(name) {
+			file.Enums[name] = enum
+		}
+	}
+	return &file
+}
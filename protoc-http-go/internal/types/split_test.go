@@ -0,0 +1,103 @@
+package types_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+const twoServicesProto = `syntax = "proto3";
+package shop;
+
+enum Currency { CURRENCY_UNSPECIFIED = 0; EUR = 1; }
+message Money { int64 units = 1; Currency currency = 2; }
+message RequestHeader { string trace_id = 1; }
+
+message GetOrderRequest { RequestHeader header = 1; string id = 2; }
+message Order {
+  message Line { string sku = 1; Money price = 2; }
+  repeated Line lines = 1;
+}
+
+message GetStockRequest { RequestHeader header = 1; string sku = 2; }
+message Stock { int32 available = 1; }
+
+message Unused { string note = 1; }
+
+service OrderService {
+  rpc GetOrder (GetOrderRequest) returns (Order);
+}
+service StockService {
+  rpc GetStock (GetStockRequest) returns (Stock);
+}
+`
+
+func typeNames(file *types.ProtoFile) string {
+	var names []string
+	for name := range file.Messages {
+		names = append(names, name)
+	}
+	for name := range file.Enums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestSplitByServiceComputesTypeClosures(t *testing.T) {
+	file := parseContent(t, "shop.proto", twoServicesProto)
+	services, common, err := types.SplitByService(file)
+	if err != nil {
+		t.Fatalf("SplitByService() error = %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("expected 2 service files, got %d", len(services))
+	}
+
+	// Money and Currency are only reached through Order.Line
+	if got := typeNames(services[0]); got != "Currency,GetOrderRequest,Money,Order" {
+		t.Errorf("OrderService types = %s", got)
+	}
+	if got := typeNames(services[1]); got != "GetStockRequest,Stock" {
+		t.Errorf("StockService types = %s", got)
+	}
+	if services[0].Services[0].Name != "OrderService" || len(services[0].Services) != 1 {
+		t.Errorf("unexpected services %+v", services[0].Services)
+	}
+
+	// RequestHeader is used by both services, Unused by neither
+	if common == nil || typeNames(common) != "RequestHeader,Unused" || len(common.Services) != 0 {
+		t.Fatalf("unexpected common file %+v", common)
+	}
+
+	// The input is left alone and the copies keep its names
+	if len(file.Messages) != 7 || len(file.Services) != 2 {
+		t.Errorf("SplitByService modified its input")
+	}
+	if services[1].BaseName != "shop" || services[1].Package != "shop" {
+		t.Errorf("split file renamed to %s/%s", services[1].BaseName, services[1].Package)
+	}
+}
+
+func TestSplitByServiceWithoutSharedTypes(t *testing.T) {
+	file := parseContent(t, "greeter.proto", `syntax = "proto3";
+package demo;
+message HelloRequest { string name = 1; }
+message HelloReply { string message = 1; }
+message Ping {}
+service Greeter { rpc SayHello (HelloRequest) returns (HelloReply); }
+service Health { rpc Check (Ping) returns (Ping); }
+`)
+	services, common, err := types.SplitByService(file)
+	if err != nil {
+		t.Fatalf("SplitByService() error = %v", err)
+	}
+	if common != nil {
+		t.Errorf("expected no common file, got %s", typeNames(common))
+	}
+	if got := typeNames(services[0]) + "|" + typeNames(services[1]); got != "HelloReply,HelloRequest|Ping" {
+		t.Errorf("unexpected split %s", got)
+	}
+}
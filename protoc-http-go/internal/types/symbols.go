@@ -0,0 +1,114 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Symbol is a message or enum declared in one of the parsed files
+type Symbol struct {
+	FullName string     // Package-qualified name, e.g. "trading.Quote.Side"
+	File     *ProtoFile // Declaring file
+	Path     []string   // Name within the file, outermost message first, e.g. ["Quote", "Side"]
+	IsEnum   bool
+}
+
+// SymbolTable indexes the messages and enums of every parsed file by fully
+// qualified name, so references can be resolved across files and packages
+type SymbolTable struct {
+	symbols map[string]*Symbol
+}
+
+// BuildSymbolTable indexes the types of files, including nested ones, and attaches
+// the table to each file as ProtoFile.Symbols. Types with the same name in different
+// packages are distinct; two declarations of the same fully-qualified name are an error.
+func BuildSymbolTable(files []*ProtoFile) (*SymbolTable, error) {
+	table := &SymbolTable{symbols: make(map[string]*Symbol)}
+	for _, file := range files {
+		for _, name := range sortedKeys(file.Enums) {
+			if err := table.add(file, []string{name}, true); err != nil {
+				return nil, err
+			}
+		}
+		for _, name := range sortedKeys(file.Messages) {
+			if err := table.addMessage(file, nil, file.Messages[name]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, file := range files {
+		file.Symbols = table
+	}
+	return table, nil
+}
+
+func (t *SymbolTable) addMessage(file *ProtoFile, parent []string, message *ProtoMessage) error {
+	path := append(append([]string{}, parent...), message.Name)
+	if err := t.add(file, path, false); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(message.NestedEnums) {
+		if err := t.add(file, append(append([]string{}, path...), name), true); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(message.NestedMessages) {
+		if err := t.addMessage(file, path, message.NestedMessages[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *SymbolTable) add(file *ProtoFile, path []string, isEnum bool) error {
+	fullName := qualify(file.Package, strings.Join(path, "."))
+	if existing, ok := t.symbols[fullName]; ok {
+		return fmt.Errorf("type %s is defined in both %s and %s", fullName, existing.File.FileName, file.FileName)
+	}
+	t.symbols[fullName] = &Symbol{FullName: fullName, File: file, Path: path, IsEnum: isEnum}
+	return nil
+}
+
+// Lookup returns the symbol with the given fully-qualified name (a leading dot is allowed)
+func (t *SymbolTable) Lookup(fullName string) (*Symbol, bool) {
+	symbol, ok := t.symbols[strings.TrimPrefix(fullName, ".")]
+	return symbol, ok
+}
+
+// Resolve finds the type a field of the message at scope in package pkg refers to
+// with ref, following protobuf scoping: the innermost enclosing message first, then
+// outward through the package and its parent packages. A ref with a leading dot
+// is fully qualified.
+func (t *SymbolTable) Resolve(pkg string, scope []string, ref string) (*Symbol, bool) {
+	if strings.HasPrefix(ref, ".") {
+		return t.Lookup(ref)
+	}
+	var enclosing []string
+	if pkg != "" {
+		enclosing = strings.Split(pkg, ".")
+	}
+	enclosing = append(enclosing, scope...)
+	for depth := len(enclosing); depth >= 0; depth-- {
+		if symbol, ok := t.Lookup(qualify(strings.Join(enclosing[:depth], "."), ref)); ok {
+			return symbol, true
+		}
+	}
+	return nil, false
+}
+
+func qualify(pkg, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/parser"
+	"github.com/yinghanhung/grpc-polyglot/protoc-http-go/internal/types"
+)
+
+func parseContent(t *testing.T, fileName, content string) *types.ProtoFile {
+	t.Helper()
+	protoFile, err := parser.ParseProtoContent(fileName, content, parser.Options{})
+	if err != nil {
+		t.Fatalf("ParseProtoContent(%s) error = %v", fileName, err)
+	}
+	return protoFile
+}
+
+func TestSymbolTableSameNameInDifferentPackages(t *testing.T) {
+	common := parseContent(t, "common.proto", `syntax = "proto3";
+package common;
+message Ticker { string symbol = 1; }
+`)
+	trading := parseContent(t, "trading.proto", `syntax = "proto3";
+package trading;
+message Ticker { string venue = 1; }
+message Quote {
+  common.Ticker listing = 1;
+  Ticker local = 2;
+}
+`)
+
+	table, err := types.BuildSymbolTable([]*types.ProtoFile{common, trading})
+	if err != nil {
+		t.Fatalf("BuildSymbolTable() error = %v", err)
+	}
+	if common.Symbols != table || trading.Symbols != table {
+		t.Fatal("expected the table to be attached to every file")
+	}
+
+	symbol, ok := table.Resolve("trading", []string{"Quote"}, "common.Ticker")
+	if !ok || symbol.File != common || symbol.FullName != "common.Ticker" {
+		t.Fatalf("common.Ticker resolved to %+v", symbol)
+	}
+	symbol, ok = table.Resolve("trading", []string{"Quote"}, "Ticker")
+	if !ok || symbol.File != trading || symbol.FullName != "trading.Ticker" {
+		t.Fatalf("Ticker resolved to %+v", symbol)
+	}
+}
+
+func TestSymbolTableRejectsDuplicateFullName(t *testing.T) {
+	a := parseContent(t, "a.proto", "syntax = \"proto3\";\npackage common;\nmessage Ticker { string symbol = 1; }\n")
+	b := parseContent(t, "b.proto", "syntax = \"proto3\";\npackage common;\nmessage Ticker { string venue = 1; }\n")
+
+	_, err := types.BuildSymbolTable([]*types.ProtoFile{a, b})
+	if err == nil {
+		t.Fatal("expected an error for common.Ticker defined twice")
+	}
+	for _, want := range []string{"common.Ticker", "a.proto", "b.proto"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestSymbolTableResolvesNestedAndQualifiedNames(t *testing.T) {
+	file := parseContent(t, "orders.proto", `syntax = "proto3";
+package acme.orders;
+message Order {
+  enum Side { SIDE_UNSPECIFIED = 0; BUY = 1; }
+  message Line { Side side = 1; }
+  Line line = 1;
+}
+`)
+	table, err := types.BuildSymbolTable([]*types.ProtoFile{file})
+	if err != nil {
+		t.Fatalf("BuildSymbolTable() error = %v", err)
+	}
+
+	tests := []struct {
+		scope  []string
+		ref    string
+		want   string
+		isEnum bool
+	}{
+		{[]string{"Order", "Line"}, "Side", "acme.orders.Order.Side", true},
+		{[]string{"Order"}, "Line", "acme.orders.Order.Line", false},
+		{nil, "orders.Order", "acme.orders.Order", false},
+		{nil, ".acme.orders.Order.Line", "acme.orders.Order.Line", false},
+	}
+	for _, tt := range tests {
+		symbol, ok := table.Resolve("acme.orders", tt.scope, tt.ref)
+		if !ok || symbol.FullName != tt.want || symbol.IsEnum != tt.isEnum {
+			t.Errorf("Resolve(%v, %q) = %+v, want %s", tt.scope, tt.ref, symbol, tt.want)
+		}
+	}
+	if _, ok := table.Resolve("acme.orders", nil, "Missing"); ok {
+		t.Error("expected Missing not to resolve")
+	}
+}
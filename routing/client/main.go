@@ -3,9 +3,12 @@ package main
 import (
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -49,7 +52,9 @@ func printMenu() {
 	fmt.Println()
 }
 
-func sendRequest(client pb.GreeterClient, name string, headerValue string) error {
+// callBackend sends one SayHello through APISIX, with the x-backend-version
+// header set when headerValue is non-empty
+func callBackend(client pb.GreeterClient, name string, headerValue string) (*pb.HelloReply, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -59,13 +64,19 @@ func sendRequest(client pb.GreeterClient, name string, headerValue string) error
 			"x-backend-version": headerValue,
 		})
 		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+	return client.SayHello(ctx, &pb.HelloRequest{Name: name})
+}
+
+func sendRequest(client pb.GreeterClient, name string, headerValue string) error {
+	if headerValue != "" {
 		fmt.Printf(colorBlue+"Sending with header: x-backend-version=%s\n"+colorReset, headerValue)
 	} else {
 		fmt.Println(colorBlue + "Sending without header" + colorReset)
 	}
 
 	// Make the request
-	r, err := client.SayHello(ctx, &pb.HelloRequest{Name: name})
+	r, err := callBackend(client, name, headerValue)
 	if err != nil {
 		return fmt.Errorf(colorRed+"Request failed: %v"+colorReset, err)
 	}
@@ -82,16 +93,226 @@ func sendRequest(client pb.GreeterClient, name string, headerValue string) error
 	return nil
 }
 
+// expectation is the backend a request with a given x-backend-version header
+// must be routed to
+type expectation struct {
+	Header        string // x-backend-version value; "" sends no header
+	ServerName    string // Expected ServerName of the reply
+	ServerVersion string // Expected ServerVersion of the reply; "" accepts any
+}
+
+func (e expectation) String() string {
+	header := "no header"
+	if e.Header != "" {
+		header = "x-backend-version=" + e.Header
+	}
+	backend := e.ServerName
+	if e.ServerVersion != "" {
+		backend += " " + e.ServerVersion
+	}
+	return header + " -> " + backend
+}
+
+// expectations collects repeated --expect flags of the form
+// "header=server name[@version]", e.g. "=Go Server@v1" or "v2=Rust Server"
+type expectations []expectation
+
+func (e *expectations) String() string {
+	parts := make([]string, len(*e))
+	for i, exp := range *e {
+		parts[i] = exp.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (e *expectations) Set(value string) error {
+	header, backend, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected header=server name[@version], got %q", value)
+	}
+	name, version, _ := strings.Cut(backend, "@")
+	exp := expectation{
+		Header:        strings.TrimSpace(header),
+		ServerName:    strings.TrimSpace(name),
+		ServerVersion: strings.TrimSpace(version),
+	}
+	if exp.ServerName == "" {
+		return fmt.Errorf("missing server name in %q", value)
+	}
+	for _, existing := range *e {
+		if existing.Header == exp.Header {
+			return fmt.Errorf("header %q has more than one expectation", exp.Header)
+		}
+	}
+	*e = append(*e, exp)
+	return nil
+}
+
+// defaultExpectations mirrors the APISIX routes: no header goes to the Go
+// server, x-backend-version: v2 to the Rust server
+var defaultExpectations = expectations{
+	{Header: "", ServerName: "Go Server", ServerVersion: "v1"},
+	{Header: "v2", ServerName: "Rust Server", ServerVersion: "v2"},
+}
+
+// runAssertions sends requests for every expectation and compares the backend
+// that answered. It prints one line per request and a summary of the mismatches,
+// and returns the process exit code: 0 when every request reached the expected
+// backend, 1 otherwise (including failed requests).
+func runAssertions(client pb.GreeterClient, exps expectations, requests int) int {
+	exps = append(expectations(nil), exps...)
+	sort.SliceStable(exps, func(i, j int) bool { return exps[i].Header < exps[j].Header })
+
+	var failures []string
+	total := 0
+	for _, exp := range exps {
+		for i := 1; i <= requests; i++ {
+			total++
+			r, err := callBackend(client, fmt.Sprintf("Assert%d", total), exp.Header)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: request failed: %v", exp, err))
+				fmt.Printf(colorRed+"FAIL"+colorReset+" %s: request failed: %v\n", exp, err)
+				continue
+			}
+			if r.GetServerName() != exp.ServerName || (exp.ServerVersion != "" && r.GetServerVersion() != exp.ServerVersion) {
+				failures = append(failures, fmt.Sprintf("%s: got %s %s", exp, r.GetServerName(), r.GetServerVersion()))
+				fmt.Printf(colorRed+"FAIL"+colorReset+" %s: got %s %s\n", exp, r.GetServerName(), r.GetServerVersion())
+				continue
+			}
+			fmt.Printf(colorGreen+"PASS"+colorReset+" %s\n", exp)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d/%d requests reached the expected backend\n", total-len(failures), total)
+	if len(failures) == 0 {
+		return 0
+	}
+	fmt.Println(colorRed + "Mismatches:" + colorReset)
+	for _, failure := range failures {
+		fmt.Printf("  - %s\n", failure)
+	}
+	return 1
+}
+
+// readNames reads the --names-file used by the load test: one name per line,
+// surrounding whitespace trimmed and blank lines skipped
+func readNames(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open names file: %w", err)
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read names file %s: %w", path, err)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("names file %s contains no names", path)
+	}
+	return names, nil
+}
+
+// loadTestName returns the name of the i-th (1-based) load test request: the
+// names from --names-file in turn, starting over after the last one, or "User<i>"
+func loadTestName(names []string, i int) string {
+	if len(names) == 0 {
+		return fmt.Sprintf("User%d", i)
+	}
+	return names[(i-1)%len(names)]
+}
+
+// headerPicker chooses the x-backend-version header of each load test request:
+// strictly alternating between no header and v2, or v2 with a fixed probability
+// when --v2-weight is given
+type headerPicker struct {
+	rng      *rand.Rand // Source of the weighted choice; nil alternates
+	v2Weight float64    // Probability of sending v2, between 0 and 1
+}
+
+// newHeaderPicker returns a picker sending v2 with probability v2Weight, drawn
+// from a generator seeded with seed so a run can be repeated
+func newHeaderPicker(v2Weight float64, seed int64) *headerPicker {
+	return &headerPicker{rng: rand.New(rand.NewSource(seed)), v2Weight: v2Weight}
+}
+
+// header returns the header value of the i-th (1-based) load test request, ""
+// meaning no header
+func (p *headerPicker) header(i int) string {
+	if p.rng == nil {
+		if i%2 == 0 {
+			return "v2"
+		}
+		return ""
+	}
+	if p.rng.Float64() < p.v2Weight {
+		return "v2"
+	}
+	return ""
+}
+
+func (p *headerPicker) String() string {
+	if p.rng == nil {
+		return "alternating headers"
+	}
+	return fmt.Sprintf("%.0f%% v2", p.v2Weight*100)
+}
+
 func main() {
+	var exps expectations
+	assert := flag.Bool("assert", false, "send requests for every --expect and exit non-zero if any reached the wrong backend (non-interactive)")
+	requests := flag.Int("assert-requests", 1, "requests sent per expectation in --assert mode")
+	namesFile := flag.String("names-file", "", "file with one name per line that load test requests cycle through instead of User1..UserN")
+	v2Weight := flag.Float64("v2-weight", 0, "probability between 0 and 1 that a load test request carries x-backend-version: v2, e.g. 0.1 for a 10% canary (default: strict alternation)")
+	seed := flag.Int64("seed", 0, "seed of the random --v2-weight choice, to repeat a run (default: random, printed in the summary)")
+	flag.Var(&exps, "expect", "routing expectation header=server name[@version] for --assert; repeatable, \"\" as header means no header (default: \"=Go Server@v1\" and \"v2=Rust Server@v2\")")
+	flag.Parse()
+	if len(exps) == 0 {
+		exps = defaultExpectations
+	}
+	if *requests <= 0 {
+		log.Fatalf("--assert-requests must be positive, got %d", *requests)
+	}
+	picker := &headerPicker{}
+	weighted, seeded := false, false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "v2-weight":
+			weighted = true
+		case "seed":
+			seeded = true
+		}
+	})
+	if weighted {
+		if *v2Weight < 0 || *v2Weight > 1 {
+			log.Fatalf("--v2-weight must be between 0 and 1, got %g", *v2Weight)
+		}
+		if !seeded {
+			*seed = time.Now().UnixNano()
+		}
+		picker = newHeaderPicker(*v2Weight, *seed)
+	}
+	var names []string
+	if *namesFile != "" {
+		var err error
+		if names, err = readNames(*namesFile); err != nil {
+			log.Fatalf("--names-file: %v", err)
+		}
+	}
+
 	// Get APISIX address from environment or use default
 	address := os.Getenv("APISIX_ADDR")
 	if address == "" {
 		address = defaultAddress
 	}
 
-	printBanner()
-	fmt.Printf("Connecting to APISIX at: %s\n\n", address)
-
 	// Set up connection to the server
 	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -101,6 +322,16 @@ func main() {
 
 	client := pb.NewGreeterClient(conn)
 
+	if *assert {
+		fmt.Printf("Checking routing through APISIX at: %s\n\n", address)
+		code := runAssertions(client, exps, *requests)
+		conn.Close()
+		os.Exit(code)
+	}
+
+	printBanner()
+	fmt.Printf("Connecting to APISIX at: %s\n\n", address)
+
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
@@ -159,19 +390,20 @@ func main() {
 				count = 10
 			}
 
-			fmt.Printf("\nSending %d requests (alternating headers)...\n\n", count)
+			fmt.Printf("\nSending %d requests (%s)...\n\n", count, picker)
 
 			goCount := 0
 			rustCount := 0
 			errors := 0
+			v2Sent := 0
 
 			for i := 1; i <= count; i++ {
-				headerValue := ""
-				if i%2 == 0 {
-					headerValue = "v2"
+				headerValue := picker.header(i)
+				if headerValue == "v2" {
+					v2Sent++
 				}
 
-				err := sendRequest(client, fmt.Sprintf("User%d", i), headerValue)
+				err := sendRequest(client, loadTestName(names, i), headerValue)
 				if err != nil {
 					fmt.Println(err)
 					errors++
@@ -190,6 +422,11 @@ func main() {
 			fmt.Printf("  Go Server:         %d\n", goCount)
 			fmt.Printf("  Rust Server:       %d\n", rustCount)
 			fmt.Printf("  Errors:            %d\n", errors)
+			fmt.Printf("  Header split:      %.1f%% none / %.1f%% v2\n",
+				float64(count-v2Sent)*100/float64(count), float64(v2Sent)*100/float64(count))
+			if picker.rng != nil {
+				fmt.Printf("  Seed:              %d\n", *seed)
+			}
 			fmt.Println(colorCyan + "╚════════════════════════════════════════════════╝" + colorReset)
 			fmt.Println()
 
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "github.com/yinghanhung/grpc-polyglot/routing/proto/go/helloworld"
+	"google.golang.org/grpc"
+)
+
+// stubGreeter answers SayHello with a fixed reply
+type stubGreeter struct {
+	pb.GreeterClient
+	reply *pb.HelloReply
+}
+
+func (s *stubGreeter) SayHello(ctx context.Context, in *pb.HelloRequest, opts ...grpc.CallOption) (*pb.HelloReply, error) {
+	return s.reply, nil
+}
+
+func TestHeaderPickerAlternatesByDefault(t *testing.T) {
+	picker := &headerPicker{}
+	for i, want := range []string{"", "v2", "", "v2"} {
+		if got := picker.header(i + 1); got != want {
+			t.Errorf("header(%d) = %q, want %q", i+1, got, want)
+		}
+	}
+}
+
+func TestHeaderPickerWeighting(t *testing.T) {
+	const requests = 100000
+	for _, weight := range []float64{0, 0.1, 0.5, 1} {
+		picker := newHeaderPicker(weight, 42)
+		v2 := 0
+		for i := 1; i <= requests; i++ {
+			if picker.header(i) == "v2" {
+				v2++
+			}
+		}
+		if got := float64(v2) / requests; math.Abs(got-weight) > 0.01 {
+			t.Errorf("weight %g: observed v2 share %g", weight, got)
+		}
+	}
+}
+
+func TestHeaderPickerSeedIsReproducible(t *testing.T) {
+	a, b := newHeaderPicker(0.3, 7), newHeaderPicker(0.3, 7)
+	for i := 1; i <= 1000; i++ {
+		if a.header(i) != b.header(i) {
+			t.Fatalf("request %d: pickers with the same seed differ", i)
+		}
+	}
+}
+
+func TestExpectationsSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    expectations
+		wantErr string
+	}{
+		{
+			name:   "no header with version",
+			values: []string{"=Go Server@v1"},
+			want:   expectations{{Header: "", ServerName: "Go Server", ServerVersion: "v1"}},
+		},
+		{
+			name:   "header without version",
+			values: []string{"v2=Rust Server"},
+			want:   expectations{{Header: "v2", ServerName: "Rust Server"}},
+		},
+		{
+			name:    "missing name",
+			values:  []string{"v2=@v2"},
+			wantErr: "missing server name",
+		},
+		{
+			name:    "missing separator",
+			values:  []string{"Go Server"},
+			wantErr: "expected header=server name[@version]",
+		},
+		{
+			name:    "duplicate header",
+			values:  []string{"v2=Rust Server", "v2=Go Server"},
+			wantErr: `header "v2" has more than one expectation`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exps expectations
+			var err error
+			for _, value := range tt.values {
+				if err = exps.Set(value); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Set() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if !reflect.DeepEqual(exps, tt.want) {
+				t.Errorf("expectations = %+v, want %+v", exps, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunAssertions(t *testing.T) {
+	exps := expectations{{Header: "", ServerName: "Go Server", ServerVersion: "v1"}}
+	tests := []struct {
+		name  string
+		reply *pb.HelloReply
+		want  int
+	}{
+		{"expected backend", &pb.HelloReply{ServerName: "Go Server", ServerVersion: "v1"}, 0},
+		{"wrong server", &pb.HelloReply{ServerName: "Rust Server", ServerVersion: "v1"}, 1},
+		{"wrong version", &pb.HelloReply{ServerName: "Go Server", ServerVersion: "v2"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runAssertions(&stubGreeter{reply: tt.reply}, exps, 2); got != tt.want {
+				t.Errorf("runAssertions() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadNames(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	names, err := readNames(write("names.txt", "  Alice \n\nBob\n\t\nCarol"))
+	if err != nil {
+		t.Fatalf("readNames() error = %v", err)
+	}
+	if want := []string{"Alice", "Bob", "Carol"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("readNames() = %q, want %q", names, want)
+	}
+
+	if _, err := readNames(filepath.Join(dir, "missing.txt")); err == nil || !strings.Contains(err.Error(), "open names file") {
+		t.Errorf("readNames(missing) error = %v, want an open error", err)
+	}
+	if _, err := readNames(write("blank.txt", "\n  \n\t\n")); err == nil || !strings.Contains(err.Error(), "contains no names") {
+		t.Errorf("readNames(blank) error = %v, want a no names error", err)
+	}
+}
+
+func TestLoadTestName(t *testing.T) {
+	names := []string{"Alice", "Bob", "Carol"}
+	for i, want := range []string{"Alice", "Bob", "Carol", "Alice", "Bob"} {
+		if got := loadTestName(names, i+1); got != want {
+			t.Errorf("loadTestName(%d) = %q, want %q", i+1, got, want)
+		}
+	}
+	if got := loadTestName(nil, 7); got != "User7" {
+		t.Errorf("loadTestName(nil, 7) = %q, want User7", got)
+	}
+}
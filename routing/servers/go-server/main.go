@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net"
@@ -9,6 +12,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -21,10 +25,19 @@ import (
 )
 
 const (
-	grpcPort    = ":50051"
-	metricsPort = ":9090"
-	serverName  = "Go Server"
-	version     = "v1"
+	defaultGRPCPort    = 50051
+	defaultMetricsPort = 9090
+	serverName         = "Go Server"
+	version            = "v1"
+)
+
+// Build information, injected at build time with
+// -ldflags "-X main.buildVersion=... -X main.commit=... -X main.buildTime=..."
+// (version above is the API version reported in replies, not the build)
+var (
+	buildVersion = "dev"
+	commit       = "none"
+	buildTime    = "unknown"
 )
 
 // Prometheus metrics
@@ -59,6 +72,60 @@ func init() {
 	prometheus.MustRegister(activeConnections)
 }
 
+// config holds the ports the server listens on
+type config struct {
+	grpcPort    int // gRPC server port (GRPC_PORT, --grpc-port)
+	metricsPort int // Metrics, health and version HTTP port (METRICS_PORT, --metrics-port)
+}
+
+// loadConfig reads the ports from the GRPC_PORT and METRICS_PORT environment
+// variables, then from the --grpc-port and --metrics-port flags, which win.
+// Unset values keep the defaults (50051 and 9090).
+func loadConfig(args []string, getenv func(string) string) (config, error) {
+	cfg := config{grpcPort: defaultGRPCPort, metricsPort: defaultMetricsPort}
+	for _, env := range []struct {
+		key  string
+		dest *int
+	}{
+		{"GRPC_PORT", &cfg.grpcPort},
+		{"METRICS_PORT", &cfg.metricsPort},
+	} {
+		value := getenv(env.key)
+		if value == "" {
+			continue
+		}
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return config{}, fmt.Errorf("%s must be a port number, got %q", env.key, value)
+		}
+		*env.dest = port
+	}
+
+	fs := flag.NewFlagSet("go-server", flag.ContinueOnError)
+	fs.IntVar(&cfg.grpcPort, "grpc-port", cfg.grpcPort, "gRPC server port (env GRPC_PORT)")
+	fs.IntVar(&cfg.metricsPort, "metrics-port", cfg.metricsPort, "metrics, health and version HTTP port (env METRICS_PORT)")
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	return cfg, cfg.validate()
+}
+
+// validate checks that both ports are valid TCP ports and that they differ, since
+// the gRPC and metrics listeners cannot share a port
+func (c config) validate() error {
+	var errs []error
+	if c.grpcPort < 1 || c.grpcPort > 65535 {
+		errs = append(errs, fmt.Errorf("gRPC port must be between 1 and 65535, got %d", c.grpcPort))
+	}
+	if c.metricsPort < 1 || c.metricsPort > 65535 {
+		errs = append(errs, fmt.Errorf("metrics port must be between 1 and 65535, got %d", c.metricsPort))
+	}
+	if c.grpcPort == c.metricsPort {
+		errs = append(errs, fmt.Errorf("gRPC and metrics ports must differ, both are %d", c.grpcPort))
+	}
+	return errors.Join(errs...)
+}
+
 // server is used to implement helloworld.GreeterServer
 type server struct {
 	pb.UnimplementedGreeterServer
@@ -90,30 +157,51 @@ func (s *server) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloRe
 	return reply, nil
 }
 
-// startMetricsServer starts the HTTP server for Prometheus metrics
-func startMetricsServer() {
+// versionHandler answers GET /version with the injected build information as JSON
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"version":   buildVersion,
+		"commit":    commit,
+		"buildTime": buildTime,
+	})
+}
+
+// startMetricsServer starts the HTTP server for Prometheus metrics on addr
+func startMetricsServer(addr string) {
 	http.Handle("/metrics", promhttp.Handler())
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
+	http.HandleFunc("/version", versionHandler)
 
-	log.Printf("Metrics server listening on %s", metricsPort)
-	if err := http.ListenAndServe(metricsPort, nil); err != nil {
+	log.Printf("Metrics server listening on %s", addr)
+	if err := http.ListenAndServe(addr, nil); err != nil {
 		log.Fatalf("Failed to start metrics server: %v", err)
 	}
 }
 
 func main() {
+	cfg, err := loadConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	grpcAddr := fmt.Sprintf(":%d", cfg.grpcPort)
+
 	// Log startup info
 	log.Printf("Starting %s %s", serverName, version)
+	log.Printf("Build: version=%s commit=%s buildTime=%s", buildVersion, commit, buildTime)
 	log.Printf("Runtime architecture: %s/%s", runtime.GOOS, runtime.GOARCH)
 
 	// Start metrics server in a goroutine
-	go startMetricsServer()
+	go startMetricsServer(fmt.Sprintf(":%d", cfg.metricsPort))
 
 	// Create gRPC server
-	lis, err := net.Listen("tcp", grpcPort)
+	lis, err := net.Listen("tcp", grpcAddr)
 	if err != nil {
 		log.Fatalf("Failed to listen: %v", err)
 	}
@@ -126,7 +214,7 @@ func main() {
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
 	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
 
-	log.Printf("gRPC server listening on %s", grpcPort)
+	log.Printf("gRPC server listening on %s", grpcAddr)
 
 	// Handle graceful shutdown
 	go func() {
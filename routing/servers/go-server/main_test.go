@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func envFrom(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := loadConfig(nil, envFrom(nil))
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.grpcPort != 50051 || cfg.metricsPort != 9090 {
+		t.Errorf("loadConfig() = %+v, want ports 50051 and 9090", cfg)
+	}
+}
+
+func TestLoadConfigEnvAndFlags(t *testing.T) {
+	env := envFrom(map[string]string{"GRPC_PORT": "6000", "METRICS_PORT": "6001"})
+
+	cfg, err := loadConfig(nil, env)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.grpcPort != 6000 || cfg.metricsPort != 6001 {
+		t.Errorf("loadConfig() = %+v, want ports from the environment", cfg)
+	}
+
+	// Flags win over the environment
+	cfg, err = loadConfig([]string{"--metrics-port", "7001"}, env)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.grpcPort != 6000 || cfg.metricsPort != 7001 {
+		t.Errorf("loadConfig() = %+v, want gRPC port 6000 and metrics port 7001", cfg)
+	}
+}
+
+func TestLoadConfigRejectsInvalidPorts(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"same ports", []string{"--grpc-port", "8000", "--metrics-port", "8000"}, nil, "gRPC and metrics ports must differ, both are 8000"},
+		{"same ports from env", nil, map[string]string{"METRICS_PORT": "50051"}, "gRPC and metrics ports must differ, both are 50051"},
+		{"gRPC port out of range", []string{"--grpc-port", "70000"}, nil, "gRPC port must be between 1 and 65535, got 70000"},
+		{"metrics port zero", []string{"--metrics-port", "0"}, nil, "metrics port must be between 1 and 65535, got 0"},
+		{"non-numeric env", nil, map[string]string{"GRPC_PORT": ":50051"}, `GRPC_PORT must be a port number, got ":50051"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(tt.args, envFrom(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("loadConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionHandlerReturnsBuildInfo(t *testing.T) {
+	defer func(v, c, b string) { buildVersion, commit, buildTime = v, c, b }(buildVersion, commit, buildTime)
+	buildVersion, commit, buildTime = "1.2.3", "abc1234", "2026-10-15T00:00:00Z"
+
+	rec := httptest.NewRecorder()
+	versionHandler(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	want := map[string]string{"version": "1.2.3", "commit": "abc1234", "buildTime": "2026-10-15T00:00:00Z"}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+}
@@ -32,6 +32,22 @@ lazy_static::lazy_static! {
 const SERVER_NAME: &str = "Rust Server";
 const VERSION: &str = "v2";
 
+// Build information, injected at compile time through the BUILD_VERSION,
+// BUILD_COMMIT and BUILD_TIME environment variables (see the Dockerfile).
+// VERSION above is the API version reported in replies, not the build.
+const BUILD_VERSION: &str = match option_env!("BUILD_VERSION") {
+    Some(v) => v,
+    None => "dev",
+};
+const BUILD_COMMIT: &str = match option_env!("BUILD_COMMIT") {
+    Some(v) => v,
+    None => "none",
+};
+const BUILD_TIME: &str = match option_env!("BUILD_TIME") {
+    Some(v) => v,
+    None => "unknown",
+};
+
 #[derive(Debug, Default)]
 pub struct GreeterService {}
 
@@ -86,6 +102,32 @@ async fn health_handler() -> Result<&'static str, hyper::http::Error> {
     Ok("OK")
 }
 
+// Quotes s as a JSON string
+fn json_string(s: &str) -> String {
+    let mut out = String::with_capacity(s.len() + 2);
+    out.push('"');
+    for c in s.chars() {
+        match c {
+            '"' => out.push_str("\\\""),
+            '\\' => out.push_str("\\\\"),
+            c if (c as u32) < 0x20 => out.push_str(&format!("\\u{:04x}", c as u32)),
+            c => out.push(c),
+        }
+    }
+    out.push('"');
+    out
+}
+
+// Returns the build information served at /version as JSON
+fn version_json() -> String {
+    format!(
+        "{{\"version\":{},\"commit\":{},\"buildTime\":{}}}",
+        json_string(BUILD_VERSION),
+        json_string(BUILD_COMMIT),
+        json_string(BUILD_TIME)
+    )
+}
+
 async fn run_metrics_server(addr: SocketAddr) {
     use hyper::server::conn::http1;
     use hyper::service::service_fn;
@@ -114,6 +156,14 @@ async fn run_metrics_server(addr: SocketAddr) {
                     resp
                 }
             },
+            "/version" => {
+                let mut resp = HyperResponse::new(version_json());
+                resp.headers_mut().insert(
+                    hyper::header::CONTENT_TYPE,
+                    hyper::header::HeaderValue::from_static("application/json"),
+                );
+                resp
+            }
             _ => {
                 let mut resp = HyperResponse::new("Not Found".to_string());
                 *resp.status_mut() = hyper::StatusCode::NOT_FOUND;
@@ -145,6 +195,10 @@ async fn run_metrics_server(addr: SocketAddr) {
 async fn main() -> Result<(), Box<dyn std::error::Error>> {
     // Log startup info
     println!("Starting {} {}", SERVER_NAME, VERSION);
+    println!(
+        "Build: version={} commit={} buildTime={}",
+        BUILD_VERSION, BUILD_COMMIT, BUILD_TIME
+    );
     println!(
         "Runtime architecture: {}/{}",
         std::env::consts::OS,